@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// The statuses of the credentials returned by CheckCookieAuth
+const (
+	AUTH_OK      = "OK"
+	AUTH_EXPIRED = "expired"
+	AUTH_INVALID = "invalid"
+	AUTH_ERROR   = "error"
+)
+
+const AUTH_CHECK_TIMEOUT = 15
+
+// AuthCheckResult is the outcome of a lightweight authenticated request to a site
+type AuthCheckResult struct {
+	Status      string
+	AccountName string // empty if the site's response does not include it
+	Err         error  // set if the status is AUTH_ERROR
+}
+
+type pixivSelfStatusJson struct {
+	Error bool `json:"error"`
+	Body  struct {
+		UserStatus struct {
+			UserName   string `json:"user_name"`
+			IsLoggedIn bool   `json:"is_logged_in"`
+		} `json:"user_status"`
+	} `json:"body"`
+}
+
+// Returns the URL of the authenticated endpoint used to check the cookies of the site
+func getAuthCheckUrl(site string, cookies []*http.Cookie) string {
+	switch site {
+	case utils.FANTIA:
+		return utils.FANTIA_URL + "/mypage"
+	case utils.PIXIV_FANBOX:
+		return utils.PIXIV_FANBOX_API_URL + "/plan.listSupporting"
+	case utils.PIXIV:
+		return utils.PIXIV_URL + "/touch/ajax/user/self/status"
+	case utils.KEMONO:
+		apiUrl := utils.BACKUP_KEMONO_API_URL
+		for _, cookie := range cookies {
+			if cookie.Name == utils.KEMONO_SESSION_COOKIE_NAME && cookie.Domain == utils.KEMONO_COOKIE_DOMAIN {
+				apiUrl = utils.KEMONO_API_URL
+				break
+			}
+		}
+		return apiUrl + "/v1/account/favorites?type=artist"
+	default:
+		// Shouldn't happen but could happen during development
+		panic(
+			fmt.Errorf(
+				"error %d, invalid website, %q, in getAuthCheckUrl",
+				utils.DEV_ERROR,
+				site,
+			),
+		)
+	}
+}
+
+// Sends one authenticated request to the site with the given cookies
+// and returns whether the site accepted them along with the account's name if available.
+//
+// Rejected cookies will be reported as AUTH_EXPIRED if their expiry date has passed
+// and as AUTH_INVALID otherwise.
+func CheckCookieAuth(site string, cookies []*http.Cookie, userAgent string) *AuthCheckResult {
+	checkUrl := getAuthCheckUrl(site, cookies)
+	var headers map[string]string
+	if site != utils.KEMONO {
+		headers = getHeaders(site, userAgent)
+		delete(headers, "User-Agent")
+	}
+
+	useHttp3 := utils.IsHttp3Supported(site, site != utils.FANTIA)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       checkUrl,
+			Cookies:   cookies,
+			Headers:   headers,
+			UserAgent: userAgent,
+			Timeout:   AUTH_CHECK_TIMEOUT,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return &AuthCheckResult{
+			Status: AUTH_ERROR,
+			Err: fmt.Errorf(
+				"error %d: failed to check the credentials for %s, more info => %v",
+				utils.CONNECTION_ERROR,
+				utils.GetReadableSiteStr(site),
+				err,
+			),
+		}
+	}
+
+	result := &AuthCheckResult{}
+	isAuthenticated := false
+	switch site {
+	case utils.FANTIA:
+		// Fantia redirects to the sign in page if the session cookie is not accepted
+		res.Body.Close()
+		resUrl := res.Request.URL.String()
+		isAuthenticated = res.StatusCode == 200 &&
+			(strings.HasPrefix(resUrl, checkUrl) || strings.HasPrefix(resUrl, utils.FANTIA_RECAPTCHA_URL))
+	case utils.PIXIV:
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			break
+		}
+
+		var selfStatus pixivSelfStatusJson
+		if err := utils.LoadJsonFromResponse(res, &selfStatus); err != nil {
+			return &AuthCheckResult{Status: AUTH_ERROR, Err: err}
+		}
+		isAuthenticated = !selfStatus.Error && selfStatus.Body.UserStatus.IsLoggedIn
+		result.AccountName = selfStatus.Body.UserStatus.UserName
+	default:
+		res.Body.Close()
+		isAuthenticated = res.StatusCode == 200
+		if !isAuthenticated && res.StatusCode != 401 && res.StatusCode != 403 {
+			return &AuthCheckResult{
+				Status: AUTH_ERROR,
+				Err: fmt.Errorf(
+					"error %d: unexpected %s response from %s while checking the credentials",
+					utils.RESPONSE_ERROR,
+					res.Status,
+					utils.GetReadableSiteStr(site),
+				),
+			}
+		}
+	}
+
+	switch {
+	case isAuthenticated:
+		result.Status = AUTH_OK
+	case isCookieExpired(cookies):
+		result.Status = AUTH_EXPIRED
+	default:
+		result.Status = AUTH_INVALID
+	}
+	return result
+}
+
+// Returns true if any of the cookies have an expiry date that has passed
+func isCookieExpired(cookies []*http.Cookie) bool {
+	expiry, hasExpiry := utils.GetEarliestCookieExpiry(cookies)
+	return hasExpiry && expiry.Before(time.Now())
+}
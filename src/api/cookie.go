@@ -3,13 +3,11 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
 // Returns a cookie with given value and website to be used in requests
@@ -138,14 +136,14 @@ func processCookieVerification(website string, err error) {
 			true,
 			utils.ERROR,
 		)
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"error %d: could not verify %s cookie.\nPlease refer to the log file for more details.",
 				utils.INPUT_ERROR,
 				utils.GetReadableSiteStr(website),
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_NETWORK_ERROR)
 	}
 }
 
@@ -157,28 +155,28 @@ func backupVerifyCookie(website, cookieValue, userAgent string) *http.Cookie {
 		backupWebsite = utils.KEMONO_BACKUP
 	default:
 		// Shouldn't happen but could happen during development
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"error %d: %s is not supported for cookie verification on a backup domain.",
 				utils.DEV_ERROR,
 				utils.GetReadableSiteStr(website),
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_GENERAL_ERROR)
 	}
 
 	cookie := GetCookie(cookieValue, backupWebsite)
 	cookieIsValid, err := VerifyCookie(cookie, backupWebsite, userAgent)
 	processCookieVerification(backupWebsite, err)
 	if !cookieIsValid {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"error %d: %s cookie is invalid",
 				utils.INPUT_ERROR,
 				utils.GetReadableSiteStr(backupWebsite),
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_AUTH_ERROR)
 	}
 	return cookie
 }
@@ -194,14 +192,14 @@ func VerifyAndGetCookie(website, cookieValue, userAgent string) *http.Cookie {
 
 	if !cookieIsValid {
 		if website != utils.KEMONO {
-			color.Red(
+			utils.PrintError(
 				fmt.Sprintf(
 					"error %d: %s cookie is invalid",
 					utils.INPUT_ERROR,
 					utils.GetReadableSiteStr(website),
 				),
 			)
-			os.Exit(1)
+			utils.Exit(utils.EXIT_AUTH_ERROR)
 		} else {
 			// try to verify the cookie on the backup domain
 			cookie = backupVerifyCookie(website, cookieValue, userAgent)
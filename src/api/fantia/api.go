@@ -8,7 +8,6 @@ import (
 	"strconv"
 	"sync"
 	"time"
-	"os"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
@@ -172,7 +171,7 @@ func manualSolveCaptcha(dlOptions *FantiaDlOptions) error {
 			},
 		)
 		if err != nil {
-			color.Red("failed to check if reCAPTCHA has been solved\n%v", err)
+			utils.PrintError("failed to check if reCAPTCHA has been solved\n%v", err)
 			continue
 		}
 		return nil
@@ -188,13 +187,13 @@ func SolveCaptcha(dlOptions *FantiaDlOptions, alertUser bool) error {
 		// Since reCAPTCHA is per session, the program shall avoid 
 		// trying to solve it and alert the user to login or create a Fantia account.
 		// It is possible that the reCAPTCHA is per IP address for guests, but I'm not sure.
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"fantia error %d: reCAPTCHA detected but you are not logged in. Please login to Fantia and try again.",
 				utils.CAPTCHA_ERROR,
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_AUTH_ERROR)
 	}
 
 	if dlOptions.AutoSolveCaptcha {
@@ -250,7 +249,7 @@ func dlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 		err = SolveCaptcha(dlOptions, true)
 		if err != nil {
 			if err := handleCaptchaErr(err, dlOptions, true); err != nil {
-				os.Exit(1)
+				utils.Exit(utils.EXIT_AUTH_ERROR)
 			}
 		}
 
@@ -258,6 +257,7 @@ func dlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 	} else if err != nil {
 		return nil, err
 	}
+	utils.RUN_STATS.AddPostsProcessed(1)
 
 	// Download the urls
 	request.DownloadUrls(
@@ -267,10 +267,13 @@ func dlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 			Headers:        nil,
 			Cookies:        dlOptions.SessionCookies,
 			UseHttp3:       false,
+			RecordFailures: true,
 		},
 		dlOptions.Configs,
 	)
-	fmt.Println()
+	if !utils.IsQuiet() {
+		fmt.Println()
+	}
 	return postGdriveUrls, nil
 }
 
@@ -287,6 +290,7 @@ func (f *FantiaDl) dlFantiaPosts(dlOptions *FantiaDlOptions) []*request.ToDownlo
 		postGdriveLinks, err := dlFantiaPost(i+1, postIdsLen, postId, dlOptions)
 
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			continue
 		}
@@ -443,6 +447,7 @@ func (f *FantiaDl) getCreatorsPosts(dlOptions *FantiaDlOptions) {
 			}()
 
 			queue <- struct{}{}
+			progress.SetDetail("fanclub " + creatorId)
 			postIds, err := getCreatorPosts(
 				creatorId,
 				f.FanclubPageNums[pageNumIdx],
@@ -29,7 +29,7 @@ type FantiaDl struct {
 func (f *FantiaDl) ValidateArgs() {
 	utils.ValidateIds(f.PostIds)
 	utils.ValidateIds(f.FanclubIds)
-	f.PostIds = utils.RemoveSliceDuplicates(f.PostIds)
+	f.PostIds = utils.RemoveDuplicateIds("post ID", f.PostIds)
 
 	if len(f.FanclubPageNums) > 0 {
 		utils.ValidatePageNumInput(
@@ -44,6 +44,7 @@ func (f *FantiaDl) ValidateArgs() {
 	}
 
 	f.FanclubIds, f.FanclubPageNums = utils.RemoveDuplicateIdAndPageNum(
+		"fanclub ID",
 		f.FanclubIds,
 		f.FanclubPageNums,
 	)
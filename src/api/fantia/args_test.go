@@ -0,0 +1,34 @@
+package fantia
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+)
+
+func TestFantiaDlValidateArgsRemovesDuplicates(t *testing.T) {
+	output := testutils.CaptureColorOutput(t)
+	fantiaDl := &FantiaDl{
+		FanclubIds:      []string{"1", "2", "1"},
+		FanclubPageNums: []string{"1-2", "4", "3"},
+		PostIds:         []string{"3", "3"},
+	}
+	fantiaDl.ValidateArgs()
+
+	if want := []string{"3"}; !reflect.DeepEqual(fantiaDl.PostIds, want) {
+		t.Errorf("got post IDs %q, want %q", fantiaDl.PostIds, want)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(fantiaDl.FanclubIds, want) {
+		t.Errorf("got fanclub IDs %q, want %q", fantiaDl.FanclubIds, want)
+	}
+	if want := []string{"1-2", "4"}; !reflect.DeepEqual(fantiaDl.FanclubPageNums, want) {
+		t.Errorf("got fanclub page numbers %q, want %q", fantiaDl.FanclubPageNums, want)
+	}
+
+	wantOutput := "Removed duplicate post ID 3 at position(s) 2 (kept the one at position 1)\n" +
+		"Removed duplicate fanclub ID 1 at position(s) 3 (kept the one at position 1)\n"
+	if output.String() != wantOutput {
+		t.Errorf("warned %q, want %q", output.String(), wantOutput)
+	}
+}
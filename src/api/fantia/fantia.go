@@ -1,13 +1,14 @@
 package fantia
 
 import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/mega"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
 // Start the download process for Fantia
 func FantiaDownloadProcess(fantiaDl *FantiaDl, fantiaDlOptions *FantiaDlOptions) {
-	if !fantiaDlOptions.DlThumbnails && !fantiaDlOptions.DlImages && !fantiaDlOptions.DlAttachments {
+	if !fantiaDlOptions.DlThumbnails && !fantiaDlOptions.DlImages && !fantiaDlOptions.DlAttachments && !fantiaDlOptions.DlGdrive {
 		return
 	}
 
@@ -18,7 +19,7 @@ func FantiaDownloadProcess(fantiaDl *FantiaDl, fantiaDlOptions *FantiaDlOptions)
 	var gdriveLinks []*request.ToDownload
 	var downloadedPosts bool
 	if len(fantiaDl.PostIds) > 0 {
-		fantiaDl.dlFantiaPosts(fantiaDlOptions)
+		gdriveLinks = fantiaDl.dlFantiaPosts(fantiaDlOptions)
 		downloadedPosts = true
 	}
 
@@ -26,6 +27,9 @@ func FantiaDownloadProcess(fantiaDl *FantiaDl, fantiaDlOptions *FantiaDlOptions)
 		fantiaDlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, fantiaDlOptions.Configs)
 		downloadedPosts = true
 	}
+	if mega.DownloadQueuedLinks() {
+		downloadedPosts = true
+	}
 
 	if downloadedPosts {
 		utils.AlertWithoutErr(utils.Title, "Downloaded all posts from Fantia!")
@@ -35,7 +35,11 @@ type FantiaPost struct {
 			} `json:"user"`
 		} `json:"fanclub"`
 		Status       string `json:"status"`
+		PostedAt     string `json:"posted_at"`
 		PostContents []FantiaContent `json:"post_contents"`
+		Tags         []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
 	} `json:"post"`
 	Redirect string `json:"redirect"` // if get flagged by the system, it will redirect to this recaptcha url
 }
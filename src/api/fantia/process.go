@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 	"strconv"
 
-	"github.com/fatih/color"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/fantia/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
@@ -88,6 +87,10 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 	}
 
 	post := postJson.Post
+	if utils.IsBeforeSince(post.PostedAt) {
+		return nil, nil, nil
+	}
+
 	postId := strconv.Itoa(post.ID)
 	postTitle := post.Title
 	creatorName := post.Fanclub.User.Name
@@ -100,6 +103,26 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 		postId,
 		postTitle,
 	)
+	metadata := &utils.FileMetadata{
+		PostUrl: fmt.Sprintf("%s/posts/%s", utils.FANTIA_URL, postId),
+		Creator: creatorName,
+	}
+	for _, tag := range post.Tags {
+		metadata.Tags = append(metadata.Tags, tag.Name)
+	}
+	if dlOptions.Configs.WriteMetadata {
+		postMetadata := &utils.PostMetadata{
+			Id:       postId,
+			Title:    postTitle,
+			Creator:  creatorName,
+			Tags:     metadata.Tags,
+			PostDate: post.PostedAt,
+			Url:      metadata.PostUrl,
+		}
+		if err := utils.WritePostMetadata(postFolderPath, postMetadata, dlOptions.Configs.OverwriteFiles); err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
+	}
 
 	var urlsSlice []*request.ToDownload
 	thumbnail := post.Thumb.Original
@@ -119,7 +142,8 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 
 	postContent := post.PostContents
 	if postContent == nil {
-		return urlsSlice, gdriveLinks, nil
+		urlsSlice = request.SampleToDownload(urlsSlice, dlOptions.Configs.SampleSize)
+		return request.SetMetadata(urlsSlice, metadata), request.SetMetadata(gdriveLinks, metadata), nil
 	}
 	for _, content := range postContent {
 		commentGdriveLinks := gdrive.ProcessPostText(
@@ -138,7 +162,8 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 			urlsSlice = append(urlsSlice, dlAttachmentsFromPost(&content, postFolderPath)...)
 		}
 	}
-	return urlsSlice, gdriveLinks, nil
+	urlsSlice = request.SampleToDownload(urlsSlice, dlOptions.Configs.SampleSize)
+	return request.SetMetadata(urlsSlice, metadata), request.SetMetadata(gdriveLinks, metadata), nil
 }
 
 type processIllustArgs struct {
@@ -173,13 +198,13 @@ func processIllustDetailApiRes(illustArgs *processIllustArgs, dlOptions *FantiaD
 	progress.Start()
 	urlsToDownload, gdriveLinks, err := processFantiaPost(
 		illustArgs.res,
-		utils.DOWNLOAD_PATH,
+		dlOptions.Configs.DownloadPath,
 		dlOptions,
 	)
 	if err != nil {
 		if err == errRecaptcha {
 			progress.StopWithFn(func() {
-				color.Red("✗ reCAPTCHA detected for the current session...")
+				utils.PrintError("✗ reCAPTCHA detected for the current session...")
 			})
 		} else {
 			progress.Stop(true)
@@ -333,6 +333,7 @@ func getMultipleCreators(creators []*models.KemonoCreatorToDl, downloadPath stri
 	for _, creator := range creators {
 		postsToDl, gdriveLinksToDl, err := getCreatorPosts(creator, downloadPath, dlOptions)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			progress.MsgIncrement(baseMsg)
 			continue
@@ -3,7 +3,6 @@ package kemono
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api"
@@ -11,7 +10,6 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
 const (
@@ -88,60 +86,39 @@ func ProcessPostUrls(postUrls []string) []*models.KemonoPostToDl {
 }
 
 // RemoveDuplicates removes duplicate creators and posts from the slice
+// while preserving the order and warns the user about the removed duplicates.
 func (k *KemonoDl) RemoveDuplicates() {
-	if len(k.CreatorsToDl) > 0 {
-		newCreatorSlice := make([]*models.KemonoCreatorToDl, 0, len(k.CreatorsToDl))
-		seen := make(map[string]struct{})
-		for _, creator := range k.CreatorsToDl {
-			key := fmt.Sprintf("%s/%s", creator.Service, creator.CreatorId)
-			if _, ok := seen[key]; ok {
-				continue
-			}
-			seen[key] = struct{}{}
-			newCreatorSlice = append(newCreatorSlice, creator)
-		}
-		k.CreatorsToDl = newCreatorSlice
-	}
-
-	if len(k.PostsToDl) == 0 {
-		return
-	}
-	newPostSlice := make([]*models.KemonoPostToDl, 0, len(k.PostsToDl))
-	seen := make(map[string]struct{})
-	for _, post := range k.PostsToDl {
-		key := fmt.Sprintf("%s/%s/%s", post.Service, post.CreatorId, post.PostId)
-		if _, ok := seen[key]; ok {
-			continue
-		}
-		seen[key] = struct{}{}
-		newPostSlice = append(newPostSlice, post)
-	}
-	k.PostsToDl = newPostSlice
+	k.CreatorsToDl = utils.RemoveDuplicatesFunc("creator", k.CreatorsToDl, func(creator *models.KemonoCreatorToDl) string {
+		return fmt.Sprintf("%s/%s", creator.Service, creator.CreatorId)
+	})
+	k.PostsToDl = utils.RemoveDuplicatesFunc("post", k.PostsToDl, func(post *models.KemonoPostToDl) string {
+		return fmt.Sprintf("%s/%s/%s", post.Service, post.CreatorId, post.PostId)
+	})
 }
 
 func (k *KemonoDl) ValidateArgs() {
 	valid, outlier := utils.SliceMatchesRegex(CREATOR_URL_REGEX, k.CreatorUrls)
 	if !valid {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"kemono error %d: invalid creator URL found for kemono party: %s",
 				utils.INPUT_ERROR,
 				outlier,
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 
 	valid, outlier = utils.SliceMatchesRegex(POST_URL_REGEX, k.PostUrls)
 	if !valid {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"kemono error %d: invalid post URL found for kemono party: %s",
 				utils.INPUT_ERROR,
 				outlier,
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 
 	if len(k.CreatorUrls) > 0 {
@@ -193,9 +170,9 @@ func (k *KemonoDlOptions) ValidateArgs(userAgent string) {
 		k.SessionCookies = []*http.Cookie{
 			api.VerifyAndGetCookie(utils.KEMONO, k.SessionCookieId, userAgent),
 		}
-	} else {
-		color.Red("kemono error %d: session cookie ID is required", utils.INPUT_ERROR)
-		os.Exit(1)
+	} else if len(k.SessionCookies) == 0 {
+		utils.PrintError("kemono error %d: session cookie ID or cookie file is required", utils.INPUT_ERROR)
+		utils.Exit(utils.EXIT_AUTH_ERROR)
 	}
 
 	if k.DlGdrive && k.GdriveClient == nil {
@@ -0,0 +1,41 @@
+package kemono
+
+import (
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+)
+
+func TestKemonoDlValidateArgsRemovesDuplicates(t *testing.T) {
+	output := testutils.CaptureColorOutput(t)
+	kemonoDl := &KemonoDl{
+		CreatorUrls: []string{
+			"https://kemono.su/fanbox/user/1",
+			"https://kemono.su/patreon/user/1",
+			"https://kemono.party/fanbox/user/1",
+		},
+		PostUrls: []string{
+			"https://kemono.su/fanbox/user/1/post/2",
+			"https://kemono.su/fanbox/user/1/post/2",
+			"https://kemono.su/fanbox/user/1/post/3",
+		},
+	}
+	kemonoDl.ValidateArgs()
+
+	if got := len(kemonoDl.CreatorsToDl); got != 2 {
+		t.Errorf("kept %d creators, want 2", got)
+	} else if creator := kemonoDl.CreatorsToDl[1]; creator.Service != "patreon" {
+		t.Errorf("kept the creator from %s as the second one, want patreon", creator.Service)
+	}
+	if got := len(kemonoDl.PostsToDl); got != 2 {
+		t.Errorf("kept %d posts, want 2", got)
+	} else if post := kemonoDl.PostsToDl[1]; post.PostId != "3" {
+		t.Errorf("kept the post %s as the second one, want 3", post.PostId)
+	}
+
+	wantOutput := "Removed duplicate creator fanbox/1 at position(s) 3 (kept the one at position 1)\n" +
+		"Removed duplicate post fanbox/1/2 at position(s) 2 (kept the one at position 1)\n"
+	if output.String() != wantOutput {
+		t.Errorf("warned %q, want %q", output.String(), wantOutput)
+	}
+}
@@ -2,6 +2,7 @@ package kemono
 
 import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/mega"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
@@ -24,7 +25,7 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 		)
 		progress.Start()
 		favToDl, favGdriveLinks, err := getFavourites(
-			utils.DOWNLOAD_PATH,
+			config.DownloadPath,
 			dlOptions,
 		)
 		hasErr := (err != nil)
@@ -40,7 +41,7 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 	if len(kemonoDl.PostsToDl) > 0 {
 		postsToDl, gdriveLinksToDl := getMultiplePosts(
 			kemonoDl.PostsToDl,
-			utils.DOWNLOAD_PATH,
+			config.DownloadPath,
 			dlOptions,
 		)
 		toDownload = append(toDownload, postsToDl...)
@@ -49,7 +50,7 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 	if len(kemonoDl.CreatorsToDl) > 0 {
 		creatorsToDl, gdriveLinksToDl := getMultipleCreators(
 			kemonoDl.CreatorsToDl,
-			utils.DOWNLOAD_PATH,
+			config.DownloadPath,
 			dlOptions,
 		)
 		toDownload = append(toDownload, creatorsToDl...)
@@ -65,6 +66,7 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 				MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
 				Cookies:        dlOptions.SessionCookies,
 				UseHttp3:       utils.IsHttp3Supported(utils.KEMONO, false),
+				RecordFailures: true,
 			},
 			config,
 		)
@@ -73,6 +75,9 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 		downloadedPosts = true
 		dlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, config)
 	}
+	if mega.DownloadQueuedLinks() {
+		downloadedPosts = true
+	}
 
 	if downloadedPosts {
 		utils.AlertWithoutErr(utils.Title, "Downloaded all posts from Kemono Party!")
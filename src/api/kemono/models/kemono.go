@@ -1,5 +1,45 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// KemonoTags is the tags of a post which are returned by Kemono Party as a JSON array
+// or as a PostgreSQL array string like "{tag1,tag2}" depending on the post.
+type KemonoTags []string
+
+func (tags *KemonoTags) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*tags = nil
+	case []any:
+		*tags = make(KemonoTags, 0, len(v))
+		for _, tag := range v {
+			if tagStr, ok := tag.(string); ok && tagStr != "" {
+				*tags = append(*tags, tagStr)
+			}
+		}
+	case string:
+		*tags = nil
+		for _, tag := range strings.Split(strings.Trim(v, "{}"), ",") {
+			tag = strings.Trim(strings.TrimSpace(tag), `"`)
+			if tag != "" {
+				*tags = append(*tags, tag)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown Kemono Party post tags, %s", string(data))
+	}
+	return nil
+}
+
 type MainKemonoJson struct {
 	Added       string `json:"added"`
 	Attachments []struct {
@@ -18,12 +58,13 @@ type MainKemonoJson struct {
 		Name string `json:"name"`
 		Path string `json:"path"`
 	} `json:"file"`
-	Id         string `json:"id"`
-	Published  string `json:"published"`
-	Service    string `json:"service"`
-	SharedFile bool   `json:"shared_file"`
-	Title      string `json:"title"`
-	User       string `json:"user"`
+	Id         string     `json:"id"`
+	Published  string     `json:"published"`
+	Service    string     `json:"service"`
+	SharedFile bool       `json:"shared_file"`
+	Tags       KemonoTags `json:"tags"`
+	Title      string     `json:"title"`
+	User       string     `json:"user"`
 }
 
 type KemonoJson []*MainKemonoJson
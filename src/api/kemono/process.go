@@ -42,7 +42,22 @@ func getKemonoFilePath(postFolderPath, childDir, fileName string) string {
 }
 
 func processJson(resJson *models.MainKemonoJson, tld, downloadPath string, dlOptions *KemonoDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
+	if utils.IsBeforeSince(resJson.Published) {
+		return nil, nil
+	}
+
 	var creatorNamePath string
+	metadata := &utils.FileMetadata{
+		PostUrl: fmt.Sprintf(
+			"%s/%s/user/%s/post/%s",
+			getKemonoUrl(tld),
+			resJson.Service,
+			resJson.User,
+			resJson.Id,
+		),
+		Creator: resJson.User,
+		Tags:    resJson.Tags,
+	}
 	if creatorName, err := getCreatorName(resJson.Service, resJson.User, dlOptions); err != nil {
 		err = fmt.Errorf(
 			"error getting creator name for %q (%s)... falling back to creator ID! (Details below)\n%v",
@@ -54,6 +69,7 @@ func processJson(resJson *models.MainKemonoJson, tld, downloadPath string, dlOpt
 		creatorNamePath = resJson.User
 	} else {
 		creatorNamePath = fmt.Sprintf("%s [%s]", creatorName, resJson.User)
+		metadata.Creator = creatorName
 	}
 
 	postFolderPath := utils.GetPostFolder(
@@ -62,6 +78,19 @@ func processJson(resJson *models.MainKemonoJson, tld, downloadPath string, dlOpt
 		resJson.Id,
 		resJson.Title,
 	)
+	if dlOptions.Configs.WriteMetadata {
+		postMetadata := &utils.PostMetadata{
+			Id:       resJson.Id,
+			Title:    resJson.Title,
+			Creator:  metadata.Creator,
+			Tags:     metadata.Tags,
+			PostDate: resJson.Published,
+			Url:      metadata.PostUrl,
+		}
+		if err := utils.WritePostMetadata(postFolderPath, postMetadata, dlOptions.Configs.OverwriteFiles); err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
+	}
 
 	var gdriveLinks []*request.ToDownload
 	var toDownload []*request.ToDownload
@@ -79,6 +108,7 @@ func processJson(resJson *models.MainKemonoJson, tld, downloadPath string, dlOpt
 			if dlOptions.Configs.LogUrls {
 				utils.DetectOtherExtDLLink(resJson.Embed.Url, embedsDirPath)
 			}
+			utils.DetectMegaLinks(resJson.Embed.Url, embedsDirPath)
 			if utils.DetectGDriveLinks(resJson.Embed.Url, postFolderPath, true, dlOptions.Configs.LogUrls,) && dlOptions.DlGdrive {
 				gdriveLinks = append(gdriveLinks, &request.ToDownload{
 					Url:      resJson.Embed.Url,
@@ -103,7 +133,7 @@ func processJson(resJson *models.MainKemonoJson, tld, downloadPath string, dlOpt
 		dlOptions.Configs.LogUrls,
 	)
 	gdriveLinks = append(gdriveLinks, contentGdriveLinks...)
-	return toDownload, gdriveLinks
+	return request.SetMetadata(toDownload, metadata), request.SetMetadata(gdriveLinks, metadata)
 }
 
 func processMultipleJson(resJson models.KemonoJson, tld, downloadPath string, dlOptions *KemonoDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
@@ -113,5 +143,6 @@ func processMultipleJson(resJson models.KemonoJson, tld, downloadPath string, dl
 		urlsToDownload = append(urlsToDownload, toDownload...)
 		gdriveLinks = append(gdriveLinks, foundGdriveLinks...)
 	}
+	utils.RUN_STATS.AddPostsProcessed(len(resJson))
 	return urlsToDownload, gdriveLinks
 }
@@ -1,6 +1,21 @@
 package pixiv
 
-import "github.com/KJHJason/Cultured-Downloader-CLI/utils"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/web"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	PIXIV_CLIENT_MOBILE = "mobile"
+	PIXIV_CLIENT_WEB    = "web"
+)
+
+// The Pixiv clients that can be preferred when both the refresh token and the session cookie are given
+var ACCEPTED_PIXIV_CLIENTS = []string{PIXIV_CLIENT_MOBILE, PIXIV_CLIENT_WEB}
 
 // PixivDl contains the IDs of the Pixiv artworks and
 // illustrators and Tag Names to download.
@@ -10,8 +25,143 @@ type PixivDl struct {
 	IllustratorIds      []string
 	IllustratorPageNums []string
 
+	// Download the avatars and banners of the illustrators into their folders
+	DlIllustratorProfile bool
+
 	TagNames         []string
 	TagNamesPageNums []string
+
+	// The novels to download which are only supported by the mobile client
+	NovelIds         []string
+	NovelistIds      []string
+	NovelistPageNums []string
+	NovelSeriesIds   []string
+
+	// The user ID whose bookmarked artworks to download
+	BookmarksUserId  string
+	BookmarksPageNum string
+	PrivateBookmarks bool
+
+	// The manga series to download which are only supported by the web client
+	SeriesIds []string
+
+	// Download the latest artworks from the illustrators followed by the user
+	// which is only supported by the web client and requires the user's session cookie
+	Following        bool
+	FollowingPageNum string
+
+	// The rankings to download which are only supported by the web client
+	RankingMode    string
+	RankingDate    string // in the format of YYYYMMDD, defaults to the latest rankings if empty
+	RankingPageNum string
+
+	// Download paths for each source that will override
+	// the download path in the configs if not empty.
+	ArtworkDlPath     string
+	IllustratorDlPath string
+	TagDlPath         string
+}
+
+// Returns true if downloading the artworks requires the user to be logged in
+// like searching for artworks by tag names which Pixiv only returns the R-18 results of to logged in users.
+func (p *PixivDl) RequiresAuth(ratingMode string) bool {
+	return len(p.TagNames) > 0 && strings.ToLower(ratingMode) != "safe"
+}
+
+// Returns true if the bookmarked artworks of a user should be downloaded
+// which requires the web client and the user's session cookie.
+func (p *PixivDl) HasBookmarks() bool {
+	return p.BookmarksUserId != ""
+}
+
+// Returns true if the artworks from the rankings should be downloaded
+// which requires the web client.
+func (p *PixivDl) HasRanking() bool {
+	return p.RankingMode != ""
+}
+
+// Returns true if the R-18 rankings should be downloaded which requires the user's session cookie
+func (p *PixivDl) HasR18Ranking() bool {
+	return strings.Contains(p.RankingMode, "r18")
+}
+
+// Returns true if downloading the artworks is only supported by the web client
+func (p *PixivDl) RequiresWebClient() bool {
+	return p.HasRanking() || len(p.SeriesIds) > 0 || p.Following
+}
+
+// Returns true if any novels should be downloaded
+// which requires the mobile client and the user's refresh token.
+func (p *PixivDl) HasNovels() bool {
+	return len(p.NovelIds) > 0 || len(p.NovelistIds) > 0 || len(p.NovelSeriesIds) > 0
+}
+
+// Returns true if any artworks (as opposed to novels) should be downloaded
+// which means that ugoira may have to be converted.
+func (p *PixivDl) HasArtworks() bool {
+	return len(p.ArtworkIds) > 0 || len(p.IllustratorIds) > 0 || len(p.TagNames) > 0 ||
+		p.HasBookmarks() || p.RequiresWebClient()
+}
+
+// Returns the Pixiv client, PIXIV_CLIENT_MOBILE or PIXIV_CLIENT_WEB, to use based on the given credentials.
+//
+// The preferred client will be used if both the refresh token and the session cookie are given.
+// Returns an empty string if neither was given.
+func GetPixivClient(hasRefreshToken, hasSession bool, prefer string) string {
+	switch {
+	case hasRefreshToken && hasSession:
+		return prefer
+	case hasRefreshToken:
+		return PIXIV_CLIENT_MOBILE
+	case hasSession:
+		return PIXIV_CLIENT_WEB
+	}
+	return ""
+}
+
+// Returns the download path of the source if set, otherwise the given default download path
+func getSourceDlPath(sourceDlPath, defaultDlPath string) string {
+	if sourceDlPath != "" {
+		return sourceDlPath
+	}
+	return defaultDlPath
+}
+
+// Validates the ranking mode, date, and page number if the rankings should be downloaded
+func (p *PixivDl) validateRankingArgs() {
+	p.RankingMode = strings.ToLower(strings.TrimSpace(p.RankingMode))
+	if !p.HasRanking() {
+		return
+	}
+
+	utils.ValidateStrArgs(
+		p.RankingMode,
+		pixivweb.ACCEPTED_RANKING_MODES,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Ranking mode %s is not allowed",
+				utils.INPUT_ERROR,
+				p.RankingMode,
+			),
+		},
+	)
+	if p.RankingDate != "" {
+		if _, err := time.Parse("20060102", p.RankingDate); err != nil {
+			utils.PrintError(
+				"pixiv error %d: invalid ranking date, %q, please follow the format \"YYYYMMDD\", e.g. \"20240101\"",
+				utils.INPUT_ERROR,
+				p.RankingDate,
+			)
+			utils.Exit(utils.EXIT_INPUT_ERROR)
+		}
+	}
+	if p.RankingPageNum != "" {
+		utils.ValidatePageNumInput(
+			1,
+			[]string{p.RankingPageNum},
+			nil,
+		)
+	}
 }
 
 // ValidateArgs validates the IDs of the Pixiv artworks and illustrators to download.
@@ -22,7 +172,27 @@ type PixivDl struct {
 func (p *PixivDl) ValidateArgs() {
 	utils.ValidateIds(p.ArtworkIds)
 	utils.ValidateIds(p.IllustratorIds)
-	p.ArtworkIds = utils.RemoveSliceDuplicates(p.ArtworkIds)
+	if p.HasBookmarks() {
+		utils.ValidateIds([]string{p.BookmarksUserId})
+		if p.BookmarksPageNum != "" {
+			utils.ValidatePageNumInput(
+				1,
+				[]string{p.BookmarksPageNum},
+				nil,
+			)
+		}
+	}
+	p.ArtworkIds = utils.RemoveDuplicateIds("artwork ID", p.ArtworkIds)
+	utils.ValidateIds(p.SeriesIds)
+	p.SeriesIds = utils.RemoveDuplicateIds("series ID", p.SeriesIds)
+	p.validateRankingArgs()
+	if p.Following && p.FollowingPageNum != "" {
+		utils.ValidatePageNumInput(
+			1,
+			[]string{p.FollowingPageNum},
+			nil,
+		)
+	}
 
 	if len(p.IllustratorPageNums) > 0 {
 		utils.ValidatePageNumInput(
@@ -36,6 +206,7 @@ func (p *PixivDl) ValidateArgs() {
 		p.IllustratorPageNums = make([]string, len(p.IllustratorIds))
 	}
 	p.IllustratorIds, p.IllustratorPageNums = utils.RemoveDuplicateIdAndPageNum(
+		"illustrator ID",
 		p.IllustratorIds,
 		p.IllustratorPageNums,
 	)
@@ -52,7 +223,30 @@ func (p *PixivDl) ValidateArgs() {
 		p.TagNamesPageNums = make([]string, len(p.TagNames))
 	}
 	p.TagNames, p.TagNamesPageNums = utils.RemoveDuplicateIdAndPageNum(
+		"tag name",
 		p.TagNames,
 		p.TagNamesPageNums,
 	)
+
+	utils.ValidateIds(p.NovelIds)
+	utils.ValidateIds(p.NovelistIds)
+	utils.ValidateIds(p.NovelSeriesIds)
+	p.NovelIds = utils.RemoveDuplicateIds("novel ID", p.NovelIds)
+	p.NovelSeriesIds = utils.RemoveDuplicateIds("novel series ID", p.NovelSeriesIds)
+	if len(p.NovelistPageNums) > 0 {
+		utils.ValidatePageNumInput(
+			len(p.NovelistIds),
+			p.NovelistPageNums,
+			[]string{
+				"Number of novelist ID(s) and novelists' page numbers must be equal.",
+			},
+		)
+	} else {
+		p.NovelistPageNums = make([]string, len(p.NovelistIds))
+	}
+	p.NovelistIds, p.NovelistPageNums = utils.RemoveDuplicateIdAndPageNum(
+		"novelist ID",
+		p.NovelistIds,
+		p.NovelistPageNums,
+	)
 }
@@ -0,0 +1,71 @@
+package pixiv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+)
+
+func TestGetPixivClient(t *testing.T) {
+	tests := []struct {
+		name            string
+		hasRefreshToken bool
+		hasSession      bool
+		prefer          string
+		want            string
+	}{
+		{name: "no credentials", prefer: PIXIV_CLIENT_MOBILE, want: ""},
+		{name: "refresh token only", hasRefreshToken: true, prefer: PIXIV_CLIENT_WEB, want: PIXIV_CLIENT_MOBILE},
+		{name: "session only", hasSession: true, prefer: PIXIV_CLIENT_MOBILE, want: PIXIV_CLIENT_WEB},
+		{name: "both prefer mobile", hasRefreshToken: true, hasSession: true, prefer: PIXIV_CLIENT_MOBILE, want: PIXIV_CLIENT_MOBILE},
+		{name: "both prefer web", hasRefreshToken: true, hasSession: true, prefer: PIXIV_CLIENT_WEB, want: PIXIV_CLIENT_WEB},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := GetPixivClient(test.hasRefreshToken, test.hasSession, test.prefer)
+			if got != test.want {
+				t.Errorf(
+					"GetPixivClient(%v, %v, %q) = %q, want %q",
+					test.hasRefreshToken, test.hasSession, test.prefer, got, test.want,
+				)
+			}
+		})
+	}
+}
+
+func TestPixivDlValidateArgsRemovesDuplicates(t *testing.T) {
+	output := testutils.CaptureColorOutput(t)
+	pixivDl := &PixivDl{
+		ArtworkIds:          []string{"1", "1"},
+		IllustratorIds:      []string{"2", "3", "2"},
+		IllustratorPageNums: []string{"1", "2", "3"},
+		TagNames:            []string{"tag", "tag"},
+		NovelIds:            []string{"4", "5", "4"},
+	}
+	pixivDl.ValidateArgs()
+
+	if want := []string{"1"}; !reflect.DeepEqual(pixivDl.ArtworkIds, want) {
+		t.Errorf("got artwork IDs %q, want %q", pixivDl.ArtworkIds, want)
+	}
+	if want := []string{"2", "3"}; !reflect.DeepEqual(pixivDl.IllustratorIds, want) {
+		t.Errorf("got illustrator IDs %q, want %q", pixivDl.IllustratorIds, want)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(pixivDl.IllustratorPageNums, want) {
+		t.Errorf("got illustrator page numbers %q, want %q", pixivDl.IllustratorPageNums, want)
+	}
+	if want := []string{"tag"}; !reflect.DeepEqual(pixivDl.TagNames, want) {
+		t.Errorf("got tag names %q, want %q", pixivDl.TagNames, want)
+	}
+	if want := []string{"4", "5"}; !reflect.DeepEqual(pixivDl.NovelIds, want) {
+		t.Errorf("got novel IDs %q, want %q", pixivDl.NovelIds, want)
+	}
+
+	wantOutput := "Removed duplicate artwork ID 1 at position(s) 2 (kept the one at position 1)\n" +
+		"Removed duplicate illustrator ID 2 at position(s) 3 (kept the one at position 1)\n" +
+		"Removed duplicate tag name tag at position(s) 2 (kept the one at position 1)\n" +
+		"Removed duplicate novel ID 4 at position(s) 3 (kept the one at position 1)\n"
+	if output.String() != wantOutput {
+		t.Errorf("warned %q, want %q", output.String(), wantOutput)
+	}
+}
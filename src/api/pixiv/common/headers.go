@@ -23,6 +23,12 @@ func GetIllustUrl(illustId string) string {
 	)
 }
 
+// Returns the cache key of the artwork's page (starting from 0) which stays
+// the same even if the same artwork is queued from different sources like a tag search and an illustrator.
+func GetArtworkCacheKey(artworkId string, page int) string {
+	return fmt.Sprintf("pixiv/%s_p%d", artworkId, page)
+}
+
 // Get the Pixiv user page URL for the referral header value
 func GetUserUrl(userId string) string {
 	return fmt.Sprintf(
@@ -31,3 +37,21 @@ func GetUserUrl(userId string) string {
 		userId,
 	)
 }
+
+// Get the Pixiv novel page URL
+func GetNovelUrl(novelId string) string {
+	return fmt.Sprintf(
+		"%s/novel/show.php?id=%s",
+		utils.PIXIV_URL,
+		novelId,
+	)
+}
+
+// Get the Pixiv novel series page URL
+func GetNovelSeriesUrl(seriesId string) string {
+	return fmt.Sprintf(
+		"%s/novel/series/%s",
+		utils.PIXIV_URL,
+		seriesId,
+	)
+}
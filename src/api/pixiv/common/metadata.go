@@ -0,0 +1,39 @@
+package pixivcommon
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// ArtworkMetadata is the artwork's context that is saved alongside the downloaded files with "--write_metadata".
+//
+// It has the same fields as the metadata of the other sites with the Pixiv-specific details added.
+type ArtworkMetadata struct {
+	utils.PostMetadata
+	UserId        string `json:"userId"`
+	Description   string `json:"description"`
+	PageCount     int    `json:"pageCount"`
+	BookmarkCount int    `json:"bookmarkCount"`
+
+	// Deprecated: the same as "creator" and "postDate" which are only kept
+	// for the metadata files that were written with "--save_metadata".
+	UserName   string `json:"userName"`
+	CreateDate string `json:"createDate"`
+}
+
+// Returns the English translation of the tag if "--translate_tags" is enabled
+// and Pixiv has a translation for it, otherwise the original tag name is returned.
+func GetTagName(tagName, translation string, translateTags bool) string {
+	if translateTags && translation != "" {
+		return translation
+	}
+	return tagName
+}
+
+// Writes the artwork's metadata as a JSON file into the given post folder.
+//
+// The existing metadata file will be left as it is unless overwrite is true.
+func WriteArtworkMetadata(postFolderPath string, metadata *ArtworkMetadata, overwrite bool) error {
+	metadata.UserName = metadata.Creator
+	metadata.CreateDate = metadata.PostDate
+	return utils.WritePostMetadata(postFolderPath, metadata, overwrite)
+}
@@ -0,0 +1,29 @@
+package pixivcommon
+
+import "testing"
+
+func TestGetTagName(t *testing.T) {
+	tests := []struct {
+		name          string
+		tagName       string
+		translation   string
+		translateTags bool
+		want          string
+	}{
+		{name: "translated", tagName: "オリジナル", translation: "original", translateTags: true, want: "original"},
+		{name: "no translation", tagName: "オリジナル", translateTags: true, want: "オリジナル"},
+		{name: "translation disabled", tagName: "オリジナル", translation: "original", want: "オリジナル"},
+		{name: "translation disabled without translation", tagName: "オリジナル", want: "オリジナル"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := GetTagName(test.tagName, test.translation, test.translateTags)
+			if got != test.want {
+				t.Errorf(
+					"GetTagName(%q, %q, %v) = %q, want %q",
+					test.tagName, test.translation, test.translateTags, got, test.want,
+				)
+			}
+		})
+	}
+}
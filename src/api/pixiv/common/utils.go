@@ -1,6 +1,19 @@
 package pixivcommon
 
-import "github.com/KJHJason/Cultured-Downloader-CLI/utils"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
 
 // Convert the page number to the offset as one page will have 60 illustrations.
 //
@@ -22,3 +35,622 @@ func ConvertPageNumToOffset(minPageNum, maxPageNum, perPage int, checkPixivMax b
 	}
 	return minOffset, maxOffset
 }
+
+// Exits the program if the range of the random delay in seconds between requests to Pixiv
+// given by "--pixiv_delay_min" and "--pixiv_delay_max" is invalid.
+func ValidateSleepRange(minSleep, maxSleep float64) {
+	if minSleep < 0 || maxSleep < 0 {
+		utils.PrintError(
+			"pixiv error %d: the delay between requests must be 0 or more seconds, got %v and %v",
+			utils.INPUT_ERROR,
+			minSleep,
+			maxSleep,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	if minSleep > maxSleep {
+		utils.PrintError(
+			"pixiv error %d: the minimum delay between requests, %v, must not be more than the maximum delay, %v",
+			utils.INPUT_ERROR,
+			minSleep,
+			maxSleep,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+}
+
+// Returns true if the illustrator ID is in the whitelist from "--whitelist_file"
+// or if there is no whitelist.
+func IsWhitelistedIllustrator(whitelist []string, illustratorId string) bool {
+	return len(whitelist) == 0 || utils.SliceContains(whitelist, illustratorId)
+}
+
+// Logs the number of tag search results of the given tag name
+// that were dropped as they are not from the whitelisted illustrators.
+func LogWhitelistFilteredCount(tagName string, filteredCount int) {
+	if filteredCount == 0 {
+		return
+	}
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, filteredCount)
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Filtered out %d artwork(s) from the tag search results of %q as they are not from the whitelisted illustrator(s).",
+			filteredCount,
+			tagName,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// Returns the tag names from "--exclude_tags" without the surrounding
+// whitespaces, the leading "-", and the duplicates.
+func CleanExcludeTags(excludeTags []string) []string {
+	cleanedTags := make([]string, 0, len(excludeTags))
+	for _, tag := range excludeTags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "-")
+		if tag != "" {
+			cleanedTags = append(cleanedTags, tag)
+		}
+	}
+	return utils.RemoveSliceDuplicates(cleanedTags)
+}
+
+// Returns the search term for the tag name with the excluded tags
+// appended as "-tag" tokens so that Pixiv will leave out the artworks with them.
+func GetTagSearchWord(tagName string, excludeTags []string) string {
+	var sb strings.Builder
+	sb.WriteString(tagName)
+	for _, tag := range excludeTags {
+		sb.WriteString(" -")
+		sb.WriteString(tag)
+	}
+	return sb.String()
+}
+
+// Returns true if any of the artwork's tags is in the excluded tags from "--exclude_tags".
+//
+// The tags are compared case-insensitively as Pixiv's search does not differentiate them.
+func HasExcludedTag(artworkTags, excludeTags []string) bool {
+	for _, artworkTag := range artworkTags {
+		for _, excludeTag := range excludeTags {
+			if strings.EqualFold(artworkTag, excludeTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Logs the number of tag search results of the given tag name
+// that were dropped as they have one of the excluded tags.
+func LogExcludedTagsFilteredCount(tagName string, filteredCount int) {
+	if filteredCount == 0 {
+		return
+	}
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, filteredCount)
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Filtered out %d artwork(s) from the tag search results of %q as they have one of the excluded tag(s).",
+			filteredCount,
+			tagName,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// Prints the total number of tag search results that
+// were dropped as they have one of the excluded tags.
+func PrintExcludedTagsFilteredCount(excludeTags []string, filteredCount int) {
+	if len(excludeTags) == 0 {
+		return
+	}
+	color.Yellow(
+		"Filtered out %d artwork(s) from the tag search results as they have one of the %d excluded tag(s).",
+		filteredCount,
+		len(excludeTags),
+	)
+}
+
+// Logs the number of tag search results of the given tag name
+// that were dropped as they have fewer bookmarks than "--min_bookmarks".
+func LogMinBookmarksFilteredCount(tagName string, minBookmarks, filteredCount int) {
+	if filteredCount == 0 {
+		return
+	}
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, filteredCount)
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Filtered out %d artwork(s) from the tag search results of %q as they have fewer than %d bookmarks.",
+			filteredCount,
+			tagName,
+			minBookmarks,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// Caps the number of artwork IDs collected from the tag search results of a tag as given by "--max_artworks".
+//
+// The same artwork found by more than one sort order is only counted once.
+type ArtworkLimit struct {
+	maxArtworks int // 0 means there is no limit
+	artworkIds  map[string]struct{}
+}
+
+func NewArtworkLimit(maxArtworks int) *ArtworkLimit {
+	return &ArtworkLimit{
+		maxArtworks: maxArtworks,
+		artworkIds:  make(map[string]struct{}),
+	}
+}
+
+// Returns false if the artwork should be dropped as the limit has been reached
+func (l *ArtworkLimit) Add(artworkId string) bool {
+	if l.maxArtworks <= 0 {
+		return true
+	}
+	if _, ok := l.artworkIds[artworkId]; ok {
+		return true
+	}
+	if len(l.artworkIds) >= l.maxArtworks {
+		return false
+	}
+	l.artworkIds[artworkId] = struct{}{}
+	return true
+}
+
+// Returns true if the limit has been reached and no more pages should be requested
+func (l *ArtworkLimit) IsReached() bool {
+	return l.maxArtworks > 0 && len(l.artworkIds) >= l.maxArtworks
+}
+
+// Logs that the tag search of the tag was stopped early due to "--max_artworks"
+func LogMaxArtworksReached(tagName string, limit *ArtworkLimit) {
+	if !limit.IsReached() {
+		return
+	}
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Stopped the tag search of %q after collecting %d artwork(s) as \"--max_artworks\" has been reached.",
+			tagName,
+			limit.maxArtworks,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// The aiType of the artworks that are flagged as AI-generated by Pixiv
+const AI_GENERATED = 2
+
+// The number of AI-generated artworks that were dropped due to "--no_ai" in the run
+var aiSkippedCount atomic.Int64
+
+// Returns true if the artwork should be dropped as "--no_ai" is enabled and it is AI-generated.
+//
+// The dropped artworks are counted for PrintAiSkippedCount().
+func SkipAiGenerated(noAi bool, aiType int) bool {
+	if !noAi || aiType != AI_GENERATED {
+		return false
+	}
+	aiSkippedCount.Add(1)
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, 1)
+	return true
+}
+
+// Prints the number of AI-generated artworks that were dropped due to "--no_ai"
+func PrintAiSkippedCount(noAi bool) {
+	if !noAi {
+		return
+	}
+	color.Yellow("Skipped %d AI-generated artwork(s) as \"--no_ai\" is enabled.", aiSkippedCount.Load())
+}
+
+var ratingSkippedCount atomic.Int64
+
+// Returns true if the artwork should be dropped as its rating does not match "--rating_mode"
+// where an xRestrict value of 0 is all ages and 1 or more is R-18 or R-18G.
+//
+// The dropped artworks are counted for PrintRatingSkippedCount().
+func SkipRating(ratingMode string, xRestrict int) bool {
+	isR18 := xRestrict > 0
+	if (ratingMode == "safe" && !isR18) || (ratingMode == "r18" && isR18) || ratingMode == "all" {
+		return false
+	}
+	ratingSkippedCount.Add(1)
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, 1)
+	return true
+}
+
+// Prints the number of artworks that were dropped as their rating does not match "--rating_mode"
+func PrintRatingSkippedCount(ratingMode string) {
+	if ratingMode == "all" {
+		return
+	}
+	color.Yellow("Skipped %d artwork(s) that do not match the %q rating mode.", ratingSkippedCount.Load(), ratingMode)
+}
+
+// The date format of "--search_start_date" and "--search_end_date"
+const SEARCH_DATE_FORMAT = "2006-01-02"
+
+// Validates the date range of the tag search from "--search_start_date" and "--search_end_date".
+//
+// Either date can be empty to leave the range open on that side.
+func ValidateSearchDateRange(startDate, endDate string) {
+	parseDate := func(flagName, date string) time.Time {
+		parsedDate, err := time.ParseInLocation(SEARCH_DATE_FORMAT, date, time.Local)
+		if err != nil {
+			utils.PrintError(
+				"pixiv error %d: %s, %q, must be in the YYYY-MM-DD format",
+				utils.INPUT_ERROR,
+				flagName,
+				date,
+			)
+			utils.Exit(utils.EXIT_INPUT_ERROR)
+		}
+		if parsedDate.After(time.Now()) {
+			utils.PrintError(
+				"pixiv error %d: %s, %q, must not be in the future",
+				utils.INPUT_ERROR,
+				flagName,
+				date,
+			)
+			utils.Exit(utils.EXIT_INPUT_ERROR)
+		}
+		return parsedDate
+	}
+
+	var start, end time.Time
+	if startDate != "" {
+		start = parseDate("--search_start_date", startDate)
+	}
+	if endDate != "" {
+		end = parseDate("--search_end_date", endDate)
+	}
+	if startDate != "" && endDate != "" && end.Before(start) {
+		utils.PrintError(
+			"pixiv error %d: the search end date, %q, must not be before the search start date, %q",
+			utils.INPUT_ERROR,
+			endDate,
+			startDate,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+}
+
+// Validates the minimum bookmark count from "--min_bookmarks"
+func ValidateMinBookmarks(minBookmarks int) {
+	if minBookmarks < 0 {
+		utils.PrintError(
+			"pixiv error %d: the minimum bookmark count must be 0 or more, got %d",
+			utils.INPUT_ERROR,
+			minBookmarks,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+}
+
+// Validates the maximum number of artworks per tag from "--max_artworks"
+func ValidateMaxArtworks(maxArtworks int) {
+	if maxArtworks < 0 {
+		utils.PrintError(
+			"pixiv error %d: the maximum number of artworks per tag must be 0 or more, got %d",
+			utils.INPUT_ERROR,
+			maxArtworks,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+}
+
+// Prints the total number of tag search results that
+// were dropped as they are not from the whitelisted illustrators.
+func PrintWhitelistFilteredCount(whitelist []string, filteredCount int) {
+	if len(whitelist) == 0 {
+		return
+	}
+	color.Yellow(
+		"Filtered out %d artwork(s) from the tag search results as they are not from the %d whitelisted illustrator(s).",
+		filteredCount,
+		len(whitelist),
+	)
+}
+
+// Returns the sort orders to search for a tag name with when "--max_coverage" is enabled.
+//
+// The given sort order comes first so that its results are kept in order when removing the duplicates.
+func GetMaxCoverageSortOrders(sortOrder string, coverageSortOrders []string) []string {
+	sortOrders := []string{sortOrder}
+	for _, coverageSortOrder := range coverageSortOrders {
+		if coverageSortOrder != sortOrder {
+			sortOrders = append(sortOrders, coverageSortOrder)
+		}
+	}
+	return sortOrders
+}
+
+// Logs the number of unique artworks found after searching for
+// the tag name with multiple sort orders due to "--max_coverage".
+func LogMaxCoverageResults(tagName string, sortOrderCount, totalCount, uniqueCount int) {
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Found %d unique artwork(s) from %d tag search result(s) of %q across %d sort orders.",
+			uniqueCount,
+			totalCount,
+			tagName,
+			sortOrderCount,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// ArtworkPages is the range of pages to download from each artwork from the "--artwork_pages" flag
+type ArtworkPages struct {
+	Min int
+	Max int
+}
+
+// Parses the page range like "3-5" or "3" from the "--artwork_pages" flag.
+//
+// Returns nil if the given string is empty which means all pages will be downloaded.
+func ParseArtworkPages(pagesStr string) (*ArtworkPages, error) {
+	if pagesStr == "" {
+		return nil, nil
+	}
+	if !utils.PAGE_NUM_REGEX.MatchString(pagesStr) {
+		return nil, fmt.Errorf(
+			"pixiv error %d: invalid artwork pages, %q, please follow the format, \"3-5\" or \"3\", where the first page is 1",
+			utils.INPUT_ERROR,
+			pagesStr,
+		)
+	}
+
+	minPage, maxPage, _, err := utils.GetMinMaxFromStr(pagesStr)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtworkPages{Min: minPage, Max: maxPage}, nil
+}
+
+// Returns the files of the selected pages of the artwork with the given ID.
+//
+// If the range goes beyond the artwork's page count, it will be clamped to the last page
+// and if the range starts after the last page, none of the files will be returned.
+// The files that were not selected will be counted as filtered in the run summary.
+func (p *ArtworkPages) Select(artworkId string, toDownload []*request.ToDownload) []*request.ToDownload {
+	pageCount := len(toDownload)
+	if p == nil || pageCount == 0 {
+		return toDownload
+	}
+
+	minPage, maxPage := p.Min, p.Max
+	if minPage > pageCount {
+		msg := fmt.Sprintf(
+			"Pixiv: artwork %s only has %d page(s), skipping it as \"--artwork_pages\" starts from page %d",
+			artworkId,
+			pageCount,
+			minPage,
+		)
+		color.Yellow(msg)
+		utils.LogError(nil, msg, false, utils.INFO)
+		utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, pageCount)
+		return nil
+	}
+	if maxPage > pageCount {
+		msg := fmt.Sprintf(
+			"Pixiv: artwork %s only has %d page(s), downloading pages %d-%d instead of %d-%d",
+			artworkId,
+			pageCount,
+			minPage,
+			pageCount,
+			minPage,
+			maxPage,
+		)
+		color.Yellow(msg)
+		utils.LogError(nil, msg, false, utils.INFO)
+		maxPage = pageCount
+	}
+
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, pageCount-(maxPage-minPage+1))
+	return toDownload[minPage-1 : maxPage]
+}
+
+// Returns true if the artwork's creation date, e.g. "2024-01-01T12:34:56+09:00", is within the date range
+// from "--search_start_date" and "--search_end_date" where an empty date leaves the range open on that side.
+//
+// The date is compared in Pixiv's timezone as given and artworks with an invalid date will be kept.
+func IsWithinSearchDateRange(createDate, startDate, endDate string) bool {
+	if len(createDate) < len(SEARCH_DATE_FORMAT) {
+		return true
+	}
+
+	// dates in the YYYY-MM-DD format can be compared as strings
+	date := createDate[:len(SEARCH_DATE_FORMAT)]
+	if _, err := time.Parse(SEARCH_DATE_FORMAT, date); err != nil {
+		return true
+	}
+	return (startDate == "" || date >= startDate) && (endDate == "" || date <= endDate)
+}
+
+// The extensions of the files downloaded for an artwork, i.e. the images and the converted ugoira.
+//
+// The ugoira zip file is left out as the ugoira is only complete after it has been converted.
+var ARTWORK_FILE_EXT = []string{
+	".jpg",
+	".jpeg",
+	".png",
+	".gif",
+	".apng",
+	".webp",
+	".webm",
+	".mp4",
+}
+
+// The filename of the marker with the artwork's page count that is written
+// into the artwork folder once all of its pages have been downloaded
+const ARTWORK_COMPLETE_FILENAME = ".complete"
+
+var (
+	artworkPageCountsMu sync.Mutex
+	artworkPageCounts   = make(map[string]int) // artwork folder path -> page count
+)
+
+// Records the number of pages of the artwork after its details have been retrieved
+// so that its folder can be marked as complete by MarkCompletedArtworks() after the downloads.
+func RecordArtworkPageCount(artworkPath string, pageCount int) {
+	if pageCount < 1 {
+		pageCount = 1
+	}
+	artworkPageCountsMu.Lock()
+	defer artworkPageCountsMu.Unlock()
+	artworkPageCounts[artworkPath] = pageCount
+}
+
+// Returns the number of downloaded images or converted ugoira files in the artwork folder
+// or -1 if the folder cannot be read or has partial downloads left behind by an interrupted run.
+//
+// Other files like the metadata.json and mega_links.txt files are ignored.
+func countArtworkFiles(artworkPath string) int {
+	files, err := os.ReadDir(artworkPath)
+	if err != nil {
+		return -1
+	}
+
+	count := 0
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		fileExt := filepath.Ext(file.Name())
+		if utils.ExtInSlice([]string{request.PART_FILE_EXT}, fileExt) {
+			return -1
+		}
+		if utils.ExtInSlice(ARTWORK_FILE_EXT, fileExt) {
+			count++
+		}
+	}
+	return count
+}
+
+// Writes the completion marker into the folders of the artworks recorded by RecordArtworkPageCount()
+// that have all of their pages downloaded.
+//
+// Artworks with failed pages or with only some of their pages downloaded due to "--sample" or
+// "--artwork_pages" will not be marked so that they will not be skipped in the next run.
+func MarkCompletedArtworks() {
+	artworkPageCountsMu.Lock()
+	defer artworkPageCountsMu.Unlock()
+	for artworkPath, pageCount := range artworkPageCounts {
+		if countArtworkFiles(artworkPath) < pageCount {
+			continue
+		}
+		markerPath := filepath.Join(artworkPath, ARTWORK_COMPLETE_FILENAME)
+		if err := os.WriteFile(markerPath, []byte(strconv.Itoa(pageCount)), 0666); err != nil {
+			utils.LogError(err, "failed to mark the artwork at "+artworkPath+" as complete", false, utils.ERROR)
+		}
+	}
+	clear(artworkPageCounts)
+}
+
+// Returns true if the artwork folder has the completion marker written by MarkCompletedArtworks()
+// and still has all of its pages with no partial downloads.
+func isArtworkDownloaded(artworkPath string) bool {
+	marker, err := os.ReadFile(filepath.Join(artworkPath, ARTWORK_COMPLETE_FILENAME))
+	if err != nil {
+		return false
+	}
+	pageCount, err := strconv.Atoi(strings.TrimSpace(string(marker)))
+	if err != nil || pageCount < 1 {
+		return false
+	}
+	return countArtworkFiles(artworkPath) >= pageCount
+}
+
+// Returns the artwork IDs that do not have a "[<artworkId>] <title>" folder with its downloaded files in it
+// in the illustrator folders of the given Pixiv download path and the number of artworks that were dropped.
+//
+// An artwork will only be dropped if its folder was marked as complete by MarkCompletedArtworks()
+// after all of its pages were downloaded in a previous run.
+//
+// This allows the artworks that were downloaded in a previous run to be skipped without
+// requesting their details from Pixiv's API as the illustrator's name is only known after the request.
+func FilterDownloadedArtworkIds(artworkIds []string, pixivDlPath string) ([]string, int) {
+	illustratorDirs, err := os.ReadDir(pixivDlPath)
+	if err != nil {
+		// nothing has been downloaded from Pixiv to the download path yet
+		return artworkIds, 0
+	}
+
+	artworkIdsToCheck := make(map[string]struct{}, len(artworkIds))
+	for _, artworkId := range artworkIds {
+		artworkIdsToCheck[artworkId] = struct{}{}
+	}
+
+	downloaded := make(map[string]struct{})
+	for _, illustratorDir := range illustratorDirs {
+		if !illustratorDir.IsDir() {
+			continue
+		}
+
+		illustratorPath := filepath.Join(pixivDlPath, illustratorDir.Name())
+		artworkDirs, err := os.ReadDir(illustratorPath)
+		if err != nil {
+			continue
+		}
+		for _, artworkDir := range artworkDirs {
+			artworkDirName := artworkDir.Name()
+			if !artworkDir.IsDir() || !strings.HasPrefix(artworkDirName, "[") {
+				continue
+			}
+
+			closingIdx := strings.Index(artworkDirName, "] ")
+			if closingIdx == -1 {
+				continue
+			}
+			artworkId := artworkDirName[1:closingIdx]
+			if _, ok := artworkIdsToCheck[artworkId]; !ok {
+				continue
+			}
+
+			if isArtworkDownloaded(filepath.Join(illustratorPath, artworkDirName)) {
+				downloaded[artworkId] = struct{}{}
+			}
+		}
+	}
+
+	if len(downloaded) == 0 {
+		return artworkIds, 0
+	}
+	filteredArtworkIds := make([]string, 0, len(artworkIds)-len(downloaded))
+	for _, artworkId := range artworkIds {
+		if _, ok := downloaded[artworkId]; !ok {
+			filteredArtworkIds = append(filteredArtworkIds, artworkId)
+		}
+	}
+	return filteredArtworkIds, len(artworkIds) - len(filteredArtworkIds)
+}
+
+// Logs the number of artworks that were skipped as they have already been downloaded
+func LogDownloadedArtworksSkippedCount(skippedCount int) {
+	if skippedCount == 0 {
+		return
+	}
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_EXISTS, skippedCount)
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Skipped getting the details of %d artwork(s) from Pixiv as all of their pages were downloaded in a previous run.",
+			skippedCount,
+		),
+		false,
+		utils.INFO,
+	)
+}
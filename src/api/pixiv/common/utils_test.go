@@ -0,0 +1,120 @@
+package pixivcommon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFilterDownloadedArtworkIds(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string // the files in the "[1] title" artwork folder
+		pageCount   int      // the page count recorded from the artwork's details or 0 if not recorded
+		wantIds     []string
+		wantSkipped int
+	}{
+		{
+			name:        "all pages downloaded",
+			files:       []string{"1_p0.png", "1_p1.JPG"},
+			pageCount:   2,
+			wantIds:     []string{"2"},
+			wantSkipped: 1,
+		},
+		{
+			name:        "converted ugoira",
+			files:       []string{"1.mp4", "metadata.json"},
+			pageCount:   1,
+			wantIds:     []string{"2"},
+			wantSkipped: 1,
+		},
+		{
+			name:      "unconverted ugoira",
+			files:     []string{"1_ugoira1920x1080.zip"},
+			pageCount: 1,
+			wantIds:   []string{"1", "2"},
+		},
+		{
+			name:      "some pages downloaded",
+			files:     []string{"1_p0.png", "1_p1.png"},
+			pageCount: 3,
+			wantIds:   []string{"1", "2"},
+		},
+		{
+			name:      "empty folder",
+			files:     nil,
+			pageCount: 1,
+			wantIds:   []string{"1", "2"},
+		},
+		{
+			name:      "partial download",
+			files:     []string{"1_p0.png", "1_p1.png.part"},
+			pageCount: 1,
+			wantIds:   []string{"1", "2"},
+		},
+		{
+			name:      "only metadata",
+			files:     []string{"metadata.json", "mega_links.txt"},
+			pageCount: 1,
+			wantIds:   []string{"1", "2"},
+		},
+		{
+			name:    "not marked as complete",
+			files:   []string{"1_p0.png"},
+			wantIds: []string{"1", "2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pixivDlPath := t.TempDir()
+			artworkPath := filepath.Join(pixivDlPath, "illustrator", "[1] title")
+			if err := os.MkdirAll(artworkPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range test.files {
+				if err := os.WriteFile(filepath.Join(artworkPath, file), []byte("data"), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.pageCount > 0 {
+				RecordArtworkPageCount(artworkPath, test.pageCount)
+			}
+			MarkCompletedArtworks()
+
+			gotIds, gotSkipped := FilterDownloadedArtworkIds([]string{"1", "2"}, pixivDlPath)
+			if !reflect.DeepEqual(gotIds, test.wantIds) || gotSkipped != test.wantSkipped {
+				t.Errorf(
+					"FilterDownloadedArtworkIds() = %v, %d, want %v, %d",
+					gotIds,
+					gotSkipped,
+					test.wantIds,
+					test.wantSkipped,
+				)
+			}
+		})
+	}
+}
+
+func TestFilterDownloadedArtworkIdsAfterPageRemoved(t *testing.T) {
+	pixivDlPath := t.TempDir()
+	artworkPath := filepath.Join(pixivDlPath, "illustrator", "[1] title")
+	if err := os.MkdirAll(artworkPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"1_p0.png", "1_p1.png"} {
+		if err := os.WriteFile(filepath.Join(artworkPath, file), []byte("data"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	RecordArtworkPageCount(artworkPath, 2)
+	MarkCompletedArtworks()
+
+	// the artwork should be downloaded again if one of its pages was deleted after it was marked as complete
+	if err := os.Remove(filepath.Join(artworkPath, "1_p1.png")); err != nil {
+		t.Fatal(err)
+	}
+	if gotIds, gotSkipped := FilterDownloadedArtworkIds([]string{"1"}, pixivDlPath); gotSkipped != 0 {
+		t.Errorf("FilterDownloadedArtworkIds() = %v, %d, want [1], 0", gotIds, gotSkipped)
+	}
+}
@@ -2,8 +2,10 @@ package pixivmobile
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
@@ -19,10 +21,13 @@ type offsetArgs struct {
 	hasMax    bool
 }
 
-// Returns the Ugoira structure with the necessary information to download the ugoira
+// Returns the Ugoira structure with the necessary information to download the ugoira.
+//
+// If "--ugoira_prefer_direct" is enabled and Pixiv provided a direct animated URL in the metadata,
+// the URL will be returned instead of the Ugoira structure so that the frames will not have to be converted.
 //
 // Will return an error which has been logged if unexpected error occurs like connection error, json marshal error, etc.
-func (pixiv *PixivMobile) getUgoiraMetadata(illustId, dlFilePath string) (*models.Ugoira, error) {
+func (pixiv *PixivMobile) getUgoiraMetadata(illustId, dlFilePath string) (*models.Ugoira, string, error) {
 	ugoiraUrl := pixiv.baseUrl + "/v1/ugoira/metadata"
 	params := map[string]string{"illust_id": illustId}
 	additionalHeaders := pixiv.getHeaders(
@@ -38,7 +43,7 @@ func (pixiv *PixivMobile) getUgoiraMetadata(illustId, dlFilePath string) (*model
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf(
+		return nil, "", fmt.Errorf(
 			"pixiv movile error %d: Failed to get ugoira metadata for %s",
 			utils.CONNECTION_ERROR,
 			illustId,
@@ -47,11 +52,27 @@ func (pixiv *PixivMobile) getUgoiraMetadata(illustId, dlFilePath string) (*model
 
 	var ugoiraJson models.UgoiraJson
 	if err := utils.LoadJsonFromResponse(res, &ugoiraJson); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	ugoiraMetadata := ugoiraJson.Metadata
-	ugoiraDlUrl := ugoiraMetadata.ZipUrls.Medium
+	if pixiv.ugoiraPreferDirect {
+		// sorted so that the same URL will be picked if Pixiv provided more than one
+		sizes := make([]string, 0, len(ugoiraMetadata.ZipUrls))
+		for size := range ugoiraMetadata.ZipUrls {
+			sizes = append(sizes, size)
+		}
+		sort.Strings(sizes)
+		ugoiraUrls := make([]string, 0, len(sizes))
+		for _, size := range sizes {
+			ugoiraUrls = append(ugoiraUrls, ugoiraMetadata.ZipUrls[size])
+		}
+		if directUrl := ugoira.GetDirectUgoiraUrl(ugoiraUrls...); directUrl != "" {
+			return nil, directUrl, nil
+		}
+	}
+
+	ugoiraDlUrl := ugoiraMetadata.ZipUrls["medium"]
 	ugoiraDlUrl = strings.Replace(ugoiraDlUrl, "600x600", "1920x1080", 1)
 
 	// map the files to their delay
@@ -60,7 +81,7 @@ func (pixiv *PixivMobile) getUgoiraMetadata(illustId, dlFilePath string) (*model
 		Url:      ugoiraDlUrl,
 		Frames:   frameInfoMap,
 		FilePath: dlFilePath,
-	}, nil
+	}, "", nil
 }
 
 // Query Pixiv's API (mobile) to get the JSON of an artwork ID
@@ -88,19 +109,48 @@ func (pixiv *PixivMobile) getArtworkDetails(artworkId, downloadPath string) ([]*
 	if err := utils.LoadJsonFromResponse(res, &artworkJson); err != nil {
 		return nil, nil, err
 	}
+	if artworkJson.Illust != nil && pixivcommon.SkipAiGenerated(pixiv.noAi, artworkJson.Illust.IllustAiType) {
+		return nil, nil, nil
+	}
+	if artworkJson.Illust != nil && pixivcommon.SkipRating(pixiv.ratingMode, artworkJson.Illust.XRestrict) {
+		return nil, nil, nil
+	}
 
 	artworkDetails, ugoiraToDl, err := pixiv.processArtworkJson(
 		artworkJson.Illust,
 		downloadPath,
 	)
+	if err == nil {
+		utils.RUN_STATS.AddPostsProcessed(1)
+	}
 	return artworkDetails, ugoiraToDl, err
 }
 
+// The details of an artwork retrieved by a worker in GetMultipleArtworkDetails
+type artworkDetailsResult struct {
+	artworks []*request.ToDownload
+	ugoira   *models.Ugoira
+	err      error
+}
+
+// Retrieves the details of the artworks concurrently with up to apiConcurrency workers
+// where each worker waits for the delay between requests after getting an artwork's details.
+//
+// The results are returned in the same order as the given artwork IDs.
 func (pixiv *PixivMobile) GetMultipleArtworkDetails(artworkIds []string, downloadPath string) ([]*request.ToDownload, []*models.Ugoira) {
 	var artworksToDownload []*request.ToDownload
 	var ugoiraSlice []*models.Ugoira
 	artworkIdsLen := len(artworkIds)
-	lastIdx := artworkIdsLen - 1
+	maxConcurrency := pixiv.apiConcurrency
+	if artworkIdsLen < maxConcurrency {
+		maxConcurrency = artworkIdsLen
+	}
+	var wg sync.WaitGroup
+	queue := make(chan struct{}, maxConcurrency)
+
+	// stored by the artwork's index so that the artworks
+	// will be downloaded in the same order as the artwork IDs
+	results := make([]*artworkDetailsResult, artworkIdsLen)
 
 	var errSlice []error
 	baseMsg := "Getting and processing artwork details from Pixiv's Mobile API [%d/" + fmt.Sprintf("%d]...", artworkIdsLen)
@@ -123,23 +173,54 @@ func (pixiv *PixivMobile) GetMultipleArtworkDetails(artworkIds []string, downloa
 	)
 	progress.Start()
 	for idx, artworkId := range artworkIds {
-		artworkDetails, ugoiraInfo, err := pixiv.getArtworkDetails(artworkId, downloadPath)
-		if err != nil {
-			errSlice = append(errSlice, err)
+		wg.Add(1)
+		go func(idx int, artworkId string) {
+			defer func() {
+				wg.Done()
+				<-queue
+			}()
+
+			queue <- struct{}{}
+			if utils.IsInterrupted() {
+				return
+			}
+			progress.SetDetail("artwork " + artworkId)
+			artworkDetails, ugoiraInfo, err := pixiv.getArtworkDetails(artworkId, downloadPath)
+			if err != nil {
+				utils.RecordFailure(err)
+			}
+			results[idx] = &artworkDetailsResult{
+				artworks: artworkDetails,
+				ugoira:   ugoiraInfo,
+				err:      err,
+			}
 			progress.MsgIncrement(baseMsg)
+
+			// the slot is only released after the delay to
+			// keep the minimum delay between requests of each worker
+			utils.SleepWithContext(utils.GetInterruptCtx(), utils.GetRandomTime(pixiv.minSleep, pixiv.maxSleep))
+		}(idx, artworkId)
+	}
+	wg.Wait()
+	close(queue)
+
+	if utils.IsInterrupted() {
+		// discard the partial artwork details as the run is stopping
+		progress.Stop(true)
+		return nil, nil
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			errSlice = append(errSlice, result.err)
 			continue
 		}
 
-		if ugoiraInfo != nil {
-			ugoiraSlice = append(ugoiraSlice, ugoiraInfo)
+		if result.ugoira != nil {
+			ugoiraSlice = append(ugoiraSlice, result.ugoira)
 		} else {
-			artworksToDownload = append(artworksToDownload, artworkDetails...)
-		}
-
-		if idx != lastIdx {
-			pixiv.Sleep()
+			artworksToDownload = append(artworksToDownload, result.artworks...)
 		}
-		progress.MsgIncrement(baseMsg)
 	}
 
 	hasErr := false
@@ -160,11 +241,16 @@ func (pixiv *PixivMobile) getIllustratorPostMainLogic(params map[string]string,
 
 	curOffset := offsetArg.minOffset
 	for nextUrl != "" {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil, nil
+		}
 		res, err := pixiv.SendRequest(
 			&request.RequestArgs{
 				Url:         nextUrl,
 				Params:      params,
 				CheckStatus: true,
+				Context:     utils.GetInterruptCtx(),
 			},
 		)
 		if err != nil {
@@ -276,6 +362,7 @@ func (pixiv *PixivMobile) GetMultipleIllustratorPosts(userIds, pageNums []string
 	)
 	progress.Start()
 	for idx, userId := range userIds {
+		progress.SetDetail("illustrator " + userId)
 		artworkDetails, ugoiraInfo, err := pixiv.getIllustratorPosts(
 			userId,
 			pageNums[idx],
@@ -306,25 +393,207 @@ func (pixiv *PixivMobile) GetMultipleIllustratorPosts(userIds, pageNums []string
 	return artworksToDownload, ugoiraSlice
 }
 
-func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath string, dlOptions *PixivMobileDlOptions, offsetArg *offsetArgs) ([]*request.ToDownload, []*models.Ugoira, []error) {
+// The number of bookmarks that Pixiv's mobile API returns per request
+const BOOKMARKS_PER_PAGE = 30
+
+// Returns true if the bookmarked artwork matches the "--artwork_type" flag
+func isArtworkTypeAllowed(illustType, artworkType string) bool {
+	switch artworkType {
+	case "illust":
+		return illustType == "illust" || illustType == "ugoira"
+	case "manga":
+		return illustType == "manga"
+	default:
+		return true
+	}
+}
+
+// Query Pixiv's API (mobile) for the artworks bookmarked by the user
+// within the given page numbers (BOOKMARKS_PER_PAGE bookmarks per page).
+//
+// As the bookmarks are paginated with a cursor in the next URL, the pages
+// before the minimum page number have to be requested but will not be processed.
+//
+// If privateBookmarks is true, the private bookmarks will be retrieved instead
+// which requires the refresh token to belong to the user.
+func (pixiv *PixivMobile) getUserBookmarks(userId, pageNum string, privateBookmarks bool, downloadPath, artworkType string) ([]*request.ToDownload, []*models.Ugoira, []error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	restrict := "public"
+	if privateBookmarks {
+		restrict = "private"
+	}
+	params := map[string]string{
+		"user_id":  userId,
+		"restrict": restrict,
+		"filter":   "for_ios",
+	}
+
 	var errSlice []error
 	var ugoiraSlice []*models.Ugoira
 	var artworksToDownload []*request.ToDownload
+	hiddenCount := 0
+	nextUrl := pixiv.baseUrl + "/v1/user/bookmarks/illust"
+	for page := 1; nextUrl != "" && (!hasMax || page <= maxPage); page++ {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil, nil
+		}
+		if page > 1 {
+			pixiv.Sleep()
+		}
+
+		res, err := pixiv.SendRequest(
+			&request.RequestArgs{
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+				Context:     utils.GetInterruptCtx(),
+			},
+		)
+		if err != nil {
+			err = fmt.Errorf(
+				"pixiv mobile error %d: failed to get the bookmarks of the user with an ID of %s, more info => %v",
+				utils.CONNECTION_ERROR,
+				userId,
+				err,
+			)
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+			break
+		}
+
+		var resJson models.PixivMobileArtworksJson
+		if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+			break
+		}
+
+		// the next URL already has all the query parameters including the cursor
+		params = nil
+		nextUrl = ""
+		if resJson.NextUrl != nil {
+			nextUrl = *resJson.NextUrl
+		}
+		if page < minPage {
+			continue
+		}
+
+		allowedIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+		for _, illust := range resJson.Illusts {
+			if !illust.Visible {
+				// deleted or private artworks that can no longer be viewed
+				hiddenCount++
+				continue
+			}
+			if isArtworkTypeAllowed(illust.Type, artworkType) {
+				allowedIllusts = append(allowedIllusts, illust)
+			}
+		}
+		resJson.Illusts = allowedIllusts
+
+		artworks, ugoira, errS := pixiv.processMultipleArtworkJson(&resJson, downloadPath)
+		errSlice = append(errSlice, errS...)
+		artworksToDownload = append(artworksToDownload, artworks...)
+		ugoiraSlice = append(ugoiraSlice, ugoira...)
+	}
+
+	if hiddenCount > 0 {
+		utils.LogError(
+			nil,
+			fmt.Sprintf(
+				"Skipped %d bookmark(s) of the user with an ID of %s as the artworks were deleted or made private.",
+				hiddenCount,
+				userId,
+			),
+			false,
+			utils.INFO,
+		)
+	}
+	return artworksToDownload, ugoiraSlice, errSlice
+}
+
+// Get the artworks bookmarked by the user from Pixiv's API (mobile)
+// and returns the artworks to download and a slice of Ugoira structures
+func (pixiv *PixivMobile) GetUserBookmarks(userId, pageNum string, privateBookmarks bool, downloadPath, artworkType string) ([]*request.ToDownload, []*models.Ugoira) {
+	bookmarkType := "public"
+	if privateBookmarks {
+		bookmarkType = "private"
+	}
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			"Getting the %s bookmarks of the user with an ID of %s on Pixiv...",
+			bookmarkType,
+			userId,
+		),
+		fmt.Sprintf(
+			"Finished getting the %s bookmarks of the user with an ID of %s on Pixiv!",
+			bookmarkType,
+			userId,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting the %s bookmarks of the user with an ID of %s on Pixiv!\nPlease refer to the logs for more details.",
+			bookmarkType,
+			userId,
+		),
+		0,
+	)
+	progress.Start()
+	artworksToDl, ugoiraSlice, errSlice := pixiv.getUserBookmarks(
+		userId,
+		pageNum,
+		privateBookmarks,
+		downloadPath,
+		artworkType,
+	)
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return artworksToDl, ugoiraSlice
+}
+
+func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath, sortOrder string, dlOptions *PixivMobileDlOptions, offsetArg *offsetArgs, limit *pixivcommon.ArtworkLimit) ([]*request.ToDownload, []*models.Ugoira, int, int, []error) {
+	var errSlice []error
+	filteredCount := 0
+	excludedCount := 0
+	minBookmarksCount := 0
+	var ugoiraSlice []*models.Ugoira
+	var artworksToDownload []*request.ToDownload
 	params := map[string]string{
-		"word":          tagName,
+		"word":          pixivcommon.GetTagSearchWord(tagName, dlOptions.ExcludeTags),
 		"search_target": dlOptions.SearchMode,
-		"sort":          dlOptions.SortOrder,
+		"sort":          sortOrder,
 		"filter":        "for_ios",
 		"offset":        strconv.Itoa(offsetArg.minOffset),
 	}
+	if dlOptions.SearchStartDate != "" {
+		params["start_date"] = dlOptions.SearchStartDate
+	}
+	if dlOptions.SearchEndDate != "" {
+		params["end_date"] = dlOptions.SearchEndDate
+	}
 	curOffset := offsetArg.minOffset
 	nextUrl := pixiv.baseUrl + "/v1/search/illust"
 	for nextUrl != "" {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil, 0, 0, nil
+		}
 		res, err := pixiv.SendRequest(
 			&request.RequestArgs{
 				Url:         nextUrl,
 				Params:      params,
 				CheckStatus: true,
+				Context:     utils.GetInterruptCtx(),
 			},
 		)
 		if err != nil {
@@ -334,15 +603,82 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath string, dlOptions
 				tagName,
 				err,
 			)
-			return nil, nil, []error{err} 
+			return nil, nil, filteredCount, excludedCount, []error{err} 
 		}
 
 		var resJson models.PixivMobileArtworksJson
 		if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			continue
 		}
 
+		if len(dlOptions.IllustratorWhitelist) > 0 {
+			whitelistedIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				if pixivcommon.IsWhitelistedIllustrator(dlOptions.IllustratorWhitelist, strconv.Itoa(illust.User.Id)) {
+					whitelistedIllusts = append(whitelistedIllusts, illust)
+				} else {
+					filteredCount++
+				}
+			}
+			resJson.Illusts = whitelistedIllusts
+		}
+		if len(dlOptions.ExcludeTags) > 0 {
+			// filter against each artwork's tags before getting
+			// the URLs to download as a fallback to the "-tag" tokens
+			includedIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				artworkTags := make([]string, 0, len(illust.Tags))
+				for _, tag := range illust.Tags {
+					artworkTags = append(artworkTags, tag.Name)
+				}
+				if pixivcommon.HasExcludedTag(artworkTags, dlOptions.ExcludeTags) {
+					excludedCount++
+				} else {
+					includedIllusts = append(includedIllusts, illust)
+				}
+			}
+			resJson.Illusts = includedIllusts
+		}
+		if dlOptions.MinBookmarks > 0 {
+			// filter before processing so that no requests are wasted on the ugoira metadata
+			popularIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				if illust.TotalBookmarks < dlOptions.MinBookmarks {
+					minBookmarksCount++
+				} else {
+					popularIllusts = append(popularIllusts, illust)
+				}
+			}
+			resJson.Illusts = popularIllusts
+		}
+		if dlOptions.SearchStartDate != "" || dlOptions.SearchEndDate != "" {
+			// filter by the creation dates as a fallback in case the API ignores the date range
+			datedIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				if pixivcommon.IsWithinSearchDateRange(illust.CreateDate, dlOptions.SearchStartDate, dlOptions.SearchEndDate) {
+					datedIllusts = append(datedIllusts, illust)
+				}
+			}
+			resJson.Illusts = datedIllusts
+		}
+		if dlOptions.MaxArtworks > 0 {
+			// drop the artworks that will be skipped by processMultipleArtworkJson()
+			// first so that they will not count towards "--max_artworks"
+			limitedIllusts := make([]*models.PixivMobileIllustJson, 0, len(resJson.Illusts))
+			for _, illust := range resJson.Illusts {
+				if pixivcommon.SkipAiGenerated(pixiv.noAi, illust.IllustAiType) || pixivcommon.SkipRating(pixiv.ratingMode, illust.XRestrict) {
+					continue
+				}
+				if !limit.Add(strconv.Itoa(illust.Id)) {
+					break
+				}
+				limitedIllusts = append(limitedIllusts, illust)
+			}
+			resJson.Illusts = limitedIllusts
+		}
+
 		artworks, ugoira, errS := pixiv.processMultipleArtworkJson(&resJson, downloadPath)
 		errSlice = append(errSlice, errS...)
 		artworksToDownload = append(artworksToDownload, artworks...)
@@ -351,18 +687,22 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath string, dlOptions
 		curOffset += 30
 		params["offset"] = strconv.Itoa(curOffset)
 		jsonNextUrl := resJson.NextUrl
-		if jsonNextUrl == nil || (offsetArg.hasMax && curOffset >= offsetArg.maxOffset) {
+		if jsonNextUrl == nil || limit.IsReached() || (offsetArg.hasMax && curOffset >= offsetArg.maxOffset) {
 			nextUrl = ""
 		} else {
 			nextUrl = *jsonNextUrl
 			pixiv.Sleep()
 		}
 	}
-	return artworksToDownload, ugoiraSlice, errSlice
+	pixivcommon.LogMinBookmarksFilteredCount(tagName, dlOptions.MinBookmarks, minBookmarksCount)
+	return artworksToDownload, ugoiraSlice, filteredCount, excludedCount, errSlice
 }
 
 // Query Pixiv's API (mobile) to get the JSON of a search query
-func (pixiv *PixivMobile) TagSearch(tagName, downloadPath, pageNum string, dlOptions *PixivMobileDlOptions) ([]*request.ToDownload, []*models.Ugoira, bool) {
+//
+// Also returns the number of results that were filtered out as they are not from the whitelisted illustrators
+// and the number of results that were filtered out as they have one of the excluded tags.
+func (pixiv *PixivMobile) TagSearch(tagName, downloadPath, pageNum string, dlOptions *PixivMobileDlOptions) ([]*request.ToDownload, []*models.Ugoira, int, int, bool) {
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
 		utils.LogError(
@@ -371,22 +711,86 @@ func (pixiv *PixivMobile) TagSearch(tagName, downloadPath, pageNum string, dlOpt
 			false,
 			utils.ERROR,
 		)
-		return nil, nil, true
+		return nil, nil, 0, 0, true
 	}
 	minOffset, maxOffset := pixivcommon.ConvertPageNumToOffset(minPage, maxPage, utils.PIXIV_PER_PAGE, false)
 
-	artworksToDl, ugoiraSlice, errSlice := pixiv.tagSearchLogic(
-		tagName,
-		downloadPath,
-		dlOptions,
-		&offsetArgs{
-			minOffset: minOffset,
-			maxOffset: maxOffset,
-			hasMax:    hasMax,
-		},
-	)
+	sortOrders := []string{dlOptions.SortOrder}
+	if dlOptions.MaxCoverage {
+		sortOrders = pixivcommon.GetMaxCoverageSortOrders(dlOptions.SortOrder, MAX_COVERAGE_SORT_ORDERS)
+	}
+
+	var errSlice []error
+	var ugoiraSlice []*models.Ugoira
+	var artworksToDl []*request.ToDownload
+	filteredCount := 0
+	excludedCount := 0
+	limit := pixivcommon.NewArtworkLimit(dlOptions.MaxArtworks)
+	for idx, sortOrder := range sortOrders {
+		if limit.IsReached() {
+			break
+		}
+		if idx > 0 {
+			pixiv.Sleep()
+		}
+
+		artworks, ugoira, sortOrderFilteredCount, sortOrderExcludedCount, errS := pixiv.tagSearchLogic(
+			tagName,
+			downloadPath,
+			sortOrder,
+			dlOptions,
+			&offsetArgs{
+				minOffset: minOffset,
+				maxOffset: maxOffset,
+				hasMax:    hasMax,
+			},
+			limit,
+		)
+		artworksToDl = append(artworksToDl, artworks...)
+		ugoiraSlice = append(ugoiraSlice, ugoira...)
+		filteredCount += sortOrderFilteredCount
+		excludedCount += sortOrderExcludedCount
+		errSlice = append(errSlice, errS...)
+	}
+	if len(sortOrders) > 1 {
+		totalCount := len(artworksToDl) + len(ugoiraSlice)
+		artworksToDl = removeDuplicateArtworks(artworksToDl)
+		ugoiraSlice = removeDuplicateUgoira(ugoiraSlice)
+		pixivcommon.LogMaxCoverageResults(tagName, len(sortOrders), totalCount, len(artworksToDl)+len(ugoiraSlice))
+	}
+	pixivcommon.LogWhitelistFilteredCount(tagName, filteredCount)
+	pixivcommon.LogExcludedTagsFilteredCount(tagName, excludedCount)
+	pixivcommon.LogMaxArtworksReached(tagName, limit)
 	if len(errSlice) > 0 {
 		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
 	}
-	return artworksToDl, ugoiraSlice, len(errSlice) > 0
+	return artworksToDl, ugoiraSlice, filteredCount, excludedCount, len(errSlice) > 0
+}
+
+// Removes the artworks with the same URL that were found by more than one sort order while keeping their order
+func removeDuplicateArtworks(artworks []*request.ToDownload) []*request.ToDownload {
+	seen := make(map[string]struct{}, len(artworks))
+	uniqueArtworks := make([]*request.ToDownload, 0, len(artworks))
+	for _, artwork := range artworks {
+		if _, ok := seen[artwork.Url]; ok {
+			continue
+		}
+		seen[artwork.Url] = struct{}{}
+		uniqueArtworks = append(uniqueArtworks, artwork)
+	}
+	return uniqueArtworks
+}
+
+// Removes the ugoira with the same URL that were found by more than one sort order while keeping their order
+func removeDuplicateUgoira(ugoiraSlice []*models.Ugoira) []*models.Ugoira {
+	seen := make(map[string]struct{}, len(ugoiraSlice))
+	uniqueUgoira := make([]*models.Ugoira, 0, len(ugoiraSlice))
+	for _, ugoira := range ugoiraSlice {
+		if _, ok := seen[ugoira.Url]; ok {
+			continue
+		}
+		seen[ugoira.Url] = struct{}{}
+		uniqueUgoira = append(uniqueUgoira, ugoira)
+	}
+	return uniqueUgoira
 }
@@ -0,0 +1,389 @@
+package pixivmobile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestMain(m *testing.M) {
+	testutils.RunWithTempAppPath(m)
+}
+
+// Routes the requests through the test server as a proxy as the
+// Pixiv mobile API requests are otherwise sent over HTTP/3.
+func routeThroughServer(t *testing.T, server *httptest.Server) {
+	if err := utils.SetProxy(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		utils.SetProxy("")
+	})
+}
+
+// Returns a PixivMobile client that sends its requests to the given server with a valid access token
+func newTestPixivMobile(t *testing.T, server *httptest.Server) *PixivMobile {
+	routeThroughServer(t, server)
+	serverUrl := server.URL
+	pixiv := NewPixivMobile("refresh-token", 10)
+	pixiv.baseUrl = serverUrl
+	pixiv.minSleep, pixiv.maxSleep = 2, 2
+	pixiv.ratingMode = "all"
+	pixiv.accessTokenMap = accessTokenInfo{
+		accessToken: "access-token",
+		expiresAt:   time.Now().Add(time.Hour),
+	}
+	return pixiv
+}
+
+func TestTagSearchLogicPagination(t *testing.T) {
+	const (
+		resultPages = 3
+		perPage     = 30 // the offset step of the mobile API
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		page := offset/perPage + 1
+		illusts := []map[string]any{}
+		for i := 1; i <= 2; i++ {
+			illusts = append(illusts, map[string]any{
+				"id":    page*10 + i,
+				"title": "title",
+				"type":  "illust",
+				"user":  map[string]any{"id": 1, "name": "user"},
+				"meta_single_page": map[string]any{
+					"original_image_url": fmt.Sprintf("https://i.pximg.net/%d%d.png", page, i),
+				},
+			})
+		}
+		resJson := map[string]any{"illusts": illusts, "next_url": nil}
+		if page < resultPages {
+			resJson["next_url"] = fmt.Sprintf("%s/v1/search/illust?offset=%d", server.URL, offset+perPage)
+		}
+		json.NewEncoder(w).Encode(resJson)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		offsetArgs  *offsetArgs
+		maxArtworks int
+		wantCount   int
+		wantSleeps  int
+	}{
+		{
+			name:       "all pages",
+			offsetArgs: &offsetArgs{},
+			wantCount:  6,
+			wantSleeps: 2, // not after the last page without a next URL
+		},
+		{
+			name:       "page range",
+			offsetArgs: &offsetArgs{minOffset: 0, maxOffset: 2 * perPage, hasMax: true},
+			wantCount:  4,
+			wantSleeps: 1,
+		},
+		{
+			name:        "max artworks",
+			offsetArgs:  &offsetArgs{},
+			maxArtworks: 3,
+			wantCount:   3,
+			wantSleeps:  1, // stops before requesting the third page
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := testutils.RecordSleeps(t)
+			pixiv := newTestPixivMobile(t, server)
+			dlOptions := &PixivMobileDlOptions{MaxArtworks: test.maxArtworks}
+			artworks, _, _, _, errSlice := pixiv.tagSearchLogic(
+				"test",
+				t.TempDir(),
+				"date_desc",
+				dlOptions,
+				test.offsetArgs,
+				pixivcommon.NewArtworkLimit(test.maxArtworks),
+			)
+			if len(errSlice) > 0 {
+				t.Fatalf("tagSearchLogic() returned errors: %v", errSlice)
+			}
+			if len(artworks) != test.wantCount {
+				t.Errorf("tagSearchLogic() returned %d artworks, want %d", len(artworks), test.wantCount)
+			}
+			sleeps := recorder.Sleeps()
+			if len(sleeps) != test.wantSleeps {
+				t.Fatalf("slept %v, want %d sleep(s)", sleeps, test.wantSleeps)
+			}
+			for _, d := range sleeps {
+				if d != 2*time.Second {
+					t.Errorf("slept %s between pages, want 2s", d)
+				}
+			}
+		})
+	}
+}
+
+func TestSendRequestRetryDelays(t *testing.T) {
+	recorder := testutils.RecordSleeps(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "20")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pixiv := newTestPixivMobile(t, server)
+	res, err := pixiv.SendRequest(&request.RequestArgs{Url: server.URL, CheckStatus: true})
+	if err != nil {
+		t.Fatalf("SendRequest() returned %v", err)
+	}
+	res.Body.Close()
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("sent %d request(s), want 3", got)
+	}
+	want := []time.Duration{20 * time.Second, 20 * time.Second}
+	if sleeps := recorder.Sleeps(); fmt.Sprint(sleeps) != fmt.Sprint(want) {
+		t.Errorf("slept %v, want %v", sleeps, want)
+	}
+}
+
+func TestSendRequestNoDelayAfterLastRetry(t *testing.T) {
+	recorder := testutils.RecordSleeps(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	pixiv := newTestPixivMobile(t, server)
+	if _, err := pixiv.SendRequest(&request.RequestArgs{Url: server.URL, CheckStatus: true}); err == nil {
+		t.Fatal("SendRequest() returned no error after all the retries failed")
+	}
+
+	if got := requests.Load(); got != utils.RETRY_COUNTER {
+		t.Errorf("sent %d request(s), want %d", got, utils.RETRY_COUNTER)
+	}
+	// there is nothing to wait for after the last retry
+	if got := len(recorder.Sleeps()); got != utils.RETRY_COUNTER-1 {
+		t.Errorf("slept %d time(s), want %d", got, utils.RETRY_COUNTER-1)
+	}
+}
+
+func TestSendRequestStopsOnContextErr(t *testing.T) {
+	tests := []struct {
+		name         string
+		newCtx       func() (context.Context, context.CancelFunc)
+		cancelOnWait bool // cancel the context when waiting to retry
+		wantErr      error
+		wantRequests int32
+	}{
+		{
+			name: "deadline exceeded",
+			newCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+			wantErr:      context.DeadlineExceeded,
+			wantRequests: 0,
+		},
+		{
+			name: "cancelled while waiting to retry",
+			newCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			cancelOnWait: true,
+			wantErr:      context.Canceled,
+			wantRequests: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			ctx, cancel := test.newCtx()
+			defer cancel()
+			var sleeps atomic.Int32
+			t.Cleanup(utils.SetSleeper(func(time.Duration) {
+				sleeps.Add(1)
+				if test.cancelOnWait {
+					cancel()
+				}
+			}))
+
+			pixiv := newTestPixivMobile(t, server)
+			_, err := pixiv.SendRequest(&request.RequestArgs{Url: server.URL, CheckStatus: true, Context: ctx})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("SendRequest() returned %v, want %v", err, test.wantErr)
+			}
+			if got := requests.Load(); got != test.wantRequests {
+				t.Errorf("sent %d request(s), want %d", got, test.wantRequests)
+			}
+			if got := sleeps.Load(); got > 1 {
+				t.Errorf("waited to retry %d time(s) after the context was done", got)
+			}
+		})
+	}
+}
+
+func TestGetUgoiraMetadataPreferDirect(t *testing.T) {
+	const (
+		zipUrl  = "https://i.pximg.net/img-zip-ugoira/img/1_ugoira600x600.zip"
+		webpUrl = "https://i.pximg.net/img-original/img/1_ugoira.webp"
+	)
+	tests := []struct {
+		name          string
+		zipUrls       map[string]string
+		preferDirect  bool
+		wantDirectUrl string
+		wantZipUrl    string
+	}{
+		{
+			name:         "only zip",
+			zipUrls:      map[string]string{"medium": zipUrl},
+			preferDirect: true,
+			wantZipUrl:   "https://i.pximg.net/img-zip-ugoira/img/1_ugoira1920x1080.zip",
+		},
+		{
+			name:          "direct animated URL",
+			zipUrls:       map[string]string{"medium": zipUrl, "original": webpUrl},
+			preferDirect:  true,
+			wantDirectUrl: webpUrl,
+		},
+		{
+			name:       "direct animated URL without the preference",
+			zipUrls:    map[string]string{"medium": zipUrl, "original": webpUrl},
+			wantZipUrl: "https://i.pximg.net/img-zip-ugoira/img/1_ugoira1920x1080.zip",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(map[string]any{
+					"ugoira_metadata": map[string]any{
+						"zip_urls": test.zipUrls,
+						"frames":   []map[string]any{{"file": "000000.jpg", "delay": 100}},
+					},
+				})
+			}))
+			defer server.Close()
+
+			pixiv := newTestPixivMobile(t, server)
+			pixiv.ugoiraPreferDirect = test.preferDirect
+			ugoiraInfo, directUrl, err := pixiv.getUgoiraMetadata("1", t.TempDir())
+			if err != nil {
+				t.Fatalf("getUgoiraMetadata() returned %v", err)
+			}
+			if directUrl != test.wantDirectUrl {
+				t.Errorf("getUgoiraMetadata() direct URL = %q, want %q", directUrl, test.wantDirectUrl)
+			}
+			gotZipUrl := ""
+			if ugoiraInfo != nil {
+				gotZipUrl = ugoiraInfo.Url
+			}
+			if gotZipUrl != test.wantZipUrl {
+				t.Errorf("getUgoiraMetadata() zip URL = %q, want %q", gotZipUrl, test.wantZipUrl)
+			}
+		})
+	}
+}
+
+func TestGetRefreshTokenExitCode(t *testing.T) {
+	const invalidGrantBody = `{"has_error":true,"errors":{"system":{"message":"Invalid refresh token","code":1508}},"error":"invalid_grant"}`
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		wantInvalid bool
+		wantExit    int
+	}{
+		{name: "invalid grant", statusCode: http.StatusBadRequest, body: invalidGrantBody, wantInvalid: true, wantExit: utils.EXIT_AUTH_ERROR},
+		{name: "unauthorised invalid grant", statusCode: http.StatusUnauthorized, body: invalidGrantBody, wantInvalid: true, wantExit: utils.EXIT_AUTH_ERROR},
+		{name: "bad request without invalid grant", statusCode: http.StatusBadRequest, body: `{"has_error":true}`, wantExit: utils.GetExitCode(utils.RESPONSE_ERROR)},
+		{name: "rate limited", statusCode: http.StatusTooManyRequests, wantExit: utils.GetExitCode(utils.RESPONSE_ERROR)},
+		{name: "outage", statusCode: http.StatusServiceUnavailable, body: invalidGrantBody, wantExit: utils.GetExitCode(utils.RESPONSE_ERROR)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			pixiv := newTestPixivMobile(t, server)
+			pixiv.authTokenUrl = server.URL
+			err := pixiv.RefreshAccessToken()
+			if err == nil {
+				t.Fatal("RefreshAccessToken() returned no error")
+			}
+			if got := errors.Is(err, ErrInvalidRefreshToken); got != test.wantInvalid {
+				t.Errorf("errors.Is(%v, ErrInvalidRefreshToken) = %v, want %v", err, got, test.wantInvalid)
+			}
+			if got := GetRefreshTokenExitCode(err); got != test.wantExit {
+				t.Errorf("GetRefreshTokenExitCode() = %d, want %d", got, test.wantExit)
+			}
+		})
+	}
+
+	t.Run("other errors", func(t *testing.T) {
+		err := fmt.Errorf("pixiv mobile error %d: failed to refresh token", utils.CONNECTION_ERROR)
+		if got, want := GetRefreshTokenExitCode(err), utils.GetExitCodeFromErr(err); got != want {
+			t.Errorf("GetRefreshTokenExitCode() = %d, want %d", got, want)
+		}
+	})
+}
+
+func TestRefreshTokenIfReqRetriesAfterOutage(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "new-access-token",
+			"expires_in":   3600,
+			"user":         map[string]any{"name": "user"},
+		})
+	}))
+	defer server.Close()
+
+	pixiv := newTestPixivMobile(t, server)
+	pixiv.authTokenUrl = server.URL
+	pixiv.accessTokenMap = accessTokenInfo{}
+	if err := pixiv.VerifyRefreshToken(); err == nil {
+		t.Fatal("VerifyRefreshToken() returned no error during the outage")
+	}
+
+	// the error of the outage should not be cached unlike a rejected refresh token
+	if err := pixiv.VerifyRefreshToken(); err != nil {
+		t.Fatalf("VerifyRefreshToken() returned %v after the outage", err)
+	}
+	if got := pixiv.getAccessToken(); got != "new-access-token" {
+		t.Errorf("got the access token %q, want %q", got, "new-access-token")
+	}
+}
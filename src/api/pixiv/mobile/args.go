@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
 // PixivToDl is the struct that contains the arguments of Pixiv download options.
@@ -19,11 +19,63 @@ type PixivMobileDlOptions struct {
 
 	Configs     *configs.Config
 
+	// Download the animated file directly if Pixiv provides one
+	// instead of downloading the ugoira frames and converting them with FFmpeg
+	UgoiraPreferDirect bool
+
+	// If not empty, tag search results that are not from
+	// these illustrator IDs will be dropped before getting their details
+	IllustratorWhitelist []string
+
+	// Tag search results with any of these tags will be dropped
+	// before getting their details or downloading them
+	ExcludeTags []string
+
+	// Tag search results with fewer bookmarks than this will be dropped
+	// before getting their URLs to download (0 means no minimum)
+	MinBookmarks int
+
+	// Stop requesting the tag search results of a tag once this many
+	// artwork IDs have been collected after filtering (0 means no limit)
+	MaxArtworks int
+
+	// Drop the artworks that are flagged as AI-generated by Pixiv
+	NoAi bool
+
+	// Use the English translation of the artwork's tags in the metadata where available
+	TranslateTags bool
+
+	// Only search for artworks posted within these dates in the YYYY-MM-DD format
+	// (an empty date leaves the range open on that side)
+	SearchStartDate string
+	SearchEndDate   string
+
+	// If not nil, only these pages of each multi-page artwork will be downloaded
+	ArtworkPages *pixivcommon.ArtworkPages
+
+	// Search the tag names with multiple sort orders and
+	// download the union of the results to get past Pixiv's result limit
+	MaxCoverage bool
+
+	// The range of the random delay in seconds between requests to Pixiv
+	MinSleep float64
+	MaxSleep float64
+
+	// The maximum number of concurrent requests when getting the details of multiple artworks
+	ApiConcurrency int
+
 	MobileClient *PixivMobile
 	RefreshToken string
 }
 
 var (
+	// The sort orders used for the tag search when "--max_coverage" is enabled
+	// as Pixiv's mobile API only supports these sort orders
+	MAX_COVERAGE_SORT_ORDERS = []string{
+		"date_desc",
+		"date_asc",
+		"popular_desc",
+	}
 	ACCEPTED_SORT_ORDER = []string{
 		"date", "date_d",
 		"popular", "popular_d",
@@ -103,23 +155,35 @@ func (p *PixivMobileDlOptions) ValidateArgs(userAgent string) {
 		},
 	)
 
+	p.ExcludeTags = pixivcommon.CleanExcludeTags(p.ExcludeTags)
+	pixivcommon.ValidateMinBookmarks(p.MinBookmarks)
+	pixivcommon.ValidateMaxArtworks(p.MaxArtworks)
+	pixivcommon.ValidateSearchDateRange(p.SearchStartDate, p.SearchEndDate)
+	pixivcommon.ValidateSleepRange(p.MinSleep, p.MaxSleep)
+	if p.ApiConcurrency < 1 {
+		utils.PrintError(
+			"pixiv error %d: the number of concurrent requests to Pixiv's mobile API must be at least 1, got %d",
+			utils.INPUT_ERROR,
+			p.ApiConcurrency,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
 	if p.RefreshToken != "" {
 		p.MobileClient = NewPixivMobile(p.RefreshToken, 10)
-		if p.RatingMode != "all" {
-			color.Red(
-				utils.CombineStringsWithNewline(
-					fmt.Sprintf(
-						"pixiv error %d: when using the refresh token, only \"all\" is supported for the --rating_mode flag.",
-						utils.INPUT_ERROR,
-					),
-					fmt.Sprintf(
-						"hence, the rating mode will be updated from %q to \"all\"...\n",
-						p.RatingMode,
-					),
-				),
-			)
-			p.RatingMode = "all"
-		}
+		p.MobileClient.SetUserAgent(userAgent)
+		p.MobileClient.minSleep = p.MinSleep
+		p.MobileClient.maxSleep = p.MaxSleep
+		p.MobileClient.apiConcurrency = p.ApiConcurrency
+		p.MobileClient.ugoiraPreferDirect = p.UgoiraPreferDirect
+		p.MobileClient.sampleSize = p.Configs.SampleSize
+		p.MobileClient.overwriteFiles = p.Configs.OverwriteFiles
+		p.MobileClient.artworkPages = p.ArtworkPages
+		p.MobileClient.noAi = p.NoAi
+		p.MobileClient.writeMetadata = p.Configs.WriteMetadata
+		p.MobileClient.translateTags = p.TranslateTags
+		// Pixiv's mobile API does not filter the artworks by their rating
+		// so the artworks will be filtered after retrieving their details instead
+		p.MobileClient.ratingMode = p.RatingMode
 
 		if p.ArtworkType == "illust_and_ugoira" {
 			// convert "illust_and_ugoira" to "illust"
@@ -177,7 +241,7 @@ func (p *PixivMobileDlOptions) ValidateArgs(userAgent string) {
 				)
 			}
 
-			color.Red(
+			utils.PrintError(
 				utils.CombineStringsWithNewline(
 					fmt.Sprintf(
 						"pixiv error %d: when using the refresh token, only \"date\", \"date_d\", \"popular_d\" are supported for the --sort_order flag.",
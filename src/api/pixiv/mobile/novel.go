@@ -0,0 +1,457 @@
+package pixivmobile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// The number of novels returned per request by Pixiv's mobile API
+const NOVELS_PER_PAGE = 30
+
+var (
+	// The novel JSON is embedded in a script tag of the webview page
+	novelWebviewJsonRegex = regexp.MustCompile(`novel:\s*(\{.+\}),\s*isOwnWork`)
+
+	// Matches "[uploadedimage:<id>]" and "[pixivimage:<id>]" or "[pixivimage:<id>-<page>]" in the novel text
+	novelImageTagRegex = regexp.MustCompile(`\[(uploadedimage|pixivimage):([\d-]+)\]`)
+)
+
+// Query Pixiv's API (mobile) to get the details of a novel
+func (pixiv *PixivMobile) getNovelJson(novelId string) (*models.PixivMobileNovelJson, error) {
+	res, err := pixiv.SendRequest(
+		&request.RequestArgs{
+			Url:         pixiv.baseUrl + "/v2/novel/detail",
+			Params:      map[string]string{"novel_id": novelId},
+			CheckStatus: true,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv mobile error %d: failed to get novel details for %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			novelId,
+			err,
+		)
+	}
+
+	var novelJson models.PixivMobileNovelDetailJson
+	if err := utils.LoadJsonFromResponse(res, &novelJson); err != nil {
+		return nil, err
+	}
+	if novelJson.Novel == nil {
+		return nil, fmt.Errorf(
+			"pixiv mobile error %d: novel details for %s is empty",
+			utils.JSON_ERROR,
+			novelId,
+		)
+	}
+	return novelJson.Novel, nil
+}
+
+// Query Pixiv's novel webview page to get the body text and the embedded images of a novel
+func (pixiv *PixivMobile) getNovelWebviewJson(novelId string) (*models.PixivMobileNovelWebviewJson, error) {
+	res, err := pixiv.SendRequest(
+		&request.RequestArgs{
+			Url: pixiv.baseUrl + "/webview/v2/novel",
+			Params: map[string]string{
+				"id":             novelId,
+				"viewer_version": "20221031_ai",
+			},
+			CheckStatus: true,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv mobile error %d: failed to get novel text for %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			novelId,
+			err,
+		)
+	}
+
+	body, err := utils.ReadResBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := novelWebviewJsonRegex.FindSubmatch(body)
+	if matched == nil {
+		return nil, fmt.Errorf(
+			"pixiv mobile error %d: failed to find the novel text for %s in the webview page",
+			utils.UNEXPECTED_ERROR,
+			novelId,
+		)
+	}
+
+	var webviewJson models.PixivMobileNovelWebviewJson
+	if err := utils.LoadJsonFromBytes(matched[1], &webviewJson); err != nil {
+		return nil, err
+	}
+	return &webviewJson, nil
+}
+
+// Unmarshals the images or illusts of the novel webview JSON
+// which are empty arrays instead of objects if the novel has no images.
+func loadNovelImagesJson(rawJson json.RawMessage, v any) error {
+	trimmed := strings.TrimSpace(string(rawJson))
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+	return utils.LoadJsonFromBytes(rawJson, v)
+}
+
+// Returns the URLs of the images embedded in the novel text keyed by the image tag like "[uploadedimage:123]"
+func getNovelImageUrls(webviewJson *models.PixivMobileNovelWebviewJson) (map[string]string, error) {
+	var images models.PixivMobileNovelImagesJson
+	if err := loadNovelImagesJson(webviewJson.Images, &images); err != nil {
+		return nil, err
+	}
+	var illusts models.PixivMobileNovelIllustsJson
+	if err := loadNovelImagesJson(webviewJson.Illusts, &illusts); err != nil {
+		return nil, err
+	}
+
+	imageUrls := make(map[string]string)
+	for imageId, image := range images {
+		if image != nil && image.Urls.Original != "" {
+			imageUrls[fmt.Sprintf("[uploadedimage:%s]", imageId)] = image.Urls.Original
+		}
+	}
+	for illustId, illust := range illusts {
+		if illust != nil && illust.Illust != nil && illust.Illust.Images.Original != "" {
+			imageUrls[fmt.Sprintf("[pixivimage:%s]", illustId)] = illust.Illust.Images.Original
+		}
+	}
+	return imageUrls, nil
+}
+
+// Returns the contents of the novel's text file with the novel's details,
+// the body text, and the URLs of the embedded images in the order they appear in the text.
+func formatNovelText(novelJson *models.PixivMobileNovelJson, webviewJson *models.PixivMobileNovelWebviewJson, imageUrls map[string]string) string {
+	novelId := strconv.Itoa(novelJson.Id)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Title: %s\n", novelJson.Title)
+	fmt.Fprintf(
+		&sb,
+		"Author: %s (%s)\n",
+		novelJson.User.Name,
+		pixivcommon.GetUserUrl(strconv.Itoa(novelJson.User.Id)),
+	)
+	fmt.Fprintf(&sb, "URL: %s\n", pixivcommon.GetNovelUrl(novelId))
+	if novelJson.Series.Id != 0 {
+		fmt.Fprintf(
+			&sb,
+			"Series: %s (%s)\n",
+			novelJson.Series.Title,
+			pixivcommon.GetNovelSeriesUrl(strconv.Itoa(novelJson.Series.Id)),
+		)
+	}
+	if len(novelJson.Tags) > 0 {
+		tags := make([]string, 0, len(novelJson.Tags))
+		for _, tag := range novelJson.Tags {
+			tags = append(tags, tag.Name)
+		}
+		fmt.Fprintf(&sb, "Tags: %s\n", strings.Join(tags, ", "))
+	}
+	if caption := strings.TrimSpace(novelJson.Caption); caption != "" {
+		caption = strings.ReplaceAll(caption, "<br />", "\n")
+		fmt.Fprintf(&sb, "Caption:\n%s\n", caption)
+	}
+
+	// replace the image tags with the URLs so that the position of the images is kept
+	var embeddedUrls []string
+	text := novelImageTagRegex.ReplaceAllStringFunc(webviewJson.Text, func(imageTag string) string {
+		imageUrl, ok := imageUrls[imageTag]
+		if !ok {
+			return imageTag
+		}
+		embeddedUrls = append(embeddedUrls, imageUrl)
+		return fmt.Sprintf("[image: %s]", imageUrl)
+	})
+	fmt.Fprintf(&sb, "\n%s\n", text)
+
+	if len(embeddedUrls) > 0 {
+		sb.WriteString("\nEmbedded illustrations:\n")
+		for _, imageUrl := range utils.RemoveSliceDuplicates(embeddedUrls) {
+			fmt.Fprintf(&sb, "- %s\n", imageUrl)
+		}
+	}
+	return sb.String()
+}
+
+// Gets the details and the body text of the novel and writes them to
+// a text file in the novel's folder along with the URLs of the embedded illustrations.
+//
+// The text file will not be written again if it already exists unless the user wants to overwrite files.
+func (pixiv *PixivMobile) GetNovelDetails(novelId, downloadPath string) error {
+	novelJson, err := pixiv.getNovelJson(novelId)
+	if err != nil {
+		return err
+	}
+
+	novelFolderPath := utils.GetPostFolder(
+		filepath.Join(downloadPath, utils.PIXIV_TITLE), novelJson.User.Name, novelId, novelJson.Title,
+	)
+	fileName := utils.CleanPathName(novelJson.Title)
+	if fileName == "" {
+		fileName = novelId
+	}
+	filePath := filepath.Join(novelFolderPath, fileName+".txt")
+	if !pixiv.overwriteFiles && utils.PathExists(filePath) {
+		utils.RUN_STATS.AddPostsProcessed(1)
+		utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_EXISTS)
+		return nil
+	}
+
+	if !utils.AcquireFileSlot() {
+		return nil
+	}
+	downloaded := false
+	defer func() {
+		utils.ReleaseFileSlot(downloaded)
+	}()
+
+	pixiv.Sleep()
+	webviewJson, err := pixiv.getNovelWebviewJson(novelId)
+	if err != nil {
+		return err
+	}
+	imageUrls, err := getNovelImageUrls(webviewJson)
+	if err != nil {
+		return err
+	}
+
+	novelText := formatNovelText(novelJson, webviewJson, imageUrls)
+	os.MkdirAll(novelFolderPath, 0755)
+	if err := os.WriteFile(filePath, []byte(novelText), 0666); err != nil {
+		return fmt.Errorf(
+			"pixiv mobile error %d: failed to write novel text for %s, more info => %v\nfile path: %s",
+			utils.OS_ERROR,
+			novelId,
+			err,
+			filePath,
+		)
+	}
+
+	downloaded = true
+	utils.RUN_STATS.AddPostsProcessed(1)
+	utils.RUN_STATS.AddDownloadedFile(int64(len(novelText)))
+	return nil
+}
+
+// Gets the details and the body text of the novels and writes them to the download path.
+//
+// Returns the number of novels that were processed without errors.
+func (pixiv *PixivMobile) GetMultipleNovelDetails(novelIds []string, downloadPath string) int {
+	novelIdsLen := len(novelIds)
+	lastIdx := novelIdsLen - 1
+
+	var errSlice []error
+	baseMsg := "Getting and saving novels from Pixiv's Mobile API [%d/" + fmt.Sprintf("%d]...", novelIdsLen)
+	progress := spinner.New(
+		spinner.JSON_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting and saving %d novels from Pixiv's Mobile API!",
+			novelIdsLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting and saving %d novels from Pixiv's Mobile API!\nPlease refer to the logs for more details.",
+			novelIdsLen,
+		),
+		novelIdsLen,
+	)
+	progress.Start()
+	for idx, novelId := range novelIds {
+		if utils.IsMaxFilesReached() {
+			utils.AddCancelledFiles(novelIdsLen - idx)
+			break
+		}
+
+		progress.SetDetail("novel " + novelId)
+		if err := pixiv.GetNovelDetails(novelId, downloadPath); err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+		}
+
+		if idx != lastIdx {
+			pixiv.Sleep()
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return novelIdsLen - len(errSlice)
+}
+
+// Returns the IDs of the novels in the JSON response of a paginated novel list
+func getNovelIdsFromJson(resJson *models.PixivMobileNovelsJson) []string {
+	novelIds := make([]string, 0, len(resJson.Novels))
+	for _, novel := range resJson.Novels {
+		if novel != nil {
+			novelIds = append(novelIds, strconv.Itoa(novel.Id))
+		}
+	}
+	return novelIds
+}
+
+// Query Pixiv's API (mobile) to get the IDs of the novels posted by a user within the given page numbers
+func (pixiv *PixivMobile) GetIllustratorNovels(userId, pageNum string) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	minOffset, maxOffset := pixivcommon.ConvertPageNumToOffset(minPage, maxPage, NOVELS_PER_PAGE, false)
+
+	var novelIds []string
+	curOffset := minOffset
+	for {
+		res, err := pixiv.SendRequest(
+			&request.RequestArgs{
+				Url: pixiv.baseUrl + "/v1/user/novels",
+				Params: map[string]string{
+					"user_id": userId,
+					"offset":  strconv.Itoa(curOffset),
+				},
+				CheckStatus: true,
+			},
+		)
+		if err != nil {
+			return novelIds, fmt.Errorf(
+				"pixiv mobile error %d: failed to get novels of the user %s, more info => %v",
+				utils.CONNECTION_ERROR,
+				userId,
+				err,
+			)
+		}
+
+		var resJson models.PixivMobileNovelsJson
+		if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+			return novelIds, err
+		}
+		novelIds = append(novelIds, getNovelIdsFromJson(&resJson)...)
+
+		curOffset += NOVELS_PER_PAGE
+		if resJson.NextUrl == nil || (hasMax && curOffset >= maxOffset) {
+			return novelIds, nil
+		}
+		pixiv.Sleep()
+	}
+}
+
+// Query Pixiv's API (mobile) to get the IDs of all the novels in a novel series in the order of the series
+func (pixiv *PixivMobile) GetNovelSeries(seriesId string) ([]string, error) {
+	var novelIds []string
+	nextUrl := pixiv.baseUrl + "/v2/novel/series"
+	params := map[string]string{"series_id": seriesId}
+	for nextUrl != "" {
+		res, err := pixiv.SendRequest(
+			&request.RequestArgs{
+				Url:         nextUrl,
+				Params:      params,
+				CheckStatus: true,
+			},
+		)
+		if err != nil {
+			return novelIds, fmt.Errorf(
+				"pixiv mobile error %d: failed to get novels of the series %s, more info => %v",
+				utils.CONNECTION_ERROR,
+				seriesId,
+				err,
+			)
+		}
+
+		var resJson models.PixivMobileNovelsJson
+		if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+			return novelIds, err
+		}
+		novelIds = append(novelIds, getNovelIdsFromJson(&resJson)...)
+
+		nextUrl = ""
+		if resJson.NextUrl != nil {
+			// the next URL already contains the series ID and the cursor
+			nextUrl = *resJson.NextUrl
+			params = nil
+			pixiv.Sleep()
+		}
+	}
+	return novelIds, nil
+}
+
+// Gets the IDs of the novels from the novelists and novel series with a spinner
+//
+// Errors are logged and the novel IDs retrieved before the error are still returned.
+func (pixiv *PixivMobile) GetNovelIds(novelistIds, novelistPageNums, seriesIds []string) []string {
+	total := len(novelistIds) + len(seriesIds)
+	var novelIds []string
+	var errSlice []error
+	baseMsg := "Getting novels from novelist(s) and novel series on Pixiv [%d/" + fmt.Sprintf("%d]...", total)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting novels from %d novelist(s) and novel series on Pixiv!",
+			total,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting novels from %d novelist(s) and novel series on Pixiv!\nPlease refer to the logs for more details.",
+			total,
+		),
+		total,
+	)
+	progress.Start()
+	for idx, novelistId := range novelistIds {
+		progress.SetDetail("novelist " + novelistId)
+		ids, err := pixiv.GetIllustratorNovels(novelistId, novelistPageNums[idx])
+		if err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+		}
+		novelIds = append(novelIds, ids...)
+		pixiv.Sleep()
+		progress.MsgIncrement(baseMsg)
+	}
+	for _, seriesId := range seriesIds {
+		progress.SetDetail("novel series " + seriesId)
+		ids, err := pixiv.GetNovelSeries(seriesId)
+		if err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+		}
+		novelIds = append(novelIds, ids...)
+		pixiv.Sleep()
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return novelIds
+}
@@ -4,8 +4,12 @@ import (
 	cryptorand "crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
@@ -15,9 +19,28 @@ import (
 	"github.com/pkg/browser"
 )
 
+// Returned when Pixiv rejects the refresh token with an "invalid_grant" error
+var ErrInvalidRefreshToken = errors.New("the refresh token is invalid or has expired")
+
+// Returns true if the response to the refresh token request means that Pixiv has rejected the refresh token
+// as opposed to other errors like rate limits or outages which do not say anything about the refresh token.
+//
+// Note: The response body will be read.
+func isInvalidGrantRes(res *http.Response) bool {
+	if res.StatusCode != http.StatusBadRequest && res.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(body), "invalid_grant")
+}
+
 type accessTokenInfo struct {
 	accessToken string    // The access token that will be used to communicate with the Pixiv's Mobile API
 	expiresAt   time.Time // The time when the access token expires
+	userName    string    // The name of the account that the refresh token belongs to
 }
 
 // Perform a S256 transformation method on a byte array
@@ -29,13 +52,15 @@ func S256(bytes []byte) string {
 var pixivOauthCodeRegex = regexp.MustCompile(`^[\w-]{43}$`)
 
 // Start the OAuth flow to get the refresh token
-func (pixiv *PixivMobile) StartOauthFlow() error {
+//
+// Returns the refresh token from Pixiv once the user has entered a valid code.
+func (pixiv *PixivMobile) StartOauthFlow() (string, error) {
 	// create a random 32 bytes that is cryptographically secure
 	codeVerifierBytes := make([]byte, 32)
 	_, err := cryptorand.Read(codeVerifierBytes)
 	if err != nil {
 		// should never happen but just in case
-		return fmt.Errorf(
+		return "", fmt.Errorf(
 			"pixiv mobile error %d: failed to generate random bytes, more info => %v",
 			utils.DEV_ERROR,
 			err,
@@ -53,9 +78,9 @@ func (pixiv *PixivMobile) StartOauthFlow() error {
 	loginUrl := pixiv.loginUrl + "?" + utils.ParamsToString(loginParams)
 	err = browser.OpenURL(loginUrl)
 	if err != nil {
-		color.Red("Pixiv: Failed to open browser: " + err.Error())
-		color.Red("Please open the following URL in your browser:")
-		color.Red(loginUrl)
+		utils.PrintError("Pixiv: Failed to open browser: " + err.Error())
+		utils.PrintError("Please open the following URL in your browser:")
+		utils.PrintError(loginUrl)
 	} else {
 		color.Green("Opened a new tab in your browser to\n" + loginUrl)
 	}
@@ -68,12 +93,19 @@ func (pixiv *PixivMobile) StartOauthFlow() error {
 		fmt.Print(color.YellowString("Please enter the code you received from Pixiv: "))
 		_, err := fmt.Scanln(&code)
 		fmt.Println()
+		if err == io.EOF {
+			// there is no way to get the code if stdin is closed or not a terminal
+			return "", fmt.Errorf(
+				"pixiv mobile error %d: failed to read the code as there is no input",
+				utils.INPUT_ERROR,
+			)
+		}
 		if err != nil {
-			color.Red("Failed to read inputted code: " + err.Error())
+			utils.PrintError("Failed to read inputted code: " + err.Error())
 			continue
 		}
 		if !pixivOauthCodeRegex.MatchString(code) {
-			color.Red("Invalid code format...")
+			utils.PrintError("Invalid code format...")
 			continue
 		}
 
@@ -98,28 +130,39 @@ func (pixiv *PixivMobile) StartOauthFlow() error {
 			},
 		)
 		if err != nil {
-			color.Red("Please check if the code you entered is correct.")
+			utils.PrintError("Please check if the code you entered is correct.")
 			continue
 		}
 
 		var oauthFlowJson models.PixivOauthFlowJson
 		if err := utils.LoadJsonFromResponse(res, &oauthFlowJson); err != nil {
-			color.Red(err.Error())
+			utils.PrintError(err.Error())
 			continue
 		}
 
-		refreshToken := oauthFlowJson.RefreshToken
-		color.Green("Your Pixiv Refresh Token: " + refreshToken)
-		color.Yellow("Please save your refresh token somewhere SECURE and do NOT share it with anyone!")
-		return nil
+		return oauthFlowJson.RefreshToken, nil
 	}
 }
 
-// Refresh the access token
-func (pixiv *PixivMobile) refreshAccessToken() error {
+// Returns the exit code for the error returned when refreshing the access token
+func GetRefreshTokenExitCode(err error) int {
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		return utils.EXIT_AUTH_ERROR
+	}
+	return utils.GetExitCodeFromErr(err)
+}
+
+// Refresh the access token using the refresh token
+func (pixiv *PixivMobile) RefreshAccessToken() error {
 	pixiv.accessTokenMu.Lock()
 	defer pixiv.accessTokenMu.Unlock()
+	return pixiv.refreshAccessToken()
+}
 
+// Refresh the access token using the refresh token.
+//
+// Note: The caller must hold the accessTokenMu lock.
+func (pixiv *PixivMobile) refreshAccessToken() error {
 	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_MOBILE, true)
 	res, err := request.CallRequestWithData(
 		&request.RequestArgs{
@@ -141,10 +184,21 @@ func (pixiv *PixivMobile) refreshAccessToken() error {
 	if err != nil || res.StatusCode != 200 {
 		const errPrefix = "pixiv mobile error"
 		if err == nil {
+			invalidGrant := isInvalidGrantRes(res)
 			res.Body.Close()
+			if invalidGrant {
+				return fmt.Errorf(
+					"%s %d: failed to refresh token due to %s response from Pixiv as %w\n"+
+						"Please check your refresh token and try again or run the \"pixiv oauth\" command to get a new refresh token",
+					errPrefix,
+					utils.RESPONSE_ERROR,
+					res.Status,
+					ErrInvalidRefreshToken,
+				)
+			}
 			err = fmt.Errorf(
 				"%s %d: failed to refresh token due to %s response from Pixiv\n"+
-					"Please check your refresh token and try again or use the \"-pixiv_start_oauth\" flag to get a new refresh token",
+					"Please try again later",
 				errPrefix,
 				utils.RESPONSE_ERROR,
 				res.Status,
@@ -169,21 +223,69 @@ func (pixiv *PixivMobile) refreshAccessToken() error {
 	expiresIn := oauthJson.ExpiresIn - 15 // usually 3600 but minus 15 seconds to be safe
 	pixiv.accessTokenMap.accessToken = oauthJson.AccessToken
 	pixiv.accessTokenMap.expiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	pixiv.accessTokenMap.userName = oauthJson.User.Name
 	return nil
 }
 
-// Reads the response JSON and checks if the access token has expired,
+// Replaces the refresh token like after the OAuth flow
+// and clears the access token retrieved with the previous refresh token.
+func (pixiv *PixivMobile) SetRefreshToken(refreshToken string) {
+	pixiv.accessTokenMu.Lock()
+	defer pixiv.accessTokenMu.Unlock()
+	pixiv.refreshToken = refreshToken
+	pixiv.accessTokenMap = accessTokenInfo{}
+	pixiv.invalidTokenErr = nil
+}
+
+// Checks if the access token has not been retrieved yet or has expired,
 // if so, refreshes the access token for future requests.
 //
-// Returns a boolean indicating if the access token was refreshed.
-func (pixiv *PixivMobile) refreshTokenIfReq() (bool, error) {
+// Once Pixiv has rejected the refresh token, the same error will be returned
+// without sending any more requests to refresh the access token.
+// Other errors like rate limits or outages are not cached so that the next call will try again.
+func (pixiv *PixivMobile) refreshTokenIfReq() error {
+	pixiv.accessTokenMu.Lock()
+	defer pixiv.accessTokenMu.Unlock()
+
+	if pixiv.invalidTokenErr != nil {
+		return pixiv.invalidTokenErr
+	}
 	if pixiv.accessTokenMap.accessToken != "" && pixiv.accessTokenMap.expiresAt.After(time.Now()) {
-		return false, nil
+		return nil
 	}
 
 	err := pixiv.refreshAccessToken()
-	if err != nil {
-		return true, err
+	if err != nil && GetRefreshTokenExitCode(err) == utils.EXIT_AUTH_ERROR {
+		pixiv.invalidTokenErr = err
 	}
-	return true, nil
+	return err
+}
+
+// Retrieves the access token with the refresh token if there is no valid access token yet.
+//
+// Returns an error if the refresh token is invalid or if Pixiv could not be reached.
+func (pixiv *PixivMobile) VerifyRefreshToken() error {
+	return pixiv.refreshTokenIfReq()
+}
+
+// Returns the current access token
+func (pixiv *PixivMobile) getAccessToken() string {
+	pixiv.accessTokenMu.Lock()
+	defer pixiv.accessTokenMu.Unlock()
+	return pixiv.accessTokenMap.accessToken
+}
+
+// Returns the time when the current access token expires
+func (pixiv *PixivMobile) GetAccessTokenExpiry() time.Time {
+	pixiv.accessTokenMu.Lock()
+	defer pixiv.accessTokenMu.Unlock()
+	return pixiv.accessTokenMap.expiresAt
+}
+
+// Returns the name of the account that the refresh token belongs to
+// or an empty string if the access token has not been retrieved yet
+func (pixiv *PixivMobile) GetAccountName() string {
+	pixiv.accessTokenMu.Lock()
+	defer pixiv.accessTokenMu.Unlock()
+	return pixiv.accessTokenMap.userName
 }
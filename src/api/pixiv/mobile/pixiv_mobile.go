@@ -3,15 +3,23 @@ package pixivmobile
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
+// The default range of the random delay in seconds between requests to Pixiv's mobile API
+const (
+	DEFAULT_MIN_SLEEP = 1.0
+	DEFAULT_MAX_SLEEP = 1.5
+)
+
+// The default number of concurrent requests when getting the details of multiple artworks
+const DEFAULT_API_CONCURRENCY = 3
+
 type PixivMobile struct {
 	// API information and its endpoints
 	baseUrl      string
@@ -24,37 +32,62 @@ type PixivMobile struct {
 	refreshToken string
 
 	// User given arguments
-	apiTimeout int
+	apiTimeout         int
+	ugoiraPreferDirect bool
+	sampleSize         int
+	overwriteFiles     bool
+	artworkPages       *pixivcommon.ArtworkPages
+	noAi               bool
+	ratingMode         string
+	writeMetadata      bool
+	translateTags      bool
+
+	// The range of the random delay in seconds between requests
+	minSleep float64
+	maxSleep float64
+
+	// The maximum number of concurrent requests when getting the details of multiple artworks
+	apiConcurrency int
 
 	// Access token information
 	accessTokenMu  sync.Mutex
 	accessTokenMap accessTokenInfo
+
+	// Set if Pixiv rejected the refresh token to avoid retrying the refresh on every request
+	invalidTokenErr error
 }
 
 // Get a new PixivMobile structure
+//
+// The access token will only be retrieved with the refresh token on the first API call
+// so that an invalid refresh token will not affect operations that do not use the Pixiv mobile API.
 func NewPixivMobile(refreshToken string, timeout int) *PixivMobile {
 	pixivMobile := &PixivMobile{
-		baseUrl:       utils.PIXIV_MOBILE_URL,
-		clientId:      "MOBrBDS8blbauoSck0ZfDbtuzpyT",
-		clientSecret:  "lsACyCD94FhDUtGTXi3QzcFE2uU1hqtDaKeqrdwj",
-		userAgent:     "PixivIOSApp/7.13.3 (iOS 14.6; iPhone13,2)",
-		authTokenUrl:  "https://oauth.secure.pixiv.net/auth/token",
-		loginUrl:      utils.PIXIV_MOBILE_URL + "/web/v1/login",
-		redirectUri:   utils.PIXIV_MOBILE_URL + "/web/v1/users/auth/pixiv/callback",
-		refreshToken:  refreshToken,
-		apiTimeout:    timeout,
-	}
-	if refreshToken != "" {
-		// refresh the access token and verify it
-		err := pixivMobile.refreshAccessToken()
-		if err != nil {
-			color.Red(err.Error())
-			os.Exit(1)
-		}
+		baseUrl:        utils.PIXIV_MOBILE_URL,
+		clientId:       "MOBrBDS8blbauoSck0ZfDbtuzpyT",
+		clientSecret:   "lsACyCD94FhDUtGTXi3QzcFE2uU1hqtDaKeqrdwj",
+		userAgent:      "PixivIOSApp/7.13.3 (iOS 14.6; iPhone13,2)",
+		authTokenUrl:   "https://oauth.secure.pixiv.net/auth/token",
+		loginUrl:       utils.PIXIV_MOBILE_URL + "/web/v1/login",
+		redirectUri:    utils.PIXIV_MOBILE_URL + "/web/v1/users/auth/pixiv/callback",
+		refreshToken:   refreshToken,
+		apiTimeout:     timeout,
+		minSleep:       DEFAULT_MIN_SLEEP,
+		maxSleep:       DEFAULT_MAX_SLEEP,
+		apiConcurrency: DEFAULT_API_CONCURRENCY,
 	}
 	return pixivMobile
 }
 
+// Overrides the User-Agent header of the Pixiv iOS app used by default.
+//
+// Does nothing if the given user agent is empty.
+func (pixiv *PixivMobile) SetUserAgent(userAgent string) {
+	if userAgent != "" {
+		pixiv.userAgent = userAgent
+	}
+}
+
 // This is due to Pixiv's strict rate limiting.
 //
 // Without delays, the user might get 429 too many requests
@@ -63,7 +96,7 @@ func NewPixivMobile(refreshToken string, timeout int) *PixivMobile {
 // Additionally, pixiv.net is protected by cloudflare, so
 // to prevent the user's IP reputation from going down, delays are added.
 func (pixiv *PixivMobile) Sleep() {
-	time.Sleep(utils.GetRandomTime(1.0, 1.5))
+	utils.Sleep(utils.GetRandomTime(pixiv.minSleep, pixiv.maxSleep))
 }
 
 // Get the required headers to communicate with the Pixiv API
@@ -77,7 +110,7 @@ func (pixiv *PixivMobile) getHeaders(additional map[string]string) map[string]st
 		"User-Agent":     pixiv.userAgent,
 		"App-OS":         "ios",
 		"App-OS-Version": "14.6",
-		"Authorization":  "Bearer " + pixiv.accessTokenMap.accessToken,
+		"Authorization":  "Bearer " + pixiv.getAccessToken(),
 	}
 	for k, v := range baseHeaders {
 		headers[k] = v
@@ -101,13 +134,12 @@ func (pixiv *PixivMobile) SendRequest(reqArgs *request.RequestArgs) (*http.Respo
 	reqArgs.Http2 = !useHttp3
 	reqArgs.ValidateArgs()
 
-	req, err := http.NewRequest(reqArgs.Method, reqArgs.Url, nil)
+	req, err := http.NewRequestWithContext(reqArgs.Context, reqArgs.Method, reqArgs.Url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshed, err := pixiv.refreshTokenIfReq()
-	if err != nil {
+	if err := pixiv.refreshTokenIfReq(); err != nil {
 		return nil, err
 	}
 
@@ -119,20 +151,40 @@ func (pixiv *PixivMobile) SendRequest(reqArgs *request.RequestArgs) (*http.Respo
 	var res *http.Response
 	client := request.GetHttpClient(reqArgs)
 	client.Timeout = time.Duration(reqArgs.Timeout) * time.Second
-	for i := 1; i <= utils.RETRY_COUNTER; i++ {
+	retryCount := utils.GetRetryCount(utils.PIXIV_MOBILE)
+	for i := 1; i <= retryCount; i++ {
+		utils.WaitForRateLimit(utils.PIXIV_MOBILE)
 		res, err = client.Do(req)
-		if err == nil {
-			if refreshed {
-				continue
-			} else if res.StatusCode == 200 || !reqArgs.CheckStatus {
-				return res, nil
+		if ctxErr := reqArgs.Context.Err(); ctxErr != nil {
+			// cancelled by the user or the deadline has passed so there is no point in retrying
+			if err == nil {
+				res.Body.Close()
+			}
+			return nil, ctxErr
+		}
+		if err != nil {
+			if i < retryCount {
+				if sleepErr := utils.SleepWithContext(reqArgs.Context, utils.GetRetryDelay(utils.PIXIV_MOBILE)); sleepErr != nil {
+					return nil, sleepErr
+				}
+			}
+			continue
+		}
+		// 429 Too Many Requests is retried even if the status is not checked
+		if res.StatusCode == 200 || (!reqArgs.CheckStatus && (res.StatusCode != http.StatusTooManyRequests || i == retryCount)) {
+			return res, nil
+		}
+
+		res.Body.Close()
+		if i < retryCount {
+			if sleepErr := utils.SleepWithContext(reqArgs.Context, utils.GetRetryDelayFromRes(utils.PIXIV_MOBILE, res)); sleepErr != nil {
+				return nil, sleepErr
 			}
 		}
-		time.Sleep(utils.GetRandomDelay())
 	}
 	return nil, fmt.Errorf(
 		"request to %s failed after %d retries",
 		reqArgs.Url,
-		utils.RETRY_COUNTER,
+		retryCount,
 	)
 }
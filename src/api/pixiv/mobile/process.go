@@ -4,14 +4,17 @@ import (
 	"strconv"
 	"path/filepath"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
 // Process the artwork JSON and returns a slice of map that contains the urls of the images and the file path
+//
+// For multi-page artworks, only the pages selected by "--artwork_pages" (if any) will be returned.
 func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllustJson, downloadPath string) ([]*request.ToDownload, *models.Ugoira, error) {
-	if artworkJson == nil {
+	if artworkJson == nil || utils.IsBeforeSince(artworkJson.CreateDate) {
 		return nil, nil, nil
 	}
 
@@ -22,12 +25,51 @@ func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllu
 	artworkFolderPath := utils.GetPostFolder(
 		filepath.Join(downloadPath, utils.PIXIV_TITLE), illustratorName, artworkId, artworkTitle,
 	)
+	pixivcommon.RecordArtworkPageCount(artworkFolderPath, artworkJson.PageCount)
+	metadata := &utils.FileMetadata{
+		PostUrl: pixivcommon.GetIllustUrl(artworkId),
+		Creator: illustratorName,
+	}
+	for _, tag := range artworkJson.Tags {
+		metadata.Tags = append(metadata.Tags, pixivcommon.GetTagName(tag.Name, tag.TranslatedName, pixiv.translateTags))
+	}
+	if pixiv.writeMetadata {
+		artworkMetadata := &pixivcommon.ArtworkMetadata{
+			PostMetadata: utils.PostMetadata{
+				Id:       artworkId,
+				Title:    artworkTitle,
+				Creator:  illustratorName,
+				Tags:     metadata.Tags,
+				PostDate: artworkJson.CreateDate,
+				Url:      metadata.PostUrl,
+			},
+			UserId:        strconv.Itoa(artworkJson.User.Id),
+			Description:   artworkJson.Caption,
+			PageCount:     artworkJson.PageCount,
+			BookmarkCount: artworkJson.TotalBookmarks,
+		}
+		if err := pixivcommon.WriteArtworkMetadata(artworkFolderPath, artworkMetadata, pixiv.overwriteFiles); err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
+	}
 
 	if artworkType == "ugoira" {
-		ugoiraInfo, err := pixiv.getUgoiraMetadata(artworkId, artworkFolderPath)
+		ugoiraInfo, directUrl, err := pixiv.getUgoiraMetadata(artworkId, artworkFolderPath)
 		if err != nil {
 			return nil, nil, err
 		}
+
+		if directUrl != "" {
+			return []*request.ToDownload{
+				{
+					Url:      directUrl,
+					FilePath: artworkFolderPath,
+					Referer:  metadata.PostUrl,
+					CacheKey: pixivcommon.GetArtworkCacheKey(artworkId, 0),
+					Metadata: metadata,
+				},
+			}, nil, nil
+		}
 		return nil, ugoiraInfo, nil
 	}
 
@@ -37,17 +79,22 @@ func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllu
 		artworksToDownload = append(artworksToDownload, &request.ToDownload{
 			Url:      singlePageImageUrl,
 			FilePath: artworkFolderPath,
+			CacheKey: pixivcommon.GetArtworkCacheKey(artworkId, 0),
 		})
 	} else {
-		for _, image := range artworkJson.MetaPages {
+		for page, image := range artworkJson.MetaPages {
 			imageUrl := image.ImageUrls.Original
 			artworksToDownload = append(artworksToDownload, &request.ToDownload{
 				Url:      imageUrl,
 				FilePath: artworkFolderPath,
+				CacheKey: pixivcommon.GetArtworkCacheKey(artworkId, page),
 			})
 		}
+		artworksToDownload = pixiv.artworkPages.Select(artworkId, artworksToDownload)
 	}
-	return artworksToDownload, nil, nil
+	artworksToDownload = request.SampleToDownload(artworksToDownload, pixiv.sampleSize)
+	artworksToDownload = request.SetReferer(artworksToDownload, metadata.PostUrl)
+	return request.SetMetadata(artworksToDownload, metadata), nil, nil
 }
 
 // The same as the processArtworkJson function but for mutliple JSONs at once
@@ -66,11 +113,19 @@ func (pixiv *PixivMobile) processMultipleArtworkJson(resJson *models.PixivMobile
 	var ugoiraToDl []*models.Ugoira
 	var artworksToDl []*request.ToDownload
 	for _, artwork := range artworksMaps {
+		if pixivcommon.SkipAiGenerated(pixiv.noAi, artwork.IllustAiType) {
+			continue
+		}
+		if pixivcommon.SkipRating(pixiv.ratingMode, artwork.XRestrict) {
+			continue
+		}
 		artworks, ugoira, err := pixiv.processArtworkJson(artwork, downloadPath)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			continue
 		}
+		utils.RUN_STATS.AddPostsProcessed(1)
 		if ugoira != nil {
 			ugoiraToDl = append(ugoiraToDl, ugoira)
 			continue
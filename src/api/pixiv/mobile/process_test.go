@@ -0,0 +1,80 @@
+package pixivmobile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestProcessArtworkJson(t *testing.T) {
+	var artworkJson models.PixivMobileIllustJson
+	err := json.Unmarshal([]byte(`{
+		"id": 123,
+		"title": "title",
+		"type": "illust",
+		"caption": "caption",
+		"create_date": "2024-01-31T15:04:05+09:00",
+		"page_count": 1,
+		"total_bookmarks": 10,
+		"user": {"id": 456, "name": "illustrator"},
+		"tags": [{"name": "オリジナル", "translated_name": "original"}, {"name": "風景"}],
+		"meta_single_page": {"original_image_url": "https://i.pximg.net/img-original/img/123_p0.png"}
+	}`), &artworkJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downloadPath := t.TempDir()
+	pixiv := NewPixivMobile("refresh-token", 10)
+	pixiv.writeMetadata = true
+	pixiv.translateTags = true
+	toDownload, _, err := pixiv.processArtworkJson(&artworkJson, downloadPath)
+	if err != nil {
+		t.Fatalf("processArtworkJson() returned %v", err)
+	}
+	if len(toDownload) != 1 {
+		t.Fatalf("processArtworkJson() returned %d files, want 1", len(toDownload))
+	}
+	if referer := toDownload[0].Referer; referer != "https://www.pixiv.net/artworks/123" {
+		t.Errorf("the file has the referer %q, want the artwork URL", referer)
+	}
+	if cacheKey := toDownload[0].CacheKey; cacheKey != "pixiv/123_p0" {
+		t.Errorf("the file has the cache key %q, want %q", cacheKey, "pixiv/123_p0")
+	}
+
+	artworkFolderPath := utils.GetPostFolder(
+		filepath.Join(downloadPath, utils.PIXIV_TITLE), "illustrator", "123", "title",
+	)
+	metadataJson, err := os.ReadFile(filepath.Join(artworkFolderPath, utils.POST_METADATA_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(metadataJson, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys shared with the other sites followed by the Pixiv-specific and the deprecated ones
+	want := map[string]any{
+		"id":            "123",
+		"title":         "title",
+		"creator":       "illustrator",
+		"tags":          []any{"original", "風景"},
+		"postDate":      "2024-01-31T15:04:05+09:00",
+		"url":           "https://www.pixiv.net/artworks/123",
+		"userId":        "456",
+		"description":   "caption",
+		"pageCount":     float64(1),
+		"bookmarkCount": float64(10),
+		"userName":      "illustrator",
+		"createDate":    "2024-01-31T15:04:05+09:00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote metadata %v, want %v", got, want)
+	}
+}
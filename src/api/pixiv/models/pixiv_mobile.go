@@ -1,8 +1,13 @@
 package models
 
+import "encoding/json"
+
 type PixivOauthJson struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   float64 `json:"expires_in"`
+	User        struct {
+		Name string `json:"name"`
+	} `json:"user"`
 }
 
 type PixivOauthFlowJson struct {
@@ -12,21 +17,33 @@ type PixivOauthFlowJson struct {
 type UgoiraJson struct {
 	Metadata struct {
 		Frames UgoiraFramesJson `json:"frames"`
-		ZipUrls struct {
-			Medium string `json:"medium"`
-		} `json:"zip_urls"`
+		// usually only has the "medium" key but Pixiv may provide other sizes or formats
+		ZipUrls map[string]string `json:"zip_urls"`
 	} `json:"ugoira_metadata"`
 }
 
 type PixivMobileIllustJson struct {
-	Id    int    `json:"id"`
-	Title string `json:"title"`
-	Type  string `json:"type"`
+	Id             int    `json:"id"`
+	Title          string `json:"title"`
+	Type           string `json:"type"`
+	Caption        string `json:"caption"`
+	CreateDate     string `json:"create_date"`
+	PageCount      int    `json:"page_count"`
+	TotalBookmarks int    `json:"total_bookmarks"`
+	Visible        bool   `json:"visible"`
+	IllustAiType   int    `json:"illust_ai_type"`
+	XRestrict      int    `json:"x_restrict"`
 
 	User struct {
+		Id    int    `json:"id"`
 		Name  string `json:"name"`
 	} `json:"user"`
 
+	Tags []struct {
+		Name           string `json:"name"`
+		TranslatedName string `json:"translated_name"`
+	} `json:"tags"`
+
 	MetaSinglePage struct {
 		OriginalImageUrl string `json:"original_image_url"`
 	} `json:"meta_single_page"`
@@ -45,3 +62,57 @@ type PixivMobileArtworksJson struct {
 	Illusts []*PixivMobileIllustJson `json:"illusts"`
 	NextUrl *string                  `json:"next_url"`
 }
+
+type PixivMobileNovelJson struct {
+	Id      int    `json:"id"`
+	Title   string `json:"title"`
+	Caption string `json:"caption"`
+
+	User struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+
+	// Empty if the novel is not part of a series
+	Series struct {
+		Id    int    `json:"id"`
+		Title string `json:"title"`
+	} `json:"series"`
+}
+
+type PixivMobileNovelDetailJson struct {
+	Novel *PixivMobileNovelJson `json:"novel"`
+}
+type PixivMobileNovelsJson struct {
+	Novels  []*PixivMobileNovelJson `json:"novels"`
+	NextUrl *string                 `json:"next_url"`
+}
+
+// The novel JSON embedded in the webview page of the novel which contains the body text
+type PixivMobileNovelWebviewJson struct {
+	Text string `json:"text"`
+
+	// Objects keyed by the image ID but Pixiv returns an empty array if there are no images
+	Images  json.RawMessage `json:"images"`
+	Illusts json.RawMessage `json:"illusts"`
+}
+
+// The images uploaded to the novel, referenced by "[uploadedimage:<id>]" in the text
+type PixivMobileNovelImagesJson map[string]*struct {
+	Urls struct {
+		Original string `json:"original"`
+	} `json:"urls"`
+}
+
+// The Pixiv artworks embedded in the novel, referenced by "[pixivimage:<id>]" or "[pixivimage:<id>-<page>]" in the text
+type PixivMobileNovelIllustsJson map[string]*struct {
+	Illust *struct {
+		Images struct {
+			Original string `json:"original"`
+		} `json:"images"`
+	} `json:"illust"`
+}
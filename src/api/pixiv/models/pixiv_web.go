@@ -1,10 +1,27 @@
 package models
 
+import "encoding/json"
+
 type ArtworkDetails struct {
 	Body struct {
-		UserName   string `json:"userName"`
-		Title      string `json:"title"`
-		IllustType int64  `json:"illustType"`
+		UserName      string `json:"userName"`
+		UserId        string `json:"userId"`
+		Title         string `json:"title"`
+		Description   string `json:"description"`
+		CreateDate    string `json:"createDate"`
+		PageCount     int    `json:"pageCount"`
+		IllustType    int64  `json:"illustType"`
+		BookmarkCount int    `json:"bookmarkCount"`
+		AiType        int    `json:"aiType"`
+		XRestrict     int    `json:"xRestrict"`
+		Tags          struct {
+			Tags []struct {
+				Tag         string `json:"tag"`
+				Translation struct {
+					En string `json:"en"`
+				} `json:"translation"`
+			} `json:"tags"`
+		} `json:"tags"`
 	}
 }
 
@@ -34,7 +51,10 @@ type PixivTag struct {
 	Body struct {
 		IllustManga struct {
 			Data []struct {
-				Id string `json:"id"`
+				Id     string   `json:"id"`
+				UserId string   `json:"userId"`
+				Tags   []string `json:"tags"`
+				AiType int      `json:"aiType"`
 			} `json:"data"`
 		} `json:"illustManga"`
 	} `json:"body"`
@@ -46,3 +66,71 @@ type PixivWebIllustratorJson struct {
         Manga   interface{} `json:"manga"`
     } `json:"body"`
 }
+
+type PixivWebUserJson struct {
+	Body struct {
+		UserId     string `json:"userId"`
+		Name       string `json:"name"`
+		ImageBig   string `json:"imageBig"`
+		Background *struct {
+			Url string `json:"url"`
+		} `json:"background"`
+	} `json:"body"`
+}
+
+type PixivWebSeriesJson struct {
+	Error bool `json:"error"`
+	Body  struct {
+		Page struct {
+			Series []struct {
+				WorkId string `json:"workId"`
+				Order  int    `json:"order"`
+			} `json:"series"`
+			Total int `json:"total"`
+		} `json:"page"`
+	} `json:"body"`
+}
+
+type PixivWebRankingJson struct {
+	Contents []struct {
+		IllustId   int    `json:"illust_id"`
+		IllustType string `json:"illust_type"`
+	} `json:"contents"`
+
+	// The next page number or false on the last page
+	Next json.RawMessage `json:"next"`
+}
+
+// Returns true if there is a page of rankings after the current page
+func (r *PixivWebRankingJson) HasNextPage() bool {
+	next := string(r.Next)
+	return next != "" && next != "false" && next != "null"
+}
+
+type PixivWebFollowLatestJson struct {
+	Body struct {
+		Page struct {
+			Ids []int `json:"ids"`
+		} `json:"page"`
+		Thumbnails struct {
+			Illust []struct {
+				Id         string `json:"id"`
+				IllustType int64  `json:"illustType"`
+				XRestrict  int    `json:"xRestrict"`
+			} `json:"illust"`
+		} `json:"thumbnails"`
+	} `json:"body"`
+}
+
+type PixivWebBookmarksJson struct {
+	Error bool `json:"error"`
+	Body  struct {
+		Works []struct {
+			// a number instead of a string for some deleted works
+			Id         json.Number `json:"id"`
+			IllustType int64       `json:"illustType"`
+			IsMasked   bool        `json:"isMasked"`
+		} `json:"works"`
+		Total int `json:"total"`
+	} `json:"body"`
+}
@@ -13,8 +13,8 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
-func alertUser(artworksToDl []*request.ToDownload, ugoiraToDl []*models.Ugoira) {
-	if len(artworksToDl) > 0 || len(ugoiraToDl) > 0 {
+func alertUser(artworksToDl []*request.ToDownload, ugoiraToDl []*models.Ugoira, novelCount int) {
+	if len(artworksToDl) > 0 || len(ugoiraToDl) > 0 || novelCount > 0 {
 		utils.AlertWithoutErr(utils.Title, "Finished downloading artworks from Pixiv!")
 	} else {
 		utils.AlertWithoutErr(utils.Title, "No artworks to download from Pixiv!")
@@ -25,21 +25,81 @@ func alertUser(artworksToDl []*request.ToDownload, ugoiraToDl []*models.Ugoira)
 func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWebDlOptions, pixivUgoiraOptions *ugoira.UgoiraOptions) {
 	var ugoiraToDl []*models.Ugoira
 	var artworksToDl []*request.ToDownload
+	artworkDlPath := getSourceDlPath(pixivDl.ArtworkDlPath, pixivDlOptions.Configs.DownloadPath)
+	illustratorDlPath := getSourceDlPath(pixivDl.IllustratorDlPath, pixivDlOptions.Configs.DownloadPath)
 	if len(pixivDl.IllustratorIds) > 0 {
+		if pixivDl.DlIllustratorProfile {
+			artworksToDl = pixivweb.GetMultipleIllustratorProfileImages(
+				pixivDl.IllustratorIds,
+				illustratorDlPath,
+				pixivDlOptions.SessionCookies,
+				pixivDlOptions.Configs.UserAgent,
+			)
+		}
 		artworkIdsSlice := pixivweb.GetMultipleIllustratorPosts(
 			pixivDl.IllustratorIds,
 			pixivDl.IllustratorPageNums,
-			utils.DOWNLOAD_PATH,
+			illustratorDlPath,
 			pixivDlOptions,
 		)
-		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIdsSlice...)
+		if illustratorDlPath == artworkDlPath {
+			pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, artworkIdsSlice...)
+			pixivDl.ArtworkIds = utils.RemoveSliceDuplicates(pixivDl.ArtworkIds)
+		} else if len(artworkIdsSlice) > 0 {
+			// the illustrators' artworks have to be processed separately
+			// as they are downloaded to a different path from the artwork IDs
+			artworkSlice, ugoiraSlice := pixivweb.GetMultipleArtworkDetails(
+				utils.RemoveSliceDuplicates(artworkIdsSlice),
+				illustratorDlPath,
+				pixivDlOptions,
+			)
+			artworksToDl = append(artworksToDl, artworkSlice...)
+			ugoiraToDl = append(ugoiraToDl, ugoiraSlice...)
+		}
+	}
+
+	if len(pixivDl.SeriesIds) > 0 {
+		// the chapters are downloaded to the same path as the artwork IDs in their reading order
+		seriesArtworkIds := pixivweb.GetMultipleSeriesArtworks(pixivDl.SeriesIds, pixivDlOptions)
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, seriesArtworkIds...)
+		pixivDl.ArtworkIds = utils.RemoveSliceDuplicates(pixivDl.ArtworkIds)
+	}
+
+	if pixivDl.HasRanking() {
+		// the ranked artworks are downloaded to the same path as the artwork IDs
+		rankingIds := pixivweb.GetRankingArtworks(
+			pixivDl.RankingMode,
+			pixivDl.RankingDate,
+			pixivDl.RankingPageNum,
+			pixivDlOptions,
+		)
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, rankingIds...)
+		pixivDl.ArtworkIds = utils.RemoveSliceDuplicates(pixivDl.ArtworkIds)
+	}
+
+	if pixivDl.Following {
+		// the latest artworks from the followed illustrators are downloaded to the same path as the artwork IDs
+		followingIds := pixivweb.GetFollowingArtworks(pixivDl.FollowingPageNum, pixivDlOptions)
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, followingIds...)
+		pixivDl.ArtworkIds = utils.RemoveSliceDuplicates(pixivDl.ArtworkIds)
+	}
+
+	if pixivDl.HasBookmarks() {
+		// the bookmarked artworks are downloaded to the same path as the artwork IDs
+		bookmarkedIds := pixivweb.GetBookmarkedArtworks(
+			pixivDl.BookmarksUserId,
+			pixivDl.BookmarksPageNum,
+			pixivDl.PrivateBookmarks,
+			pixivDlOptions,
+		)
+		pixivDl.ArtworkIds = append(pixivDl.ArtworkIds, bookmarkedIds...)
 		pixivDl.ArtworkIds = utils.RemoveSliceDuplicates(pixivDl.ArtworkIds)
 	}
 
 	if len(pixivDl.ArtworkIds) > 0 {
 		artworkSlice, ugoiraSlice := pixivweb.GetMultipleArtworkDetails(
 			pixivDl.ArtworkIds,
-			utils.DOWNLOAD_PATH,
+			artworkDlPath,
 			pixivDlOptions,
 		)
 		artworksToDl = append(artworksToDl, artworkSlice...)
@@ -68,20 +128,29 @@ func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWeb
 		)
 		progress.Start()
 		hasErr := false
+		whitelistFilteredCount := 0
+		excludedTagsFilteredCount := 0
+		tagDlPath := getSourceDlPath(pixivDl.TagDlPath, pixivDlOptions.Configs.DownloadPath)
 		for idx, tagName := range pixivDl.TagNames {
+			progress.SetDetail("tag " + tagName)
 			var artworksSlice []*request.ToDownload
 			var ugoiraSlice []*models.Ugoira
-			artworksSlice, ugoiraSlice, hasErr = pixivweb.TagSearch(
+			var filteredCount, excludedCount int
+			artworksSlice, ugoiraSlice, filteredCount, excludedCount, hasErr = pixivweb.TagSearch(
 				tagName,
-				utils.DOWNLOAD_PATH,
+				tagDlPath,
 				pixivDl.TagNamesPageNums[idx],
 				pixivDlOptions,
 			)
+			whitelistFilteredCount += filteredCount
+			excludedTagsFilteredCount += excludedCount
 			artworksToDl = append(artworksToDl, artworksSlice...)
 			ugoiraToDl = append(ugoiraToDl, ugoiraSlice...)
 			progress.MsgIncrement(baseMsg)
 		}
 		progress.Stop(hasErr)
+		pixivcommon.PrintWhitelistFilteredCount(pixivDlOptions.IllustratorWhitelist, whitelistFilteredCount)
+		pixivcommon.PrintExcludedTagsFilteredCount(pixivDlOptions.ExcludeTags, excludedTagsFilteredCount)
 	}
 
 	if len(artworksToDl) > 0 {
@@ -92,6 +161,7 @@ func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWeb
 				Headers:        pixivcommon.GetPixivRequestHeaders(),
 				Cookies:        pixivDlOptions.SessionCookies,
 				UseHttp3:       false,
+				RecordFailures: true,
 			},
 			pixivDlOptions.Configs,
 		)
@@ -108,29 +178,61 @@ func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWeb
 			request.CallRequest,
 		)
 	}
+	pixivcommon.MarkCompletedArtworks()
 
-	alertUser(artworksToDl, ugoiraToDl)
+	pixivcommon.PrintAiSkippedCount(pixivDlOptions.NoAi)
+	pixivcommon.PrintRatingSkippedCount(pixivDlOptions.RatingMode)
+	alertUser(artworksToDl, ugoiraToDl, 0)
 }
 
 // Start the download process for Pixiv
 func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.PixivMobileDlOptions, pixivUgoiraOptions *ugoira.UgoiraOptions) {
+	// verify the refresh token before fetching anything so that
+	// an invalid refresh token will not fail every request to the Pixiv API
+	if err := pixivDlOptions.MobileClient.VerifyRefreshToken(); err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(pixivmobile.GetRefreshTokenExitCode(err))
+	}
+
 	var ugoiraToDl []*models.Ugoira
 	var artworksToDl []*request.ToDownload
 	if len(pixivDl.IllustratorIds) > 0 {
+		illustratorDlPath := getSourceDlPath(pixivDl.IllustratorDlPath, pixivDlOptions.Configs.DownloadPath)
+		if pixivDl.DlIllustratorProfile {
+			artworksToDl = pixivweb.GetMultipleIllustratorProfileImages(
+				pixivDl.IllustratorIds,
+				illustratorDlPath,
+				nil,
+				pixivDlOptions.Configs.UserAgent,
+			)
+		}
 		artworkSlice, ugoiraSlice := pixivDlOptions.MobileClient.GetMultipleIllustratorPosts(
 			pixivDl.IllustratorIds,
 			pixivDl.IllustratorPageNums,
-			utils.DOWNLOAD_PATH,
+			illustratorDlPath,
 			pixivDlOptions.ArtworkType,
 		)
-		artworksToDl = artworkSlice
+		artworksToDl = append(artworksToDl, artworkSlice...)
 		ugoiraToDl = ugoiraSlice
 	}
 
+	if pixivDl.HasBookmarks() {
+		// the bookmarked artworks are downloaded to the same path as the artwork IDs
+		artworkSlice, ugoiraSlice := pixivDlOptions.MobileClient.GetUserBookmarks(
+			pixivDl.BookmarksUserId,
+			pixivDl.BookmarksPageNum,
+			pixivDl.PrivateBookmarks,
+			getSourceDlPath(pixivDl.ArtworkDlPath, pixivDlOptions.Configs.DownloadPath),
+			pixivDlOptions.ArtworkType,
+		)
+		artworksToDl = append(artworksToDl, artworkSlice...)
+		ugoiraToDl = append(ugoiraToDl, ugoiraSlice...)
+	}
+
 	if len(pixivDl.ArtworkIds) > 0 {
 		artworkSlice, ugoiraSlice := pixivDlOptions.MobileClient.GetMultipleArtworkDetails(
 			pixivDl.ArtworkIds,
-			utils.DOWNLOAD_PATH,
+			getSourceDlPath(pixivDl.ArtworkDlPath, pixivDlOptions.Configs.DownloadPath),
 		)
 		artworksToDl = append(artworksToDl, artworkSlice...)
 		ugoiraToDl = append(ugoiraToDl, ugoiraSlice...)
@@ -158,20 +260,29 @@ func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.Pi
 		)
 		progress.Start()
 		hasErr := false
+		whitelistFilteredCount := 0
+		excludedTagsFilteredCount := 0
+		tagDlPath := getSourceDlPath(pixivDl.TagDlPath, pixivDlOptions.Configs.DownloadPath)
 		for idx, tagName := range pixivDl.TagNames {
+			progress.SetDetail("tag " + tagName)
 			var artworksSlice []*request.ToDownload
 			var ugoiraSlice []*models.Ugoira
-			artworksSlice, ugoiraSlice, hasErr = pixivDlOptions.MobileClient.TagSearch(
+			var filteredCount, excludedCount int
+			artworksSlice, ugoiraSlice, filteredCount, excludedCount, hasErr = pixivDlOptions.MobileClient.TagSearch(
 				tagName,
-				utils.DOWNLOAD_PATH,
+				tagDlPath,
 				pixivDl.TagNamesPageNums[idx],
 				pixivDlOptions,
 			)
+			whitelistFilteredCount += filteredCount
+			excludedTagsFilteredCount += excludedCount
 			artworksToDl = append(artworksToDl, artworksSlice...)
 			ugoiraToDl = append(ugoiraToDl, ugoiraSlice...)
 			progress.MsgIncrement(baseMsg)
 		}
 		progress.Stop(hasErr)
+		pixivcommon.PrintWhitelistFilteredCount(pixivDlOptions.IllustratorWhitelist, whitelistFilteredCount)
+		pixivcommon.PrintExcludedTagsFilteredCount(pixivDlOptions.ExcludeTags, excludedTagsFilteredCount)
 	}
 
 	if len(artworksToDl) > 0 {
@@ -181,6 +292,7 @@ func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.Pi
 				MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
 				Headers:        pixivcommon.GetPixivRequestHeaders(),
 				UseHttp3:       false,
+				RecordFailures: true,
 			},
 			pixivDlOptions.Configs,
 		)
@@ -197,6 +309,28 @@ func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.Pi
 			pixivDlOptions.MobileClient.SendRequest,
 		)
 	}
+	pixivcommon.MarkCompletedArtworks()
+
+	novelCount := 0
+	if pixivDl.HasNovels() {
+		novelIds := pixivDl.NovelIds
+		if len(pixivDl.NovelistIds) > 0 || len(pixivDl.NovelSeriesIds) > 0 {
+			novelIds = append(novelIds, pixivDlOptions.MobileClient.GetNovelIds(
+				pixivDl.NovelistIds,
+				pixivDl.NovelistPageNums,
+				pixivDl.NovelSeriesIds,
+			)...)
+			novelIds = utils.RemoveSliceDuplicates(novelIds)
+		}
+		if len(novelIds) > 0 {
+			novelCount = pixivDlOptions.MobileClient.GetMultipleNovelDetails(
+				novelIds,
+				pixivDlOptions.Configs.DownloadPath,
+			)
+		}
+	}
 
-	alertUser(artworksToDl, ugoiraToDl)
+	pixivcommon.PrintAiSkippedCount(pixivDlOptions.NoAi)
+	pixivcommon.PrintRatingSkippedCount(pixivDlOptions.RatingMode)
+	alertUser(artworksToDl, ugoiraToDl, novelCount)
 }
@@ -21,13 +21,19 @@ type ffmpegOptions struct {
 	outputPath          string
 }
 
-func writeDelays(ugoiraInfo *models.Ugoira, imagesFolderPath string) (string, []string, error) {
+// Returns the filenames of the ugoira frames from MapDelaysToFilename() in the order they are played
+func getSortedFrameFilenames(frames map[string]int64) []string {
 	// sort the ugoira frames by their filename which are %6d.imageExt
-	sortedFilenames := make([]string, 0, len(ugoiraInfo.Frames))
-	for fileName := range ugoiraInfo.Frames {
+	sortedFilenames := make([]string, 0, len(frames))
+	for fileName := range frames {
 		sortedFilenames = append(sortedFilenames, fileName)
 	}
 	sort.Strings(sortedFilenames)
+	return sortedFilenames
+}
+
+func writeDelays(ugoiraInfo *models.Ugoira, imagesFolderPath string) (string, []string, error) {
+	sortedFilenames := getSortedFrameFilenames(ugoiraInfo.Frames)
 
 	// write the frames' variable delays to a text file
 	baseFmtStr := "file '%s'\nduration %f\n"
@@ -0,0 +1,92 @@
+package ugoira
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// GIF delays are in hundredths of a second and most viewers
+// will play frames with a delay below 2 (20ms) at a much slower speed
+const MIN_GIF_DELAY = 2
+
+// Decodes the ugoira frame and converts it to a paletted image for the GIF
+func readGifFrame(framePath string) (*image.Paletted, error) {
+	f, err := os.Open(framePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	palettedImg := image.NewPaletted(bounds, palette.Plan9)
+	draw.FloydSteinberg.Draw(palettedImg, bounds, img, bounds.Min)
+	return palettedImg, nil
+}
+
+// Encodes the ugoira frames into a looping GIF without FFmpeg.
+//
+// The frames are quantised to a fixed palette so the quality
+// will be lower than the GIFs converted by FFmpeg.
+func encodeGif(ugoiraInfo *models.Ugoira, imagesFolderPath, outputPath string) error {
+	sortedFilenames := getSortedFrameFilenames(ugoiraInfo.Frames)
+	anim := &gif.GIF{
+		Image:     make([]*image.Paletted, 0, len(sortedFilenames)),
+		Delay:     make([]int, 0, len(sortedFilenames)),
+		LoopCount: 0, // loop forever
+	}
+	for _, frameName := range sortedFilenames {
+		frame, err := readGifFrame(filepath.Join(imagesFolderPath, frameName))
+		if err != nil {
+			return fmt.Errorf(
+				"pixiv error %d: failed to read ugoira frame %s for %s, more info => %v",
+				utils.OS_ERROR,
+				frameName,
+				outputPath,
+				err,
+			)
+		}
+
+		delay := int(ugoiraInfo.Frames[frameName] / 10)
+		if delay < MIN_GIF_DELAY {
+			delay = MIN_GIF_DELAY
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to create %s, more info => %v",
+			utils.OS_ERROR,
+			outputPath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	if err := gif.EncodeAll(f, anim); err != nil {
+		return fmt.Errorf(
+			"pixiv error %d: failed to convert ugoira to %s, more info => %v",
+			utils.OS_ERROR,
+			outputPath,
+			err,
+		)
+	}
+	return nil
+}
@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
-	"os/signal"
+	"path"
 	"path/filepath"
-	"syscall"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
@@ -16,6 +16,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
 )
 
 // Map the Ugoira frame delays to their respective filenames
@@ -27,16 +28,34 @@ func MapDelaysToFilename(ugoiraFramesJson models.UgoiraFramesJson) map[string]in
 	return frameInfoMap
 }
 
+// Returns the first URL that points directly to an animated file (e.g. .webp, .mp4)
+// instead of a zip file of the frames which has to be converted with FFmpeg.
+//
+// An empty string is returned if Pixiv did not provide any direct animated URL.
+func GetDirectUgoiraUrl(ugoiraUrls ...string) string {
+	for _, ugoiraUrl := range ugoiraUrls {
+		parsedUrl, err := url.Parse(ugoiraUrl)
+		if ugoiraUrl == "" || err != nil {
+			continue
+		}
+		if utils.ExtInSlice(UGOIRA_ACCEPTED_EXT, path.Ext(parsedUrl.Path)) {
+			return ugoiraUrl
+		}
+	}
+	return ""
+}
+
 type UgoiraFfmpegArgs struct {
-	ffmpegPath    string
+	ffmpegPath    string // empty if FFmpeg is not available
 	outputPath    string
 	ugoiraQuality int
 }
 
 // Converts the Ugoira to the desired output path using FFmpeg
+// or with the pure-Go GIF encoder if FFmpeg is not available and the output is a GIF.
 func ConvertUgoira(ugoiraInfo *models.Ugoira, imagesFolderPath string, ugoiraFfmpeg *UgoiraFfmpegArgs) error {
 	outputExt := filepath.Ext(ugoiraFfmpeg.outputPath)
-	if !utils.SliceContains(UGOIRA_ACCEPTED_EXT, outputExt) {
+	if !utils.ExtInSlice(UGOIRA_ACCEPTED_EXT, outputExt) {
 		return fmt.Errorf(
 			"pixiv error %d: Output extension %v is not allowed for ugoira conversion",
 			utils.INPUT_ERROR,
@@ -44,6 +63,22 @@ func ConvertUgoira(ugoiraInfo *models.Ugoira, imagesFolderPath string, ugoiraFfm
 		)
 	}
 
+	if ugoiraFfmpeg.ffmpegPath == "" {
+		if outputExt != ".gif" {
+			return fmt.Errorf(
+				"pixiv error %d: FFmpeg is needed to convert ugoira to %s",
+				utils.CMD_ERROR,
+				ugoiraFfmpeg.outputPath,
+			)
+		}
+		if err := encodeGif(ugoiraInfo, imagesFolderPath, ugoiraFfmpeg.outputPath); err != nil {
+			os.Remove(ugoiraFfmpeg.outputPath)
+			return err
+		}
+		os.RemoveAll(imagesFolderPath)
+		return nil
+	}
+
 	concatDelayFilePath, sortedFilenames, err := writeDelays(ugoiraInfo, imagesFolderPath)
 	if err != nil {
 		return err
@@ -93,18 +128,20 @@ func GetUgoiraFilePaths(ugoireFilePath, ugoiraUrl, outputFormat string) (string,
 }
 
 func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions, config *configs.Config) {
-	// Create a context that can be cancelled when SIGINT/SIGTERM signal is received
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if !ugoiraOptions.ShouldConvert() {
+		return
+	}
+
+	ffmpegPath := config.FfmpegPath
+	if !config.HasFfmpeg() {
+		// only reachable for GIFs as ValidateFfmpeg() would have exited for the other formats
+		ffmpegPath = ""
+		color.Yellow("FFmpeg could not be found, the ugoira will be converted to GIFs without FFmpeg instead.")
+	}
 
-	// Catch SIGINT/SIGTERM signal and cancel the context when received
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigs
-		cancel()
-	}()
-	defer signal.Stop(sigs)
+	// Create a context that will be cancelled when SIGINT/SIGTERM signal is received
+	ctx, cancel := context.WithCancel(utils.GetInterruptCtx())
+	defer cancel()
 
 	var errSlice []error
 	downloadInfoLen := len(ugoiraArgs.ToDownload)
@@ -162,6 +199,7 @@ func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions,
 				zipFilePath,
 				err,
 			)
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			progress.MsgIncrement(baseMsg)
 			continue
@@ -171,12 +209,13 @@ func convertMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions,
 			ugoira,
 			unzipFolderPath,
 			&UgoiraFfmpegArgs{
-				ffmpegPath: config.FfmpegPath,
+				ffmpegPath: ffmpegPath,
 				outputPath: outputPath,
 				ugoiraQuality: ugoiraOptions.Quality,
 			},
 		)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 		} else if ugoiraOptions.DeleteZip {
 			os.Remove(zipFilePath)
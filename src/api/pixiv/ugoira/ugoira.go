@@ -2,11 +2,10 @@ package ugoira
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
 // UgoiraDlOptions is the struct that contains the
@@ -17,6 +16,9 @@ type UgoiraOptions struct {
 	OutputFormat string
 }
 
+// The output format to keep the downloaded ugoira zip file without converting it
+const UGOIRA_ZIP_FORMAT = ".zip"
+
 var UGOIRA_ACCEPTED_EXT = []string{
 	".gif",
 	".apng",
@@ -25,39 +27,45 @@ var UGOIRA_ACCEPTED_EXT = []string{
 	".mp4",
 }
 
+// The output formats that can be given to the "--ugoira_output_format" flag
+var UGOIRA_OUTPUT_FORMATS = append([]string{UGOIRA_ZIP_FORMAT}, UGOIRA_ACCEPTED_EXT...)
+
 // ValidateArgs validates the arguments of the ugoira process options.
 //
 // Should be called after initialising the struct.
 func (u *UgoiraOptions) ValidateArgs() {
-	u.OutputFormat = strings.ToLower(u.OutputFormat)
+	u.OutputFormat = strings.ToLower(strings.TrimSpace(u.OutputFormat))
+	if u.OutputFormat != "" && !strings.HasPrefix(u.OutputFormat, ".") {
+		// allow the output format to be given without the dot like "mp4"
+		u.OutputFormat = "." + u.OutputFormat
+	}
 
 	// u.Quality is only for .mp4 and .webm
 	if u.OutputFormat == ".mp4" && u.Quality < 0 || u.Quality > 51 {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"pixiv error %d: Ugoira quality of %d is not allowed",
 				utils.INPUT_ERROR,
 				u.Quality,
 			),
 		)
-		color.Red("Ugoira quality for FFmpeg must be between 0 and 51 for .mp4")
-		os.Exit(1)
+		utils.PrintError("Ugoira quality for FFmpeg must be between 0 and 51 for .mp4")
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	} else if u.OutputFormat == ".webm" && u.Quality < 0 || u.Quality > 63 {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"pixiv error %d: Ugoira quality of %d is not allowed",
 				utils.INPUT_ERROR,
 				u.Quality,
 			),
 		)
-		color.Red("Ugoira quality for FFmpeg must be between 0 and 63 for .webm")
-		os.Exit(1)
+		utils.PrintError("Ugoira quality for FFmpeg must be between 0 and 63 for .webm")
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 
-	u.OutputFormat = strings.ToLower(u.OutputFormat)
 	utils.ValidateStrArgs(
 		u.OutputFormat,
-		UGOIRA_ACCEPTED_EXT,
+		UGOIRA_OUTPUT_FORMATS,
 		[]string{
 			fmt.Sprintf(
 				"pixiv error %d: Output extension %q is not allowed for ugoira conversion",
@@ -67,3 +75,19 @@ func (u *UgoiraOptions) ValidateArgs() {
 		},
 	)
 }
+
+// Returns true if the downloaded ugoira zip files should be converted to the output format
+func (u *UgoiraOptions) ShouldConvert() bool {
+	return u.OutputFormat != UGOIRA_ZIP_FORMAT
+}
+
+// Validates that FFmpeg is available if it is needed to convert the ugoira to the output format.
+//
+// FFmpeg is not needed for ".zip" and is optional for ".gif"
+// as the frames can be encoded with the pure-Go GIF encoder instead.
+func (u *UgoiraOptions) ValidateFfmpeg(config *configs.Config) {
+	if u.OutputFormat == UGOIRA_ZIP_FORMAT || u.OutputFormat == ".gif" {
+		return
+	}
+	config.ValidateFfmpeg()
+}
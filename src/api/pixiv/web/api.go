@@ -1,10 +1,13 @@
 package pixivweb
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
@@ -87,9 +90,15 @@ func getArtworkUrlsToDlLogic(artworkType int64, artworkId string, reqArgs *reque
 	return artworkUrlsRes, nil
 }
 
+// Returned by getArtworkDetails() when the artwork has fewer bookmarks than the minimum
+var errBelowMinBookmarks = errors.New("the artwork has fewer bookmarks than the minimum")
+
 // Retrieves details of an artwork ID and returns
 // the folder path to download the artwork to, the JSON response, and the artwork type
-func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, *models.Ugoira, error) {
+//
+// If minBookmarks is more than 0, errBelowMinBookmarks is returned for artworks with fewer
+// bookmarks before requesting their URLs so that no requests are wasted on their ugoira metadata.
+func getArtworkDetails(artworkId, downloadPath string, minBookmarks int, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, *models.Ugoira, error) {
 	if artworkId == "" {
 		return nil, nil, nil
 	}
@@ -114,6 +123,20 @@ func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOpti
 	}
 
 	artworkJsonBody := artworkDetailsJsonRes.Body
+	if artworkJsonBody.BookmarkCount < minBookmarks {
+		return nil, nil, errBelowMinBookmarks
+	}
+	if pixivcommon.SkipAiGenerated(dlOptions.NoAi, artworkJsonBody.AiType) {
+		return nil, nil, nil
+	}
+	if utils.IsBeforeSince(artworkJsonBody.CreateDate) {
+		return nil, nil, nil
+	}
+	// the illustrator's posts and the artwork IDs are not filtered by Pixiv unlike the tag search results
+	if pixivcommon.SkipRating(dlOptions.RatingMode, artworkJsonBody.XRestrict) {
+		return nil, nil, nil
+	}
+
 	illustratorName := artworkJsonBody.UserName
 	artworkName := artworkJsonBody.Title
 	artworkPostDir := utils.GetPostFolder(
@@ -122,6 +145,12 @@ func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOpti
 		artworkId,
 		artworkName,
 	)
+	if chapterOrder, ok := dlOptions.seriesChapterOrders[artworkId]; ok {
+		artworkPostDir = filepath.Join(
+			filepath.Dir(artworkPostDir),
+			chapterOrder+" "+filepath.Base(artworkPostDir),
+		)
+	}
 
 	artworkType := artworkJsonBody.IllustType
 	artworkUrlsRes, err := getArtworkUrlsToDlLogic(artworkType, artworkId, reqArgs)
@@ -132,22 +161,91 @@ func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOpti
 	urlsToDl, ugoiraInfo, err := processArtworkJson(
 		artworkUrlsRes,
 		artworkType,
+		artworkId,
 		artworkPostDir,
+		dlOptions,
 	)
 	if err != nil {
 		return nil, nil, err
 	}
-	return urlsToDl, ugoiraInfo, nil
+	pixivcommon.RecordArtworkPageCount(artworkPostDir, artworkJsonBody.PageCount)
+
+	metadata := &utils.FileMetadata{
+		PostUrl: pixivcommon.GetIllustUrl(artworkId),
+		Creator: illustratorName,
+	}
+	for _, tag := range artworkJsonBody.Tags.Tags {
+		metadata.Tags = append(metadata.Tags, pixivcommon.GetTagName(tag.Tag, tag.Translation.En, dlOptions.TranslateTags))
+	}
+	if dlOptions.Configs.WriteMetadata {
+		artworkMetadata := &pixivcommon.ArtworkMetadata{
+			PostMetadata: utils.PostMetadata{
+				Id:       artworkId,
+				Title:    artworkName,
+				Creator:  illustratorName,
+				Tags:     metadata.Tags,
+				PostDate: artworkJsonBody.CreateDate,
+				Url:      metadata.PostUrl,
+			},
+			UserId:        artworkJsonBody.UserId,
+			Description:   artworkJsonBody.Description,
+			PageCount:     artworkJsonBody.PageCount,
+			BookmarkCount: artworkJsonBody.BookmarkCount,
+		}
+		err := pixivcommon.WriteArtworkMetadata(artworkPostDir, artworkMetadata, dlOptions.Configs.OverwriteFiles)
+		if err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
+	}
+	utils.RUN_STATS.AddPostsProcessed(1)
+	urlsToDl = request.SampleToDownload(urlsToDl, dlOptions.Configs.SampleSize)
+	urlsToDl = request.SetReferer(urlsToDl, metadata.PostUrl)
+	return request.SetMetadata(urlsToDl, metadata), ugoiraInfo, nil
 }
 
 // Retrieves multiple artwork details based on the given slice of artwork IDs
 // and returns a map to use for downloading and a slice of Ugoira structures
 func GetMultipleArtworkDetails(artworkIds []string, downloadPath string, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, []*models.Ugoira) {
+	artworkDetails, ugoiraDetails, _ := getMultipleArtworkDetails(artworkIds, downloadPath, 0, dlOptions)
+	return artworkDetails, ugoiraDetails
+}
+
+// The details of an artwork retrieved by a worker in getMultipleArtworkDetails()
+type artworkDetailsResult struct {
+	artworks []*request.ToDownload
+	ugoira   *models.Ugoira
+	err      error
+}
+
+// Same as GetMultipleArtworkDetails() but drops the artworks with fewer bookmarks than minBookmarks
+// and also returns the number of artworks that were dropped.
+//
+// The details are retrieved concurrently by ARTWORK_DETAILS_WORKERS workers
+// and the results are returned in the same order as the given artwork IDs.
+//
+// Unless "--overwrite" is set, the artworks that were fully downloaded in a previous run are skipped.
+func getMultipleArtworkDetails(artworkIds []string, downloadPath string, minBookmarks int, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, []*models.Ugoira, int) {
+	if !dlOptions.Configs.OverwriteFiles {
+		var skippedCount int
+		artworkIds, skippedCount = pixivcommon.FilterDownloadedArtworkIds(
+			artworkIds,
+			filepath.Join(downloadPath, utils.PIXIV_TITLE),
+		)
+		pixivcommon.LogDownloadedArtworksSkippedCount(skippedCount)
+		if len(artworkIds) == 0 {
+			return nil, nil, 0
+		}
+	}
+
 	var errSlice []error
+	filteredCount := 0
 	var ugoiraDetails []*models.Ugoira
 	var artworkDetails []*request.ToDownload
 	artworkIdsLen := len(artworkIds)
-	lastArtworkId := artworkIds[artworkIdsLen-1]
+	workers := ARTWORK_DETAILS_WORKERS
+	if artworkIdsLen < workers {
+		workers = artworkIdsLen
+	}
 
 	baseMsg := "Getting and processing artwork details from Pixiv [%d/" + fmt.Sprintf("%d]...", artworkIdsLen)
 	progress := spinner.New(
@@ -168,27 +266,62 @@ func GetMultipleArtworkDetails(artworkIds []string, downloadPath string, dlOptio
 		artworkIdsLen,
 	)
 	progress.Start()
-	for _, artworkId := range artworkIds {
-		artworksToDl, ugoiraInfo, err := getArtworkDetails(
-			artworkId,
-			downloadPath,
-			dlOptions,
-		)
-		if err != nil {
-			errSlice = append(errSlice, err)
-			progress.MsgIncrement(baseMsg)
+
+	// stored by the artwork's index so that the artworks
+	// will be downloaded in the same order as the artwork IDs
+	results := make([]*artworkDetailsResult, artworkIdsLen)
+	idxChan := make(chan int, artworkIdsLen)
+	for idx := range artworkIds {
+		idxChan <- idx
+	}
+	close(idxChan)
+
+	// the workers share the limiter so that the artworks are requested
+	// at the same rate as getting their details one after another
+	limiter := newRequestLimiter()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range idxChan {
+				artworkId := artworkIds[idx]
+				limiter.wait()
+				progress.SetDetail("artwork " + artworkId)
+				artworksToDl, ugoiraInfo, err := getArtworkDetails(
+					artworkId,
+					downloadPath,
+					minBookmarks,
+					dlOptions,
+				)
+				if err != nil && !errors.Is(err, errBelowMinBookmarks) {
+					utils.RecordFailure(err)
+				}
+				results[idx] = &artworkDetailsResult{
+					artworks: artworksToDl,
+					ugoira:   ugoiraInfo,
+					err:      err,
+				}
+				progress.MsgIncrement(baseMsg)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if errors.Is(result.err, errBelowMinBookmarks) {
+			filteredCount++
 			continue
 		}
-
-		if ugoiraInfo != nil {
-			ugoiraDetails = append(ugoiraDetails, ugoiraInfo)
-		} else {
-			artworkDetails = append(artworkDetails, artworksToDl...)
+		if result.err != nil {
+			errSlice = append(errSlice, result.err)
+			continue
 		}
 
-		progress.MsgIncrement(baseMsg)
-		if artworkId != lastArtworkId {
-			pixivSleep()
+		if result.ugoira != nil {
+			ugoiraDetails = append(ugoiraDetails, result.ugoira)
+		} else {
+			artworkDetails = append(artworkDetails, result.artworks...)
 		}
 	}
 
@@ -199,7 +332,7 @@ func GetMultipleArtworkDetails(artworkIds []string, downloadPath string, dlOptio
 	}
 	progress.Stop(hasErr)
 
-	return artworkDetails, ugoiraDetails
+	return artworkDetails, ugoiraDetails, filteredCount
 }
 
 // Query Pixiv's API for all the illustrator's posts
@@ -273,12 +406,14 @@ func GetMultipleIllustratorPosts(illustratorIds, pageNums []string, downloadPath
 	)
 	progress.Start()
 	for idx, illustratorId := range illustratorIds {
+		progress.SetDetail("illustrator " + illustratorId)
 		artworkIds, err := getIllustratorPosts(
 			illustratorId,
 			pageNums[idx],
 			dlOptions,
 		)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 		} else {
 			artworkIdsSlice = append(artworkIdsSlice, artworkIds...)
@@ -300,17 +435,541 @@ func GetMultipleIllustratorPosts(illustratorIds, pageNums []string, downloadPath
 	return artworkIdsSlice
 }
 
+// The maximum number of bookmarks that Pixiv returns per request
+const BOOKMARKS_PER_PAGE = 48
+
+// Returns true if the artwork from the bookmarks or the rankings matches the "--artwork_type" flag
+func isArtworkTypeAllowed(illustType int64, artworkType string) bool {
+	switch artworkType {
+	case "illust_and_ugoira":
+		return illustType == ILLUST || illustType == UGOIRA
+	case "manga":
+		return illustType == MANGA
+	default:
+		return true
+	}
+}
+
+// Query Pixiv's API for the IDs of the artworks bookmarked by the user
+// within the given page numbers (BOOKMARKS_PER_PAGE bookmarks per page).
+//
+// If privateBookmarks is true, the private bookmarks will be retrieved instead
+// which requires the session cookie to belong to the user.
+func getBookmarkedArtworkIds(userId, pageNum string, privateBookmarks bool, dlOptions *PixivWebDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := "show"
+	if privateBookmarks {
+		rest = "hide"
+	}
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = fmt.Sprintf("%s/users/%s/bookmarks/artworks", utils.PIXIV_URL, userId)
+	url := fmt.Sprintf("%s/user/%s/illusts/bookmarks", utils.PIXIV_API_URL, userId)
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+
+	var artworkIds []string
+	maskedCount := 0
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil
+		}
+		if page > minPage {
+			pixivSleep()
+		}
+
+		res, err := request.CallRequest(
+			&request.RequestArgs{
+				Url:     url,
+				Method:  "GET",
+				Cookies: dlOptions.SessionCookies,
+				Headers: headers,
+				Params: map[string]string{
+					"tag":    "",
+					"offset": strconv.Itoa((page - 1) * BOOKMARKS_PER_PAGE),
+					"limit":  strconv.Itoa(BOOKMARKS_PER_PAGE),
+					"rest":   rest,
+				},
+				UserAgent: dlOptions.Configs.UserAgent,
+				Http2:     !useHttp3,
+				Http3:     useHttp3,
+			},
+		)
+		if err != nil {
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get the bookmarks of the user with an ID of %s due to %v",
+				utils.CONNECTION_ERROR,
+				userId,
+				err,
+			)
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get the bookmarks of the user with an ID of %s due to %s response",
+				utils.RESPONSE_ERROR,
+				userId,
+				res.Status,
+			)
+		}
+
+		var bookmarksJson models.PixivWebBookmarksJson
+		if err := utils.LoadJsonFromResponse(res, &bookmarksJson); err != nil {
+			return artworkIds, err
+		}
+
+		works := bookmarksJson.Body.Works
+		for _, work := range works {
+			if work.IsMasked {
+				// deleted or private artworks that can no longer be viewed
+				maskedCount++
+				continue
+			}
+			if isArtworkTypeAllowed(work.IllustType, dlOptions.ArtworkType) {
+				artworkIds = append(artworkIds, work.Id.String())
+			}
+		}
+		if len(works) < BOOKMARKS_PER_PAGE || page*BOOKMARKS_PER_PAGE >= bookmarksJson.Body.Total {
+			break
+		}
+	}
+
+	if maskedCount > 0 {
+		utils.LogError(
+			nil,
+			fmt.Sprintf(
+				"Skipped %d bookmark(s) of the user with an ID of %s as the artworks were deleted or made private.",
+				maskedCount,
+				userId,
+			),
+			false,
+			utils.INFO,
+		)
+	}
+	return artworkIds, nil
+}
+
+// Get the artwork IDs bookmarked by the user and returns a slice of artwork IDs
+func GetBookmarkedArtworks(userId, pageNum string, privateBookmarks bool, dlOptions *PixivWebDlOptions) []string {
+	bookmarkType := "public"
+	if privateBookmarks {
+		bookmarkType = "private"
+	}
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			"Getting the %s bookmarks of the user with an ID of %s on Pixiv...",
+			bookmarkType,
+			userId,
+		),
+		fmt.Sprintf(
+			"Finished getting the %s bookmarks of the user with an ID of %s on Pixiv!",
+			bookmarkType,
+			userId,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting the %s bookmarks of the user with an ID of %s on Pixiv!\nPlease refer to the logs for more details.",
+			bookmarkType,
+			userId,
+		),
+		0,
+	)
+	progress.Start()
+	artworkIds, err := getBookmarkedArtworkIds(userId, pageNum, privateBookmarks, dlOptions)
+	if err != nil {
+		utils.RecordFailure(err)
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	progress.Stop(err != nil)
+	return artworkIds
+}
+
+// Query Pixiv's API for the chapters of the manga series and returns the chapters sorted by their order
+func getSeriesChapters(seriesId string, dlOptions *PixivWebDlOptions) ([]*seriesChapter, error) {
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = fmt.Sprintf("%s/series/%s", utils.PIXIV_URL, seriesId)
+	url := fmt.Sprintf("%s/series/%s", utils.PIXIV_API_URL, seriesId)
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+
+	var chapters []*seriesChapter
+	for page := 1; ; page++ {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil
+		}
+		if page > 1 {
+			pixivSleep()
+		}
+
+		res, err := request.CallRequest(
+			&request.RequestArgs{
+				Url:       url,
+				Method:    "GET",
+				Cookies:   dlOptions.SessionCookies,
+				Headers:   headers,
+				Params:    map[string]string{"p": strconv.Itoa(page)},
+				UserAgent: dlOptions.Configs.UserAgent,
+				Http2:     !useHttp3,
+				Http3:     useHttp3,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"pixiv error %d: failed to get the chapters of the series with an ID of %s due to %v",
+				utils.CONNECTION_ERROR,
+				seriesId,
+				err,
+			)
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return nil, fmt.Errorf(
+				"pixiv error %d: failed to get the chapters of the series with an ID of %s due to %s response",
+				utils.RESPONSE_ERROR,
+				seriesId,
+				res.Status,
+			)
+		}
+
+		var seriesJson models.PixivWebSeriesJson
+		if err := utils.LoadJsonFromResponse(res, &seriesJson); err != nil {
+			return nil, err
+		}
+
+		seriesPage := seriesJson.Body.Page
+		for _, work := range seriesPage.Series {
+			chapters = append(chapters, &seriesChapter{artworkId: work.WorkId, order: work.Order})
+		}
+		if len(seriesPage.Series) == 0 || len(chapters) >= seriesPage.Total {
+			break
+		}
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].order < chapters[j].order
+	})
+	return chapters, nil
+}
+
+// Get the artwork IDs of the chapters of the manga series in their reading order.
+//
+// The chapter order of each artwork is also recorded in the download options
+// so that it will be prefixed to the artwork's folder name when getting the artwork details.
+func GetMultipleSeriesArtworks(seriesIds []string, dlOptions *PixivWebDlOptions) []string {
+	seriesIdsLen := len(seriesIds)
+	var errSlice []error
+	var artworkIds []string
+	baseMsg := "Getting the chapters of the manga series on Pixiv [%d/" + fmt.Sprintf("%d]...", seriesIdsLen)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting the chapters of %d manga series on Pixiv!",
+			seriesIdsLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting the chapters of %d manga series on Pixiv!\nPlease refer to the logs for more details.",
+			seriesIdsLen,
+		),
+		seriesIdsLen,
+	)
+	progress.Start()
+	if dlOptions.seriesChapterOrders == nil {
+		dlOptions.seriesChapterOrders = make(map[string]string)
+	}
+	for idx, seriesId := range seriesIds {
+		progress.SetDetail("series " + seriesId)
+		chapters, err := getSeriesChapters(seriesId, dlOptions)
+		if err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+			progress.MsgIncrement(baseMsg)
+			continue
+		}
+
+		// pad the chapter orders to the same width so that the folders are sorted correctly
+		orderWidth := 3
+		if len(chapters) > 0 {
+			orderWidth = max(orderWidth, len(strconv.Itoa(chapters[len(chapters)-1].order)))
+		}
+		for _, chapter := range chapters {
+			dlOptions.seriesChapterOrders[chapter.artworkId] = fmt.Sprintf("%0*d", orderWidth, chapter.order)
+			artworkIds = append(artworkIds, chapter.artworkId)
+		}
+		if idx != seriesIdsLen-1 {
+			pixivSleep()
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return artworkIds
+}
+
+// The number of artworks on each page of the rankings
+const RANKING_PER_PAGE = 50
+
+// Query Pixiv's ranking endpoint for the IDs of the ranked artworks within the given page numbers.
+//
+// If rankingDate is empty, the latest rankings of the mode will be retrieved.
+func getRankingArtworkIds(mode, rankingDate, pageNum string, dlOptions *PixivWebDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		"mode":   mode,
+		"format": "json",
+	}
+	if rankingDate != "" {
+		params["date"] = rankingDate
+	}
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = fmt.Sprintf("%s/ranking.php?mode=%s", utils.PIXIV_URL, mode)
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+
+	var artworkIds []string
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil
+		}
+		if page > minPage {
+			pixivSleep()
+		}
+
+		params["p"] = strconv.Itoa(page)
+		res, err := request.CallRequest(
+			&request.RequestArgs{
+				Url:       utils.PIXIV_URL + "/ranking.php",
+				Method:    "GET",
+				Cookies:   dlOptions.SessionCookies,
+				Headers:   headers,
+				Params:    params,
+				UserAgent: dlOptions.Configs.UserAgent,
+				Http2:     !useHttp3,
+				Http3:     useHttp3,
+			},
+		)
+		if err != nil {
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get page %d of the %s rankings due to %v",
+				utils.CONNECTION_ERROR,
+				page,
+				mode,
+				err,
+			)
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			if page > minPage && (res.StatusCode == 400 || res.StatusCode == 404) {
+				// Pixiv responds with an error for the pages after the last page
+				break
+			}
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get page %d of the %s rankings due to %s response",
+				utils.RESPONSE_ERROR,
+				page,
+				mode,
+				res.Status,
+			)
+		}
+
+		var rankingJson models.PixivWebRankingJson
+		if err := utils.LoadJsonFromResponse(res, &rankingJson); err != nil {
+			return artworkIds, err
+		}
+
+		for _, content := range rankingJson.Contents {
+			illustType, err := strconv.ParseInt(content.IllustType, 10, 64)
+			if err != nil || isArtworkTypeAllowed(illustType, dlOptions.ArtworkType) {
+				artworkIds = append(artworkIds, strconv.Itoa(content.IllustId))
+			}
+		}
+		if len(rankingJson.Contents) == 0 || !rankingJson.HasNextPage() {
+			break
+		}
+	}
+	return artworkIds, nil
+}
+
+// Get the artwork IDs from the Pixiv rankings and returns a slice of artwork IDs
+func GetRankingArtworks(mode, rankingDate, pageNum string, dlOptions *PixivWebDlOptions) []string {
+	rankingStr := mode
+	if rankingDate != "" {
+		rankingStr += " (" + rankingDate + ")"
+	}
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			"Getting the %s rankings on Pixiv...",
+			rankingStr,
+		),
+		fmt.Sprintf(
+			"Finished getting the %s rankings on Pixiv!",
+			rankingStr,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting the %s rankings on Pixiv!\nPlease refer to the logs for more details.",
+			rankingStr,
+		),
+		0,
+	)
+	progress.Start()
+	artworkIds, err := getRankingArtworkIds(mode, rankingDate, pageNum, dlOptions)
+	if err != nil {
+		utils.RecordFailure(err)
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	progress.Stop(err != nil)
+	return artworkIds
+}
+
+// Query Pixiv's API for the IDs of the latest artworks from the illustrators
+// followed by the logged in user within the given page numbers.
+//
+// The "r18" rating mode will only retrieve the R-18 artworks while the "safe" rating mode
+// will drop the R-18 artworks from the results as Pixiv does not have a mode for it.
+func getFollowingArtworkIds(pageNum string, dlOptions *PixivWebDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := "all"
+	if dlOptions.RatingMode == "r18" {
+		mode = "r18"
+	}
+	params := map[string]string{
+		"mode": mode,
+	}
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = utils.PIXIV_URL + "/bookmark_new_illust.php"
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+
+	var artworkIds []string
+	for page := minPage; !hasMax || page <= maxPage; page++ {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, nil
+		}
+		if page > minPage {
+			pixivSleep()
+		}
+
+		params["p"] = strconv.Itoa(page)
+		res, err := request.CallRequest(
+			&request.RequestArgs{
+				Url:       utils.PIXIV_API_URL + "/follow_latest/illust",
+				Method:    "GET",
+				Cookies:   dlOptions.SessionCookies,
+				Headers:   headers,
+				Params:    params,
+				UserAgent: dlOptions.Configs.UserAgent,
+				Http2:     !useHttp3,
+				Http3:     useHttp3,
+			},
+		)
+		if err != nil {
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get page %d of the latest artworks from your followed illustrators due to %v",
+				utils.CONNECTION_ERROR,
+				page,
+				err,
+			)
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return artworkIds, fmt.Errorf(
+				"pixiv error %d: failed to get page %d of the latest artworks from your followed illustrators due to %s response",
+				utils.RESPONSE_ERROR,
+				page,
+				res.Status,
+			)
+		}
+
+		var followingJson models.PixivWebFollowLatestJson
+		if err := utils.LoadJsonFromResponse(res, &followingJson); err != nil {
+			return artworkIds, err
+		}
+
+		ids := followingJson.Body.Page.Ids
+		if len(ids) == 0 {
+			// no more artworks after the last page
+			break
+		}
+
+		// the thumbnails may not contain every artwork ID on the page,
+		// in which case the artwork will be kept
+		droppedIds := make(map[string]struct{})
+		for _, illust := range followingJson.Body.Thumbnails.Illust {
+			isR18 := illust.XRestrict > 0
+			if !isArtworkTypeAllowed(illust.IllustType, dlOptions.ArtworkType) || (dlOptions.RatingMode == "safe" && isR18) {
+				droppedIds[illust.Id] = struct{}{}
+			}
+		}
+		for _, id := range ids {
+			artworkId := strconv.Itoa(id)
+			if _, dropped := droppedIds[artworkId]; !dropped {
+				artworkIds = append(artworkIds, artworkId)
+			}
+		}
+	}
+	return artworkIds, nil
+}
+
+// Get the latest artworks from the illustrators followed by the logged in user and returns a slice of artwork IDs
+func GetFollowingArtworks(pageNum string, dlOptions *PixivWebDlOptions) []string {
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		"Getting the latest artworks from your followed illustrators on Pixiv...",
+		"Finished getting the latest artworks from your followed illustrators on Pixiv!",
+		"Something went wrong while getting the latest artworks from your followed illustrators on Pixiv!\nPlease refer to the logs for more details.",
+		0,
+	)
+	progress.Start()
+	artworkIds, err := getFollowingArtworkIds(pageNum, dlOptions)
+	if err != nil {
+		utils.RecordFailure(err)
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	progress.Stop(err != nil)
+	return artworkIds
+}
+
 type pageNumArgs struct {
 	minPage int
 	maxPage int
 	hasMax  bool
 }
 
-func tagSearchLogic(tagName string, reqArgs *request.RequestArgs, pageNumArgs *pageNumArgs) ([]string, []error) {
+func tagSearchLogic(tagName string, reqArgs *request.RequestArgs, pageNumArgs *pageNumArgs, dlOptions *PixivWebDlOptions, limit *pixivcommon.ArtworkLimit) ([]string, int, int, []error) {
 	var errSlice []error
 	var artworkIds []string
+	filteredCount := 0
+	excludedCount := 0
 	page := 0
 	for {
+		if utils.IsInterrupted() {
+			// discard the partial results as the run is stopping
+			return nil, 0, 0, nil
+		}
 		page++
 		if page < pageNumArgs.minPage {
 			continue
@@ -328,78 +987,132 @@ func tagSearchLogic(tagName string, reqArgs *request.RequestArgs, pageNumArgs *p
 				tagName,
 				err,
 			)
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			continue
 		}
 
-		tagArtworkIds, err := processTagJsonResults(res)
+		tagPage, err := processTagJsonResults(res, dlOptions)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 			continue
 		}
 
-		if len(tagArtworkIds) == 0 {
+		if tagPage.resultsCount == 0 {
 			break
 		}
+		filteredCount += tagPage.filteredCount
+		excludedCount += tagPage.excludedCount
 
-		artworkIds = append(artworkIds, tagArtworkIds...)
+		for _, artworkId := range tagPage.artworkIds {
+			if !limit.Add(artworkId) {
+				break
+			}
+			artworkIds = append(artworkIds, artworkId)
+		}
+		if limit.IsReached() {
+			break
+		}
 		if page != pageNumArgs.maxPage {
 			pixivSleep()
 		}
 	}
-	return artworkIds, errSlice
+	return artworkIds, filteredCount, excludedCount, errSlice
 }
 
 // Query Pixiv's API and search for posts based on the supplied tag name
 // which will return a map and a slice of Ugoira structures for downloads
-func TagSearch(tagName, downloadPath, pageNum string, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, []*models.Ugoira, bool) {
+//
+// Also returns the number of results that were filtered out as they are not from the whitelisted illustrators
+// and the number of results that were filtered out as they have one of the excluded tags.
+func TagSearch(tagName, downloadPath, pageNum string, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, []*models.Ugoira, int, int, bool) {
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
 		utils.LogError(err, "", false, utils.ERROR)
-		return nil, nil, true
+		return nil, nil, 0, 0, true
 	}
 
+	sortOrders := []string{dlOptions.SortOrder}
+	if dlOptions.MaxCoverage {
+		sortOrders = pixivcommon.GetMaxCoverageSortOrders(dlOptions.SortOrder, MAX_COVERAGE_SORT_ORDERS)
+	}
+
+	var artworkIds []string
+	var errSlice []error
+	filteredCount := 0
+	excludedCount := 0
 	url := fmt.Sprintf("%s/search/artworks/%s", utils.PIXIV_API_URL, tagName)
-	params := map[string]string{
-		// search term
-		"word": tagName,
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = fmt.Sprintf("%s/tags/%s/artworks", utils.PIXIV_URL, tagName)
+	limit := pixivcommon.NewArtworkLimit(dlOptions.MaxArtworks)
+	for idx, sortOrder := range sortOrders {
+		if limit.IsReached() {
+			break
+		}
+		if idx > 0 {
+			pixivSleep()
+		}
 
-		// search mode: s_tag, s_tag_full, s_tc
-		"s_mode": dlOptions.SearchMode,
+		params := map[string]string{
+			// search term with the excluded tags as "-tag" tokens
+			"word": pixivcommon.GetTagSearchWord(tagName, dlOptions.ExcludeTags),
 
-		// sort order: date, popular, popular_male, popular_female
-		// (add "_d" suffix for descending order, e.g. date_d)
-		"order": dlOptions.SortOrder,
+			// search mode: s_tag, s_tag_full, s_tc
+			"s_mode": dlOptions.SearchMode,
 
-		//  r18, safe, or all for both
-		"mode": dlOptions.RatingMode,
+			// sort order: date, popular, popular_male, popular_female
+			// (add "_d" suffix for descending order, e.g. date_d)
+			"order": sortOrder,
 
-		// illust_and_ugoira, manga, all
-		"type": dlOptions.ArtworkType,
-	}
+			//  r18, safe, or all for both
+			"mode": dlOptions.RatingMode,
 
-	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
-	headers := pixivcommon.GetPixivRequestHeaders()
-	headers["Referer"] = fmt.Sprintf("%s/tags/%s/artworks", utils.PIXIV_URL, tagName)
-	artworkIds, errSlice := tagSearchLogic(
-		tagName,
-		&request.RequestArgs{
-			Url:         url,
-			Method:      "GET",
-			Cookies:     dlOptions.SessionCookies,
-			Headers:     headers,
-			Params:      params,
-			CheckStatus: true,
-			UserAgent:   dlOptions.Configs.UserAgent,
-			Http2:       !useHttp3,
-			Http3:       useHttp3,
-		},
-		&pageNumArgs{
-			minPage: minPage,
-			maxPage: maxPage,
-			hasMax:  hasMax,
-		},
-	)
+			// illust_and_ugoira, manga, all
+			"type": dlOptions.ArtworkType,
+		}
+		// start and end dates of the search in the YYYY-MM-DD format
+		if dlOptions.SearchStartDate != "" {
+			params["scd"] = dlOptions.SearchStartDate
+		}
+		if dlOptions.SearchEndDate != "" {
+			params["ecd"] = dlOptions.SearchEndDate
+		}
+		sortOrderArtworkIds, sortOrderFilteredCount, sortOrderExcludedCount, sortOrderErrSlice := tagSearchLogic(
+			tagName,
+			&request.RequestArgs{
+				Url:         url,
+				Method:      "GET",
+				Cookies:     dlOptions.SessionCookies,
+				Headers:     headers,
+				Params:      params,
+				CheckStatus: true,
+				UserAgent:   dlOptions.Configs.UserAgent,
+				Http2:       !useHttp3,
+				Http3:       useHttp3,
+			},
+			&pageNumArgs{
+				minPage: minPage,
+				maxPage: maxPage,
+				hasMax:  hasMax,
+			},
+			dlOptions,
+			limit,
+		)
+		artworkIds = append(artworkIds, sortOrderArtworkIds...)
+		filteredCount += sortOrderFilteredCount
+		excludedCount += sortOrderExcludedCount
+		errSlice = append(errSlice, sortOrderErrSlice...)
+	}
+	if len(sortOrders) > 1 {
+		totalCount := len(artworkIds)
+		artworkIds = utils.RemoveSliceDuplicates(artworkIds)
+		pixivcommon.LogMaxCoverageResults(tagName, len(sortOrders), totalCount, len(artworkIds))
+	}
+	pixivcommon.LogWhitelistFilteredCount(tagName, filteredCount)
+	pixivcommon.LogExcludedTagsFilteredCount(tagName, excludedCount)
+	pixivcommon.LogMaxArtworksReached(tagName, limit)
 
 	hasErr := false
 	if len(errSlice) > 0 {
@@ -407,10 +1120,12 @@ func TagSearch(tagName, downloadPath, pageNum string, dlOptions *PixivWebDlOptio
 		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
 	}
 
-	artworkSlice, ugoiraSlice := GetMultipleArtworkDetails(
+	artworkSlice, ugoiraSlice, minBookmarksFilteredCount := getMultipleArtworkDetails(
 		artworkIds,
 		downloadPath,
+		dlOptions.MinBookmarks,
 		dlOptions,
 	)
-	return artworkSlice, ugoiraSlice, hasErr
+	pixivcommon.LogMinBookmarksFilteredCount(tagName, dlOptions.MinBookmarks, minBookmarksFilteredCount)
+	return artworkSlice, ugoiraSlice, filteredCount, excludedCount, hasErr
 }
@@ -0,0 +1,114 @@
+package pixivweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+)
+
+func TestMain(m *testing.M) {
+	testutils.RunWithTempAppPath(m)
+}
+
+// Serves the given number of tag search result pages with 2 artworks each followed by empty pages
+func newTagSearchServer(t *testing.T, resultPages int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		data := []map[string]any{}
+		if page <= resultPages {
+			for i := 1; i <= 2; i++ {
+				data = append(data, map[string]any{
+					"id":     fmt.Sprintf("%d%d", page, i),
+					"userId": "1",
+					"tags":   []string{},
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"body": map[string]any{
+				"illustManga": map[string]any{"data": data},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTagSearchLogicPagination(t *testing.T) {
+	prevMinSleep, prevMaxSleep := minSleep, maxSleep
+	minSleep, maxSleep = 2, 2
+	defer func() {
+		minSleep, maxSleep = prevMinSleep, prevMaxSleep
+	}()
+
+	tests := []struct {
+		name        string
+		pageNumArgs *pageNumArgs
+		maxArtworks int
+		wantIds     []string
+		wantSleeps  int
+	}{
+		{
+			name:        "all pages",
+			pageNumArgs: &pageNumArgs{minPage: 1},
+			wantIds:     []string{"11", "12", "21", "22", "31", "32"},
+			wantSleeps:  3, // after each page with results
+		},
+		{
+			name:        "page range",
+			pageNumArgs: &pageNumArgs{minPage: 2, maxPage: 3, hasMax: true},
+			wantIds:     []string{"21", "22", "31", "32"},
+			wantSleeps:  1, // not after the last page of the range
+		},
+		{
+			name:        "max artworks",
+			pageNumArgs: &pageNumArgs{minPage: 1},
+			maxArtworks: 3,
+			wantIds:     []string{"11", "12", "21"},
+			wantSleeps:  1, // stops before requesting the third page
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := testutils.RecordSleeps(t)
+
+			server := newTagSearchServer(t, 3)
+			artworkIds, _, _, errSlice := tagSearchLogic(
+				"test",
+				&request.RequestArgs{
+					Url:         server.URL,
+					Method:      "GET",
+					Params:      map[string]string{},
+					CheckStatus: true,
+					Http2:       true,
+				},
+				test.pageNumArgs,
+				&PixivWebDlOptions{},
+				pixivcommon.NewArtworkLimit(test.maxArtworks),
+			)
+			if len(errSlice) > 0 {
+				t.Fatalf("tagSearchLogic() returned errors: %v", errSlice)
+			}
+			if fmt.Sprint(artworkIds) != fmt.Sprint(test.wantIds) {
+				t.Errorf("tagSearchLogic() = %v, want %v", artworkIds, test.wantIds)
+			}
+			sleeps := recorder.Sleeps()
+			if len(sleeps) != test.wantSleeps {
+				t.Fatalf("slept %v, want %d sleep(s)", sleeps, test.wantSleeps)
+			}
+			for _, d := range sleeps {
+				if d != 2*time.Second {
+					t.Errorf("slept %s between pages, want 2s", d)
+				}
+			}
+		})
+	}
+}
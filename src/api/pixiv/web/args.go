@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
 )
 
 // PixivToDl is the struct that contains the arguments of Pixiv download options.
@@ -20,11 +23,69 @@ type PixivWebDlOptions struct {
 
 	Configs     *configs.Config
 
+	// Not supported by the web API as it only provides the zip file of the ugoira frames,
+	// ValidateArgs() will warn the user and disable it if it is enabled.
+	UgoiraPreferDirect bool
+
+	// If not empty, tag search results that are not from
+	// these illustrator IDs will be dropped before getting their details
+	IllustratorWhitelist []string
+
+	// Tag search results with any of these tags will be dropped
+	// before getting their details or downloading them
+	ExcludeTags []string
+
+	// Tag search results with fewer bookmarks than this will be dropped
+	// before getting their URLs to download (0 means no minimum)
+	MinBookmarks int
+
+	// Stop requesting the tag search results of a tag once this many
+	// artwork IDs have been collected after filtering (0 means no limit)
+	MaxArtworks int
+
+	// Drop the artworks that are flagged as AI-generated by Pixiv
+	NoAi bool
+
+	// Use the English translation of the artwork's tags in the metadata where available
+	TranslateTags bool
+
+	// Only search for artworks posted within these dates in the YYYY-MM-DD format
+	// (an empty date leaves the range open on that side)
+	SearchStartDate string
+	SearchEndDate   string
+
+	// If not nil, only these pages of each multi-page artwork will be downloaded
+	ArtworkPages *pixivcommon.ArtworkPages
+
+	// Search the tag names with multiple sort orders and
+	// download the union of the results to get past Pixiv's result limit
+	MaxCoverage bool
+
+	// The range of the random delay in seconds between requests to Pixiv
+	MinSleep float64
+	MaxSleep float64
+
+	// The maximum delay in seconds of the exponential backoff when Pixiv responds
+	// with 429 Too Many Requests (0 disables the backoff)
+	MaxBackoff float64
+
 	SessionCookies  []*http.Cookie
 	SessionCookieId string
+
+	// The chapter order of the artworks from "--series_id" mapped by their artwork ID
+	// which will be prefixed to the folder names to preserve the reading order.
+	seriesChapterOrders map[string]string
 }
 
 var (
+	// The sort orders used for the tag search when "--max_coverage" is enabled
+	MAX_COVERAGE_SORT_ORDERS = []string{
+		"date_d",
+		"date",
+		"popular_d",
+		"popular_male_d",
+		"popular_female_d",
+	}
 	ACCEPTED_SORT_ORDER = []string{
 		"date", "date_d",
 		"popular", "popular_d",
@@ -46,6 +107,15 @@ var (
 		"manga",
 		"all",
 	}
+	ACCEPTED_RANKING_MODES = []string{
+		"daily", "weekly", "monthly",
+		"rookie", "original",
+		"male", "female",
+		"daily_ai",
+		"daily_r18", "weekly_r18",
+		"male_r18", "female_r18",
+		"daily_r18_ai", "r18g",
+	}
 )
 
 // ValidateArgs validates the arguments of the Pixiv download options.
@@ -104,6 +174,29 @@ func (p *PixivWebDlOptions) ValidateArgs(userAgent string) {
 		},
 	)
 
+	if p.UgoiraPreferDirect {
+		color.Yellow(
+			"--ugoira_prefer_direct is ignored as Pixiv's web API only provides the ugoira frames, use a refresh token instead to download the animated files directly where available.",
+		)
+		p.UgoiraPreferDirect = false
+	}
+
+	p.ExcludeTags = pixivcommon.CleanExcludeTags(p.ExcludeTags)
+	pixivcommon.ValidateMinBookmarks(p.MinBookmarks)
+	pixivcommon.ValidateMaxArtworks(p.MaxArtworks)
+	pixivcommon.ValidateSearchDateRange(p.SearchStartDate, p.SearchEndDate)
+	pixivcommon.ValidateSleepRange(p.MinSleep, p.MaxSleep)
+	minSleep, maxSleep = p.MinSleep, p.MaxSleep
+	if p.MaxBackoff < 0 {
+		utils.PrintError(
+			"pixiv error %d: the maximum backoff delay, %v, must be 0 (disabled) or more",
+			utils.INPUT_ERROR,
+			p.MaxBackoff,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	utils.SetRateLimitBackoff(utils.PIXIV, time.Duration(p.MaxBackoff*float64(time.Second)))
+
 	if p.SessionCookieId != "" {
 		p.SessionCookies = []*http.Cookie{
 			api.VerifyAndGetCookie(utils.PIXIV, p.SessionCookieId, userAgent),
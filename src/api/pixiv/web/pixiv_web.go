@@ -1,6 +1,7 @@
 package pixivweb
 
 import (
+	"sync"
 	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
@@ -12,6 +13,32 @@ const (
 	UGOIRA
 )
 
+// A chapter of a manga series with the artwork ID and its order in the series
+type seriesChapter struct {
+	artworkId string
+	order     int
+}
+
+// The default range of the random delay in seconds between requests to Pixiv's web API
+const (
+	DEFAULT_MIN_SLEEP = 0.5
+	DEFAULT_MAX_SLEEP = 1.0
+
+	// The default maximum delay in seconds between the retries of a request
+	// that keeps getting 429 Too Many Requests responses from Pixiv
+	DEFAULT_MAX_BACKOFF = 120.0
+)
+
+// The number of workers getting the details of multiple artworks concurrently
+const ARTWORK_DETAILS_WORKERS = 3
+
+// The range of the random delay in seconds between requests
+// which can be changed via "--pixiv_delay_min" and "--pixiv_delay_max"
+var (
+	minSleep = DEFAULT_MIN_SLEEP
+	maxSleep = DEFAULT_MAX_SLEEP
+)
+
 // This is due to Pixiv's strict rate limiting.
 //
 // Without delays, the user might get 429 too many requests
@@ -22,5 +49,33 @@ const (
 //
 // More info: https://github.com/Nandaka/PixivUtil2/issues/477
 func pixivSleep() {
-	time.Sleep(utils.GetRandomTime(0.5, 1.0))
+	utils.Sleep(utils.GetRandomTime(minSleep, maxSleep))
+}
+
+// requestLimiter is a token bucket with a capacity of one token that is refilled
+// after a random delay like pixivSleep() so that concurrent workers sharing it
+// will send their requests at the same rate as a single worker using pixivSleep().
+type requestLimiter struct {
+	mu          sync.Mutex
+	nextRequest time.Time
+}
+
+func newRequestLimiter() *requestLimiter {
+	return &requestLimiter{nextRequest: time.Now()}
+}
+
+// Blocks until the token is available and takes it
+func (l *requestLimiter) wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.nextRequest.Before(now) {
+		l.nextRequest = now
+	}
+	wait := l.nextRequest.Sub(now)
+	l.nextRequest = l.nextRequest.Add(utils.GetRandomTime(minSleep, maxSleep))
+	l.mu.Unlock()
+
+	if wait > 0 {
+		utils.Sleep(wait)
+	}
 }
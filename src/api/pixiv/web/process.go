@@ -2,6 +2,7 @@ package pixivweb
 
 import (
 	"net/http"
+	"sort"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/ugoira"
@@ -10,6 +11,46 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
+// Returns the artwork IDs from the keys of the illustrator's artworks map sorted numerically
+// in descending order to match Pixiv's newest-first order as Go's map iteration order is random.
+func getSortedArtworkIds(artworks map[string]interface{}) []string {
+	artworkIds := make([]string, 0, len(artworks))
+	for artworkId := range artworks {
+		artworkIds = append(artworkIds, artworkId)
+	}
+
+	// compare the lengths first since the IDs are numeric strings without leading zeros
+	sort.Slice(artworkIds, func(i, j int) bool {
+		if len(artworkIds[i]) != len(artworkIds[j]) {
+			return len(artworkIds[i]) > len(artworkIds[j])
+		}
+		return artworkIds[i] > artworkIds[j]
+	})
+	return artworkIds
+}
+
+// Returns the artwork IDs of the illustrator's artworks map within the page range
+// where minOffset is the number of artworks to skip and maxOffset is the number of artworks in the range.
+func getArtworkIdsInRange(artworks interface{}, minOffset, maxOffset int, hasMax bool) []string {
+	artworksMap, ok := artworks.(map[string]interface{})
+	if !ok {
+		// where there are no posts or has an unknown type
+		return nil
+	}
+
+	var artworkIds []string
+	for curOffset, artworkId := range getSortedArtworkIds(artworksMap) {
+		if curOffset < minOffset {
+			continue
+		}
+		if hasMax && curOffset >= minOffset+maxOffset {
+			break
+		}
+		artworkIds = append(artworkIds, artworkId)
+	}
+	return artworkIds
+}
+
 func processIllustratorPostJson(resJson *models.PixivWebIllustratorJson, pageNum string, pixivDlOptions *PixivWebDlOptions) ([]string, error) {
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
@@ -19,52 +60,20 @@ func processIllustratorPostJson(resJson *models.PixivWebIllustratorJson, pageNum
 
 	var artworkIds []string
 	if pixivDlOptions.ArtworkType == "all" || pixivDlOptions.ArtworkType == "illust_and_ugoira" {
-		illusts := resJson.Body.Illusts
-		switch t := illusts.(type) {
-		case map[string]interface{}:
-			curOffset := 0
-			for illustId := range t {
-				curOffset++
-				if curOffset < minOffset {
-					continue
-				}
-				if hasMax && curOffset > maxOffset {
-					break
-				}
-
-				artworkIds = append(artworkIds, illustId)
-			}
-		default: // where there are no posts or has an unknown type
-			break
-		}
+		artworkIds = append(artworkIds, getArtworkIdsInRange(resJson.Body.Illusts, minOffset, maxOffset, hasMax)...)
 	}
 
 	if pixivDlOptions.ArtworkType == "all" || pixivDlOptions.ArtworkType == "manga" {
-		manga := resJson.Body.Manga
-		switch t := manga.(type) {
-		case map[string]interface{}:
-			curOffset := 0
-			for mangaId := range t {
-				curOffset++
-				if curOffset < minOffset {
-					continue
-				}
-				if hasMax && curOffset > maxOffset {
-					break
-				}
-
-				artworkIds = append(artworkIds, mangaId)
-			}
-		default: // where there are no posts or has an unknown type
-			break
-		}
+		artworkIds = append(artworkIds, getArtworkIdsInRange(resJson.Body.Manga, minOffset, maxOffset, hasMax)...)
 	}
 	return artworkIds, nil
 }
 
 // Process the artwork details JSON and returns a map of urls
 // with its file path or a Ugoira struct (One of them will be null depending on the artworkType)
-func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir string) ([]*request.ToDownload, *models.Ugoira, error) {
+//
+// For multi-page artworks, only the pages selected by ArtworkPages (if any) will be returned.
+func processArtworkJson(res *http.Response, artworkType int64, artworkId, postDownloadDir string, dlOptions *PixivWebDlOptions) ([]*request.ToDownload, *models.Ugoira, error) {
 	if artworkType == UGOIRA {
 		var ugoiraJson models.PixivWebArtworkUgoiraJson
 		if err := utils.LoadJsonFromResponse(res, &ugoiraJson); err != nil {
@@ -72,9 +81,8 @@ func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir s
 		}
 
 		ugoiraMap := ugoiraJson.Body
-		originalUrl := ugoiraMap.OriginalSrc
 		ugoiraInfo := &models.Ugoira{
-			Url:      originalUrl,
+			Url:      ugoiraMap.OriginalSrc,
 			FilePath: postDownloadDir,
 			Frames:   ugoira.MapDelaysToFilename(ugoiraMap.Frames),
 		}
@@ -87,25 +95,55 @@ func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir s
 	}
 
 	var urlsToDownload []*request.ToDownload
-	for _, artworkUrl := range artworkUrls.Body {
+	for page, artworkUrl := range artworkUrls.Body {
 		urlsToDownload = append(urlsToDownload, &request.ToDownload{
 			Url:      artworkUrl.Urls.Original,
 			FilePath: postDownloadDir,
+			CacheKey: pixivcommon.GetArtworkCacheKey(artworkId, page),
 		})
 	}
-	return urlsToDownload, nil, nil
+	return dlOptions.ArtworkPages.Select(artworkId, urlsToDownload), nil, nil
 }
 
-// Process the tag search results JSON and returns a slice of artwork IDs
-func processTagJsonResults(res *http.Response) ([]string, error) {
+// The artwork IDs of a page of the tag search results after filtering
+type tagSearchPage struct {
+	artworkIds    []string
+	resultsCount  int // the number of results before filtering
+	filteredCount int // not from the whitelisted illustrators
+	excludedCount int // has one of the excluded tags
+}
+
+// Process the tag search results JSON and returns the page with the artwork IDs after filtering
+// and the number of results that were filtered out as they are not from the whitelisted illustrators
+// or as they have one of the excluded tags.
+//
+// The AI-generated artworks dropped by "--no_ai" are counted by pixivcommon.SkipAiGenerated() instead.
+func processTagJsonResults(res *http.Response, dlOptions *PixivWebDlOptions) (*tagSearchPage, error) {
 	var pixivTagJson models.PixivTag
 	if err := utils.LoadJsonFromResponse(res, &pixivTagJson); err != nil {
 		return nil, err
 	}
 
-	artworksSlice := []string{}
-	for _, illust := range pixivTagJson.Body.IllustManga.Data {
-		artworksSlice = append(artworksSlice, illust.Id)
+	results := pixivTagJson.Body.IllustManga.Data
+	page := &tagSearchPage{
+		artworkIds:   []string{},
+		resultsCount: len(results),
+	}
+	for _, illust := range results {
+		if !pixivcommon.IsWhitelistedIllustrator(dlOptions.IllustratorWhitelist, illust.UserId) {
+			page.filteredCount++
+			continue
+		}
+		// Pixiv should have left out the artworks with the excluded tags
+		// but check again in case the search term was not parsed as expected
+		if pixivcommon.HasExcludedTag(illust.Tags, dlOptions.ExcludeTags) {
+			page.excludedCount++
+			continue
+		}
+		if pixivcommon.SkipAiGenerated(dlOptions.NoAi, illust.AiType) {
+			continue
+		}
+		page.artworkIds = append(page.artworkIds, illust.Id)
 	}
-	return artworksSlice, nil
+	return page, nil
 }
@@ -0,0 +1,148 @@
+package pixivweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
+)
+
+func TestGetSortedArtworkIds(t *testing.T) {
+	tests := []struct {
+		name     string
+		artworks map[string]interface{}
+		want     []string
+	}{
+		{
+			name:     "empty",
+			artworks: map[string]interface{}{},
+			want:     []string{},
+		},
+		{
+			name:     "same length",
+			artworks: map[string]interface{}{"103": nil, "101": nil, "102": nil},
+			want:     []string{"103", "102", "101"},
+		},
+		{
+			name:     "numeric not lexical order",
+			artworks: map[string]interface{}{"9": nil, "100": nil, "25": nil, "1000": nil},
+			want:     []string{"1000", "100", "25", "9"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the map iteration order is random so check that the order is stable across runs
+			for i := 0; i < 20; i++ {
+				if got := getSortedArtworkIds(test.artworks); !reflect.DeepEqual(got, test.want) {
+					t.Fatalf("getSortedArtworkIds() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// Returns an illustrator's profile JSON with the given number of illusts and manga
+// where the IDs of the illusts start from 1001 and the manga from 5001.
+func newIllustratorJsonFixture(t *testing.T, illustCount, mangaCount int) *models.PixivWebIllustratorJson {
+	toJsonMap := func(startId, count int) string {
+		entries := make([]string, 0, count)
+		for id := startId; id < startId+count; id++ {
+			entries = append(entries, fmt.Sprintf("%q: null", strconv.Itoa(id)))
+		}
+		return "{" + strings.Join(entries, ",") + "}"
+	}
+	fixture := fmt.Sprintf(
+		`{"body": {"illusts": %s, "manga": %s}}`,
+		toJsonMap(1001, illustCount),
+		toJsonMap(5001, mangaCount),
+	)
+
+	var resJson models.PixivWebIllustratorJson
+	if err := json.Unmarshal([]byte(fixture), &resJson); err != nil {
+		t.Fatal(err)
+	}
+	return &resJson
+}
+
+// Returns the IDs from the end ID counting down
+func descendingIds(endId, count int) []string {
+	ids := make([]string, 0, count)
+	for id := endId; id > endId-count; id-- {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return ids
+}
+
+func TestProcessIllustratorPostJsonPageRange(t *testing.T) {
+	// 150 illusts (1001-1150) spanning three pages of 60 and 5 manga (5001-5005)
+	resJson := newIllustratorJsonFixture(t, 150, 5)
+
+	tests := []struct {
+		name        string
+		pageNum     string
+		artworkType string
+		want        []string
+	}{
+		{
+			name:        "first page",
+			pageNum:     "1",
+			artworkType: "illust_and_ugoira",
+			want:        descendingIds(1150, 60),
+		},
+		{
+			name:        "second page",
+			pageNum:     "2",
+			artworkType: "illust_and_ugoira",
+			want:        descendingIds(1090, 60),
+		},
+		{
+			name:        "last partial page",
+			pageNum:     "3",
+			artworkType: "illust_and_ugoira",
+			want:        descendingIds(1030, 30),
+		},
+		{
+			name:        "page range",
+			pageNum:     "2-3",
+			artworkType: "illust_and_ugoira",
+			want:        descendingIds(1090, 90),
+		},
+		{
+			name:        "all pages",
+			pageNum:     "",
+			artworkType: "illust_and_ugoira",
+			want:        descendingIds(1150, 150),
+		},
+		{
+			name:        "manga",
+			pageNum:     "1",
+			artworkType: "manga",
+			want:        descendingIds(5005, 5),
+		},
+		{
+			name:        "page out of range",
+			pageNum:     "4",
+			artworkType: "all",
+			want:        nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dlOptions := &PixivWebDlOptions{ArtworkType: test.artworkType}
+			// the same page range should always return the same artwork IDs
+			for i := 0; i < 20; i++ {
+				got, err := processIllustratorPostJson(resJson, test.pageNum, dlOptions)
+				if err != nil {
+					t.Fatalf("processIllustratorPostJson() returned %v", err)
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Fatalf("processIllustratorPostJson(%q) = %v, want %v", test.pageNum, got, test.want)
+				}
+			}
+		})
+	}
+}
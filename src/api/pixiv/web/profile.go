@@ -0,0 +1,160 @@
+package pixivweb
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	AVATAR_FILENAME = "avatar"
+	BANNER_FILENAME = "banner"
+)
+
+// Returns the file path to save the profile image to with the extension of the image URL
+func getProfileImagePath(illustratorFolder, filename, imageUrl string) string {
+	ext := ".jpg"
+	if parsedUrl, err := url.Parse(imageUrl); err == nil && path.Ext(parsedUrl.Path) != "" {
+		ext = path.Ext(parsedUrl.Path)
+	}
+	return filepath.Join(illustratorFolder, filename+ext)
+}
+
+// Query Pixiv's API for the illustrator's profile and returns
+// the avatar and the banner (if the illustrator has set one) to download
+// into the illustrator's folder. Images that have already been downloaded are skipped.
+func getIllustratorProfileImages(illustratorId, downloadPath string, cookies []*http.Cookie, userAgent string) ([]*request.ToDownload, error) {
+	headers := pixivcommon.GetPixivRequestHeaders()
+	headers["Referer"] = pixivcommon.GetUserUrl(illustratorId)
+	url := fmt.Sprintf("%s/user/%s", utils.PIXIV_API_URL, illustratorId)
+
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV, true)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Url:       url,
+			Method:    "GET",
+			Cookies:   cookies,
+			Headers:   headers,
+			Params:    map[string]string{"full": "1"},
+			UserAgent: userAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv error %d: failed to get the profile of the illustrator with an ID of %s due to %v",
+			utils.CONNECTION_ERROR,
+			illustratorId,
+			err,
+		)
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		return nil, fmt.Errorf(
+			"pixiv error %d: failed to get the profile of the illustrator with an ID of %s due to %s response",
+			utils.RESPONSE_ERROR,
+			illustratorId,
+			res.Status,
+		)
+	}
+
+	var jsonBody models.PixivWebUserJson
+	if err := utils.LoadJsonFromResponse(res, &jsonBody); err != nil {
+		return nil, err
+	}
+
+	illustratorFolder := utils.GetCreatorFolder(
+		filepath.Join(downloadPath, utils.PIXIV_TITLE),
+		jsonBody.Body.Name,
+	)
+	imageUrls := map[string]string{
+		AVATAR_FILENAME: jsonBody.Body.ImageBig,
+	}
+	// the background is null if the illustrator has not set a banner
+	if jsonBody.Body.Background != nil {
+		imageUrls[BANNER_FILENAME] = jsonBody.Body.Background.Url
+	}
+
+	var toDownload []*request.ToDownload
+	for _, filename := range []string{AVATAR_FILENAME, BANNER_FILENAME} {
+		imageUrl := imageUrls[filename]
+		if imageUrl == "" {
+			continue
+		}
+
+		filePath := getProfileImagePath(illustratorFolder, filename, imageUrl)
+		if utils.PathExists(filePath) {
+			utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_EXISTS)
+			continue
+		}
+		toDownload = append(toDownload, &request.ToDownload{
+			Url:      imageUrl,
+			FilePath: filePath,
+		})
+	}
+	return toDownload, nil
+}
+
+// Get the avatars and banners of multiple illustrators to download into their folders.
+//
+// Only Pixiv's web API returns the full-sized profile images, hence
+// this is also used when downloading the illustrators' works with the mobile API.
+func GetMultipleIllustratorProfileImages(illustratorIds []string, downloadPath string, cookies []*http.Cookie, userAgent string) []*request.ToDownload {
+	var errSlice []error
+	var toDownload []*request.ToDownload
+	illustratorIdsLen := len(illustratorIds)
+	lastIllustratorIdx := illustratorIdsLen - 1
+
+	baseMsg := "Getting profile images of illustrator(s) on Pixiv [%d/" + fmt.Sprintf("%d]...", illustratorIdsLen)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting profile images of %d illustrator(s) on Pixiv!",
+			illustratorIdsLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting profile images of %d illustrator(s) on Pixiv!\nPlease refer to the logs for more details.",
+			illustratorIdsLen,
+		),
+		illustratorIdsLen,
+	)
+	progress.Start()
+	for idx, illustratorId := range illustratorIds {
+		progress.SetDetail("illustrator " + illustratorId)
+		profileImages, err := getIllustratorProfileImages(illustratorId, downloadPath, cookies, userAgent)
+		if err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+		} else {
+			toDownload = append(toDownload, profileImages...)
+		}
+
+		if idx != lastIllustratorIdx {
+			pixivSleep()
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+
+	return toDownload
+}
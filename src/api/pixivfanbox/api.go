@@ -3,6 +3,7 @@ package pixivfanbox
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"sync"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
@@ -29,9 +30,12 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 	}
 	var wg sync.WaitGroup
 	queue := make(chan struct{}, maxConcurrency)
-	resChan := make(chan *http.Response, postIdsLen)
 	errChan := make(chan error, postIdsLen)
 
+	// the responses are stored by the post's index so that
+	// the posts will be processed in the same order as the post IDs
+	responses := make([]*http.Response, postIdsLen)
+
 	baseMsg := "Getting post details from Pixiv Fanbox [%d/" + fmt.Sprintf("%d]...", postIdsLen)
 	progress := spinner.New(
 		spinner.REQ_SPINNER,
@@ -54,15 +58,19 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 
 	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
 	url := fmt.Sprintf("%s/post.info", utils.PIXIV_FANBOX_API_URL)
-	for _, postId := range pf.PostIds {
+	for idx, postId := range pf.PostIds {
 		wg.Add(1)
-		go func(postId string) {
+		go func(idx int, postId string) {
 			defer func() {
 				wg.Done()
 				<-queue
 			}()
 
 			queue <- struct{}{}
+			if utils.IsInterrupted() {
+				return
+			}
+			progress.SetDetail("post " + postId)
 			header := GetPixivFanboxHeaders()
 			params := map[string]string{"postId": postId}
 			res, err := request.CallRequest(
@@ -92,16 +100,34 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 					res.Status,
 				)
 			} else {
-				resChan <- res
+				responses[idx] = res
 			}
 			progress.MsgIncrement(baseMsg)
-		}(postId)
+		}(idx, postId)
 	}
 	wg.Wait()
 	close(queue)
-	close(resChan)
 	close(errChan)
 
+	if utils.IsInterrupted() {
+		// discard the partial post details as the run is stopping
+		for _, res := range responses {
+			if res != nil {
+				res.Body.Close()
+			}
+		}
+		progress.Stop(true)
+		return nil, nil
+	}
+
+	resChan := make(chan *http.Response, postIdsLen)
+	for _, res := range responses {
+		if res != nil {
+			resChan <- res
+		}
+	}
+	close(resChan)
+
 	hasErr := false
 	if len(errChan) > 0 {
 		hasErr = true
@@ -167,12 +193,22 @@ type resStruct struct {
 }
 
 // GetFanboxCreatorPosts returns a slice of post IDs for a given creator
+// in the order set by the download options, i.e. newest or oldest first.
+//
+// Pixiv Fanbox's post.listCreator URLs from post.paginateCreator use a date cursor and are
+// ordered from the newest page to the oldest page, hence for the oldest-first order,
+// the pages are reversed (page 1 being the oldest page) and so are the posts in each page.
 func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions) ([]string, error) {
 	paginatedUrls, err := getCreatorPaginatedPosts(creatorId, dlOptions)
 	if err != nil {
 		return nil, err
 	}
 
+	oldestFirst := dlOptions.Order == FANBOX_ORDER_OLDEST
+	if oldestFirst {
+		slices.Reverse(paginatedUrls)
+	}
+
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
 	if err != nil {
 		return nil, err
@@ -186,8 +222,13 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 		maxConcurrency = len(paginatedUrls)
 	}
 	queue := make(chan struct{}, maxConcurrency)
-	resChan := make(chan *resStruct, len(paginatedUrls))
+
+	// the results are stored by the page's index so that the post IDs will be in the page order
+	results := make([]*resStruct, len(paginatedUrls))
 	for idx, paginatedUrl := range paginatedUrls {
+		if utils.IsInterrupted() {
+			break
+		}
 		curPage := idx + 1
 		if curPage < minPage {
 			continue
@@ -197,7 +238,7 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 		}
 
 		wg.Add(1)
-		go func(reqUrl string) {
+		go func(idx int, reqUrl string) {
 			defer func() {
 				wg.Done()
 				<-queue
@@ -229,28 +270,41 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 
 			var resJson *models.FanboxCreatorPostsJson
 			if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
-				resChan <- &resStruct{err: err}
+				results[idx] = &resStruct{err: err}
 			} else {
-				resChan <- &resStruct{json: resJson}
+				results[idx] = &resStruct{json: resJson}
 			}
-		}(paginatedUrl)
+		}(idx, paginatedUrl)
 	}
 	wg.Wait()
 	close(queue)
-	close(resChan)
+	if utils.IsInterrupted() {
+		// discard the partial post IDs as the run is stopping
+		return nil, nil
+	}
 
 	// parse the JSON response
 	var errSlice []error
 	var postIds []string
-	for res := range resChan {
+	for _, res := range results {
+		if res == nil {
+			// page was not requested or its request failed
+			continue
+		}
 		if res.err != nil {
+			utils.RecordFailure(res.err)
 			errSlice = append(errSlice, res.err)
 			continue
 		}
 
+		pagePostIds := make([]string, 0, len(res.json.Body.Items))
 		for _, postInfoMap := range res.json.Body.Items {
-			postIds = append(postIds, postInfoMap.Id)
+			pagePostIds = append(pagePostIds, postInfoMap.Id)
+		}
+		if oldestFirst {
+			slices.Reverse(pagePostIds)
 		}
+		postIds = append(postIds, pagePostIds...)
 	}
 
 	if len(errSlice) > 0 {
@@ -292,18 +346,27 @@ func (pf *PixivFanboxDl) getCreatorsPosts(dlOptions *PixivFanboxDlOptions) {
 	)
 	progress.Start()
 	for idx, creatorId := range pf.CreatorIds {
+		if utils.IsInterrupted() {
+			break
+		}
+		progress.SetDetail("creator " + creatorId)
 		retrievedPostIds, err := getFanboxPosts(
 			creatorId,
 			pf.CreatorPageNums[idx],
 			dlOptions,
 		)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 		} else {
 			pf.PostIds = append(pf.PostIds, retrievedPostIds...)
 		}
 		progress.MsgIncrement(baseMsg)
 	}
+	if utils.IsInterrupted() {
+		// discard the partial post IDs as the run is stopping
+		pf.PostIds = nil
+	}
 
 	hasErr := false
 	if len(errSlice) > 0 {
@@ -1,15 +1,15 @@
 package pixivfanbox
 
 import (
+	"fmt"
 	"net/http"
-	"os"
 	"regexp"
+	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 )
 
 // PixivFanboxDl is the struct that contains the IDs of the Pixiv Fanbox creators and posts to download.
@@ -29,18 +29,23 @@ var creatorIdRegex = regexp.MustCompile(`^[\w.-]+$`)
 // Should be called after initialising the struct.
 func (pf *PixivFanboxDl) ValidateArgs() {
 	utils.ValidateIds(pf.PostIds)
-	pf.PostIds = utils.RemoveSliceDuplicates(pf.PostIds)
+	pf.PostIds = utils.RemoveDuplicateIds("post ID", pf.PostIds)
 
-	for _, creatorId := range pf.CreatorIds {
+	hasInvalidCreatorId := false
+	for idx, creatorId := range pf.CreatorIds {
 		if !creatorIdRegex.MatchString(creatorId) {
-			color.Red(
-				"error %d: invalid Pixiv Fanbox creator ID %q, must be alphanumeric with underscores, dashes, or periods",
+			hasInvalidCreatorId = true
+			utils.PrintError(
+				"error %d: invalid Pixiv Fanbox creator ID %q at position %d, must be alphanumeric with underscores, dashes, or periods",
 				utils.INPUT_ERROR,
 				creatorId,
+				idx+1,
 			)
-			os.Exit(1)
 		}
 	}
+	if hasInvalidCreatorId {
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
 
 	if len(pf.CreatorPageNums) > 0 {
 		utils.ValidatePageNumInput(
@@ -54,6 +59,7 @@ func (pf *PixivFanboxDl) ValidateArgs() {
 		pf.CreatorPageNums = make([]string, len(pf.CreatorIds))
 	}
 	pf.CreatorIds, pf.CreatorPageNums = utils.RemoveDuplicateIdAndPageNum(
+		"creator ID",
 		pf.CreatorIds,
 		pf.CreatorPageNums,
 	)
@@ -68,6 +74,9 @@ type PixivFanboxDlOptions struct {
 
 	Configs       *configs.Config
 
+	// The order to retrieve and download a creator's posts in, FANBOX_ORDER_NEWEST by default
+	Order string
+
 	// GdriveClient is the Google Drive client to be
 	// used in the download process for Pixiv Fanbox posts
 	GdriveClient *gdrive.GDrive
@@ -76,10 +85,36 @@ type PixivFanboxDlOptions struct {
 	SessionCookies  []*http.Cookie
 }
 
-// ValidateArgs validates the session cookie ID of the Pixiv Fanbox account to download from.
+const (
+	FANBOX_ORDER_NEWEST = "newest"
+	FANBOX_ORDER_OLDEST = "oldest"
+)
+
+var ACCEPTED_FANBOX_ORDER = []string{
+	FANBOX_ORDER_NEWEST,
+	FANBOX_ORDER_OLDEST,
+}
+
+// ValidateArgs validates the order of the posts and the session cookie ID of the Pixiv Fanbox account to download from.
 //
 // Should be called after initialising the struct.
 func (pf *PixivFanboxDlOptions) ValidateArgs(userAgent string) {
+	if pf.Order == "" {
+		pf.Order = FANBOX_ORDER_NEWEST
+	}
+	pf.Order = strings.ToLower(pf.Order)
+	utils.ValidateStrArgs(
+		pf.Order,
+		ACCEPTED_FANBOX_ORDER,
+		[]string{
+			fmt.Sprintf(
+				"pixiv fanbox error %d: Order %s is not allowed",
+				utils.INPUT_ERROR,
+				pf.Order,
+			),
+		},
+	)
+
 	if pf.SessionCookieId != "" {
 		pf.SessionCookies = []*http.Cookie{
 			api.VerifyAndGetCookie(utils.PIXIV_FANBOX, pf.SessionCookieId, userAgent),
@@ -0,0 +1,33 @@
+package pixivfanbox
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+)
+
+func TestPixivFanboxDlValidateArgsRemovesDuplicates(t *testing.T) {
+	output := testutils.CaptureColorOutput(t)
+	pixivFanboxDl := &PixivFanboxDl{
+		CreatorIds: []string{"creator", "other-creator", "creator"},
+		PostIds:    []string{"1", "2", "1"},
+	}
+	pixivFanboxDl.ValidateArgs()
+
+	if want := []string{"1", "2"}; !reflect.DeepEqual(pixivFanboxDl.PostIds, want) {
+		t.Errorf("got post IDs %q, want %q", pixivFanboxDl.PostIds, want)
+	}
+	if want := []string{"creator", "other-creator"}; !reflect.DeepEqual(pixivFanboxDl.CreatorIds, want) {
+		t.Errorf("got creator IDs %q, want %q", pixivFanboxDl.CreatorIds, want)
+	}
+	if got := len(pixivFanboxDl.CreatorPageNums); got != 2 {
+		t.Errorf("got %d creator page numbers, want 2", got)
+	}
+
+	wantOutput := "Removed duplicate post ID 1 at position(s) 3 (kept the one at position 1)\n" +
+		"Removed duplicate creator ID creator at position(s) 3 (kept the one at position 1)\n"
+	if output.String() != wantOutput {
+		t.Errorf("warned %q, want %q", output.String(), wantOutput)
+	}
+}
@@ -0,0 +1,98 @@
+package pixivfanbox
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Matches the CSRF token in the metadata of Pixiv Fanbox's page, e.g. "csrfToken":"abc123"
+var csrfTokenRegex = regexp.MustCompile(`"csrf_?[tT]oken"\s*:\s*"([^"]+)"`)
+
+// The CSRF token is tied to the session so it only needs to be fetched once per run
+var (
+	csrfTokenMu sync.Mutex
+	csrfToken   string
+
+	// The page to fetch the CSRF token from
+	csrfTokenUrl = utils.PIXIV_FANBOX_URL
+)
+
+// Fetches the CSRF token from Pixiv Fanbox's page which is
+// embedded in the page's metadata for the logged in session.
+func fetchCsrfToken(dlOptions *PixivFanboxDlOptions) (string, error) {
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, false)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       csrfTokenUrl,
+			Cookies:   dlOptions.SessionCookies,
+			Headers:   GetPixivFanboxHeaders(),
+			UserAgent: dlOptions.Configs.UserAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil || res.StatusCode != 200 {
+		const errPrefix = "pixiv fanbox error"
+		if err != nil {
+			err = fmt.Errorf(
+				"%s %d: failed to get the CSRF token due to %v",
+				errPrefix,
+				utils.CONNECTION_ERROR,
+				err,
+			)
+		} else {
+			res.Body.Close()
+			err = fmt.Errorf(
+				"%s %d: failed to get the CSRF token due to %s response",
+				errPrefix,
+				utils.RESPONSE_ERROR,
+				res.Status,
+			)
+		}
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf(
+			"pixiv fanbox error %d: failed to read the response body for the CSRF token, more info => %v",
+			utils.RESPONSE_ERROR,
+			err,
+		)
+	}
+
+	matched := csrfTokenRegex.FindSubmatch(body)
+	if matched == nil {
+		return "", fmt.Errorf(
+			"pixiv fanbox error %d: failed to find the CSRF token, please check if your session cookie is valid",
+			utils.RESPONSE_ERROR,
+		)
+	}
+	return string(matched[1]), nil
+}
+
+// Returns the CSRF token for the session which will be fetched on the first call and cached for the run.
+//
+// The token has to be sent as the "X-CSRF-Token" header to Pixiv Fanbox's
+// authenticated API endpoints like the comments or the supporting list.
+func getCsrfToken(dlOptions *PixivFanboxDlOptions) (string, error) {
+	csrfTokenMu.Lock()
+	defer csrfTokenMu.Unlock()
+	if csrfToken != "" {
+		return csrfToken, nil
+	}
+
+	token, err := fetchCsrfToken(dlOptions)
+	if err != nil {
+		return "", err
+	}
+	csrfToken = token
+	return csrfToken, nil
+}
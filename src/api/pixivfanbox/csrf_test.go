@@ -0,0 +1,84 @@
+package pixivfanbox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+)
+
+// Serves Fanbox's page with the given body and returns the number of requests received
+func newCsrfTokenServer(t *testing.T, body string) *atomic.Int32 {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if cookie, err := r.Cookie("FANBOXSESSID"); err != nil || cookie.Value != "session" {
+			t.Errorf("the request has the session cookie %v, want the given session", cookie)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	oldUrl := csrfTokenUrl
+	csrfTokenUrl = server.URL
+	t.Cleanup(func() {
+		csrfTokenUrl = oldUrl
+		csrfToken = ""
+	})
+	return &requests
+}
+
+func newCsrfTestDlOptions() *PixivFanboxDlOptions {
+	return &PixivFanboxDlOptions{
+		Configs:        &configs.Config{UserAgent: "user-agent"},
+		SessionCookies: []*http.Cookie{{Name: "FANBOXSESSID", Value: "session"}},
+	}
+}
+
+func TestGetCsrfToken(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "metadata", body: `<meta name="metadata" id="metadata" content='{"urlContext":{},"csrfToken":"abc123"}'>`},
+		{name: "snake case", body: `<script>window.__INITIAL_STATE__ = {"csrf_token": "abc123"};</script>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := newCsrfTokenServer(t, test.body)
+			dlOptions := newCsrfTestDlOptions()
+			for i := 0; i < 2; i++ {
+				token, err := getCsrfToken(dlOptions)
+				if err != nil {
+					t.Fatalf("getCsrfToken() returned %v", err)
+				}
+				if token != "abc123" {
+					t.Errorf("getCsrfToken() = %q, want %q", token, "abc123")
+				}
+			}
+
+			// the token should be cached for the run
+			if got := requests.Load(); got != 1 {
+				t.Errorf("fetched the CSRF token %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestGetCsrfTokenWithoutToken(t *testing.T) {
+	requests := newCsrfTokenServer(t, `<html><head></head></html>`)
+	dlOptions := newCsrfTestDlOptions()
+
+	for i := 0; i < 2; i++ {
+		if _, err := getCsrfToken(dlOptions); err == nil {
+			t.Fatal("getCsrfToken() returned no error for a page without the CSRF token")
+		}
+	}
+
+	// failures should not be cached
+	if got := requests.Load(); got != 2 {
+		t.Errorf("fetched the CSRF token %d times, want 2", got)
+	}
+}
@@ -16,12 +16,17 @@ type FanboxCreatorPostsJson struct {
 
 type FanboxPostJson struct {
 	Body struct {
-		Id            string          `json:"id"`
-		Title         string          `json:"title"`
-		Type          string          `json:"type"`
-		CreatorId     string          `json:"creatorId"`
-		CoverImageUrl string          `json:"coverImageUrl"`
-		Body          json.RawMessage `json:"body"`
+		Id            string   `json:"id"`
+		Title         string   `json:"title"`
+		Type          string   `json:"type"`
+		CreatorId     string   `json:"creatorId"`
+		CoverImageUrl string   `json:"coverImageUrl"`
+		Tags          []string `json:"tags"`
+		PublishedAt   string   `json:"publishedDatetime"`
+		User          struct {
+			Name string `json:"name"`
+		} `json:"user"`
+		Body json.RawMessage `json:"body"`
 	} `json:"body"`
 }
 
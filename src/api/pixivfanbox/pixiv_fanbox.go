@@ -1,6 +1,7 @@
 package pixivfanbox
 
 import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/mega"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
@@ -34,6 +35,7 @@ func PixivFanboxDownloadProcess(pixivFanboxDl *PixivFanboxDl, pixivFanboxDlOptio
 				Headers:        GetPixivFanboxHeaders(),
 				Cookies:        pixivFanboxDlOptions.SessionCookies,
 				UseHttp3:       false,
+				RecordFailures: true,
 			},
 			pixivFanboxDlOptions.Configs,
 		)
@@ -42,6 +44,9 @@ func PixivFanboxDownloadProcess(pixivFanboxDl *PixivFanboxDl, pixivFanboxDlOptio
 		downloadedPosts = true
 		pixivFanboxDlOptions.GdriveClient.DownloadGdriveUrls(gdriveUrlsToDownload, pixivFanboxDlOptions.Configs)
 	}
+	if mega.DownloadQueuedLinks() {
+		downloadedPosts = true
+	}
 
 	if downloadedPosts {
 		utils.AlertWithoutErr(utils.Title, "Downloaded all posts from Pixiv Fanbox!")
@@ -17,6 +17,15 @@ import (
 // https://fanbox.pixiv.help/hc/en-us/articles/360011057793-What-types-of-attachments-can-I-post-
 var pixivFanboxAllowedImageExt = []string{"jpg", "jpeg", "png", "gif"}
 
+// Returns the cache key of the image or file of a post from its ID
+// or an empty string if the ID is missing so that the file will not be removed as a duplicate.
+func getFanboxCacheKey(fileId string) string {
+	if fileId == "" {
+		return ""
+	}
+	return "fanbox/" + fileId
+}
+
 func detectUrlsAndPasswordsInPost(text, postFolderPath string, articleBlocks models.FanboxArticleBlocks, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, bool) {
 	loggedPassword := false 
 	if utils.DetectPasswordInText(text) {
@@ -43,6 +52,7 @@ func detectUrlsAndPasswordsInPost(text, postFolderPath string, articleBlocks mod
 	if dlOptions.Configs.LogUrls {
 		utils.DetectOtherExtDLLink(text, postFolderPath)
 	}
+	utils.DetectMegaLinks(text, postFolderPath)
 	if utils.DetectGDriveLinks(text, postFolderPath, false, dlOptions.Configs.LogUrls) && dlOptions.DlGdrive {
 		gdriveLinks = append(gdriveLinks, &request.ToDownload{
 			Url:      text,
@@ -67,6 +77,7 @@ func processFanboxArticlePost(postBody json.RawMessage, postFolderPath string, d
 			urlsSlice = append(urlsSlice, &request.ToDownload{
 				Url:      imageInfo.OriginalUrl,
 				FilePath: filepath.Join(postFolderPath, utils.IMAGES_FOLDER),
+				CacheKey: getFanboxCacheKey(imageInfo.ID),
 			})
 		}
 	}
@@ -79,6 +90,7 @@ func processFanboxArticlePost(postBody json.RawMessage, postFolderPath string, d
 			urlsSlice = append(urlsSlice, &request.ToDownload{
 				Url:      attachmentUrl,
 				FilePath: filepath.Join(postFolderPath, utils.ATTACHMENT_FOLDER, filename),
+				CacheKey: getFanboxCacheKey(attachmentInfo.ID),
 			})
 		}
 	}
@@ -107,6 +119,7 @@ func processFanboxArticlePost(postBody json.RawMessage, postFolderPath string, d
 			for _, articleLink := range articleLinks {
 				linkUrl := articleLink.Url
 				utils.DetectOtherExtDLLink(linkUrl, postFolderPath)
+				utils.DetectMegaLinks(linkUrl, postFolderPath)
 				if utils.DetectGDriveLinks(linkUrl, postFolderPath, true, dlOptions.Configs.LogUrls) && dlOptions.DlGdrive {
 					gdriveLinks = append(gdriveLinks, &request.ToDownload{
 						Url:      linkUrl,
@@ -150,7 +163,7 @@ func processFanboxFilePost(postBody json.RawMessage, postFolderPath string, dlOp
 		filename := fileInfo.Name + "." + extension
 
 		var filePath string
-		isImage := utils.SliceContains(pixivFanboxAllowedImageExt, extension)
+		isImage := utils.ExtInSlice(pixivFanboxAllowedImageExt, extension)
 		if isImage {
 			filePath = filepath.Join(postFolderPath, utils.IMAGES_FOLDER, filename)
 		} else {
@@ -161,6 +174,7 @@ func processFanboxFilePost(postBody json.RawMessage, postFolderPath string, dlOp
 			urlsSlice = append(urlsSlice, &request.ToDownload{
 				Url:      fileUrl,
 				FilePath: filePath,
+				CacheKey: getFanboxCacheKey(fileInfo.ID),
 			})
 		}
 	}
@@ -197,7 +211,7 @@ func processFanboxImagePost(postBody json.RawMessage, postFolderPath string, dlO
 		filename := utils.GetLastPartOfUrl(fileUrl)
 
 		var filePath string
-		isImage := utils.SliceContains(pixivFanboxAllowedImageExt, extension)
+		isImage := utils.ExtInSlice(pixivFanboxAllowedImageExt, extension)
 		if isImage {
 			filePath = filepath.Join(postFolderPath, utils.IMAGES_FOLDER, filename)
 		} else {
@@ -208,6 +222,7 @@ func processFanboxImagePost(postBody json.RawMessage, postFolderPath string, dlO
 			urlsSlice = append(urlsSlice, &request.ToDownload{
 				Url:      fileUrl,
 				FilePath: filePath,
+				CacheKey: getFanboxCacheKey(fileInfo.ID),
 			})
 		}
 	}
@@ -223,6 +238,10 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 	}
 
 	postJson := post.Body
+	if utils.IsBeforeSince(postJson.PublishedAt) {
+		return nil, nil, nil
+	}
+
 	postId := postJson.Id
 	postTitle := postJson.Title
 	creatorId := postJson.CreatorId
@@ -232,6 +251,14 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 		postId,
 		postTitle,
 	)
+	metadata := &utils.FileMetadata{
+		PostUrl: fmt.Sprintf("%s/@%s/posts/%s", utils.PIXIV_FANBOX_URL, creatorId, postId),
+		Creator: postJson.User.Name,
+		Tags:    postJson.Tags,
+	}
+	if metadata.Creator == "" {
+		metadata.Creator = creatorId
+	}
 
 	var urlsSlice []*request.ToDownload
 	thumbnail := postJson.CoverImageUrl
@@ -248,7 +275,23 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 	postType := postJson.Type
 	postBody := postJson.Body
 	if postBody == nil {
-		return urlsSlice, nil, nil
+		// the post is restricted to supporters of a higher plan
+		utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_RESTRICTED)
+		urlsSlice = request.SetReferer(urlsSlice, metadata.PostUrl)
+		return request.SetMetadata(urlsSlice, metadata), nil, nil
+	}
+	if dlOptions.Configs.WriteMetadata {
+		postMetadata := &utils.PostMetadata{
+			Id:       postId,
+			Title:    postTitle,
+			Creator:  metadata.Creator,
+			Tags:     metadata.Tags,
+			PostDate: postJson.PublishedAt,
+			Url:      metadata.PostUrl,
+		}
+		if err := utils.WritePostMetadata(postFolderPath, postMetadata, dlOptions.Configs.OverwriteFiles); err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
 	}
 
 	var err error
@@ -286,7 +329,9 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 		return nil, nil, err
 	}
 	urlsSlice = append(urlsSlice, newUrlsSlice...)
-	return urlsSlice, gdriveLinks, nil
+	urlsSlice = request.SampleToDownload(urlsSlice, dlOptions.Configs.SampleSize)
+	urlsSlice = request.SetReferer(urlsSlice, metadata.PostUrl)
+	return request.SetMetadata(urlsSlice, metadata), request.SetMetadata(gdriveLinks, metadata), nil
 }
 
 func processMultiplePostJson(resChan chan *http.Response, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
@@ -313,14 +358,17 @@ func processMultiplePostJson(resChan chan *http.Response, dlOptions *PixivFanbox
 	)
 	progress.Start()
 	for res := range resChan {
+		progress.SetDetail("post " + res.Request.URL.Query().Get("postId"))
 		postUrls, postGdriveLinks, err := processFanboxPostJson(
 			res,
-			utils.DOWNLOAD_PATH,
+			dlOptions.Configs.DownloadPath,
 			dlOptions,
 		)
 		if err != nil {
+			utils.RecordFailure(err)
 			errSlice = append(errSlice, err)
 		} else {
+			utils.RUN_STATS.AddPostsProcessed(1)
 			urlsSlice = append(urlsSlice, postUrls...)
 			gdriveUrls = append(gdriveUrls, postGdriveLinks...)
 		}
@@ -0,0 +1,223 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/mobile"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/spf13/cobra"
+)
+
+const AUTH_NOT_CONFIGURED = "not configured"
+
+// The sites that can be checked by the check-auth command in the order they are printed
+var CHECK_AUTH_SITES = []string{
+	utils.FANTIA,
+	utils.PIXIV_FANBOX,
+	utils.PIXIV,
+	utils.PIXIV_MOBILE,
+	utils.KEMONO,
+}
+
+type authCheckRow struct {
+	site        string
+	authMethod  string
+	accountName string
+	status      string
+	err         error
+}
+
+var (
+	checkAuthSites        []string
+	checkAuthRefreshToken string
+	checkAuthCmd          = &cobra.Command{
+		Use:   "check-auth",
+		Short: "Check if the saved cookies and the Pixiv refresh token are still accepted by each site",
+		Long: utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Sends one lightweight authenticated request per site using the cookie files in the %q folder",
+				utils.COOKIES_FOLDER_NAME,
+			),
+			"of the application's directory and the Pixiv refresh token given by the \"--refresh_token\" flag",
+			"or saved in the config file.",
+			"Prints the authentication method, the account name (where available), and whether the credentials are OK, expired, or invalid.",
+			"Exits with a non-zero exit code if the credentials of any of the checked sites were not accepted.",
+		),
+		Args: cobra.NoArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			applyAppDataDir()
+			request.CheckInternetConnection()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			sites := CHECK_AUTH_SITES
+			siteFiltered := len(checkAuthSites) > 0
+			if siteFiltered {
+				sites = nil
+				for _, site := range checkAuthSites {
+					site = strings.ToLower(strings.TrimSpace(site))
+					utils.ValidateStrArgs(
+						site,
+						CHECK_AUTH_SITES,
+						[]string{
+							fmt.Sprintf(
+								"check-auth error %d: site %s is not allowed",
+								utils.INPUT_ERROR,
+								site,
+							),
+						},
+					)
+					if !utils.SliceContains(sites, site) {
+						sites = append(sites, site)
+					}
+				}
+			}
+
+			rows := make([]*authCheckRow, 0, len(sites))
+			for _, site := range sites {
+				rows = append(rows, checkSiteAuth(site))
+			}
+			printAuthCheckRows(rows)
+
+			exitCode := utils.EXIT_OK
+			for _, row := range rows {
+				switch row.status {
+				case api.AUTH_OK:
+					continue
+				case AUTH_NOT_CONFIGURED:
+					// only a failure if the user explicitly asked for the site to be checked
+					if !siteFiltered {
+						continue
+					}
+					exitCode = utils.EXIT_AUTH_ERROR
+				case api.AUTH_ERROR:
+					if exitCode == utils.EXIT_OK {
+						exitCode = utils.GetExitCodeFromErr(row.err)
+					}
+				default:
+					exitCode = utils.EXIT_AUTH_ERROR
+				}
+			}
+			if exitCode != utils.EXIT_OK {
+				utils.Exit(exitCode)
+			}
+		},
+	}
+)
+
+// Checks the configured credentials of the site with one authenticated request
+func checkSiteAuth(site string) *authCheckRow {
+	row := &authCheckRow{site: site}
+	if site == utils.PIXIV_MOBILE {
+		row.authMethod = "OAuth refresh token"
+		refreshToken := checkAuthRefreshToken
+		if refreshToken == "" {
+			refreshToken = getSavedPixivRefreshToken()
+			row.authMethod = "OAuth refresh token (config file)"
+		}
+		if refreshToken == "" {
+			row.authMethod = "OAuth refresh token"
+			row.status = AUTH_NOT_CONFIGURED
+			return row
+		}
+
+		pixivMobile := pixivmobile.NewPixivMobile(refreshToken, PIXIV_OAUTH_TIMEOUT)
+		pixivMobile.SetUserAgent(userAgent)
+		if err := pixivMobile.RefreshAccessToken(); err != nil {
+			if pixivmobile.GetRefreshTokenExitCode(err) == utils.EXIT_AUTH_ERROR {
+				row.status = api.AUTH_INVALID
+			} else {
+				row.status = api.AUTH_ERROR
+				row.err = err
+			}
+			return row
+		}
+		row.status = api.AUTH_OK
+		row.accountName = pixivMobile.GetAccountName()
+		return row
+	}
+
+	cookieFilePath := findAutoloadCookieFile(site)
+	if cookieFilePath == "" {
+		row.authMethod = "cookie file"
+		row.status = AUTH_NOT_CONFIGURED
+		return row
+	}
+
+	row.authMethod = fmt.Sprintf("cookie file (%s)", filepath.Base(cookieFilePath))
+	cookies, err := utils.ParseNetscapeCookieFile(cookieFilePath, "", site)
+	if err != nil {
+		row.status = api.AUTH_INVALID
+		row.err = err
+		return row
+	}
+
+	result := api.CheckCookieAuth(site, cookies, userAgent)
+	row.status = result.Status
+	row.accountName = result.AccountName
+	row.err = result.Err
+	return row
+}
+
+// Returns the readable name of the site for the check-auth table
+func getCheckAuthSiteStr(site string) string {
+	if site == utils.PIXIV_MOBILE {
+		return utils.PIXIV_TITLE + " (mobile)"
+	}
+	return utils.GetReadableSiteStr(site)
+}
+
+// Prints the results of the authentication checks as a table followed by the errors (if any)
+func printAuthCheckRows(rows []*authCheckRow) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SITE\tAUTH METHOD\tACCOUNT\tSTATUS")
+	for _, row := range rows {
+		accountName := row.accountName
+		if accountName == "" {
+			accountName = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", getCheckAuthSiteStr(row.site), row.authMethod, accountName, row.status)
+	}
+	w.Flush()
+
+	for _, row := range rows {
+		if row.err != nil {
+			utils.PrintError("%s: %v", getCheckAuthSiteStr(row.site), row.err)
+		}
+	}
+}
+
+func init() {
+	checkAuthCmd.Flags().StringSliceVar(
+		&checkAuthSites,
+		"site",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Only check the credentials of the given site(s): %s",
+				strings.Join(CHECK_AUTH_SITES, ", "),
+			),
+			"Sites that were given but have no credentials configured will be reported as a failure.",
+			"By default, all sites are checked and the sites without any credentials will not cause a failure.",
+		),
+	)
+	checkAuthCmd.Flags().StringVarP(
+		&checkAuthRefreshToken,
+		"refresh_token",
+		"t",
+		"",
+		utils.CombineStringsWithNewline(
+			"Your Pixiv refresh token to check with Pixiv's mobile API.",
+			"Defaults to the refresh token saved in the config file by the \"pixiv oauth\" command.",
+		),
+	)
+	registerFlagValuesCompletion(checkAuthCmd, map[string][]string{
+		"site": CHECK_AUTH_SITES,
+	})
+	RootCmd.AddCommand(checkAuthCmd)
+}
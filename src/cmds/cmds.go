@@ -1,7 +1,15 @@
 package cmds
 
 import (
+	"fmt"
+	"net/http"
+
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
@@ -9,6 +17,160 @@ func getMultipleIdsMsg() string {
 	return "For multiple IDs, separate them with a comma.\nExample: \"12345,67891\" (without the quotes)"
 }
 
+// Reads the candidate passwords from the
+// password list file if the user has provided one.
+func getPasswordList(passwordListPath string) []string {
+	if passwordListPath == "" {
+		return nil
+	}
+
+	passwords, err := utils.ReadPasswordList(passwordListPath)
+	if err != nil {
+		utils.LogError(err, "", true, utils.ERROR)
+	}
+	return passwords
+}
+
+// Returns the download targets in the file given by the global
+// "--input-file" flag or nil if the user has not provided one.
+//
+// Entries of sites other than the given site will be ignored with a warning.
+func getInputFileTargets(site string) *textparser.ClassifiedUrls {
+	if inputFilePath == "" {
+		return nil
+	}
+
+	classified, err := textparser.ClassifyInput(nil, inputFilePath)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	for _, otherSite := range classified.Sites {
+		if otherSite != site {
+			color.Yellow(
+				"Ignoring the %s entries in the input file as only %s is being downloaded from.\nUse the \"download\" command to download from multiple sites in one run.",
+				utils.GetReadableSiteStr(otherSite),
+				utils.GetReadableSiteStr(site),
+			)
+		}
+	}
+	return classified
+}
+
+// Returns the GDrive client to download the GDrive links with
+// or nil if neither the API key nor the service account was given.
+func getGdriveClient(apiKey, serviceAccPath string, config *configs.Config) *gdrive.GDrive {
+	if apiKey == "" && serviceAccPath == "" {
+		return nil
+	}
+
+	gdriveClient := gdrive.GetNewGDrive(
+		apiKey,
+		serviceAccPath,
+		config,
+		utils.MAX_CONCURRENT_DOWNLOADS,
+	)
+	gdriveClient.SetDlPacing(gdriveDlStagger, gdriveDlJitter)
+	gdriveClient.SetVerifyChecksum(gdriveVerifyChecksum)
+	return gdriveClient
+}
+
+// Returns the cookies of the site from the cookie file or nil if no cookie file was given
+func getSessionCookies(site, cookieFile, session string) []*http.Cookie {
+	if cookieFile == "" {
+		return nil
+	}
+
+	cookies, err := utils.ParseNetscapeCookieFile(cookieFile, session, site)
+	if err != nil {
+		utils.LogError(
+			err,
+			"",
+			true,
+			utils.ERROR,
+		)
+	}
+	return cookies
+}
+
+// Validates the number of files to download per post given by the "--sample" flag
+func getSampleSize(sampleSize int) int {
+	if sampleSize < 0 {
+		utils.PrintError(
+			"error %d: the sample size, %d, must be 0 (unlimited) or more",
+			utils.INPUT_ERROR,
+			sampleSize,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	return sampleSize
+}
+
+// Shared by the commands that support GDrive downloads
+// as only one command will be executed per run.
+var (
+	gdriveDlStagger      float64
+	gdriveDlJitter       float64
+	gdriveVerifyChecksum bool
+	dlMega               bool
+)
+
+func getGdriveVerifyChecksumMsg() string {
+	return utils.CombineStringsWithNewline(
+		"Compare the md5 checksum of existing files with the checksum given by Google Drive and skip the download if they match.",
+		"Disable it with \"--gdrive_verify_checksum=false\" to only compare the file sizes which is faster for large files.",
+	)
+}
+
+func getDlMegaMsg() string {
+	return utils.CombineStringsWithNewline(
+		fmt.Sprintf(
+			"Download the MEGA links detected in the posts into a %q folder in each post folder.",
+			utils.MEGA_FOLDER,
+		),
+		fmt.Sprintf(
+			"The detected MEGA links are always saved to a %q file in each post folder regardless of this flag.",
+			utils.MEGA_LINKS_FILENAME,
+		),
+		"Requires megadl from megatools (https://megatools.megous.com/) to be installed and in your PATH.",
+	)
+}
+
+// Shared by the download commands as only one command will be executed per run.
+var (
+	writeXattrs    bool
+	writeMetadata  bool
+	resumeDownload bool
+)
+
+func getWriteXattrsMsg() string {
+	return utils.CombineStringsWithNewline(
+		"Write the source metadata (post URL, creator, and tags) into the extended attributes (xattr) of each downloaded file.",
+		"Only supported on Linux and macOS, it will be ignored with a warning if the filesystem does not support extended attributes.",
+	)
+}
+
+func getWriteMetadataMsg() string {
+	return utils.CombineStringsWithNewline(
+		fmt.Sprintf(
+			"Write the details of each post (ID, title, creator, tags, post date, and URL) into a %s file in its folder.",
+			utils.POST_METADATA_FILENAME,
+		),
+		"Existing metadata files will only be replaced if the \"--overwrite\" flag is used.",
+	)
+}
+
+func getResumeDownloadMsg() string {
+	return utils.CombineStringsWithNewline(
+		fmt.Sprintf(
+			"Write the downloads to %s files and resume them from where they stopped on the next run",
+			request.PART_FILE_EXT,
+		),
+		"by only requesting the remaining bytes. Files are downloaded again from the start if the server does not support it.",
+		"Does not apply to Google Drive downloads.",
+	)
+}
+
 type textFilePath struct {
 	variable *string
 	desc     string
@@ -16,11 +178,14 @@ type textFilePath struct {
 type commonFlags struct {
 	cmd                     *cobra.Command
 	overwriteVar            *bool
+	redownloadVar           *bool
 	cookieFileVar           *string
-	userAgentVar            *string
 	gdriveApiKeyVar         *string 
 	gdriveServiceAccPathVar *string
 	logUrlsVar              *bool
+	passwordListVar         *string
+	retryFailedVar          *string
+	sampleVar               *int
 	textFile                textFilePath
 }
 
@@ -29,11 +194,14 @@ func init() {
 		{
 			cmd: fantiaCmd,
 			overwriteVar:            &fantiaOverwrite,
+			redownloadVar:           &fantiaRedownloadIfSmaller,
 			cookieFileVar:           &fantiaCookieFile,
-			userAgentVar:            &fantiaUserAgent,
 			gdriveApiKeyVar:         &fantiaGdriveApiKey,
 			gdriveServiceAccPathVar: &fantiaGdriveServiceAccPath,
 			logUrlsVar:              &fantiaLogUrls,
+			passwordListVar:         &fantiaPasswordList,
+			retryFailedVar:          &fantiaRetryFailed,
+			sampleVar:               &fantiaSampleSize,
 			textFile: textFilePath {
 				variable: &fantiaDlTextFile,
 				desc:     "Path to a text file containing Fanclub and/or post URL(s) to download from Fantia.",
@@ -42,11 +210,14 @@ func init() {
 		{
 			cmd: pixivFanboxCmd,
 			overwriteVar:            &fanboxOverwriteFiles,
+			redownloadVar:           &fanboxRedownloadIfSmaller,
 			cookieFileVar:           &fanboxCookieFile,
-			userAgentVar:            &fanboxUserAgent,
 			gdriveApiKeyVar:         &fanboxGdriveApiKey,
-			gdriveServiceAccPathVar: &fanboxGdriveApiKey,
+			gdriveServiceAccPathVar: &fanboxGdriveServiceAccPath,
 			logUrlsVar:              &fanboxLogUrls,
+			passwordListVar:         &fanboxPasswordList,
+			retryFailedVar:          &fanboxRetryFailed,
+			sampleVar:               &fanboxSampleSize,
 			textFile: textFilePath {
 				variable: &fanboxDlTextFile,
 				desc:     "Path to a text file containing creator and/or post URL(s) to download from Pixiv Fanbox.",
@@ -54,9 +225,11 @@ func init() {
 		},
 		{
 			cmd: pixivCmd,
-			overwriteVar:  &pixivOverwrite,
-			cookieFileVar: &pixivCookieFile,
-			userAgentVar:  &pixivUserAgent,
+			overwriteVar:   &pixivOverwrite,
+			redownloadVar:  &pixivRedownloadIfSmaller,
+			cookieFileVar:  &pixivCookieFile,
+			retryFailedVar: &pixivRetryFailed,
+			sampleVar:      &pixivSampleSize,
 			textFile: textFilePath {
 				variable: &pixivDlTextFile,
 				desc:     "Path to a text file containing artwork, illustrator, and tag name URL(s) to download from Pixiv.",
@@ -65,16 +238,29 @@ func init() {
 		{
 			cmd: kemonoCmd,
 			overwriteVar:            &kemonoOverwrite,
+			redownloadVar:           &kemonoRedownloadIfSmaller,
 			cookieFileVar:           &kemonoCookieFile,
-			userAgentVar:            &kemonoUserAgent,
 			gdriveApiKeyVar:         &kemonoGdriveApiKey,
 			gdriveServiceAccPathVar: &kemonoGdriveServiceAccPath,
 			logUrlsVar:              &kemonoLogUrls,
+			passwordListVar:         &kemonoPasswordList,
+			retryFailedVar:          &kemonoRetryFailed,
 			textFile: textFilePath {
 				variable: &kemonoDlTextFile,
 				desc: "Path to a text file containing creator and/or post URL(s) to download from Kemono Party.",
 			},
 		},
+		{
+			// the "download" command has no cookie file, text file, or retry flags
+			// as they are specific to a site
+			cmd:                     downloadCmd,
+			overwriteVar:            &dlOverwrite,
+			redownloadVar:           &dlRedownloadIfSmaller,
+			gdriveApiKeyVar:         &dlGdriveApiKey,
+			gdriveServiceAccPathVar: &dlGdriveServiceAccPath,
+			logUrlsVar:              &dlLogUrls,
+			passwordListVar:         &dlPasswordList,
+		},
 	}
 	for _, cmdInfo := range commonCmdFlags {
 		cmd := cmdInfo.cmd
@@ -88,31 +274,40 @@ func init() {
 				"Usually used for Pixiv Fanbox when there are incomplete downloads.",
 			),
 		)
-		cmd.Flags().StringVarP(
-			cmdInfo.userAgentVar,
-			"user_agent",
-			"u",
-			"",
-			"Set a custom User-Agent header to use when communicating with the API(s) or when downloading.",
-		)
-		cmd.Flags().StringVarP(
-			cmdInfo.textFile.variable,
-			"txt_filepath",
-			"p",
-			"",
-			cmdInfo.textFile.desc,
-		)
-		cmd.Flags().StringVarP(
-			cmdInfo.cookieFileVar,
-			"cookie_file",
-			"c",
-			"",
+		cmd.Flags().BoolVar(
+			cmdInfo.redownloadVar,
+			"redownload_if_smaller",
+			false,
 			utils.CombineStringsWithNewline(
-				"Pass in a file path to your saved Netscape/Mozilla generated cookie file to use when downloading.",
-				"You can generate a cookie file by using the \"Get cookies.txt LOCALLY\" extension for your browser.",
-				"Chrome Extension URL: https://chrome.google.com/webstore/detail/get-cookiestxt-locally/cclelndahbckbenkjhflpdbgdldlbecc",
+				"Download existing files again if they are smaller than the file size reported by the server",
+				"which is usually caused by a previous download that was interrupted or truncated.",
+				"Existing files that are equal or larger in size will be treated as complete.",
 			),
 		)
+		if cmdInfo.textFile.variable != nil {
+			cmd.Flags().StringVarP(
+				cmdInfo.textFile.variable,
+				"txt_filepath",
+				"p",
+				"",
+				cmdInfo.textFile.desc,
+			)
+			registerFilePathCompletion(cmd, "txt_filepath")
+		}
+		if cmdInfo.cookieFileVar != nil {
+			cmd.Flags().StringVarP(
+				cmdInfo.cookieFileVar,
+				"cookie_file",
+				"c",
+				"",
+				utils.CombineStringsWithNewline(
+					"Pass in a file path to your saved Netscape/Mozilla generated cookie file to use when downloading.",
+					"You can generate a cookie file by using the \"Get cookies.txt LOCALLY\" extension for your browser.",
+					"Chrome Extension URL: https://chrome.google.com/webstore/detail/get-cookiestxt-locally/cclelndahbckbenkjhflpdbgdldlbecc",
+				),
+			)
+			registerFilePathCompletion(cmd, "cookie_file")
+		}
 		if cmdInfo.gdriveApiKeyVar != nil {
 			cmd.Flags().StringVar(
 				cmdInfo.gdriveApiKeyVar,
@@ -124,6 +319,32 @@ func init() {
 				),
 			)
 		}
+		if cmdInfo.gdriveApiKeyVar != nil {
+			cmd.Flags().Float64Var(
+				&gdriveDlStagger,
+				"gdrive_dl_stagger",
+				gdrive.DEFAULT_DL_STAGGER_DELAY,
+				"Delay in seconds between the start of each GDrive download worker to avoid getting rate limited by Google.",
+			)
+			cmd.Flags().Float64Var(
+				&gdriveDlJitter,
+				"gdrive_dl_jitter",
+				gdrive.DEFAULT_DL_MAX_JITTER,
+				"Max random delay in seconds to add before each GDrive download request to avoid getting rate limited by Google.",
+			)
+			cmd.Flags().BoolVar(
+				&gdriveVerifyChecksum,
+				"gdrive_verify_checksum",
+				true,
+				getGdriveVerifyChecksumMsg(),
+			)
+			cmd.Flags().BoolVar(
+				&dlMega,
+				"dl_mega",
+				false,
+				getDlMegaMsg(),
+			)
+		}
 		if cmdInfo.gdriveServiceAccPathVar != nil {
 			cmd.Flags().StringVar(
 				cmdInfo.gdriveServiceAccPathVar,
@@ -148,6 +369,86 @@ func init() {
 				),
 			)
 		}
+		if cmdInfo.passwordListVar != nil {
+			cmd.Flags().StringVar(
+				cmdInfo.passwordListVar,
+				"password_list",
+				"",
+				utils.CombineStringsWithNewline(
+					"Path to a text file containing candidate passwords separated by a new line.",
+					"For downloaded archives (.rar, .7z) of posts where a password was detected,",
+					"the program will try to extract them using the passwords in the file.",
+					"The original archive will be kept.",
+				),
+			)
+		}
+		if cmdInfo.retryFailedVar != nil {
+			cmd.Flags().StringVar(
+				cmdInfo.retryFailedVar,
+				"retry_failed",
+				"",
+				utils.CombineStringsWithNewline(
+					"Path to a failures JSON file generated by a previous run to only re-download the files that failed to download.",
+					"The failures file will be regenerated with the downloads that still failed or removed if all succeeded.",
+					"Note that the other download arguments like the IDs or URLs will be ignored.",
+				),
+			)
+			registerFilePathCompletion(cmd, "retry_failed")
+		}
+		if cmdInfo.sampleVar != nil {
+			cmd.Flags().IntVar(
+				cmdInfo.sampleVar,
+				"sample",
+				0,
+				utils.CombineStringsWithNewline(
+					"Only download the first N files of each post to preview a creator before downloading everything.",
+					"Defaults to 0 which downloads all files.",
+				),
+			)
+		}
+		cmd.Flags().BoolVar(
+			&writeXattrs,
+			"write_xattrs",
+			false,
+			getWriteXattrsMsg(),
+		)
+		cmd.Flags().BoolVar(
+			&writeMetadata,
+			"write_metadata",
+			false,
+			getWriteMetadataMsg(),
+		)
+		cmd.Flags().BoolVar(
+			&resumeDownload,
+			"resume_download",
+			false,
+			getResumeDownloadMsg(),
+		)
+		if cmdInfo.cookieFileVar != nil {
+			cmd.Flags().BoolVar(
+				&probeOnly,
+				"probe_only",
+				false,
+				utils.CombineStringsWithNewline(
+					"Only check the remaining validity of the session cookie in the cookie file and the validity of the GDrive API key (if any).",
+					"Nothing will be downloaded and only the errors will be printed like with \"--quiet\" for cron jobs.",
+					"The program will exit with a non-zero exit code if any of the credentials are invalid",
+					"or will expire within the number of days set by --warn_days.",
+				),
+			)
+			cmd.Flags().IntVar(
+				&credentialsWarnDays,
+				"warn_days",
+				7,
+				"Number of days before the session cookie expires to start warning when using --probe_only.",
+			)
+		}
+		if cmdInfo.gdriveServiceAccPathVar != nil {
+			registerFilePathCompletion(cmd, "gdrive_service_acc_path")
+		}
+		if cmdInfo.passwordListVar != nil {
+			registerFilePathCompletion(cmd, "password_list")
+		}
 		RootCmd.AddCommand(cmd)
 	}
 }
@@ -0,0 +1,25 @@
+package cmds
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Registers the shell completion of the given flags with their accepted values.
+//
+// The accepted values should be the same slices used to validate the flags
+// in the ValidateArgs methods so that the completions will not go out of sync.
+func registerFlagValuesCompletion(cmd *cobra.Command, flagValues map[string][]string) {
+	for flagName, values := range flagValues {
+		cmd.RegisterFlagCompletionFunc(
+			flagName,
+			cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp),
+		)
+	}
+}
+
+// Registers the shell completion of the given flags with file paths
+func registerFilePathCompletion(cmd *cobra.Command, flagNames ...string) {
+	for _, flagName := range flagNames {
+		cmd.MarkFlagFilename(flagName)
+	}
+}
@@ -0,0 +1,139 @@
+package cmds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func exitOnConfigErr(err error) {
+	utils.PrintError(err.Error())
+	utils.Exit(utils.GetExitCodeFromErr(err))
+}
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "View and edit the program's settings",
+		Long: utils.CombineStringsWithNewline(
+			"View and edit the settings saved in the program's config file.",
+			fmt.Sprintf("Valid keys: %s", strings.Join(utils.GetConfigKeys(), ", ")),
+		),
+		// editing the config file does not require an internet connection
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			applyAppDataDir()
+		},
+	}
+	configGetCmd = &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print the value of a setting or all settings if no key is given",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				value, err := utils.GetConfigValue(args[0])
+				if err != nil {
+					exitOnConfigErr(err)
+				}
+				fmt.Println(value)
+				return
+			}
+
+			config, err := utils.LoadConfig()
+			if err != nil {
+				exitOnConfigErr(err)
+			}
+			if config.PixivRefreshToken != "" {
+				// the refresh token should never be printed
+				config.PixivRefreshToken = "<saved>"
+			}
+			configJson, err := utils.PrettifyJson(config)
+			if err != nil {
+				exitOnConfigErr(err)
+			}
+			fmt.Println(string(configJson))
+		},
+	}
+	configSetCmd = &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Validate and save the value of a setting",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			savedValue, err := utils.SetConfigValue(args[0], args[1])
+			if err != nil {
+				exitOnConfigErr(err)
+			}
+			color.Green("%s set to: %s", args[0], savedValue)
+		},
+	}
+	configPathCmd = &cobra.Command{
+		Use:   "path",
+		Short: "Print the path to the config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(utils.GetConfigFilePath())
+		},
+	}
+)
+
+// Returns the default values of the site's dl_* toggles from the config file
+//
+// Exits the program if the config file has an invalid value for any of the toggles.
+func getConfigDlDefaults(site string) map[string]bool {
+	dlDefaults, err := utils.GetConfigDlDefaults(site)
+	if err != nil {
+		exitOnConfigErr(err)
+	}
+	return dlDefaults
+}
+
+// Returns the default value of the dl_* toggle from the config file or true if it was not set
+func getDlToggleDefault(dlDefaults map[string]bool, toggle string) bool {
+	if value, ok := dlDefaults[toggle]; ok {
+		return value
+	}
+	return true
+}
+
+// Applies the default values of the dl_* toggles from the config file
+// to the flags of the site's command that were not given by the user.
+func applyConfigDlDefaults(cmd *cobra.Command) {
+	var site string
+	switch cmd {
+	case fantiaCmd:
+		site = utils.FANTIA
+	case pixivFanboxCmd:
+		site = utils.PIXIV_FANBOX
+	case kemonoCmd:
+		site = utils.KEMONO
+	default:
+		return
+	}
+
+	for toggle, value := range getConfigDlDefaults(site) {
+		flag := cmd.Flags().Lookup(toggle)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		// the flag is not marked as changed so that it is still treated as a default value
+		flag.Value.Set(strconv.FormatBool(value))
+	}
+}
+
+// Completes the config keys for the first argument of the config commands
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return utils.GetConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configGetCmd.ValidArgsFunction = completeConfigKeys
+	configSetCmd.ValidArgsFunction = completeConfigKeys
+	configCmd.AddCommand(configGetCmd, configSetCmd, configPathCmd)
+	RootCmd.AddCommand(configCmd)
+}
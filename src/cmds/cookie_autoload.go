@@ -0,0 +1,90 @@
+package cmds
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Set by the "--no-cookie-autoload" flag for users who rely on anonymous access
+var noCookieAutoload bool
+
+// Returns the path of the first cookie file with a well-known name like "cookies/fantia.txt"
+// in the application's directory for the site or an empty string if there is none.
+//
+// Unlike getCookieFilePath(), the cookie file is not validated.
+func findAutoloadCookieFile(site string) string {
+	for _, autoloadPath := range utils.GetAutoloadCookieFilePaths(site) {
+		if utils.PathExists(autoloadPath) {
+			return autoloadPath
+		}
+	}
+	return ""
+}
+
+// Returns the cookie file path to use for the site.
+//
+// If neither the cookie file nor the session cookie was given, the first cookie file with a well-known name
+// like "cookies/fantia.txt" in the application's directory will be used after validating that
+// it contains the session cookie of the site. Otherwise, the given cookie file path is returned as-is.
+func getCookieFilePath(site, cookieFilePath, session string) string {
+	if cookieFilePath != "" || session != "" || noCookieAutoload {
+		return cookieFilePath
+	}
+
+	autoloadPath := findAutoloadCookieFile(site)
+	if autoloadPath == "" {
+		return ""
+	}
+
+	if _, err := utils.ParseNetscapeCookieFile(autoloadPath, "", site); err != nil {
+		utils.PrintError(
+			"%v\nPlease fix or remove the cookie file or use the \"--no-cookie-autoload\" flag to ignore it.",
+			err,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+
+	msg := fmt.Sprintf(
+		"%s: using the cookie file at %s",
+		utils.GetReadableSiteStr(site),
+		autoloadPath,
+	)
+	color.Green(msg)
+	utils.LogError(nil, msg, false, utils.INFO)
+	return autoloadPath
+}
+
+// Returns the session cookies from the cookie file for the site or nil if there is none.
+//
+// The cookie file will be found the same way as getCookieFilePath().
+func getAutoloadedCookies(site, session string) []*http.Cookie {
+	cookieFilePath := getCookieFilePath(site, "", session)
+	if cookieFilePath == "" {
+		return nil
+	}
+
+	cookies, err := utils.ParseNetscapeCookieFile(cookieFilePath, "", site)
+	if err != nil {
+		utils.LogError(err, "", true, utils.ERROR)
+	}
+	return cookies
+}
+
+func init() {
+	RootCmd.PersistentFlags().BoolVar(
+		&noCookieAutoload,
+		"no-cookie-autoload",
+		false,
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Disable the automatic loading of the cookie files like \"%s/fantia.txt\" or \"%s/fanbox.json\"",
+				utils.COOKIES_FOLDER_NAME,
+				utils.COOKIES_FOLDER_NAME,
+			),
+			"from the application's directory when neither the cookie file nor the session cookie ID was given.",
+		),
+	)
+}
@@ -0,0 +1,69 @@
+package cmds
+
+import (
+	"math"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Shared by the commands that download from a site
+// as only one command will be executed per run.
+var (
+	probeOnly            bool
+	credentialsWarnDays  int
+)
+
+// Checks the remaining validity of the session cookie in the cookie file
+// and the validity of the Google Drive API key without downloading anything.
+//
+// As "--probe_only" implies "--quiet", only the errors will be printed.
+// Exits the program with EXIT_AUTH_ERROR if any of the credentials are invalid
+// or will expire within the number of days given by the "--warn_days" flag.
+func checkCredentials(site, cookieFilePath, gdriveApiKey, userAgent string) {
+	siteName := utils.GetReadableSiteStr(site)
+	hasWarning := false
+	if cookieFilePath == "" {
+		color.Yellow(
+			"%s: the expiry of the session cookie can only be checked with the \"--cookie_file\" flag.",
+			siteName,
+		)
+	} else if cookies, err := utils.ParseNetscapeCookieFile(cookieFilePath, "", site); err != nil {
+		utils.PrintError(err.Error())
+		hasWarning = true
+	} else if expiry, hasExpiry := utils.GetEarliestCookieExpiry(cookies); !hasExpiry {
+		color.Yellow(
+			"%s: the session cookie does not have an expiry date and will expire when the browser session ends.",
+			siteName,
+		)
+	} else {
+		daysLeft := int(math.Floor(time.Until(expiry).Hours() / 24))
+		msg := "%s: the session cookie expires in %d day(s) on %s."
+		expiryStr := expiry.Local().Format("2006-01-02 15:04:05")
+		if daysLeft < credentialsWarnDays {
+			utils.PrintError(msg, siteName, daysLeft, expiryStr)
+			hasWarning = true
+		} else {
+			color.Green(msg, siteName, daysLeft, expiryStr)
+		}
+	}
+
+	if gdriveApiKey != "" {
+		isValid, err := gdrive.ApiKeyIsValid(gdriveApiKey, userAgent)
+		if err != nil {
+			utils.PrintError(err.Error())
+			hasWarning = true
+		} else if !isValid {
+			utils.PrintError("Google Drive: the API key is invalid.")
+			hasWarning = true
+		} else {
+			color.Green("Google Drive: the API key is valid.")
+		}
+	}
+
+	if hasWarning {
+		utils.Exit(utils.EXIT_AUTH_ERROR)
+	}
+}
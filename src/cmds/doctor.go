@@ -0,0 +1,375 @@
+package cmds
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	DOCTOR_PASS = "pass"
+	DOCTOR_WARN = "warn"
+	DOCTOR_FAIL = "fail"
+
+	DOCTOR_TIMEOUT            = 10
+	DOCTOR_COOKIE_WARN_DAYS   = 7
+	DOCTOR_DISK_SPACE_WARN_GB = 5
+	DOCTOR_DISK_SPACE_FAIL_GB = 1
+)
+
+// doctorCheck is the outcome of a diagnostic check of the doctor command
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Details string `json:"details"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// doctorReport is the output of the doctor command with the "--json" flag to attach to bug reports
+type doctorReport struct {
+	Version string         `json:"version"`
+	OS      string         `json:"os"`
+	Arch    string         `json:"arch"`
+	Checks  []*doctorCheck `json:"checks"`
+}
+
+type doctorSite struct {
+	name  string
+	url   string
+	http3 bool
+}
+
+var (
+	doctorJson         bool
+	doctorFfmpegPath   string
+	doctorGdriveApiKey string
+	doctorCmd          = &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose common problems with the program's environment",
+		Long: utils.CombineStringsWithNewline(
+			"Checks the network reachability of each supported site (including HTTP/3), FFmpeg,",
+			"the Google Drive API key given by the \"--gdrive_api_key\" flag, the cookie files in the application's directory,",
+			"the write access to the download path and the application's directory, and the free disk space.",
+			"Prints a pass, warn, or fail line with a hint for each check or a JSON report with the \"--json\" flag for bug reports.",
+			"Exits with a non-zero exit code if any of the checks failed.",
+		),
+		Args: cobra.NoArgs,
+		// the network checks are part of the diagnostics instead of exiting early
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			applyAppDataDir()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var checks []*doctorCheck
+			checks = append(checks, checkSitesReachability()...)
+			checks = append(checks, checkFfmpeg())
+			if doctorGdriveApiKey != "" {
+				checks = append(checks, checkGdriveApiKey())
+			}
+			checks = append(checks, checkCookieFiles()...)
+
+			downloadPath := getDownloadPath()
+			if downloadPath == "" {
+				// the files are downloaded relative to the current working directory by default
+				downloadPath, _ = os.Getwd()
+			}
+			checks = append(
+				checks,
+				checkWritableDir("Download path", downloadPath, false),
+				checkWritableDir("Application directory", utils.APP_PATH, true),
+				checkFreeDiskSpace(downloadPath),
+			)
+
+			if doctorJson {
+				reportJson, err := utils.PrettifyJson(&doctorReport{
+					Version: utils.VERSION,
+					OS:      runtime.GOOS,
+					Arch:    runtime.GOARCH,
+					Checks:  checks,
+				})
+				if err != nil {
+					utils.PrintError(err.Error())
+					utils.Exit(utils.GetExitCodeFromErr(err))
+				}
+				fmt.Println(string(reportJson))
+			} else {
+				printDoctorChecks(checks)
+			}
+
+			for _, check := range checks {
+				if check.Status == DOCTOR_FAIL {
+					utils.Exit(utils.EXIT_GENERAL_ERROR)
+				}
+			}
+		},
+	}
+)
+
+// Returns the sites to check the network reachability of
+func getDoctorSites() []*doctorSite {
+	return []*doctorSite{
+		{name: utils.FANTIA_TITLE, url: utils.FANTIA_URL, http3: utils.IsHttp3Supported(utils.FANTIA, false)},
+		{name: utils.PIXIV_FANBOX_TITLE, url: utils.PIXIV_FANBOX_URL, http3: utils.IsHttp3Supported(utils.PIXIV_FANBOX, false)},
+		{name: utils.PIXIV_TITLE, url: utils.PIXIV_URL, http3: utils.IsHttp3Supported(utils.PIXIV, false)},
+		{name: utils.PIXIV_TITLE + " (mobile)", url: utils.PIXIV_MOBILE_URL, http3: utils.IsHttp3Supported(utils.PIXIV_MOBILE, false)},
+		{name: utils.KEMONO_TITLE, url: utils.KEMONO_URL, http3: utils.IsHttp3Supported(utils.KEMONO, false)},
+		{name: utils.KEMONO_TITLE + " (backup)", url: utils.BACKUP_KEMONO_URL, http3: utils.IsHttp3Supported(utils.KEMONO_BACKUP, false)},
+		{name: utils.GDRIVE_TITLE, url: "https://drive.google.com", http3: true},
+	}
+}
+
+// Sends a HEAD request to the URL and returns the error if the site could not be reached
+func pingUrl(url string, useHttp3 bool) error {
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "HEAD",
+			Url:       url,
+			Timeout:   DOCTOR_TIMEOUT,
+			UserAgent: userAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// Checks if each supported site can be reached over HTTP/2 and HTTP/3 (if it is used for the site)
+func checkSitesReachability() []*doctorCheck {
+	sites := getDoctorSites()
+	checks := make([]*doctorCheck, len(sites))
+
+	var wg sync.WaitGroup
+	for idx, site := range sites {
+		wg.Add(1)
+		go func(idx int, site *doctorSite) {
+			defer wg.Done()
+			check := &doctorCheck{Name: fmt.Sprintf("Network: %s", site.name)}
+			checks[idx] = check
+
+			if err := pingUrl(site.url, false); err != nil {
+				check.Status = DOCTOR_FAIL
+				check.Details = fmt.Sprintf("%s could not be reached, more info => %v", site.url, err)
+				check.Hint = "Check your internet connection, firewall, or proxy settings and whether the site is blocked in your region."
+				return
+			}
+			if !site.http3 {
+				check.Status = DOCTOR_PASS
+				check.Details = fmt.Sprintf("%s is reachable", site.url)
+				return
+			}
+
+			if err := pingUrl(site.url, true); err != nil {
+				check.Status = DOCTOR_WARN
+				check.Details = fmt.Sprintf("%s is reachable but not over HTTP/3, more info => %v", site.url, err)
+				check.Hint = "HTTP/3 uses UDP port 443 which may be blocked by your firewall or network, please allow it as the program uses HTTP/3 for this site."
+				return
+			}
+			check.Status = DOCTOR_PASS
+			check.Details = fmt.Sprintf("%s is reachable over HTTP/2 and HTTP/3", site.url)
+		}(idx, site)
+	}
+	wg.Wait()
+	return checks
+}
+
+// Checks if FFmpeg can be found and returns its version
+func checkFfmpeg() *doctorCheck {
+	check := &doctorCheck{Name: "FFmpeg"}
+	ffmpegPath, err := exec.LookPath(doctorFfmpegPath)
+	if err != nil {
+		check.Status = DOCTOR_WARN
+		check.Details = fmt.Sprintf("%q could not be found, more info => %v", doctorFfmpegPath, err)
+		check.Hint = "FFmpeg is only required to convert Pixiv's ugoira, install it from https://ffmpeg.org/ and add it to your PATH or use the \"--ffmpeg_path\" flag."
+		return check
+	}
+
+	output, err := exec.Command(ffmpegPath, "-version").Output()
+	if err != nil {
+		check.Status = DOCTOR_FAIL
+		check.Details = fmt.Sprintf("%s could not be executed, more info => %v", ffmpegPath, err)
+		check.Hint = "Please reinstall FFmpeg from https://ffmpeg.org/."
+		return check
+	}
+
+	version, _, _ := strings.Cut(string(output), "\n")
+	check.Status = DOCTOR_PASS
+	check.Details = fmt.Sprintf("%s (%s)", strings.TrimSpace(version), ffmpegPath)
+	return check
+}
+
+// Checks if the Google Drive API key given by the "--gdrive_api_key" flag is valid
+func checkGdriveApiKey() *doctorCheck {
+	check := &doctorCheck{Name: "Google Drive API key"}
+	isValid, err := gdrive.ApiKeyIsValid(doctorGdriveApiKey, userAgent)
+	switch {
+	case err != nil:
+		check.Status = DOCTOR_WARN
+		check.Details = fmt.Sprintf("the API key could not be checked, more info => %v", err)
+		check.Hint = "Check your connection to Google Drive and try again."
+	case !isValid:
+		check.Status = DOCTOR_FAIL
+		check.Details = "the API key is invalid"
+		check.Hint = "Create a new API key with the guide at https://github.com/KJHJason/Cultured-Downloader/blob/main/doc/google_api_setup_guide.md"
+	default:
+		check.Status = DOCTOR_PASS
+		check.Details = "the API key is valid"
+	}
+	return check
+}
+
+// Checks the cookie files in the application's cookies folder and the expiry of their session cookies
+func checkCookieFiles() []*doctorCheck {
+	var checks []*doctorCheck
+	for _, site := range []string{utils.FANTIA, utils.PIXIV_FANBOX, utils.PIXIV, utils.KEMONO} {
+		check := &doctorCheck{Name: fmt.Sprintf("Cookie file: %s", utils.GetReadableSiteStr(site))}
+		checks = append(checks, check)
+
+		cookieFilePath := findAutoloadCookieFile(site)
+		if cookieFilePath == "" {
+			check.Status = DOCTOR_WARN
+			check.Details = "no cookie file found"
+			check.Hint = fmt.Sprintf(
+				"Export your cookies to %s if you want to download paid content without giving the cookie flags every run.",
+				utils.GetAutoloadCookieFilePaths(site)[0],
+			)
+			continue
+		}
+
+		cookies, err := utils.ParseNetscapeCookieFile(cookieFilePath, "", site)
+		if err != nil {
+			check.Status = DOCTOR_FAIL
+			check.Details = err.Error()
+			check.Hint = "Export your cookies again or remove the cookie file."
+			continue
+		}
+
+		expiry, hasExpiry := utils.GetEarliestCookieExpiry(cookies)
+		if !hasExpiry {
+			check.Status = DOCTOR_PASS
+			check.Details = fmt.Sprintf("%s has a session cookie without an expiry date", cookieFilePath)
+			continue
+		}
+
+		daysLeft := int(math.Floor(time.Until(expiry).Hours() / 24))
+		expiryStr := expiry.Local().Format("2006-01-02 15:04:05")
+		switch {
+		case expiry.Before(time.Now()):
+			check.Status = DOCTOR_FAIL
+			check.Details = fmt.Sprintf("the session cookie in %s expired on %s", cookieFilePath, expiryStr)
+			check.Hint = "Log in to the site again and export your cookies again."
+		case daysLeft < DOCTOR_COOKIE_WARN_DAYS:
+			check.Status = DOCTOR_WARN
+			check.Details = fmt.Sprintf("the session cookie in %s expires in %d day(s) on %s", cookieFilePath, daysLeft, expiryStr)
+			check.Hint = "Log in to the site again and export your cookies again soon."
+		default:
+			check.Status = DOCTOR_PASS
+			check.Details = fmt.Sprintf("the session cookie in %s expires in %d day(s) on %s", cookieFilePath, daysLeft, expiryStr)
+		}
+	}
+	return checks
+}
+
+// Checks if a file can be created in the directory.
+//
+// If create is true, the directory will be created if it does not exist yet like the program would.
+func checkWritableDir(name, dirPath string, create bool) *doctorCheck {
+	check := &doctorCheck{Name: name}
+	if create {
+		os.MkdirAll(dirPath, 0755)
+	}
+
+	probeFile, err := os.CreateTemp(dirPath, ".cultured_downloader_probe_*")
+	if err != nil {
+		check.Status = DOCTOR_FAIL
+		check.Details = fmt.Sprintf("%s is not writable, more info => %v", dirPath, err)
+		check.Hint = "Check the permissions of the directory or choose another directory with the \"--download-path\" or \"--app-data-dir\" flags."
+		return check
+	}
+	probeFile.Close()
+	os.Remove(probeFile.Name())
+
+	check.Status = DOCTOR_PASS
+	check.Details = fmt.Sprintf("%s is writable", filepath.Clean(dirPath))
+	return check
+}
+
+// Checks the free disk space of the download path
+func checkFreeDiskSpace(downloadPath string) *doctorCheck {
+	check := &doctorCheck{Name: "Free disk space"}
+	freeSpace, err := utils.GetFreeDiskSpace(downloadPath)
+	if err != nil {
+		check.Status = DOCTOR_WARN
+		check.Details = err.Error()
+		return check
+	}
+
+	const gb = 1024 * 1024 * 1024
+	check.Details = fmt.Sprintf("%s free on the disk of %s", utils.FormatBytes(freeSpace), downloadPath)
+	switch {
+	case freeSpace < DOCTOR_DISK_SPACE_FAIL_GB*gb:
+		check.Status = DOCTOR_FAIL
+		check.Hint = "Free up some space or choose a download path on another disk with the \"--download-path\" flag."
+	case freeSpace < DOCTOR_DISK_SPACE_WARN_GB*gb:
+		check.Status = DOCTOR_WARN
+		check.Hint = "The downloads may stop when the disk is full, consider freeing up some space."
+	default:
+		check.Status = DOCTOR_PASS
+	}
+	return check
+}
+
+// Prints a coloured pass, warn, or fail line for each check followed by its hint (if any)
+func printDoctorChecks(checks []*doctorCheck) {
+	for _, check := range checks {
+		line := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(check.Status), check.Name, check.Details)
+		switch check.Status {
+		case DOCTOR_PASS:
+			color.Green(line)
+		case DOCTOR_WARN:
+			color.Yellow(line)
+		default:
+			utils.PrintError(line)
+		}
+		if check.Hint != "" {
+			fmt.Printf("       Hint: %s\n", check.Hint)
+		}
+	}
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(
+		&doctorJson,
+		"json",
+		false,
+		"Print the results as JSON to attach to bug reports.",
+	)
+	doctorCmd.Flags().StringVar(
+		&doctorFfmpegPath,
+		"ffmpeg_path",
+		"ffmpeg",
+		"The path to the FFmpeg executable to check.",
+	)
+	registerFilePathCompletion(doctorCmd, "ffmpeg_path")
+	doctorCmd.Flags().StringVar(
+		&doctorGdriveApiKey,
+		"gdrive_api_key",
+		"",
+		"Google Drive API key to check. The check is skipped if it is not given.",
+	)
+	RootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,211 @@
+package cmds
+
+import (
+	"path/filepath"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/fantia"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox"
+	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dlGdriveApiKey         string
+	dlGdriveServiceAccPath string
+	dlOverwrite            bool
+	dlRedownloadIfSmaller  bool
+	dlLogUrls              bool
+	dlPasswordList         string
+
+	downloadCmd = &cobra.Command{
+		Use:   "download [url]...",
+		Short: "Download from any of the supported URLs",
+		Long: utils.CombineStringsWithNewline(
+			"Download from the given URLs by automatically detecting the site and what the URL points to.",
+			"URLs from different sites can be mixed in one run and will be downloaded one site after another.",
+			"",
+			"Supported URL patterns:",
+			utils.CombineStringsWithNewline(textparser.GetSupportedUrlPatterns()...),
+			"",
+			"Page numbers can be appended to the creator, fanclub, illustrator, and tag URLs, e.g. \"https://fantia.jp/fanclubs/1234; 1-3\".",
+			"",
+			"The URLs can also be given in a text file with the global \"--input-file\" flag",
+			"which additionally supports the following \"site:type:id\" entries:",
+			utils.CombineStringsWithNewline(textparser.GetSupportedIdEntryFormats()...),
+			"",
+			"The site-specific options like the ugoira output format use the defaults of each site's command",
+			"and the \"dl_*\" toggles set in the config file.",
+		),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Run: func(cmd *cobra.Command, args []string) {
+			classified, err := textparser.ClassifyInput(args, inputFilePath)
+			if err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+			cookieFiles := getDownloadCookieFiles(classified)
+			validateDownloadCredentials(classified, cookieFiles)
+
+			dlConfig := &configs.Config{
+				DownloadPath:        getDownloadPath(),
+				FfmpegPath:          pixivFfmpegPath,
+				OverwriteFiles:      dlOverwrite,
+				RedownloadIfSmaller: dlRedownloadIfSmaller,
+				UserAgent:           userAgent,
+				LogUrls:             dlLogUrls,
+				PasswordList:        getPasswordList(dlPasswordList),
+				WriteXattrs:         writeXattrs,
+				WriteMetadata:       writeMetadata,
+				ResumeDownload:      resumeDownload,
+			}
+			gdriveClient := getGdriveClient(dlGdriveApiKey, dlGdriveServiceAccPath, dlConfig)
+
+			utils.PrintWarningMsg()
+			for _, site := range classified.Sites {
+				utils.RUN_STATS.SetSite(site)
+				request.SaveFailedDownloadsOnExit("", site)
+				cookieFile := cookieFiles[site]
+				switch site {
+				case utils.FANTIA:
+					applyConfigDlDefaults(fantiaCmd)
+					classified.FantiaDl.ValidateArgs()
+					fantia.FantiaDownloadProcess(
+						classified.FantiaDl,
+						getFantiaDlOptions(dlConfig, gdriveClient, cookieFile),
+					)
+				case utils.PIXIV_FANBOX:
+					applyConfigDlDefaults(pixivFanboxCmd)
+					classified.PixivFanboxDl.ValidateArgs()
+					pixivfanbox.PixivFanboxDownloadProcess(
+						classified.PixivFanboxDl,
+						getPixivFanboxDlOptions(dlConfig, gdriveClient, cookieFile),
+					)
+				case utils.PIXIV:
+					classified.PixivDl.ValidateArgs()
+					pixivClient := getPixivClient(classified.PixivDl, cookieFile)
+					downloadFromPixiv(cmd, classified.PixivDl, dlConfig, pixivClient, cookieFile, false)
+				case utils.KEMONO:
+					applyConfigDlDefaults(kemonoCmd)
+					classified.KemonoDl.ValidateArgs()
+					kemono.KemonoDownloadProcess(
+						dlConfig,
+						classified.KemonoDl,
+						getKemonoDlOptions(dlConfig, gdriveClient, cookieFile),
+						false,
+					)
+				case utils.GDRIVE:
+					downloadFromGdrive(classified.GdriveUrls, dlConfig, gdriveClient)
+				}
+				request.SaveFailedDownloads("", site)
+			}
+		},
+	}
+)
+
+// Returns the cookie files from the application's cookies folder
+// for the sites of the given URLs that have no session cookie ID given.
+func getDownloadCookieFiles(classified *textparser.ClassifiedUrls) map[string]string {
+	sessions := map[string]string{
+		utils.FANTIA:       fantiaSession,
+		utils.PIXIV_FANBOX: fanboxSession,
+		utils.PIXIV:        pixivSession,
+		utils.KEMONO:       kemonoSession,
+	}
+	cookieFiles := make(map[string]string)
+	for _, site := range classified.Sites {
+		if session, ok := sessions[site]; ok {
+			cookieFiles[site] = getCookieFilePath(site, "", session)
+		}
+	}
+	return cookieFiles
+}
+
+// Checks that the credentials required by the sites of the given URLs
+// are provided before starting any downloads so that the run will not fail halfway.
+func validateDownloadCredentials(classified *textparser.ClassifiedUrls, cookieFiles map[string]string) {
+	for _, site := range classified.Sites {
+		switch site {
+		case utils.PIXIV:
+			if pixivRefreshToken == "" && pixivSession == "" && cookieFiles[utils.PIXIV] == "" {
+				utils.PrintError("You must provide a Pixiv refresh token or session cookie ID to download from the given Pixiv URL(s).")
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+		case utils.KEMONO:
+			if kemonoSession == "" && cookieFiles[utils.KEMONO] == "" {
+				utils.PrintError("You must provide a Kemono Party session cookie ID to download from the given Kemono Party URL(s).")
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+		case utils.GDRIVE:
+			if dlGdriveApiKey == "" && dlGdriveServiceAccPath == "" {
+				utils.PrintError("You must provide a Google Drive API key or service account JSON file to download from the given Google Drive URL(s).")
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+		}
+	}
+}
+
+func downloadFromGdrive(gdriveUrls []string, config *configs.Config, gdriveClient *gdrive.GDrive) {
+	gdriveDlPath := filepath.Join(config.DownloadPath, "Google-Drive")
+	toDownload := make([]*request.ToDownload, 0, len(gdriveUrls))
+	for _, gdriveUrl := range utils.RemoveSliceDuplicates(gdriveUrls) {
+		toDownload = append(toDownload, &request.ToDownload{
+			Url:      gdriveUrl,
+			FilePath: gdriveDlPath,
+		})
+	}
+
+	if err := gdriveClient.DownloadGdriveUrls(toDownload, config); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+}
+
+func init() {
+	downloadCmd.Flags().StringVar(
+		&fantiaSession,
+		"fantia_session",
+		"",
+		"Your Fantia \"_session_id\" cookie value to use for the Fantia URL(s).",
+	)
+	downloadCmd.Flags().StringVar(
+		&fanboxSession,
+		"fanbox_session",
+		"",
+		"Your Pixiv Fanbox \"FANBOXSESSID\" cookie value to use for the Pixiv Fanbox URL(s).",
+	)
+	downloadCmd.Flags().StringVar(
+		&kemonoSession,
+		"kemono_session",
+		"",
+		"Your Kemono Party \"session\" cookie value to use for the Kemono Party URL(s) (required for Kemono Party).",
+	)
+	downloadCmd.Flags().StringVar(
+		&pixivSession,
+		"pixiv_session",
+		"",
+		"Your Pixiv \"PHPSESSID\" cookie value to use for the Pixiv URL(s).",
+	)
+	downloadCmd.Flags().StringVar(
+		&pixivRefreshToken,
+		"pixiv_refresh_token",
+		"",
+		utils.CombineStringsWithNewline(
+			"Your Pixiv refresh token to use for the Pixiv URL(s).",
+			"If provided, it will be used instead of the \"--pixiv_session\" flag.",
+		),
+	)
+	downloadCmd.Flags().StringVar(
+		&pixivFfmpegPath,
+		"ffmpeg_path",
+		"ffmpeg",
+		utils.CombineStringsWithNewline(
+			"Configure the path to the FFmpeg executable for converting Pixiv's ugoira.",
+			"Download Link: https://ffmpeg.org/download.html",
+		),
+	)
+	registerFilePathCompletion(downloadCmd, "ffmpeg_path")
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/fantia"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
 	"github.com/spf13/cobra"
@@ -23,14 +24,24 @@ var (
 	fantiaDlImages             bool
 	fantiaDlAttachments        bool
 	fantiaOverwrite            bool
+	fantiaRedownloadIfSmaller  bool
 	fantiaAutoSolveCaptcha     bool
 	fantiaLogUrls              bool
-	fantiaUserAgent            string
+	fantiaPasswordList         string
+	fantiaRetryFailed          string
+	fantiaSampleSize           int
 	fantiaCmd = &cobra.Command{
 		Use:   "fantia",
 		Short: "Download from Fantia",
 		Long:  "Supports downloads from Fantia Fanclubs and individual posts.",
 		Run: func(cmd *cobra.Command, args []string) {
+			cookieFile := getCookieFilePath(utils.FANTIA, fantiaCookieFile, fantiaSession)
+			if probeOnly {
+				checkCredentials(utils.FANTIA, cookieFile, fantiaGdriveApiKey, userAgent)
+				return
+			}
+			utils.RUN_STATS.SetSite(utils.FANTIA)
+
 			if fantiaDlTextFile != "" {
 				postIds, fanclubInfoSlice := textparser.ParseFantiaTextFile(fantiaDlTextFile)
 				fantiaPostIds = append(fantiaPostIds, postIds...)
@@ -40,21 +51,32 @@ var (
 					fantiaPageNums = append(fantiaPageNums, fanclubInfo.PageNum)
 				}
 			}
+			if inputTargets := getInputFileTargets(utils.FANTIA); inputTargets != nil {
+				fantiaPostIds = append(fantiaPostIds, inputTargets.FantiaDl.PostIds...)
+				fantiaFanclubIds = append(fantiaFanclubIds, inputTargets.FantiaDl.FanclubIds...)
+				fantiaPageNums = append(fantiaPageNums, inputTargets.FantiaDl.FanclubPageNums...)
+			}
 
 			fantiaConfig := &configs.Config{
-				OverwriteFiles: fantiaOverwrite,
-				UserAgent:      fantiaUserAgent,
-				LogUrls:        fantiaLogUrls,
+				DownloadPath:        getDownloadPath(),
+				OverwriteFiles:      fantiaOverwrite,
+				RedownloadIfSmaller: fantiaRedownloadIfSmaller,
+				UserAgent:           userAgent,
+				LogUrls:             fantiaLogUrls,
+				PasswordList:        getPasswordList(fantiaPasswordList),
+				SampleSize:          getSampleSize(fantiaSampleSize),
+				WriteXattrs:         writeXattrs,
+				WriteMetadata:       writeMetadata,
+				ResumeDownload:      resumeDownload,
 			}
 
-			var gdriveClient *gdrive.GDrive
-			if fantiaGdriveApiKey != "" || fantiaGdriveServiceAccPath != "" {
-				gdriveClient = gdrive.GetNewGDrive(
-					fantiaGdriveApiKey,
-					fantiaGdriveServiceAccPath,
-					fantiaConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
+			gdriveClient := getGdriveClient(fantiaGdriveApiKey, fantiaGdriveServiceAccPath, fantiaConfig)
+			if gdriveClient == nil && fantiaDlGdrive && cmd.Flags().Changed("dl_gdrive") {
+				utils.PrintError(
+					"error %d: --dl_gdrive requires a Google Drive API key or service account via --gdrive_api_key or --gdrive_service_acc_path",
+					utils.INPUT_ERROR,
 				)
+				utils.Exit(utils.EXIT_INPUT_ERROR)
 			}
 
 			fantiaDl := &fantia.FantiaDl{
@@ -64,52 +86,58 @@ var (
 			}
 			fantiaDl.ValidateArgs()
 
-			fantiaDlOptions := &fantia.FantiaDlOptions{
-				DlThumbnails:     fantiaDlThumbnails,
-				DlImages:         fantiaDlImages,
-				DlAttachments:    fantiaDlAttachments,
-				DlGdrive:         fantiaDlGdrive,
-				AutoSolveCaptcha: fantiaAutoSolveCaptcha,
-				GdriveClient:     gdriveClient,
-				Configs:          fantiaConfig,
-				SessionCookieId:  fantiaSession,
-			}
-			if fantiaCookieFile != "" {
-				cookies, err := utils.ParseNetscapeCookieFile(
-					fantiaCookieFile,
-					fantiaSession,
+			fantiaDlOptions := getFantiaDlOptions(fantiaConfig, gdriveClient, cookieFile)
+			if fantiaRetryFailed != "" {
+				request.RetryFailedDownloads(
+					fantiaRetryFailed,
 					utils.FANTIA,
+					&request.DlOptions{
+						MaxConcurrency: utils.MAX_CONCURRENT_DOWNLOADS,
+						Cookies:        fantiaDlOptions.SessionCookies,
+						UseHttp3:       false,
+					},
+					fantiaConfig,
 				)
-				if err != nil {
-					utils.LogError(
-						err,
-						"",
-						true,
-						utils.ERROR,
-					)
-				}
-				fantiaDlOptions.SessionCookies = cookies
-			}
-
-			err := fantiaDlOptions.ValidateArgs(fantiaUserAgent)
-			if err != nil {
-				utils.LogError(
-					err,
-					"",
-					true,
-					utils.ERROR,
-				)
+				return
 			}
 
+			request.SaveFailedDownloadsOnExit("", utils.FANTIA)
 			utils.PrintWarningMsg()
 			fantia.FantiaDownloadProcess(
 				fantiaDl,
 				fantiaDlOptions,
 			)
+			request.SaveFailedDownloads("", utils.FANTIA)
 		},
 	}
 )
 
+// Returns the validated Fantia download options from the flags of the "fantia" command
+// and the session cookies from the cookie file if given.
+func getFantiaDlOptions(fantiaConfig *configs.Config, gdriveClient *gdrive.GDrive, cookieFile string) *fantia.FantiaDlOptions {
+	fantiaDlOptions := &fantia.FantiaDlOptions{
+		DlThumbnails:     fantiaDlThumbnails,
+		DlImages:         fantiaDlImages,
+		DlAttachments:    fantiaDlAttachments,
+		DlGdrive:         fantiaDlGdrive,
+		AutoSolveCaptcha: fantiaAutoSolveCaptcha,
+		GdriveClient:     gdriveClient,
+		Configs:          fantiaConfig,
+		SessionCookieId:  fantiaSession,
+		SessionCookies:   getSessionCookies(utils.FANTIA, cookieFile, fantiaSession),
+	}
+	err := fantiaDlOptions.ValidateArgs(userAgent)
+	if err != nil {
+		utils.LogError(
+			err,
+			"",
+			true,
+			utils.ERROR,
+		)
+	}
+	return fantiaDlOptions
+}
+
 func init() {
 	mutlipleIdsMsg := getMultipleIdsMsg()
 	fantiaCmd.Flags().StringVarP(
@@ -152,7 +180,11 @@ func init() {
 		"dl_gdrive",
 		"g",
 		true,
-		"Whether to download the Google Drive links of a post on Fantia.",
+		utils.CombineStringsWithNewline(
+			"Whether to download the Google Drive links of a post on Fantia.",
+			"Requires \"--gdrive_api_key\" or \"--gdrive_service_acc_path\" when set explicitly,",
+			"otherwise the Google Drive links will only be logged.",
+		),
 	)
 	fantiaCmd.Flags().BoolVarP(
 		&fantiaDlThumbnails,
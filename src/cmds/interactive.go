@@ -0,0 +1,461 @@
+package cmds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// interactiveTarget is a type of download target like
+// post IDs that the user can enter in the interactive mode
+type interactiveTarget struct {
+	desc        string
+	flag        string
+	pageNumFlag string
+
+	// only URLs are accepted for the target as the flag takes URLs instead of IDs
+	urlsOnly bool
+
+	// returns the IDs and the page numbers (if any) of the
+	// entered URL if it matches the target, otherwise nil
+	getIds func(inputUrl string, classified *textparser.ClassifiedUrls) ([]string, []string)
+}
+
+// interactiveDlOption is a download option like whether to download images
+type interactiveDlOption struct {
+	desc string
+	flag string
+}
+
+// interactiveSite contains what the user can enter for a site in the interactive mode
+type interactiveSite struct {
+	site      string
+	cmd       *cobra.Command
+	targets   []*interactiveTarget
+	dlOptions []*interactiveDlOption
+
+	// Pixiv supports OAuth refresh tokens in addition to the session cookies
+	hasRefreshToken bool
+	sessionRequired bool
+	hasGdrive       bool
+}
+
+func getInteractiveSites() []*interactiveSite {
+	return []*interactiveSite{
+		{
+			site: utils.FANTIA,
+			cmd:  fantiaCmd,
+			targets: []*interactiveTarget{
+				{
+					desc:        "fanclub IDs or URLs",
+					flag:        "fanclub_id",
+					pageNumFlag: "page_num",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.FantiaDl.FanclubIds, classified.FantiaDl.FanclubPageNums
+					},
+				},
+				{
+					desc: "post IDs or URLs",
+					flag: "post_id",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.FantiaDl.PostIds, nil
+					},
+				},
+			},
+			dlOptions: []*interactiveDlOption{
+				{desc: "thumbnails", flag: "dl_thumbnails"},
+				{desc: "images", flag: "dl_images"},
+				{desc: "attachments", flag: "dl_attachments"},
+				{desc: "Google Drive links", flag: "dl_gdrive"},
+			},
+			hasGdrive: true,
+		},
+		{
+			site: utils.PIXIV_FANBOX,
+			cmd:  pixivFanboxCmd,
+			targets: []*interactiveTarget{
+				{
+					desc:        "creator IDs or URLs",
+					flag:        "creator_id",
+					pageNumFlag: "page_num",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.PixivFanboxDl.CreatorIds, classified.PixivFanboxDl.CreatorPageNums
+					},
+				},
+				{
+					desc: "post IDs or URLs",
+					flag: "post_id",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.PixivFanboxDl.PostIds, nil
+					},
+				},
+			},
+			dlOptions: []*interactiveDlOption{
+				{desc: "thumbnails", flag: "dl_thumbnails"},
+				{desc: "images", flag: "dl_images"},
+				{desc: "attachments", flag: "dl_attachments"},
+				{desc: "Google Drive links", flag: "dl_gdrive"},
+			},
+			hasGdrive: true,
+		},
+		{
+			site: utils.PIXIV,
+			cmd:  pixivCmd,
+			targets: []*interactiveTarget{
+				{
+					desc: "artwork IDs or URLs",
+					flag: "artwork_id",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.PixivDl.ArtworkIds, nil
+					},
+				},
+				{
+					desc:        "illustrator IDs or URLs",
+					flag:        "illustrator_id",
+					pageNumFlag: "illustrator_page_num",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.PixivDl.IllustratorIds, classified.PixivDl.IllustratorPageNums
+					},
+				},
+				{
+					desc:        "tag names or URLs",
+					flag:        "tag_name",
+					pageNumFlag: "tag_page_num",
+					getIds: func(_ string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						return classified.PixivDl.TagNames, classified.PixivDl.TagNamesPageNums
+					},
+				},
+			},
+			hasRefreshToken: true,
+		},
+		{
+			site: utils.KEMONO,
+			cmd:  kemonoCmd,
+			targets: []*interactiveTarget{
+				{
+					desc:        "creator URLs",
+					flag:        "creator_url",
+					pageNumFlag: "page_num",
+					urlsOnly:    true,
+					getIds: func(inputUrl string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						if len(classified.KemonoDl.CreatorsToDl) == 0 {
+							return nil, nil
+						}
+						// remove the page numbers from the URL as they are given in a separate flag
+						creatorUrl := strings.TrimSpace(strings.SplitN(inputUrl, ";", 2)[0])
+						return []string{creatorUrl}, []string{classified.KemonoDl.CreatorsToDl[0].PageNum}
+					},
+				},
+				{
+					desc:     "post URLs",
+					flag:     "post_url",
+					urlsOnly: true,
+					getIds: func(inputUrl string, classified *textparser.ClassifiedUrls) ([]string, []string) {
+						if len(classified.KemonoDl.PostsToDl) == 0 {
+							return nil, nil
+						}
+						return []string{inputUrl}, nil
+					},
+				},
+			},
+			dlOptions: []*interactiveDlOption{
+				{desc: "attachments", flag: "dl_attachments"},
+				{desc: "Google Drive links", flag: "dl_gdrive"},
+			},
+			sessionRequired: true,
+			hasGdrive:       true,
+		},
+	}
+}
+
+// interactiveFlag is a flag value entered by the user in the interactive mode
+type interactiveFlag struct {
+	name  string
+	value string
+}
+
+type interactivePrompter struct {
+	reader *bufio.Reader
+}
+
+// Reads a line of input from the user after printing the given message.
+//
+// Exits the program if there is no more input like when stdin is not a terminal.
+func (p *interactivePrompter) readLine(msg string) string {
+	fmt.Print(msg)
+	lineBytes, err := utils.ReadLine(p.reader)
+	if err != nil {
+		if err == io.EOF {
+			fmt.Println()
+			utils.PrintError("No input was received. The interactive mode requires a terminal to read your answers from.")
+			utils.Exit(utils.EXIT_INPUT_ERROR)
+		}
+		utils.PrintError("error %d: failed to read input, more info => %v", utils.OS_ERROR, err)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	return strings.TrimSpace(string(lineBytes))
+}
+
+// Asks the user to pick one of the given choices and returns the index of the picked choice
+func (p *interactivePrompter) promptChoice(msg string, choices []string) int {
+	fmt.Println(msg)
+	for idx, choice := range choices {
+		fmt.Printf("  %d. %s\n", idx+1, choice)
+	}
+	for {
+		input := p.readLine(fmt.Sprintf("Enter a number (1-%d): ", len(choices)))
+		if num, err := strconv.Atoi(input); err == nil && num >= 1 && num <= len(choices) {
+			return num - 1
+		}
+		utils.PrintError("Please enter a number between 1 and %d.", len(choices))
+	}
+}
+
+// Asks the user a yes or no question where an empty input will be the given default answer
+func (p *interactivePrompter) promptYesNo(msg string, defaultAns bool) bool {
+	suffix := " [Y/n]: "
+	if !defaultAns {
+		suffix = " [y/N]: "
+	}
+	for {
+		switch strings.ToLower(p.readLine(msg + suffix)) {
+		case "":
+			return defaultAns
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		utils.PrintError("Please enter \"y\" or \"n\".")
+	}
+}
+
+// Asks the user for the entries of the given target separated by commas.
+//
+// URLs are converted to the IDs of the target while other entries are used as they are.
+func (p *interactivePrompter) promptTarget(target *interactiveTarget) ([]string, []string) {
+	for {
+		input := p.readLine(fmt.Sprintf("Enter the %s separated by a comma (leave blank to skip): ", target.desc))
+
+		var ids, pageNums []string
+		var invalidEntries []string
+		for _, entry := range strings.Split(input, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			if !strings.HasPrefix(entry, "https://") {
+				if target.urlsOnly {
+					invalidEntries = append(invalidEntries, entry)
+					continue
+				}
+				ids = append(ids, entry)
+				pageNums = append(pageNums, "")
+				continue
+			}
+
+			classified, err := textparser.ClassifyUrls([]string{entry})
+			if err != nil {
+				invalidEntries = append(invalidEntries, entry)
+				continue
+			}
+			entryIds, entryPageNums := target.getIds(entry, classified)
+			if len(entryIds) == 0 {
+				invalidEntries = append(invalidEntries, entry)
+				continue
+			}
+			ids = append(ids, entryIds...)
+			if len(entryPageNums) == 0 {
+				entryPageNums = make([]string, len(entryIds))
+			}
+			pageNums = append(pageNums, entryPageNums...)
+		}
+
+		if len(invalidEntries) == 0 {
+			return ids, pageNums
+		}
+		utils.PrintError(
+			"The following are not valid %s:\n- %s",
+			target.desc,
+			strings.Join(invalidEntries, "\n- "),
+		)
+	}
+}
+
+// Asks the user for the path to download the files to
+// and returns it if the user did not confirm the current download path
+func (p *interactivePrompter) promptDownloadPath() string {
+	currentPath := getDownloadPath()
+	if currentPath == "" {
+		currentPath, _ = os.Getwd()
+	}
+	if p.promptYesNo(fmt.Sprintf("Download the files to %q?", currentPath), true) {
+		return ""
+	}
+
+	for {
+		input := p.readLine("Enter the path to download the files to: ")
+		normalizedPath, err := utils.NormalizeDownloadPath(input)
+		if err == nil {
+			return normalizedPath
+		}
+		utils.PrintError(err.Error())
+	}
+}
+
+// Quotes the given argument if needed so that it can be pasted into a shell
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"'\\$&|;<>()*?!`") {
+		return strconv.Quote(arg)
+	}
+	return arg
+}
+
+// The flags with credentials that should never be printed in the equivalent command
+var secretInteractiveFlags = []string{
+	"refresh_token",
+	"session",
+	"gdrive_api_key",
+}
+
+// Returns the equivalent command of the flags entered in the interactive mode
+//
+// The values of the credentials will be shown as "<saved>" instead.
+func getEquivalentCommand(site *interactiveSite, flags []*interactiveFlag) string {
+	args := []string{quoteArg(os.Args[0]), site.cmd.Name()}
+	for _, flag := range flags {
+		value := flag.value
+		if utils.SliceContains(secretInteractiveFlags, flag.name) {
+			value = "<saved>"
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", flag.name, quoteArg(value)))
+	}
+	return strings.Join(args, " ")
+}
+
+// Walks the user through the download options of a site and runs the
+// command of the site with the entered options as if they were passed as flags.
+//
+// The equivalent command will be printed at the end so that the user can use it next time.
+func runInteractiveMode() {
+	color.Cyan("Welcome to %s! Answer the questions below to start downloading.", utils.Title)
+	fmt.Println("Tip: Press Ctrl+C at any time to exit.")
+	fmt.Println()
+
+	prompter := &interactivePrompter{
+		reader: bufio.NewReader(os.Stdin),
+	}
+	sites := getInteractiveSites()
+	siteNames := make([]string, len(sites))
+	for idx, site := range sites {
+		siteNames[idx] = utils.GetReadableSiteStr(site.site)
+	}
+	site := sites[prompter.promptChoice("Which site do you want to download from?", siteNames)]
+	fmt.Println()
+
+	var flags []*interactiveFlag
+	for {
+		for _, target := range site.targets {
+			ids, pageNums := prompter.promptTarget(target)
+			if len(ids) == 0 {
+				continue
+			}
+			flags = append(flags, &interactiveFlag{name: target.flag, value: strings.Join(ids, ",")})
+			if target.pageNumFlag != "" && strings.Join(pageNums, "") != "" {
+				flags = append(flags, &interactiveFlag{name: target.pageNumFlag, value: strings.Join(pageNums, ",")})
+			}
+		}
+		if len(flags) > 0 {
+			break
+		}
+		utils.PrintError("Please enter at least one thing to download from %s.", utils.GetReadableSiteStr(site.site))
+	}
+	fmt.Println()
+
+	var dlGdriveFlag *interactiveFlag
+	dlDefaults := getConfigDlDefaults(site.site)
+	for _, dlOption := range site.dlOptions {
+		dlOptionVal := prompter.promptYesNo(
+			fmt.Sprintf("Download %s?", dlOption.desc),
+			getDlToggleDefault(dlDefaults, dlOption.flag),
+		)
+		flag := &interactiveFlag{name: dlOption.flag, value: strconv.FormatBool(dlOptionVal)}
+		if dlOption.flag == "dl_gdrive" && dlOptionVal {
+			dlGdriveFlag = flag
+		}
+		flags = append(flags, flag)
+	}
+	if site.hasGdrive && dlGdriveFlag != nil {
+		if gdriveApiKey := prompter.readLine("Enter your Google Drive API key (leave blank to only log the Google Drive links): "); gdriveApiKey != "" {
+			flags = append(flags, &interactiveFlag{name: "gdrive_api_key", value: gdriveApiKey})
+		} else {
+			// the Google Drive links will only be logged without an API key
+			dlGdriveFlag.value = "false"
+		}
+	}
+	fmt.Println()
+
+	hasCredentials := false
+	if site.hasRefreshToken {
+		if refreshToken := prompter.readLine("Enter your Pixiv OAuth refresh token (leave blank to use your cookies instead): "); refreshToken != "" {
+			flags = append(flags, &interactiveFlag{name: "refresh_token", value: refreshToken})
+			hasCredentials = true
+		}
+	}
+	if !hasCredentials {
+		if cookieFile := prompter.readLine("Enter the path to your Netscape cookie file (leave blank to enter your session cookie instead): "); cookieFile != "" {
+			flags = append(flags, &interactiveFlag{name: "cookie_file", value: cookieFile})
+			hasCredentials = true
+		}
+	}
+	for !hasCredentials {
+		sessionMsg := "Enter your session cookie value (leave blank to skip): "
+		if site.sessionRequired {
+			sessionMsg = "Enter your session cookie value (required): "
+		}
+		if session := prompter.readLine(sessionMsg); session != "" {
+			flags = append(flags, &interactiveFlag{name: "session", value: session})
+			hasCredentials = true
+		} else if !site.sessionRequired {
+			break
+		}
+	}
+	fmt.Println()
+
+	if normalizedPath := prompter.promptDownloadPath(); normalizedPath != "" {
+		downloadPathOverride = normalizedPath
+		flags = append(flags, &interactiveFlag{name: "download-path", value: normalizedPath})
+	}
+
+	for _, flag := range flags {
+		if flag.name == "download-path" {
+			continue
+		}
+		if err := site.cmd.Flags().Set(flag.name, flag.value); err != nil {
+			utils.PrintError(
+				"error %d: failed to set the %q flag, more info => %v",
+				utils.INPUT_ERROR,
+				flag.name,
+				err,
+			)
+			utils.Exit(utils.EXIT_INPUT_ERROR)
+		}
+	}
+
+	equivalentCmd := getEquivalentCommand(site, flags)
+	utils.OnExit(func() {
+		fmt.Println()
+		color.Cyan("To run the same download again without the interactive mode, use the command below:")
+		fmt.Println(equivalentCmd)
+	})
+	fmt.Println()
+	site.cmd.Run(site.cmd, nil)
+}
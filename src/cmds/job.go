@@ -0,0 +1,556 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Keys that can be used in a job entry of the job file
+var jobEntryKeys = []string{"name", "site", "urls", "download_path", "options"}
+
+// jobSettings are the settings in the job file shared by all jobs
+type jobSettings struct {
+	DownloadPath string `yaml:"download_path"`
+
+	// The cookie file of each site, e.g. "fanbox: cookies.txt"
+	CookieFiles map[string]string `yaml:"cookie_files"`
+
+	// Applied to the jobs of the sites that support the option
+	Options map[string]any `yaml:"options"`
+}
+
+// Returns the cookie file of the given site in the settings
+// where the site may also be given by its alias like "pixiv_fanbox".
+func (settings *jobSettings) getCookieFile(site string) string {
+	for name, cookieFile := range settings.CookieFiles {
+		if jobSite := getJobSite(name); jobSite != nil && jobSite.site == site {
+			return cookieFile
+		}
+	}
+	return ""
+}
+
+// jobEntry is a job declared in the job file
+type jobEntry struct {
+	Name         string         `yaml:"name"`
+	Site         string         `yaml:"site"`
+	Urls         []string       `yaml:"urls"`
+	DownloadPath string         `yaml:"download_path"`
+	Options      map[string]any `yaml:"options"`
+}
+
+type jobFile struct {
+	Settings jobSettings `yaml:"settings"`
+
+	// Decoded separately to point at the line of the offending job entry in validation errors
+	Jobs []yaml.Node `yaml:"jobs"`
+}
+
+// preparedJob is a validated job with the flag values to run the command of its site with
+type preparedJob struct {
+	name         string
+	site         *interactiveSite
+	downloadPath string
+	flagNames    []string
+	flagValues   map[string][]string
+}
+
+// jobResult is the summary of a job that has been run
+type jobResult struct {
+	name            string
+	site            string
+	postsProcessed  int
+	filesDownloaded int
+	filesSkipped    int
+	filesFailed     int
+	failedItems     int
+}
+
+var (
+	jobFilePath string
+	jobOnly     []string
+	runCmd      = &cobra.Command{
+		Use:   "run",
+		Short: "Run the download jobs declared in a YAML job file",
+		Long: utils.CombineStringsWithNewline(
+			"Run the download jobs declared in a YAML job file one after another and print a combined summary at the end.",
+			"",
+			"The \"options\" of a job are the flags of the site's command without the leading dashes.",
+			"The URLs of a job will be added to the ID flags of the site like \"creator_id\".",
+			"The \"settings\" are shared by all jobs where the options are only applied to the sites that support them.",
+			"",
+			"Example:",
+			"  settings:",
+			"    download_path: /path/to/downloads",
+			"    cookie_files:",
+			"      fanbox: /path/to/fanbox_cookies.txt",
+			"      kemono: /path/to/kemono_cookies.txt",
+			"    options:",
+			"      dl_gdrive: true",
+			"  jobs:",
+			"    - name: weekly-fanbox",
+			"      site: fanbox",
+			"      urls:",
+			"        - https://www.fanbox.cc/@creator; 1-2",
+			"      options:",
+			"        creator_id: [another_creator]",
+			"        page_num: [\"1-3\"]",
+			"    - name: weekly-fantia",
+			"      site: fantia",
+			"      options:",
+			"        fanclub_id: [1234, 5678]",
+			"        dl_thumbnails: false",
+			"",
+			fmt.Sprintf("Supported sites: %s", strings.Join(getJobSiteNames(), ", ")),
+			"",
+			"Note: Errors that stop the site's command like an invalid session cookie will also stop the remaining jobs.",
+		),
+		Run: func(cmd *cobra.Command, args []string) {
+			jobs, errs := loadJobFile(jobFilePath, jobOnly)
+			if len(errs) > 0 {
+				utils.PrintError(
+					"The job file, %q, is invalid:\n- %s",
+					jobFilePath,
+					strings.Join(errs, "\n- "),
+				)
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+
+			results := make([]*jobResult, 0, len(jobs))
+			for idx, job := range jobs {
+				color.Cyan("Running job %q [%d/%d]...", job.name, idx+1, len(jobs))
+				results = append(results, runJob(job))
+				fmt.Println()
+			}
+			printJobSummary(results)
+		},
+	}
+)
+
+func getJobSiteNames() []string {
+	sites := getInteractiveSites()
+	names := make([]string, len(sites))
+	for idx, site := range sites {
+		names[idx] = site.site
+	}
+	return names
+}
+
+// Returns the site of the given name in the job file or nil if the site is not supported
+func getJobSite(name string) *interactiveSite {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "pixiv_fanbox" {
+		name = utils.PIXIV_FANBOX
+	}
+	for _, site := range getInteractiveSites() {
+		if site.site == name {
+			return site
+		}
+	}
+	return nil
+}
+
+// Converts the value of an option in the job file to the values of the flag
+func getOptionValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("value must not be empty")
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("list items must be a string, number, or boolean")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[string]any:
+		return nil, fmt.Errorf("value must be a string, number, boolean, or a list of them")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// Returns the flag of the command with the given name including the
+// persistent flags inherited from the root command like "user_agent".
+func lookupCmdFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if flag := cmd.LocalFlags().Lookup(name); flag != nil {
+		return flag
+	}
+	return cmd.InheritedFlags().Lookup(name)
+}
+
+// Calls fn for each of the local flags and the inherited persistent flags of the command
+func visitCmdFlags(cmd *cobra.Command, fn func(*pflag.Flag)) {
+	cmd.LocalFlags().VisitAll(fn)
+	cmd.InheritedFlags().VisitAll(fn)
+}
+
+// Adds the given flag values to the job if the site's command has the flag.
+//
+// Returns false if the site's command does not have the flag.
+func (job *preparedJob) addFlagValues(name string, values []string) bool {
+	if lookupCmdFlag(job.site.cmd, name) == nil {
+		return false
+	}
+	if _, ok := job.flagValues[name]; !ok {
+		job.flagNames = append(job.flagNames, name)
+	}
+	job.flagValues[name] = append(job.flagValues[name], values...)
+	return true
+}
+
+// Returns true if the job has any IDs, URLs, etc. to download from
+func (job *preparedJob) hasTargets() bool {
+	for _, target := range job.site.targets {
+		if len(job.flagValues[target.flag]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Pads the values of the flag with empty strings to the given length
+func (job *preparedJob) padFlagValues(name string, length int) {
+	for len(job.flagValues[name]) < length {
+		job.addFlagValues(name, []string{""})
+	}
+}
+
+// Adds the IDs of the given URL to the ID flag of the matching target of the job's site
+func (job *preparedJob) addUrl(inputUrl string) error {
+	classified, err := textparser.ClassifyUrls([]string{inputUrl})
+	if err != nil {
+		return err
+	}
+
+	for _, target := range job.site.targets {
+		ids, pageNums := target.getIds(inputUrl, classified)
+		if len(ids) == 0 {
+			continue
+		}
+
+		if target.pageNumFlag != "" {
+			// keep the page numbers aligned with the IDs given in the options
+			job.padFlagValues(target.pageNumFlag, len(job.flagValues[target.flag]))
+			if len(pageNums) < len(ids) {
+				pageNums = append(pageNums, make([]string, len(ids)-len(pageNums))...)
+			}
+			job.addFlagValues(target.pageNumFlag, pageNums)
+		}
+		job.addFlagValues(target.flag, ids)
+		return nil
+	}
+	return fmt.Errorf("%q is not a supported %s URL", inputUrl, utils.GetReadableSiteStr(job.site.site))
+}
+
+// Sets the flags of the job's site command to the values of the job
+//
+// The flags should be saved with newCmdFlagSnapshot() beforehand and restored
+// afterwards so that the flags of a job will not leak into the next one.
+func (job *preparedJob) applyFlags() error {
+	for _, name := range job.flagNames {
+		values := job.flagValues[name]
+		flag := lookupCmdFlag(job.site.cmd, name)
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			if err := sliceValue.Replace(values); err != nil {
+				return fmt.Errorf("invalid value for option %q, more info => %v", name, err)
+			}
+			flag.Changed = true
+			continue
+		}
+
+		if len(values) != 1 {
+			return fmt.Errorf("option %q only accepts a single value", name)
+		}
+		if err := flag.Value.Set(values[0]); err != nil {
+			return fmt.Errorf("invalid value for option %q, more info => %v", name, err)
+		}
+		flag.Changed = true
+	}
+	return nil
+}
+
+// Validates the given job entry node and returns the prepared job with its flag values
+func prepareJob(idx int, node *yaml.Node, settings *jobSettings) (*preparedJob, []string) {
+	jobDesc := fmt.Sprintf("job #%d (line %d)", idx+1, node.Line)
+	if node.Kind != yaml.MappingNode {
+		return nil, []string{fmt.Sprintf("%s: must be a mapping of the job's settings", jobDesc)}
+	}
+
+	var errs []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; !utils.SliceContains(jobEntryKeys, key) {
+			errs = append(errs, fmt.Sprintf(
+				"%s: unknown key %q on line %d, must be one of %s",
+				jobDesc,
+				key,
+				node.Content[i].Line,
+				strings.Join(jobEntryKeys, ", "),
+			))
+		}
+	}
+
+	var entry jobEntry
+	if err := node.Decode(&entry); err != nil {
+		return nil, append(errs, fmt.Sprintf("%s: %v", jobDesc, err))
+	}
+	if entry.Name == "" {
+		entry.Name = fmt.Sprintf("job #%d", idx+1)
+	} else {
+		jobDesc = fmt.Sprintf("job #%d %q (line %d)", idx+1, entry.Name, node.Line)
+	}
+
+	site := getJobSite(entry.Site)
+	if site == nil {
+		return nil, append(errs, fmt.Sprintf(
+			"%s: unsupported site %q, must be one of %s",
+			jobDesc,
+			entry.Site,
+			strings.Join(getJobSiteNames(), ", "),
+		))
+	}
+
+	job := &preparedJob{
+		name:         entry.Name,
+		site:         site,
+		downloadPath: settings.DownloadPath,
+		flagValues:   make(map[string][]string),
+	}
+	if entry.DownloadPath != "" {
+		job.downloadPath = entry.DownloadPath
+	}
+	if job.downloadPath != "" {
+		normalizedPath, err := utils.NormalizeDownloadPath(job.downloadPath)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", jobDesc, err))
+		}
+		job.downloadPath = normalizedPath
+	}
+	// the job's options take precedence over the site's cookie file which takes precedence over the shared options
+	cookieFile := settings.getCookieFile(site.site)
+	if _, ok := entry.Options["cookie_file"]; !ok && cookieFile != "" {
+		job.addFlagValues("cookie_file", []string{cookieFile})
+	}
+	for name, value := range settings.Options {
+		if _, ok := entry.Options[name]; ok {
+			continue
+		}
+		if name == "cookie_file" && cookieFile != "" {
+			continue
+		}
+		if values, err := getOptionValues(value); err == nil {
+			job.addFlagValues(name, values)
+		}
+	}
+	for name, value := range entry.Options {
+		values, err := getOptionValues(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: option %q: %v", jobDesc, name, err))
+			continue
+		}
+		if !job.addFlagValues(name, values) {
+			errs = append(errs, fmt.Sprintf(
+				"%s: unknown option %q for %s, run \"%s --help\" for the available options",
+				jobDesc,
+				name,
+				utils.GetReadableSiteStr(site.site),
+				site.cmd.Name(),
+			))
+		}
+	}
+
+	for _, inputUrl := range entry.Urls {
+		if err := job.addUrl(inputUrl); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", jobDesc, err))
+		}
+	}
+	if !job.hasTargets() && len(entry.Urls) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: nothing to download, please add URLs or ID options", jobDesc))
+	}
+
+	// the values are only parsed by the flags so they are applied and reset to validate them
+	if len(errs) == 0 {
+		snapshot := newCmdFlagSnapshot(site.cmd)
+		if err := job.applyFlags(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", jobDesc, err))
+		}
+		snapshot.restore()
+	}
+	return job, errs
+}
+
+// Reads and validates the job file and returns the jobs to run.
+//
+// If only is not empty, only the jobs with the given names will be returned.
+func loadJobFile(filePath string, only []string) ([]*preparedJob, []string) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("failed to read the job file, more info => %v", err)}
+	}
+
+	var file jobFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, []string{err.Error()}
+	}
+	if len(file.Jobs) == 0 {
+		return nil, []string{"no jobs were declared under \"jobs\""}
+	}
+
+	var errs []string
+	cookieFileSites := make(map[string]string, len(file.Settings.CookieFiles))
+	for name := range file.Settings.CookieFiles {
+		site := getJobSite(name)
+		if site == nil {
+			errs = append(errs, fmt.Sprintf("settings: unsupported site %q in \"cookie_files\"", name))
+			continue
+		}
+		if prevName, ok := cookieFileSites[site.site]; ok {
+			errs = append(errs, fmt.Sprintf(
+				"settings: %q and %q in \"cookie_files\" are the same site",
+				prevName,
+				name,
+			))
+		}
+		cookieFileSites[site.site] = name
+	}
+	for name, value := range file.Settings.Options {
+		if _, err := getOptionValues(value); err != nil {
+			errs = append(errs, fmt.Sprintf("settings: option %q: %v", name, err))
+			continue
+		}
+
+		isSupported := false
+		for _, site := range getInteractiveSites() {
+			if lookupCmdFlag(site.cmd, name) != nil {
+				isSupported = true
+				break
+			}
+		}
+		if !isSupported {
+			errs = append(errs, fmt.Sprintf("settings: option %q is not supported by any site", name))
+		}
+	}
+
+	jobs := make([]*preparedJob, 0, len(file.Jobs))
+	jobNames := make(map[string]bool, len(file.Jobs))
+	for idx := range file.Jobs {
+		job, jobErrs := prepareJob(idx, &file.Jobs[idx], &file.Settings)
+		errs = append(errs, jobErrs...)
+		if job == nil {
+			continue
+		}
+		if jobNames[job.name] {
+			errs = append(errs, fmt.Sprintf("job #%d (line %d): duplicate job name %q", idx+1, file.Jobs[idx].Line, job.name))
+		}
+		jobNames[job.name] = true
+		jobs = append(jobs, job)
+	}
+	if len(errs) > 0 || len(only) == 0 {
+		return jobs, errs
+	}
+
+	for _, name := range only {
+		if !jobNames[name] {
+			errs = append(errs, fmt.Sprintf("--only: no job named %q", name))
+		}
+	}
+	filteredJobs := make([]*preparedJob, 0, len(only))
+	for _, job := range jobs {
+		if utils.SliceContains(only, job.name) {
+			filteredJobs = append(filteredJobs, job)
+		}
+	}
+	return filteredJobs, errs
+}
+
+// Runs the command of the job's site and returns the summary of the job
+func runJob(job *preparedJob) *jobResult {
+	snapshot := newCmdFlagSnapshot(job.site.cmd)
+	defer snapshot.restore()
+	if err := job.applyFlags(); err != nil {
+		// should not happen as the flags were validated when loading the job file
+		utils.PrintError("job %q: %v", job.name, err)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+
+	prevDownloadPath := downloadPathOverride
+	if job.downloadPath != "" {
+		downloadPathOverride = job.downloadPath
+	}
+	before := utils.RUN_STATS.GetReport()
+	job.site.cmd.Run(job.site.cmd, nil)
+	after := utils.RUN_STATS.GetReport()
+	downloadPathOverride = prevDownloadPath
+
+	result := &jobResult{
+		name:        job.name,
+		site:        job.site.site,
+		failedItems: len(after.FailedItems) - len(before.FailedItems),
+	}
+	if afterStats, ok := after.Sites[job.site.site]; ok {
+		beforeStats, ok := before.Sites[job.site.site]
+		if !ok {
+			beforeStats = &utils.SiteStats{}
+		}
+		result.postsProcessed = afterStats.PostsProcessed - beforeStats.PostsProcessed
+		result.filesDownloaded = afterStats.FilesDownloaded - beforeStats.FilesDownloaded
+		result.filesSkipped = afterStats.FilesSkipped - beforeStats.FilesSkipped
+		result.filesFailed = afterStats.FilesFailed - beforeStats.FilesFailed
+	}
+	return result
+}
+
+// Prints the combined summary of the jobs that have been run
+func printJobSummary(results []*jobResult) {
+	color.Cyan("Summary of %d job(s):", len(results))
+	for _, result := range results {
+		msg := fmt.Sprintf(
+			"- %s (%s): %d post(s) processed, %d file(s) downloaded, %d skipped, %d failed",
+			result.name,
+			utils.GetReadableSiteStr(result.site),
+			result.postsProcessed,
+			result.filesDownloaded,
+			result.filesSkipped,
+			result.filesFailed,
+		)
+		if result.failedItems > 0 {
+			color.Yellow("%s (%d failed item(s) in total)", msg, result.failedItems)
+		} else {
+			color.Green(msg)
+		}
+	}
+}
+
+func init() {
+	runCmd.Flags().StringVar(
+		&jobFilePath,
+		"job-file",
+		"",
+		"Path to the YAML job file declaring the download jobs to run.",
+	)
+	runCmd.MarkFlagRequired("job-file")
+	runCmd.MarkFlagFilename("job-file", "yaml", "yml")
+	runCmd.Flags().StringSliceVar(
+		&jobOnly,
+		"only",
+		nil,
+		utils.CombineStringsWithNewline(
+			"Only run the jobs with the given names.",
+			"For multiple jobs, separate them with a comma or repeat the flag.",
+		),
+	)
+	RootCmd.AddCommand(runCmd)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
 	"github.com/spf13/cobra"
@@ -21,28 +22,35 @@ var (
 	kemonoGdriveServiceAccPath string
 	kemonoDlAttachments        bool
 	kemonoOverwrite            bool
+	kemonoRedownloadIfSmaller  bool
 	kemonoLogUrls              bool
 	kemonoDlFav                bool
-	kemonoUserAgent            string
+	kemonoPasswordList         string
+	kemonoRetryFailed          string
 	kemonoCmd = &cobra.Command{
 		Use:   "kemono",
 		Short: "Download from Kemono Party",
 		Long:  "Supports downloads from creators and posts on Kemono Party.",
 		Run: func(cmd *cobra.Command, args []string) {
-			kemonoConfig := &configs.Config{
-				OverwriteFiles: kemonoOverwrite,
-				UserAgent:      kemonoUserAgent,
-				LogUrls:        kemonoLogUrls,
+			cookieFile := getCookieFilePath(utils.KEMONO, kemonoCookieFile, kemonoSession)
+			if probeOnly {
+				checkCredentials(utils.KEMONO, cookieFile, kemonoGdriveApiKey, userAgent)
+				return
 			}
-			var gdriveClient *gdrive.GDrive
-			if kemonoGdriveApiKey != "" || kemonoGdriveServiceAccPath != "" {
-				gdriveClient = gdrive.GetNewGDrive(
-					kemonoGdriveApiKey,
-					kemonoGdriveServiceAccPath,
-					kemonoConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
-				)
+			utils.RUN_STATS.SetSite(utils.KEMONO)
+
+			kemonoConfig := &configs.Config{
+				DownloadPath:        getDownloadPath(),
+				OverwriteFiles:      kemonoOverwrite,
+				RedownloadIfSmaller: kemonoRedownloadIfSmaller,
+				UserAgent:           userAgent,
+				LogUrls:             kemonoLogUrls,
+				PasswordList:        getPasswordList(kemonoPasswordList),
+				WriteXattrs:         writeXattrs,
+				WriteMetadata:       writeMetadata,
+				ResumeDownload:      resumeDownload,
 			}
+			gdriveClient := getGdriveClient(kemonoGdriveApiKey, kemonoGdriveServiceAccPath, kemonoConfig)
 
 			kemonoDl := &kemono.KemonoDl{
 				CreatorUrls:     kemonoCreatorUrls,
@@ -54,34 +62,29 @@ var (
 				kemonoDl.PostsToDl = kemonoPostToDl
 				kemonoDl.CreatorsToDl = kemonoCreatorToDl
 			}
+			if inputTargets := getInputFileTargets(utils.KEMONO); inputTargets != nil {
+				kemonoDl.PostsToDl = append(kemonoDl.PostsToDl, inputTargets.KemonoDl.PostsToDl...)
+				kemonoDl.CreatorsToDl = append(kemonoDl.CreatorsToDl, inputTargets.KemonoDl.CreatorsToDl...)
+			}
 			kemonoDl.ValidateArgs()
 
-			kemonoDlOptions := &kemono.KemonoDlOptions{
-				DlAttachments:   kemonoDlAttachments,
-				DlGdrive:        kemonoDlGdrive,
-				Configs:         kemonoConfig,
-				SessionCookieId: kemonoSession,
-				GdriveClient:    gdriveClient,
-			}
-			if kemonoCookieFile != "" {
-				cookies, err := utils.ParseNetscapeCookieFile(
-					kemonoCookieFile,
-					kemonoSession,
+			kemonoDlOptions := getKemonoDlOptions(kemonoConfig, gdriveClient, cookieFile)
+
+			if kemonoRetryFailed != "" {
+				request.RetryFailedDownloads(
+					kemonoRetryFailed,
 					utils.KEMONO,
+					&request.DlOptions{
+						MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+						Cookies:        kemonoDlOptions.SessionCookies,
+						UseHttp3:       utils.IsHttp3Supported(utils.KEMONO, false),
+					},
+					kemonoConfig,
 				)
-				if err != nil {
-					utils.LogError(
-						err,
-						"",
-						true,
-						utils.ERROR,
-					)
-				}
-				kemonoDlOptions.SessionCookies = cookies
+				return
 			}
 
-			kemonoDlOptions.ValidateArgs(kemonoUserAgent)
-
+			request.SaveFailedDownloadsOnExit("", utils.KEMONO)
 			utils.PrintWarningMsg()
 			kemono.KemonoDownloadProcess(
 				kemonoConfig,
@@ -89,10 +92,26 @@ var (
 				kemonoDlOptions,
 				kemonoDlFav,
 			)
+			request.SaveFailedDownloads("", utils.KEMONO)
 		},
 	}
 )
 
+// Returns the validated Kemono Party download options from the flags of the "kemono" command
+// and the session cookies from the cookie file if given.
+func getKemonoDlOptions(kemonoConfig *configs.Config, gdriveClient *gdrive.GDrive, cookieFile string) *kemono.KemonoDlOptions {
+	kemonoDlOptions := &kemono.KemonoDlOptions{
+		DlAttachments:   kemonoDlAttachments,
+		DlGdrive:        kemonoDlGdrive,
+		Configs:         kemonoConfig,
+		SessionCookieId: kemonoSession,
+		SessionCookies:  getSessionCookies(utils.KEMONO, cookieFile, kemonoSession),
+		GdriveClient:    gdriveClient,
+	}
+	kemonoDlOptions.ValidateArgs(userAgent)
+	return kemonoDlOptions
+}
+
 func init() {
 	mutlipleUrlsMsg := "Multiple URLs can be supplied by separating them with a comma.\n" + 
 						"Example: \"https://kemono.party/service/user/123,https://kemono.party/service/user/456\" (without the quotes)"
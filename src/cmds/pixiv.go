@@ -2,20 +2,27 @@ package cmds
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/web"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/mobile"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/ugoira"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
 	"github.com/spf13/cobra"
 	"github.com/fatih/color"
 )
 
+// The bookmarks that can be downloaded with the "--bookmark_restrict" flag
+var ACCEPTED_BOOKMARK_RESTRICTS = []string{
+	"public",
+	"private",
+}
+
 var (
 	pixivDlTextFile          string
 	pixivCookieFile          string
@@ -23,45 +30,87 @@ var (
 	pixivStartOauth          bool
 	pixivRefreshToken        string
 	pixivSession             string
+	pixivPrefer              string
 	deleteUgoiraZip          bool
 	ugoiraQuality            int
 	ugoiraOutputFormat       string
+	ugoiraPreferDirect       bool
 	pixivArtworkIds          []string
 	pixivIllustratorIds      []string
 	pixivIllustratorPageNums []string
 	pixivTagNames            []string
 	pixivPageNums            []string
+	pixivNovelIds            []string
+	pixivNovelistIds         []string
+	pixivNovelistPageNums    []string
+	pixivNovelSeriesIds      []string
+	pixivBookmarksUserId     string
+	pixivSeriesIds           []string
+	pixivRankingMode         string
+	pixivRankingDate         string
+	pixivRankingPageNum      string
+	pixivFollowing           bool
+	pixivFollowingPageNum    string
+	pixivBookmarksPageNum    string
+	pixivBookmarkRestrict    string
 	pixivSortOrder           string
+	pixivMaxCoverage         bool
+	pixivExcludeTags         []string
+	pixivMinBookmarks        int
+	pixivMaxArtworks         int
+	pixivNoAi                bool
+	pixivTranslateTags       bool
+	pixivSearchStartDate     string
+	pixivSearchEndDate       string
 	pixivSearchMode          string
 	pixivRatingMode          string
 	pixivArtworkType         string
 	pixivOverwrite           bool
-	pixivUserAgent           string
+	pixivRedownloadIfSmaller bool
+	pixivRetryFailed         string
+	pixivSampleSize          int
+	pixivArtworkPages        string
+	pixivDelayMin            float64
+	pixivDelayMax            float64
+	pixivMaxBackoff          float64
+	pixivMobileConcurrency   int
+	pixivWhitelistFile       string
+	pixivArtworkDlPath       string
+	pixivIllustratorDlPath   string
+	pixivDlProfileImages     bool
+	pixivTagDlPath           string
 	pixivCmd = &cobra.Command{
 		Use:   "pixiv",
 		Short: "Download from Pixiv",
 		Long:  "Supports downloads from Pixiv by artwork ID, illustrator ID, tag name, and more.",
 		Run: func(cmd *cobra.Command, args []string) {
+			cookieFile := getCookieFilePath(utils.PIXIV, pixivCookieFile, pixivSession)
+			if probeOnly {
+				checkCredentials(utils.PIXIV, cookieFile, "", userAgent)
+				return
+			}
+			utils.RUN_STATS.SetSite(utils.PIXIV)
+
 			if pixivStartOauth {
-				err := pixivmobile.NewPixivMobile("", 10).StartOauthFlow()
-				if err != nil {
-					utils.LogError(
-						err,
-						"",
-						true,
-						utils.ERROR,
-					)
-				}
+				runPixivOauthFlow()
 				return
 			}
 
 			pixivConfig := &configs.Config{
-				FfmpegPath:     pixivFfmpegPath,
-				OverwriteFiles: pixivOverwrite,
-				UserAgent:      pixivUserAgent,
+				DownloadPath:        getDownloadPath(),
+				FfmpegPath:          pixivFfmpegPath,
+				OverwriteFiles:      pixivOverwrite,
+				RedownloadIfSmaller: pixivRedownloadIfSmaller,
+				UserAgent:           userAgent,
+				SampleSize:          getSampleSize(pixivSampleSize),
+				WriteXattrs:         writeXattrs,
+				WriteMetadata:       writeMetadata,
+				ResumeDownload:      resumeDownload,
 			}
-			pixivConfig.ValidateFfmpeg()
 
+			// the IDs can also be given as the URLs copied from the browser
+			pixivArtworkIds = textparser.ConvertPixivArtworkUrlArgs(pixivArtworkIds)
+			pixivIllustratorIds = textparser.ConvertPixivIllustratorUrlArgs(pixivIllustratorIds)
 			if pixivDlTextFile != "" {
 				artworkIds, illustratorInfoSlice, tagInfoSlice := textparser.ParsePixivTextFile(pixivDlTextFile)
 				pixivArtworkIds = append(pixivArtworkIds, artworkIds...)
@@ -76,79 +125,352 @@ var (
 					pixivPageNums = append(pixivPageNums, tagInfo.PageNum)
 				}
 			}
+			if inputTargets := getInputFileTargets(utils.PIXIV); inputTargets != nil {
+				pixivArtworkIds = append(pixivArtworkIds, inputTargets.PixivDl.ArtworkIds...)
+				pixivIllustratorIds = append(pixivIllustratorIds, inputTargets.PixivDl.IllustratorIds...)
+				pixivIllustratorPageNums = append(pixivIllustratorPageNums, inputTargets.PixivDl.IllustratorPageNums...)
+				pixivTagNames = append(pixivTagNames, inputTargets.PixivDl.TagNames...)
+				pixivPageNums = append(pixivPageNums, inputTargets.PixivDl.TagNamesPageNums...)
+			}
+			pixivBookmarkRestrict = strings.ToLower(strings.TrimSpace(pixivBookmarkRestrict))
+			utils.ValidateStrArgs(
+				pixivBookmarkRestrict,
+				ACCEPTED_BOOKMARK_RESTRICTS,
+				[]string{
+					fmt.Sprintf(
+						"pixiv error %d: bookmark restrict %s is not allowed",
+						utils.INPUT_ERROR,
+						pixivBookmarkRestrict,
+					),
+				},
+			)
 			pixivDl := &pixiv.PixivDl{
-				ArtworkIds:          pixivArtworkIds,
-				IllustratorIds:      pixivIllustratorIds,
-				IllustratorPageNums: pixivIllustratorPageNums,
-				TagNames:            pixivTagNames,
-				TagNamesPageNums:    pixivPageNums,
+				ArtworkIds:           pixivArtworkIds,
+				IllustratorIds:       pixivIllustratorIds,
+				IllustratorPageNums:  pixivIllustratorPageNums,
+				DlIllustratorProfile: pixivDlProfileImages,
+				TagNames:             pixivTagNames,
+				TagNamesPageNums:     pixivPageNums,
+				NovelIds:             pixivNovelIds,
+				NovelistIds:          pixivNovelistIds,
+				NovelistPageNums:     pixivNovelistPageNums,
+				NovelSeriesIds:       pixivNovelSeriesIds,
+				BookmarksUserId:      pixivBookmarksUserId,
+				BookmarksPageNum:     pixivBookmarksPageNum,
+				PrivateBookmarks:     pixivBookmarkRestrict == "private",
+				SeriesIds:            pixivSeriesIds,
+				Following:            pixivFollowing,
+				FollowingPageNum:     pixivFollowingPageNum,
+				RankingMode:          pixivRankingMode,
+				RankingDate:          pixivRankingDate,
+				RankingPageNum:       pixivRankingPageNum,
+				ArtworkDlPath:        getSourceDownloadPath(pixivArtworkDlPath),
+				IllustratorDlPath:    getSourceDownloadPath(pixivIllustratorDlPath),
+				TagDlPath:            getSourceDownloadPath(pixivTagDlPath),
 			}
 			pixivDl.ValidateArgs()
 
-			pixivUgoiraOptions := &ugoira.UgoiraOptions{
-				DeleteZip:    deleteUgoiraZip,
-				Quality:      ugoiraQuality,
-				OutputFormat: ugoiraOutputFormat,
-			}
-			pixivUgoiraOptions.ValidateArgs()
-
-			if pixivRefreshToken == "" && pixivSession == "" {
-				color.Red("You must provide a refresh token or session cookie ID to download from Pixiv.")
-				os.Exit(1)
+			if pixivRetryFailed != "" {
+				retryPixivFailedDownloads(pixivConfig)
+				return
 			}
 
-			utils.PrintWarningMsg()
-			if pixivRefreshToken != "" {
-				pixivDlOptions := &pixivmobile.PixivMobileDlOptions{
-					SortOrder:       pixivSortOrder,
-					SearchMode:      pixivSearchMode,
-					RatingMode:      pixivRatingMode,
-					ArtworkType:     pixivArtworkType,
-					Configs:         pixivConfig,
-					RefreshToken:    pixivRefreshToken,
-				}
-				pixivDlOptions.ValidateArgs(pixivUserAgent)
-				pixiv.PixivMobileDownloadProcess(
-					pixivDl,
-					pixivDlOptions,
-					pixivUgoiraOptions,
-				)
-			} else {
-				pixivDlOptions := &pixivweb.PixivWebDlOptions{
-					SortOrder:       pixivSortOrder,
-					SearchMode:      pixivSearchMode,
-					RatingMode:      pixivRatingMode,
-					ArtworkType:     pixivArtworkType,
-					Configs:         pixivConfig,
-					SessionCookieId: pixivSession,
+			usingSavedToken := false
+			// novels can only be downloaded with the refresh token
+			if pixivRefreshToken == "" && ((pixivSession == "" && cookieFile == "") || pixivDl.HasNovels()) {
+				if refreshToken := getSavedPixivRefreshToken(); refreshToken != "" {
+					pixivRefreshToken = refreshToken
+					usingSavedToken = true
+					color.Yellow("No credentials were given, the Pixiv refresh token saved in the config file will be used.")
 				}
-				if pixivCookieFile != "" {
-					cookies, err := utils.ParseNetscapeCookieFile(
-						pixivCookieFile,
-						pixivSession,
-						utils.PIXIV,
-					)
-					if err != nil {
-						utils.LogError(
-							err,
-							"",
-							true,
-							utils.ERROR,
-						)
-					}
-					pixivDlOptions.SessionCookies = cookies
-				}
-				pixivDlOptions.ValidateArgs(pixivUserAgent)
-				pixiv.PixivWebDownloadProcess(
-					pixivDl,
-					pixivDlOptions,
-					pixivUgoiraOptions,
-				)
 			}
+			pixivClient := getPixivClient(pixivDl, cookieFile)
+			request.SaveFailedDownloadsOnExit("", utils.PIXIV)
+			utils.PrintWarningMsg()
+			downloadFromPixiv(cmd, pixivDl, pixivConfig, pixivClient, cookieFile, usingSavedToken)
+			request.SaveFailedDownloads("", utils.PIXIV)
 		},
 	}
 )
 
+// Returns the validated ugoira options from the flags of the "pixiv" command.
+//
+// FFmpeg will only be validated if any of the artworks to download can be an ugoira.
+func getPixivUgoiraOptions(pixivDl *pixiv.PixivDl, pixivConfig *configs.Config) *ugoira.UgoiraOptions {
+	pixivUgoiraOptions := &ugoira.UgoiraOptions{
+		DeleteZip:    deleteUgoiraZip,
+		Quality:      ugoiraQuality,
+		OutputFormat: ugoiraOutputFormat,
+	}
+	pixivUgoiraOptions.ValidateArgs()
+	if pixivDl.HasArtworks() && pixivArtworkType != "manga" {
+		pixivUgoiraOptions.ValidateFfmpeg(pixivConfig)
+	}
+	return pixivUgoiraOptions
+}
+
+// Returns the artwork pages to download from the "--artwork_pages" flag
+func getPixivArtworkPages() *pixivcommon.ArtworkPages {
+	artworkPages, err := pixivcommon.ParseArtworkPages(pixivArtworkPages)
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	return artworkPages
+}
+
+// Returns the validated Pixiv mobile API download options from the flags of the "pixiv" command
+func getPixivMobileDlOptions(cmd *cobra.Command, pixivConfig *configs.Config) *pixivmobile.PixivMobileDlOptions {
+	mobileMinSleep, mobileMaxSleep := getPixivSleepRange(
+		cmd,
+		pixivmobile.DEFAULT_MIN_SLEEP,
+		pixivmobile.DEFAULT_MAX_SLEEP,
+	)
+	pixivDlOptions := &pixivmobile.PixivMobileDlOptions{
+		SortOrder:            pixivSortOrder,
+		MaxCoverage:          pixivMaxCoverage,
+		SearchMode:           pixivSearchMode,
+		RatingMode:           pixivRatingMode,
+		ArtworkType:          pixivArtworkType,
+		Configs:              pixivConfig,
+		RefreshToken:         pixivRefreshToken,
+		UgoiraPreferDirect:   ugoiraPreferDirect,
+		IllustratorWhitelist: getIllustratorWhitelist(pixivWhitelistFile),
+		ExcludeTags:          pixivExcludeTags,
+		MinBookmarks:         pixivMinBookmarks,
+		MaxArtworks:          pixivMaxArtworks,
+		NoAi:                 pixivNoAi,
+		TranslateTags:        pixivTranslateTags,
+		SearchStartDate:      pixivSearchStartDate,
+		SearchEndDate:        pixivSearchEndDate,
+		ArtworkPages:         getPixivArtworkPages(),
+		MinSleep:             mobileMinSleep,
+		MaxSleep:             mobileMaxSleep,
+		ApiConcurrency:       pixivMobileConcurrency,
+	}
+	pixivDlOptions.ValidateArgs(userAgent)
+	return pixivDlOptions
+}
+
+// Returns the validated Pixiv web download options from the flags of the "pixiv" command
+// and the session cookies from the cookie file if given.
+func getPixivWebDlOptions(cmd *cobra.Command, pixivConfig *configs.Config, cookieFile string) *pixivweb.PixivWebDlOptions {
+	webMinSleep, webMaxSleep := getPixivSleepRange(
+		cmd,
+		pixivweb.DEFAULT_MIN_SLEEP,
+		pixivweb.DEFAULT_MAX_SLEEP,
+	)
+	pixivDlOptions := &pixivweb.PixivWebDlOptions{
+		SortOrder:            pixivSortOrder,
+		MaxCoverage:          pixivMaxCoverage,
+		SearchMode:           pixivSearchMode,
+		RatingMode:           pixivRatingMode,
+		ArtworkType:          pixivArtworkType,
+		Configs:              pixivConfig,
+		SessionCookieId:      pixivSession,
+		SessionCookies:       getSessionCookies(utils.PIXIV, cookieFile, pixivSession),
+		UgoiraPreferDirect:   ugoiraPreferDirect,
+		IllustratorWhitelist: getIllustratorWhitelist(pixivWhitelistFile),
+		ExcludeTags:          pixivExcludeTags,
+		MinBookmarks:         pixivMinBookmarks,
+		MaxArtworks:          pixivMaxArtworks,
+		NoAi:                 pixivNoAi,
+		TranslateTags:        pixivTranslateTags,
+		SearchStartDate:      pixivSearchStartDate,
+		SearchEndDate:        pixivSearchEndDate,
+		ArtworkPages:         getPixivArtworkPages(),
+		MinSleep:             webMinSleep,
+		MaxSleep:             webMaxSleep,
+		MaxBackoff:           pixivMaxBackoff,
+	}
+	pixivDlOptions.ValidateArgs(userAgent)
+	return pixivDlOptions
+}
+
+// Downloads the Pixiv artworks with the given client using the options from the flags of the "pixiv" command
+func downloadFromPixiv(cmd *cobra.Command, pixivDl *pixiv.PixivDl, pixivConfig *configs.Config, pixivClient, cookieFile string, usingSavedToken bool) {
+	pixivUgoiraOptions := getPixivUgoiraOptions(pixivDl, pixivConfig)
+	if pixivClient == pixiv.PIXIV_CLIENT_MOBILE {
+		pixivDlOptions := getPixivMobileDlOptions(cmd, pixivConfig)
+		if usingSavedToken {
+			verifySavedPixivRefreshToken(pixivDlOptions.MobileClient)
+		}
+		pixiv.PixivMobileDownloadProcess(
+			pixivDl,
+			pixivDlOptions,
+			pixivUgoiraOptions,
+		)
+		return
+	}
+
+	pixiv.PixivWebDownloadProcess(
+		pixivDl,
+		getPixivWebDlOptions(cmd, pixivConfig, cookieFile),
+		pixivUgoiraOptions,
+	)
+}
+
+// Returns the Pixiv client to use based on the refresh token and the session cookie given by the user.
+//
+// Warns the user which client will be used if both were given and exits the program
+// if neither was given but downloading the artworks requires the user to be logged in.
+func getPixivClient(pixivDl *pixiv.PixivDl, cookieFile string) string {
+	pixivPrefer = strings.ToLower(strings.TrimSpace(pixivPrefer))
+	utils.ValidateStrArgs(
+		pixivPrefer,
+		pixiv.ACCEPTED_PIXIV_CLIENTS,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Pixiv client %s is not allowed",
+				utils.INPUT_ERROR,
+				pixivPrefer,
+			),
+		},
+	)
+
+	hasRefreshToken := pixivRefreshToken != ""
+	hasSession := pixivSession != "" || cookieFile != ""
+	pixivClient := pixiv.GetPixivClient(hasRefreshToken, hasSession, pixivPrefer)
+	switch {
+	case pixivDl.HasNovels() && pixivDl.RequiresWebClient():
+		utils.PrintError(
+			"pixiv error %d: novels cannot be downloaded in the same run as rankings, manga series, or followed illustrators' artworks as they require different Pixiv clients.",
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivDl.HasNovels() && !hasRefreshToken:
+		utils.PrintError(
+			utils.CombineStringsWithNewline(
+				"pixiv error %d: downloading novels requires your Pixiv refresh token.",
+				"Please provide it via the \"--refresh_token\" flag or run the \"pixiv oauth\" command to get and save it.",
+			),
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivDl.HasNovels() && pixivClient != pixiv.PIXIV_CLIENT_MOBILE:
+		color.Yellow(
+			"Downloading novels is only supported by the mobile client, the mobile client will be used instead of the web client.",
+		)
+		pixivClient = pixiv.PIXIV_CLIENT_MOBILE
+	case pixivDl.HasBookmarks() && !hasSession && !hasRefreshToken:
+		utils.PrintError(
+			utils.CombineStringsWithNewline(
+				"pixiv error %d: downloading bookmarks requires you to be logged in to Pixiv.",
+				"Please provide one of the following:",
+				"- your refresh token via the \"--refresh_token\" flag (run the \"pixiv oauth\" command to get it)",
+				"- your \"PHPSESSID\" cookie via the \"--session\" flag",
+				"- your exported cookies via the \"--cookie_file\" flag",
+			),
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivDl.Following && !hasSession:
+		utils.PrintError(
+			utils.CombineStringsWithNewline(
+				"pixiv error %d: downloading the latest artworks from your followed illustrators requires you to be logged in to Pixiv's website.",
+				"Please provide one of the following:",
+				"- your \"PHPSESSID\" cookie via the \"--session\" flag",
+				"- your exported cookies via the \"--cookie_file\" flag",
+			),
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivDl.HasR18Ranking() && !hasSession:
+		utils.PrintError(
+			utils.CombineStringsWithNewline(
+				"pixiv error %d: downloading the R-18 rankings requires you to be logged in to Pixiv's website.",
+				"Please provide one of the following:",
+				"- your \"PHPSESSID\" cookie via the \"--session\" flag",
+				"- your exported cookies via the \"--cookie_file\" flag",
+			),
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivDl.RequiresWebClient() && pixivClient == pixiv.PIXIV_CLIENT_MOBILE:
+		color.Yellow(
+			"Downloading rankings, manga series, and followed illustrators' artworks is only supported by the web client, the web client will be used instead of the mobile client.",
+		)
+		pixivClient = pixiv.PIXIV_CLIENT_WEB
+	case hasRefreshToken && hasSession:
+		color.Yellow(
+			"Both the refresh token and the session cookie were given, the %s client will be used.\nUse the \"--prefer\" flag to choose which client to use.",
+			pixivClient,
+		)
+	case pixivClient == "" && pixivDl.RequiresAuth(pixivRatingMode):
+		utils.PrintError(
+			utils.CombineStringsWithNewline(
+				"pixiv error %d: searching for artworks by tag names requires you to be logged in to Pixiv unless \"--rating_mode\" is \"safe\".",
+				"Please provide one of the following:",
+				"- your refresh token via the \"--refresh_token\" flag (run the \"pixiv oauth\" command to get it)",
+				"- your \"PHPSESSID\" cookie via the \"--session\" flag",
+				"- your exported cookies via the \"--cookie_file\" flag",
+			),
+			utils.INPUT_ERROR,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	case pixivClient == "":
+		color.Yellow(
+			"No refresh token or session cookie was given, R-18 artworks cannot be downloaded without logging in to Pixiv.",
+		)
+		pixivClient = pixiv.PIXIV_CLIENT_WEB
+	}
+	return pixivClient
+}
+
+// Returns the range of the random delay in seconds between requests to Pixiv from
+// "--pixiv_delay_min" and "--pixiv_delay_max" or the default values of the client if not given.
+//
+// If only one of the flags was given and it is outside the default range,
+// the other value will be set to the same value so that the range is still valid.
+func getPixivSleepRange(cmd *cobra.Command, defaultMin, defaultMax float64) (float64, float64) {
+	minChanged := cmd.Flags().Changed("pixiv_delay_min")
+	maxChanged := cmd.Flags().Changed("pixiv_delay_max")
+	switch {
+	case minChanged && maxChanged:
+		return pixivDelayMin, pixivDelayMax
+	case minChanged:
+		return pixivDelayMin, max(pixivDelayMin, defaultMax)
+	case maxChanged:
+		return min(pixivDelayMax, defaultMin), pixivDelayMax
+	}
+	return defaultMin, defaultMax
+}
+
+// Reads the illustrator IDs from the "--whitelist_file" file if the user has provided one
+func getIllustratorWhitelist(whitelistFilePath string) []string {
+	if whitelistFilePath == "" {
+		return nil
+	}
+
+	illustratorIds, err := utils.ReadIdsFromFile(whitelistFilePath)
+	if err != nil {
+		utils.LogError(err, "", true, utils.ERROR)
+	}
+	if len(illustratorIds) == 0 {
+		utils.PrintError("pixiv error %d: no illustrator IDs found in the whitelist file at %s", utils.INPUT_ERROR, whitelistFilePath)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	utils.ValidateIds(illustratorIds)
+	return illustratorIds
+}
+
+// Re-downloads the failed artworks in the failures file.
+//
+// Pixiv's image server only checks the Referer header
+// hence, there is no need to verify the user's session or refresh token.
+func retryPixivFailedDownloads(pixivConfig *configs.Config) {
+	request.RetryFailedDownloads(
+		pixivRetryFailed,
+		utils.PIXIV,
+		&request.DlOptions{
+			MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+			Headers:        pixivcommon.GetPixivRequestHeaders(),
+			UseHttp3:       false,
+		},
+		pixivConfig,
+	)
+}
+
 func init() {
 	mutlipleIdsMsg := getMultipleIdsMsg()
 	pixivCmd.Flags().StringVar(
@@ -166,6 +488,10 @@ func init() {
 		false,
 		"Whether to start the Pixiv OAuth process to get one's refresh token.",
 	)
+	pixivCmd.Flags().MarkDeprecated(
+		"start_oauth",
+		"use the \"pixiv oauth\" command instead, this flag will be removed in the next release",
+	)
 	pixivCmd.Flags().StringVarP(
 		&pixivRefreshToken,
 		"refresh_token",
@@ -177,7 +503,8 @@ func init() {
 			"instead of the \"--session\" flag as there will be significantly lesser API calls to Pixiv.",
 			"However, if you prefer more flexibility with your Pixiv downloads, you can use",
 			"the \"--session\" flag instead at the expense of longer API call time due to Pixiv's rate limiting.",
-			"Note that you can get your refresh token by running the program with the \"--start_oauth\" flag.",
+			"Note that you can get your refresh token by running the \"pixiv oauth\" command",
+			"which saves it to the config file to be used when no credentials are given.",
 		),
 	)
 	pixivCmd.Flags().StringVarP(
@@ -187,12 +514,23 @@ func init() {
 		"",
 		"Your \"PHPSESSID\" cookie value to use for the requests to Pixiv.",
 	)
+	pixivCmd.Flags().StringVar(
+		&pixivPrefer,
+		"prefer",
+		pixiv.PIXIV_CLIENT_MOBILE,
+		utils.CombineStringsWithNewline(
+			"The Pixiv client to use if both the refresh token and the session cookie (or cookie file) are given.",
+			"Client Options:",
+			"- mobile: Use Pixiv's mobile API with the \"--refresh_token\" flag",
+			"- web: Use Pixiv's web API with the \"--session\" or \"--cookie_file\" flag",
+		),
+	)
 	pixivCmd.Flags().BoolVarP(
 		&deleteUgoiraZip,
 		"delete_ugoira_zip",
 		"d",
 		true,
-		"Whether to delete the downloaded ugoira zip file after conversion.",
+		"Whether to delete the downloaded ugoira zip file after a successful conversion.",
 	)
 	pixivCmd.Flags().IntVarP(
 		&ugoiraQuality,
@@ -219,9 +557,22 @@ func init() {
 		utils.CombineStringsWithNewline(
 			"Output format for the ugoira conversion using FFmpeg.",
 			fmt.Sprintf(
-				"Accepted Extensions: %s\n",
-				strings.TrimSpace(strings.Join(ugoira.UGOIRA_ACCEPTED_EXT, ", ")),
+				"Accepted Extensions: %s",
+				strings.TrimSpace(strings.Join(ugoira.UGOIRA_OUTPUT_FORMATS, ", ")),
 			),
+			"Use \".zip\" to keep the downloaded zip file of the frames without converting it.",
+			"If FFmpeg is not installed, \".gif\" will be converted without FFmpeg at a lower quality.\n",
+		),
+	)
+	pixivCmd.Flags().BoolVar(
+		&ugoiraPreferDirect,
+		"ugoira_prefer_direct",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to download the animated file of an ugoira directly if Pixiv provides one.",
+			"This skips the extraction and conversion of the ugoira frames using FFmpeg but Pixiv's encoding will be used.",
+			"If Pixiv does not provide one, the ugoira frames will be downloaded and converted as usual.",
+			"Only supported with the \"--refresh_token\" flag as Pixiv's web API used with the \"--session\" flag only provides the frames.",
 		),
 	)
 	pixivCmd.Flags().StringSliceVar(
@@ -229,7 +580,7 @@ func init() {
 		"artwork_id",
 		[]string{},
 		utils.CombineStringsWithNewline(
-			"Artwork ID(s) to download.",
+			"Artwork ID(s) or URL(s) like \"https://www.pixiv.net/artworks/<artwork ID>\" to download.",
 			mutlipleIdsMsg,
 		),
 	)
@@ -238,7 +589,7 @@ func init() {
 		"illustrator_id",
 		[]string{},
 		utils.CombineStringsWithNewline(
-			"Illustrator ID(s) to download.",
+			"Illustrator ID(s) or URL(s) like \"https://www.pixiv.net/users/<illustrator ID>\" to download.",
 			mutlipleIdsMsg,
 		),
 	)
@@ -252,6 +603,15 @@ func init() {
 			"Leave blank to download all pages from each illustrator.",
 		),
 	)
+	pixivCmd.Flags().BoolVar(
+		&pixivDlProfileImages,
+		"dl_illustrator_profile",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to also download the avatar and the banner of the illustrator(s) given by the \"--illustrator_id\" flag.",
+			"They will be saved as \"avatar\" and \"banner\" in the illustrator's folder and skipped if they have already been downloaded.",
+		),
+	)
 	pixivCmd.Flags().StringSliceVar(
 		&pixivTagNames,
 		"tag_name",
@@ -262,6 +622,26 @@ func init() {
 			"Example: \"tag name 1, tagName2\"",
 		),
 	)
+	pixivCmd.Flags().StringVar(
+		&pixivWhitelistFile,
+		"whitelist_file",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to a text file containing illustrator IDs separated by a new line.",
+			"If provided, any tag search results that are not from the listed illustrators",
+			"will be dropped before getting the artwork details. Lines starting with \"#\" are ignored.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivArtworkPages,
+		"artwork_pages",
+		"",
+		utils.CombineStringsWithNewline(
+			"Only download the given pages of each multi-page artwork like manga, e.g. \"3-5\" or \"3\" where the first page is 1.",
+			"If the range goes beyond the artwork's page count, it will be clamped to the last page.",
+			"Defaults to downloading all pages.",
+		),
+	)
 	pixivCmd.Flags().StringSliceVar(
 		&pixivPageNums,
 		"tag_page_num",
@@ -272,6 +652,229 @@ func init() {
 			"Leave blank to search all pages for each tag name.",
 		),
 	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivNovelIds,
+		"novel_id",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Novel ID(s) to download the text of.",
+			mutlipleIdsMsg,
+			"Requires your refresh token as novels can only be downloaded via Pixiv's mobile API.",
+		),
+	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivNovelistIds,
+		"novelist_id",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"User ID(s) to download the novels of.",
+			mutlipleIdsMsg,
+			"Requires your refresh token as novels can only be downloaded via Pixiv's mobile API.",
+		),
+	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivNovelistPageNums,
+		"novelist_page_num",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Min and max page numbers of %d novels each corresponding to the order of the supplied novelist ID(s).",
+				pixivmobile.NOVELS_PER_PAGE,
+			),
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to download all pages",
+			"Leave blank to download all pages from each novelist.",
+		),
+	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivNovelSeriesIds,
+		"novel_series_id",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Novel series ID(s) to download all the novels of.",
+			mutlipleIdsMsg,
+			"Requires your refresh token as novels can only be downloaded via Pixiv's mobile API.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivBookmarksUserId,
+		"bookmarks",
+		"",
+		utils.CombineStringsWithNewline(
+			"User ID whose bookmarked artworks to download.",
+			"Requires your refresh token or your \"PHPSESSID\" cookie via the \"--session\" or \"--cookie_file\" flag",
+			"and the artworks will be downloaded to the artwork download path.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivBookmarksPageNum,
+		"bookmarks_page_num",
+		"",
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Min and max page numbers of the bookmarks to download where each page has %d bookmarks (%d if using the refresh token).",
+				pixivweb.BOOKMARKS_PER_PAGE,
+				pixivmobile.BOOKMARKS_PER_PAGE,
+			),
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to download all pages",
+			"Leave blank to download all pages of the bookmarks.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivBookmarkRestrict,
+		"bookmark_restrict",
+		"public",
+		utils.CombineStringsWithNewline(
+			"Which bookmarks of the user given by the \"--bookmarks\" flag to download.",
+			"Restrict Options:",
+			"- public: Download the public bookmarks",
+			"- private: Download the private bookmarks which Pixiv only returns for",
+			"  the user that the refresh token or the session cookie belongs to",
+		),
+	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivSeriesIds,
+		"series_id",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Manga series ID(s) to download all the chapters of.",
+			mutlipleIdsMsg,
+			"The chapters will be downloaded to the artwork download path with their order",
+			"in the series prefixed to the folder names to preserve the reading order.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivRankingMode,
+		"ranking_mode",
+		"",
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Download the artworks from Pixiv's rankings of the given mode: %s",
+				strings.Join(pixivweb.ACCEPTED_RANKING_MODES, ", "),
+			),
+			"The R-18 rankings require your \"PHPSESSID\" cookie via the \"--session\" or \"--cookie_file\" flag",
+			"and the artworks will be downloaded to the artwork download path.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivRankingDate,
+		"ranking_date",
+		"",
+		utils.CombineStringsWithNewline(
+			"Date of the rankings to download in the format of \"YYYYMMDD\", e.g. \"20240101\".",
+			"Leave blank to download the latest rankings.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivRankingPageNum,
+		"ranking_page_num",
+		"",
+		utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Min and max page numbers of the rankings to download where each page has %d artworks.",
+				pixivweb.RANKING_PER_PAGE,
+			),
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to download all pages",
+			"Leave blank to download all pages of the rankings.",
+		),
+	)
+	pixivCmd.Flags().BoolVar(
+		&pixivFollowing,
+		"following",
+		false,
+		utils.CombineStringsWithNewline(
+			"Download the latest artworks from the illustrators that you follow on Pixiv.",
+			"Requires your \"PHPSESSID\" cookie via the \"--session\" or \"--cookie_file\" flag",
+			"and the artworks will be downloaded to the artwork download path.",
+			"Use \"--rating_mode r18\" to only download the R-18 artworks or \"--rating_mode safe\" to skip them.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivFollowingPageNum,
+		"following_page_num",
+		"",
+		utils.CombineStringsWithNewline(
+			"Min and max page numbers of the latest artworks from your followed illustrators to download.",
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to download all pages",
+			"Leave blank to download all pages.",
+		),
+	)
+	pixivCmd.Flags().Float64Var(
+		&pixivDelayMin,
+		"pixiv_delay_min",
+		0,
+		utils.CombineStringsWithNewline(
+			"Minimum delay in seconds between requests to Pixiv's API.",
+			fmt.Sprintf(
+				"Defaults to %v for the web client and %v for the mobile client.",
+				pixivweb.DEFAULT_MIN_SLEEP,
+				pixivmobile.DEFAULT_MIN_SLEEP,
+			),
+			"Increase it if you keep getting 429 Too Many Requests responses from Pixiv.",
+		),
+	)
+	pixivCmd.Flags().Float64Var(
+		&pixivDelayMax,
+		"pixiv_delay_max",
+		0,
+		utils.CombineStringsWithNewline(
+			"Maximum delay in seconds between requests to Pixiv's API.",
+			fmt.Sprintf(
+				"Defaults to %v for the web client and %v for the mobile client.",
+				pixivweb.DEFAULT_MAX_SLEEP,
+				pixivmobile.DEFAULT_MAX_SLEEP,
+			),
+		),
+	)
+	pixivCmd.Flags().Float64Var(
+		&pixivMaxBackoff,
+		"pixiv_max_backoff",
+		pixivweb.DEFAULT_MAX_BACKOFF,
+		utils.CombineStringsWithNewline(
+			"Maximum delay in seconds to wait before retrying a request when Pixiv's website responds with 429 Too Many Requests.",
+			fmt.Sprintf(
+				"The delay starts at %v seconds and is doubled on each consecutive 429 response up to this value.",
+				utils.RATE_LIMIT_BACKOFF_BASE.Seconds(),
+			),
+			"Set it to 0 to retry with the usual short delay instead.",
+		),
+	)
+	pixivCmd.Flags().IntVar(
+		&pixivMobileConcurrency,
+		"mobile_api_concurrency",
+		pixivmobile.DEFAULT_API_CONCURRENCY,
+		utils.CombineStringsWithNewline(
+			"Maximum number of concurrent requests when getting the details of multiple artworks with the refresh token.",
+			"Each request will still wait for the delay given by \"--pixiv_delay_min\" and \"--pixiv_delay_max\" before the next one.",
+			"Lower it if you keep getting 429 Too Many Requests responses from Pixiv.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivArtworkDlPath,
+		"artwork_download_path",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to download the artworks from the artwork ID(s) to.",
+			"Defaults to the download path of the run if not set.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivIllustratorDlPath,
+		"illustrator_download_path",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to download the artworks from the illustrator ID(s) to.",
+			"Defaults to the download path of the run if not set.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivTagDlPath,
+		"tag_download_path",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to download the artworks from the tag name search(es) to.",
+			"Defaults to the download path of the run if not set.",
+		),
+	)
 	pixivCmd.Flags().StringVar(
 		&pixivSortOrder,
 		"sort_order",
@@ -285,6 +888,94 @@ func init() {
 			"- Pixiv Premium is needed in order to search by popularity. Otherwise, Pixiv's API will default to \"date_d\".",
 		),
 	)
+	pixivCmd.Flags().BoolVar(
+		&pixivMaxCoverage,
+		"max_coverage",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to search the tag names with every supported sort order and download the union of the results.",
+			"This gets past the limit on the number of results that Pixiv returns for a single sort order",
+			"but will take longer as each tag name and page number will be searched once per sort order.",
+			"Artworks found by more than one sort order will only be downloaded once.",
+		),
+	)
+	pixivCmd.Flags().StringSliceVar(
+		&pixivExcludeTags,
+		"exclude_tags",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Tag names to exclude from the tag search results.",
+			"The tag names will be added to the search term as \"-tag\" and",
+			"the results with any of the excluded tag names will also be dropped before downloading them.",
+			"Example: --exclude_tags \"R-18,AI-generated\"",
+		),
+	)
+	pixivCmd.Flags().IntVar(
+		&pixivMinBookmarks,
+		"min_bookmarks",
+		0,
+		utils.CombineStringsWithNewline(
+			"The minimum number of bookmarks that an artwork from the tag search results must have to be downloaded.",
+			"Artworks with fewer bookmarks will be dropped before their URLs and ugoira metadata are requested.",
+			"Defaults to 0 which means that no artworks will be dropped.",
+		),
+	)
+	pixivCmd.Flags().IntVar(
+		&pixivMaxArtworks,
+		"max_artworks",
+		0,
+		utils.CombineStringsWithNewline(
+			"The maximum number of artworks to collect from the tag search results of each tag.",
+			"The next pages will not be requested once this many artworks have been collected after filtering.",
+			"Defaults to 0 which means that there is no limit.",
+		),
+	)
+	pixivCmd.Flags().BoolVar(
+		&pixivNoAi,
+		"no_ai",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to skip the artworks that are flagged as AI-generated by Pixiv.",
+			"The number of skipped AI-generated artworks will be printed at the end of the run.",
+		),
+	)
+	pixivCmd.Flags().BoolVar(
+		&writeMetadata,
+		"save_metadata",
+		false,
+		"Same as the \"--write_metadata\" flag.",
+	)
+	pixivCmd.Flags().MarkDeprecated(
+		"save_metadata",
+		"use \"--write_metadata\" instead, this flag will be removed in the next release",
+	)
+	pixivCmd.Flags().BoolVar(
+		&pixivTranslateTags,
+		"translate_tags",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to use the English translation of the artwork's tags in the metadata file and the extended file attributes where Pixiv has one.",
+			"Tags without a translation will be kept as they are.",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivSearchStartDate,
+		"search_start_date",
+		"",
+		utils.CombineStringsWithNewline(
+			"Only search the tag names for artworks posted on or after this date in the YYYY-MM-DD format.",
+			"Example: \"2023-01-31\"",
+		),
+	)
+	pixivCmd.Flags().StringVar(
+		&pixivSearchEndDate,
+		"search_end_date",
+		"",
+		utils.CombineStringsWithNewline(
+			"Only search the tag names for artworks posted on or before this date in the YYYY-MM-DD format.",
+			"Example: \"2023-12-31\"",
+		),
+	)
 	pixivCmd.Flags().StringVar(
 		&pixivSearchMode,
 		"search_mode",
@@ -306,7 +997,8 @@ func init() {
 			"- safe: Restrict downloads to all ages artworks",
 			"- all: Include both R-18 and all ages artworks",
 			"Notes:",
-			"- If you're using the \"--refresh_token\" flag, only \"all\" is supported.",
+			"- The illustrator's artworks and the artwork IDs are also filtered by their rating after retrieving their details.",
+			"- If you're using the \"--refresh_token\" flag, the tag search results will also be filtered after retrieving them.",
 		),
 	)
 	pixivCmd.Flags().StringVar(
@@ -322,4 +1014,15 @@ func init() {
 			"- If you're using the \"-pixiv_refresh_token\" flag and are downloading by tag names, only \"all\" is supported.",
 		),
 	)
+	registerFlagValuesCompletion(pixivCmd, map[string][]string{
+		"sort_order":           pixivweb.ACCEPTED_SORT_ORDER,
+		"search_mode":          pixivweb.ACCEPTED_SEARCH_MODE,
+		"rating_mode":          pixivweb.ACCEPTED_RATING_MODE,
+		"artwork_type":         pixivweb.ACCEPTED_ARTWORK_TYPE,
+		"ugoira_output_format": ugoira.UGOIRA_OUTPUT_FORMATS,
+		"prefer":               pixiv.ACCEPTED_PIXIV_CLIENTS,
+		"ranking_mode":         pixivweb.ACCEPTED_RANKING_MODES,
+		"bookmark_restrict":    ACCEPTED_BOOKMARK_RESTRICTS,
+	})
+	registerFilePathCompletion(pixivCmd, "ffmpeg_path", "whitelist_file")
 }
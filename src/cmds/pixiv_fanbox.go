@@ -4,6 +4,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
 	"github.com/spf13/cobra"
@@ -23,27 +24,37 @@ var (
 	fanboxGdriveApiKey         string
 	fanboxGdriveServiceAccPath string
 	fanboxOverwriteFiles       bool
+	fanboxRedownloadIfSmaller  bool
 	fanboxLogUrls              bool
-	fanboxUserAgent            string
+	fanboxPasswordList         string
+	fanboxRetryFailed          string
+	fanboxSampleSize           int
+	fanboxOrder                string
 	pixivFanboxCmd = &cobra.Command{
 		Use:   "pixiv_fanbox",
 		Short: "Download from Pixiv Fanbox",
 		Long:  "Supports downloads from Pixiv Fanbox creators and individual posts.",
 		Run: func(cmd *cobra.Command, args []string) {
-			pixivFanboxConfig := &configs.Config{
-				OverwriteFiles: fanboxOverwriteFiles,
-				UserAgent:      fanboxUserAgent,
-				LogUrls:        fanboxLogUrls,
+			cookieFile := getCookieFilePath(utils.PIXIV_FANBOX, fanboxCookieFile, fanboxSession)
+			if probeOnly {
+				checkCredentials(utils.PIXIV_FANBOX, cookieFile, fanboxGdriveApiKey, userAgent)
+				return
 			}
-			var gdriveClient *gdrive.GDrive
-			if fanboxGdriveApiKey != "" || fanboxGdriveServiceAccPath != "" {
-				gdriveClient = gdrive.GetNewGDrive(
-					fanboxGdriveApiKey,
-					fanboxGdriveServiceAccPath,
-					pixivFanboxConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
-				)
+			utils.RUN_STATS.SetSite(utils.PIXIV_FANBOX)
+
+			pixivFanboxConfig := &configs.Config{
+				DownloadPath:        getDownloadPath(),
+				OverwriteFiles:      fanboxOverwriteFiles,
+				RedownloadIfSmaller: fanboxRedownloadIfSmaller,
+				UserAgent:           userAgent,
+				LogUrls:             fanboxLogUrls,
+				PasswordList:        getPasswordList(fanboxPasswordList),
+				SampleSize:          getSampleSize(fanboxSampleSize),
+				WriteXattrs:         writeXattrs,
+				WriteMetadata:       writeMetadata,
+				ResumeDownload:      resumeDownload,
 			}
+			gdriveClient := getGdriveClient(fanboxGdriveApiKey, fanboxGdriveServiceAccPath, pixivFanboxConfig)
 
 			if fanboxDlTextFile != "" {
 				postIds, creatorInfoSlice := textparser.ParsePixivFanboxTextFile(fanboxDlTextFile)
@@ -54,6 +65,11 @@ var (
 					fanboxPageNums = append(fanboxPageNums, creatorInfo.PageNum)
 				}
 			}
+			if inputTargets := getInputFileTargets(utils.PIXIV_FANBOX); inputTargets != nil {
+				fanboxPostIds = append(fanboxPostIds, inputTargets.PixivFanboxDl.PostIds...)
+				fanboxCreatorIds = append(fanboxCreatorIds, inputTargets.PixivFanboxDl.CreatorIds...)
+				fanboxPageNums = append(fanboxPageNums, inputTargets.PixivFanboxDl.CreatorPageNums...)
+			}
 			pixivFanboxDl := &pixivfanbox.PixivFanboxDl{
 				CreatorIds:      fanboxCreatorIds,
 				CreatorPageNums: fanboxPageNums,
@@ -61,42 +77,52 @@ var (
 			}
 			pixivFanboxDl.ValidateArgs()
 
-			pixivFanboxDlOptions := &pixivfanbox.PixivFanboxDlOptions{
-				DlThumbnails:    fanboxDlThumbnails,
-				DlImages:        fanboxDlImages,
-				DlAttachments:   fanboxDlAttachments,
-				Configs:         pixivFanboxConfig,
-				GdriveClient:    gdriveClient,
-				DlGdrive:        fanboxDlGdrive,
-				SessionCookieId: fanboxSession,
-			}
-			if fanboxCookieFile != "" {
-				cookies, err := utils.ParseNetscapeCookieFile(
-					fanboxCookieFile,
-					fanboxSession,
+			pixivFanboxDlOptions := getPixivFanboxDlOptions(pixivFanboxConfig, gdriveClient, cookieFile)
+
+			if fanboxRetryFailed != "" {
+				request.RetryFailedDownloads(
+					fanboxRetryFailed,
 					utils.PIXIV_FANBOX,
+					&request.DlOptions{
+						MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+						Headers:        pixivfanbox.GetPixivFanboxHeaders(),
+						Cookies:        pixivFanboxDlOptions.SessionCookies,
+						UseHttp3:       false,
+					},
+					pixivFanboxConfig,
 				)
-				if err != nil {
-					utils.LogError(
-						err,
-						"",
-						true,
-						utils.ERROR,
-					)
-				}
-				pixivFanboxDlOptions.SessionCookies = cookies
+				return
 			}
-			pixivFanboxDlOptions.ValidateArgs(fanboxUserAgent)
 
+			request.SaveFailedDownloadsOnExit("", utils.PIXIV_FANBOX)
 			utils.PrintWarningMsg()
 			pixivfanbox.PixivFanboxDownloadProcess(
 				pixivFanboxDl,
 				pixivFanboxDlOptions,
 			)
+			request.SaveFailedDownloads("", utils.PIXIV_FANBOX)
 		},
 	}
 )
 
+// Returns the validated Pixiv Fanbox download options from the flags of the "pixiv_fanbox" command
+// and the session cookies from the cookie file if given.
+func getPixivFanboxDlOptions(pixivFanboxConfig *configs.Config, gdriveClient *gdrive.GDrive, cookieFile string) *pixivfanbox.PixivFanboxDlOptions {
+	pixivFanboxDlOptions := &pixivfanbox.PixivFanboxDlOptions{
+		DlThumbnails:    fanboxDlThumbnails,
+		DlImages:        fanboxDlImages,
+		DlAttachments:   fanboxDlAttachments,
+		Configs:         pixivFanboxConfig,
+		GdriveClient:    gdriveClient,
+		DlGdrive:        fanboxDlGdrive,
+		Order:           fanboxOrder,
+		SessionCookieId: fanboxSession,
+		SessionCookies:  getSessionCookies(utils.PIXIV_FANBOX, cookieFile, fanboxSession),
+	}
+	pixivFanboxDlOptions.ValidateArgs(userAgent)
+	return pixivFanboxDlOptions
+}
+
 func init() {
 	mutlipleIdsMsg := getMultipleIdsMsg()
 	pixivFanboxCmd.Flags().StringVarP(
@@ -162,4 +188,20 @@ func init() {
 		true,
 		"Whether to download the Google Drive links of a Pixiv Fanbox post.",
 	)
+	pixivFanboxCmd.Flags().StringVar(
+		&fanboxOrder,
+		"fanbox_order",
+		pixivfanbox.FANBOX_ORDER_NEWEST,
+		utils.CombineStringsWithNewline(
+			"The order to retrieve and download the posts of the supplied Pixiv Fanbox creator ID(s) in.",
+			"Order Options:",
+			"- newest: Newest posts first",
+			"- oldest: Oldest posts first, so an interrupted run will leave the earliest posts downloaded",
+			"Notes:",
+			"- The page numbers from \"--page_num\" follow the order, e.g. page 1 is the oldest page for \"oldest\".",
+		),
+	)
+	registerFlagValuesCompletion(pixivFanboxCmd, map[string][]string{
+		"fanbox_order": pixivfanbox.ACCEPTED_FANBOX_ORDER,
+	})
 }
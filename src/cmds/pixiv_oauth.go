@@ -0,0 +1,125 @@
+package cmds
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/mobile"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const PIXIV_OAUTH_TIMEOUT = 10
+
+var (
+	pixivRefreshCmdToken string
+	pixivOauthCmd        = &cobra.Command{
+		Use:   "oauth",
+		Short: "Start the Pixiv OAuth flow to get your refresh token",
+		Long: utils.CombineStringsWithNewline(
+			"Opens Pixiv's login page in your browser and prompts for the code given by Pixiv after logging in",
+			"to get your refresh token which will be saved to the config file and reused by the pixiv command",
+			"when the \"--refresh_token\", \"--session\", and \"--cookie_file\" flags are not given.",
+			"Guide: https://github.com/KJHJason/Cultured-Downloader/blob/main/doc/pixiv_oauth_guide.md",
+		),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runPixivOauthFlow()
+		},
+	}
+	pixivRefreshCmd = &cobra.Command{
+		Use:   "refresh",
+		Short: "Verify your Pixiv refresh token by refreshing the access token",
+		Long: utils.CombineStringsWithNewline(
+			"Uses the refresh token to get a new access token from Pixiv without downloading anything.",
+			"Defaults to the refresh token saved in the config file if the \"--refresh_token\" flag is not given.",
+			"Exits with a non-zero exit code if the refresh token is invalid or if Pixiv could not be reached.",
+		),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			refreshToken := pixivRefreshCmdToken
+			if refreshToken == "" {
+				refreshToken = getSavedPixivRefreshToken()
+			}
+			if refreshToken == "" {
+				utils.PrintError(
+					"pixiv error %d: no refresh token was given and there is none saved in the config file, run the \"pixiv oauth\" command to get one.",
+					utils.INPUT_ERROR,
+				)
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+
+			pixivMobile := pixivmobile.NewPixivMobile(refreshToken, PIXIV_OAUTH_TIMEOUT)
+			pixivMobile.SetUserAgent(userAgent)
+			if err := pixivMobile.RefreshAccessToken(); err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(pixivmobile.GetRefreshTokenExitCode(err))
+			}
+			color.Green(
+				"Pixiv: the refresh token is valid, the new access token expires at %s.",
+				pixivMobile.GetAccessTokenExpiry().Format("2006-01-02 15:04:05"),
+			)
+		},
+	}
+)
+
+// Starts the Pixiv OAuth flow and saves the refresh token to the config file
+//
+// Exits the program with the exit code of the error if the OAuth flow failed.
+// The refresh token is only printed if it could not be saved so that it will not be lost.
+func runPixivOauthFlow() string {
+	pixivMobile := pixivmobile.NewPixivMobile("", PIXIV_OAUTH_TIMEOUT)
+	pixivMobile.SetUserAgent(userAgent)
+	refreshToken, err := pixivMobile.StartOauthFlow()
+	if err != nil {
+		utils.LogError(err, "", true, utils.ERROR)
+	}
+
+	if err := utils.SavePixivRefreshToken(refreshToken); err != nil {
+		utils.PrintError("Failed to save your Pixiv refresh token to the config file: %v", err)
+		color.Green("Your Pixiv Refresh Token: " + refreshToken)
+		color.Yellow("Please save your refresh token somewhere SECURE and do NOT share it with anyone!")
+		return refreshToken
+	}
+	color.Green(
+		"Your Pixiv refresh token has been saved to %s and will be used when no credentials are given.",
+		utils.GetConfigFilePath(),
+	)
+	color.Yellow("Please do NOT share your config file with anyone as it contains your refresh token!")
+	return refreshToken
+}
+
+// Returns the Pixiv refresh token saved in the config file or an empty string if there is none
+//
+// Errors are only printed as a warning as the user can still give the refresh token via the flag.
+func getSavedPixivRefreshToken() string {
+	refreshToken, err := utils.LoadPixivRefreshToken()
+	if err != nil {
+		color.Yellow("Failed to load the Pixiv refresh token from the config file: %v", err)
+		return ""
+	}
+	return refreshToken
+}
+
+// Verifies the refresh token saved in the config file and
+// starts the OAuth flow again to replace it if Pixiv has rejected it.
+//
+// Other errors like connection errors are left for the download process to handle.
+func verifySavedPixivRefreshToken(pixivMobile *pixivmobile.PixivMobile) {
+	err := pixivMobile.VerifyRefreshToken()
+	if err == nil || pixivmobile.GetRefreshTokenExitCode(err) != utils.EXIT_AUTH_ERROR {
+		return
+	}
+
+	color.Yellow("The Pixiv refresh token saved in the config file was rejected by Pixiv, please log in again to get a new one.")
+	pixivMobile.SetRefreshToken(runPixivOauthFlow())
+}
+
+func init() {
+	pixivRefreshCmd.Flags().StringVarP(
+		&pixivRefreshCmdToken,
+		"refresh_token",
+		"t",
+		"",
+		"Your Pixiv refresh token to verify.",
+	)
+	pixivCmd.AddCommand(pixivOauthCmd, pixivRefreshCmd)
+}
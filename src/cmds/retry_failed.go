@@ -0,0 +1,202 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox"
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// failuresFileToRetry is a failures file with the path it was loaded from
+type failuresFileToRetry struct {
+	filePath string
+	*request.FailuresFile
+}
+
+var (
+	retryFailedReport string
+	retryFailedList   bool
+	retryFailedCmd    = &cobra.Command{
+		Use:   "retry-failed",
+		Short: "Download the failed downloads recorded in the failures files again",
+		Long: utils.CombineStringsWithNewline(
+			"Re-attempts the failed downloads recorded in the failures file given by the \"--report\" flag",
+			"or in all the failures files saved in the application's directory if the flag is not given.",
+			"The session cookies are loaded from the cookie files in the application's cookies folder",
+			"so that expired cookies can be replaced without editing the failures files.",
+			"Downloads that succeed are removed from the failures file and the file is deleted once all of them succeed.",
+		),
+		Args: cobra.NoArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// listing the failed downloads does not require an internet connection
+			if retryFailedList {
+				applyAppDataDir()
+				return
+			}
+			initProgram(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			failuresFiles := loadFailuresFilesToRetry()
+			if len(failuresFiles) == 0 {
+				color.Green("There are no failed downloads to retry.")
+				return
+			}
+
+			if retryFailedList {
+				printFailuresFiles(failuresFiles)
+				return
+			}
+
+			utils.PrintWarningMsg()
+			for _, failuresFile := range failuresFiles {
+				utils.RUN_STATS.SetSite(failuresFile.Site)
+				request.RetryFailedDownloads(
+					failuresFile.filePath,
+					failuresFile.Site,
+					getRetryFailedDlOptions(failuresFile.Site),
+					&configs.Config{
+						DownloadPath:   getDownloadPath(),
+						UserAgent:      userAgent,
+						WriteXattrs:    writeXattrs,
+						ResumeDownload: resumeDownload,
+					},
+				)
+			}
+		},
+	}
+)
+
+// Loads the failures file given by the "--report" flag or all the failures files in the application's directory.
+//
+// Unlike the failures file given by the user which will exit the program if it is invalid,
+// invalid failures files in the application's directory will be skipped with a warning.
+func loadFailuresFilesToRetry() []*failuresFileToRetry {
+	if retryFailedReport != "" {
+		failuresFile, err := request.LoadFailuresFile(retryFailedReport)
+		if err != nil {
+			utils.PrintError(err.Error())
+			utils.Exit(utils.GetExitCodeFromErr(err))
+		}
+		return []*failuresFileToRetry{{filePath: retryFailedReport, FailuresFile: failuresFile}}
+	}
+
+	filePaths, err := request.GetFailuresFilePaths()
+	if err != nil {
+		utils.PrintError(err.Error())
+		utils.Exit(utils.GetExitCodeFromErr(err))
+	}
+
+	failuresFiles := make([]*failuresFileToRetry, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		failuresFile, err := request.LoadFailuresFile(filePath)
+		if err != nil {
+			color.Yellow("Skipping failures file: %v", err)
+			continue
+		}
+		failuresFiles = append(failuresFiles, &failuresFileToRetry{filePath: filePath, FailuresFile: failuresFile})
+	}
+	return failuresFiles
+}
+
+// Prints the failed downloads in the failures files without downloading them
+func printFailuresFiles(failuresFiles []*failuresFileToRetry) {
+	total := 0
+	for _, failuresFile := range failuresFiles {
+		total += len(failuresFile.Failed)
+		color.Cyan(
+			"\n%s (%s, %d failed download(s)):",
+			failuresFile.filePath,
+			utils.GetReadableSiteStr(failuresFile.Site),
+			len(failuresFile.Failed),
+		)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  URL\tFILE PATH")
+		for _, failed := range failuresFile.Failed {
+			fmt.Fprintf(w, "  %s\t%s\n", failed.Url, failed.FilePath)
+		}
+		w.Flush()
+	}
+	fmt.Printf("\nTotal: %d failed download(s) in %d failures file(s)\n", total, len(failuresFiles))
+}
+
+// Returns the download options to retry the failed downloads of the site with
+// using the same headers as the site's command and the cookies from the application's cookies folder.
+func getRetryFailedDlOptions(site string) *request.DlOptions {
+	switch site {
+	case utils.FANTIA:
+		return &request.DlOptions{
+			MaxConcurrency: utils.MAX_CONCURRENT_DOWNLOADS,
+			Cookies:        getAutoloadedCookies(site, ""),
+			UseHttp3:       false,
+		}
+	case utils.PIXIV_FANBOX:
+		return &request.DlOptions{
+			MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+			Headers:        pixivfanbox.GetPixivFanboxHeaders(),
+			Cookies:        getAutoloadedCookies(site, ""),
+			UseHttp3:       false,
+		}
+	case utils.PIXIV:
+		// Pixiv's image server only checks the Referer header
+		return &request.DlOptions{
+			MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+			Headers:        pixivcommon.GetPixivRequestHeaders(),
+			UseHttp3:       false,
+		}
+	case utils.KEMONO:
+		return &request.DlOptions{
+			MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+			Cookies:        getAutoloadedCookies(site, ""),
+			UseHttp3:       utils.IsHttp3Supported(utils.KEMONO, false),
+		}
+	default:
+		// Shouldn't happen as the site is validated when loading the failures file
+		panic(
+			fmt.Errorf(
+				"error %d, invalid website, %q, in getRetryFailedDlOptions",
+				utils.DEV_ERROR,
+				site,
+			),
+		)
+	}
+}
+
+func init() {
+	retryFailedCmd.Flags().StringVar(
+		&retryFailedReport,
+		"report",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to the failures file to retry the failed downloads of.",
+			"By default, the failed downloads in all the failures files in the application's directory will be retried.",
+		),
+	)
+	retryFailedCmd.Flags().BoolVar(
+		&retryFailedList,
+		"list",
+		false,
+		"Only print the failed downloads in the failures file(s) without downloading them.",
+	)
+	retryFailedCmd.Flags().BoolVar(
+		&writeXattrs,
+		"write_xattrs",
+		false,
+		getWriteXattrsMsg(),
+	)
+	retryFailedCmd.Flags().BoolVar(
+		&resumeDownload,
+		"resume_download",
+		false,
+		getResumeDownloadMsg(),
+	)
+	registerFilePathCompletion(retryFailedCmd, "report")
+	RootCmd.AddCommand(retryFailedCmd)
+}
@@ -0,0 +1,286 @@
+package textparser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	ID_ENTRY_REGEX = regexp.MustCompile(
+		// ^(?P<site>[a-z_]+):(?P<type>[a-z]+):(?P<id>[^;]+?)(?:; (?P<pageNum>[1-9]\d*(?:-[1-9]\d*)?))?$
+		fmt.Sprintf(
+			`^(?P<site>[a-z_]+):(?P<type>[a-z]+):(?P<id>[^;]+?)%s$`,
+			PAGE_NUM_REGEX_STR,
+		),
+	)
+	ID_ENTRY_REGEX_SITE_INDEX     = ID_ENTRY_REGEX.SubexpIndex("site")
+	ID_ENTRY_REGEX_TYPE_INDEX     = ID_ENTRY_REGEX.SubexpIndex("type")
+	ID_ENTRY_REGEX_ID_INDEX       = ID_ENTRY_REGEX.SubexpIndex("id")
+	ID_ENTRY_REGEX_PAGE_NUM_INDEX = ID_ENTRY_REGEX.SubexpIndex(PAGE_NUM_REGEX_GRP_NAME)
+
+	numericIdRegex       = regexp.MustCompile(`^\d+$`)
+	fanboxCreatorIdRegex = regexp.MustCompile(`^[\w.-]+$`)
+	gdriveIdRegex        = regexp.MustCompile(`^[\w-]+$`)
+	kemonoPostIdRegex    = regexp.MustCompile(`^(?P<service>[^/]+)/(?P<creatorId>[^/]+)/(?P<postId>[^/]+)$`)
+	kemonoCreatorIdRegex = regexp.MustCompile(`^(?P<service>[^/]+)/(?P<creatorId>[^/]+)$`)
+)
+
+type idEntryType struct {
+	site        string
+	entryType   string
+	idFormat    string
+	idRegex     *regexp.Regexp
+	hasPageNums bool
+
+	// adds the download target of the entry to the classified URLs
+	// where matched is the result of idRegex on the ID of the entry
+	addTarget func(matched []string, pageNum string, classified *ClassifiedUrls)
+}
+
+var idEntryTypes = []*idEntryType{
+	{
+		site:      utils.FANTIA,
+		entryType: "post",
+		idFormat:  "<post ID>",
+		idRegex:   numericIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.FantiaDl.PostIds = append(classified.FantiaDl.PostIds, matched[0])
+		},
+	},
+	{
+		site:        utils.FANTIA,
+		entryType:   "fanclub",
+		idFormat:    "<fanclub ID>",
+		idRegex:     numericIdRegex,
+		hasPageNums: true,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			fantiaDl := classified.FantiaDl
+			fantiaDl.FanclubIds = append(fantiaDl.FanclubIds, matched[0])
+			fantiaDl.FanclubPageNums = append(fantiaDl.FanclubPageNums, pageNum)
+		},
+	},
+	{
+		site:      utils.PIXIV_FANBOX,
+		entryType: "post",
+		idFormat:  "<post ID>",
+		idRegex:   numericIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.PixivFanboxDl.PostIds = append(classified.PixivFanboxDl.PostIds, matched[0])
+		},
+	},
+	{
+		site:        utils.PIXIV_FANBOX,
+		entryType:   "creator",
+		idFormat:    "<creator ID>",
+		idRegex:     fanboxCreatorIdRegex,
+		hasPageNums: true,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			fanboxDl := classified.PixivFanboxDl
+			fanboxDl.CreatorIds = append(fanboxDl.CreatorIds, matched[0])
+			fanboxDl.CreatorPageNums = append(fanboxDl.CreatorPageNums, pageNum)
+		},
+	},
+	{
+		site:      utils.PIXIV,
+		entryType: "artwork",
+		idFormat:  "<artwork ID>",
+		idRegex:   numericIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.PixivDl.ArtworkIds = append(classified.PixivDl.ArtworkIds, matched[0])
+		},
+	},
+	{
+		site:        utils.PIXIV,
+		entryType:   "illustrator",
+		idFormat:    "<illustrator ID>",
+		idRegex:     numericIdRegex,
+		hasPageNums: true,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			pixivDl := classified.PixivDl
+			pixivDl.IllustratorIds = append(pixivDl.IllustratorIds, matched[0])
+			pixivDl.IllustratorPageNums = append(pixivDl.IllustratorPageNums, pageNum)
+		},
+	},
+	{
+		site:        utils.PIXIV,
+		entryType:   "tag",
+		idFormat:    "<tag name>",
+		idRegex:     regexp.MustCompile(`^.+$`),
+		hasPageNums: true,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			pixivDl := classified.PixivDl
+			pixivDl.TagNames = append(pixivDl.TagNames, matched[0])
+			pixivDl.TagNamesPageNums = append(pixivDl.TagNamesPageNums, pageNum)
+		},
+	},
+	{
+		site:      utils.KEMONO,
+		entryType: "post",
+		idFormat:  "<service>/<creator ID>/<post ID>",
+		idRegex:   kemonoPostIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.KemonoDl.PostsToDl = append(classified.KemonoDl.PostsToDl, &models.KemonoPostToDl{
+				Service:   matched[kemonoPostIdRegex.SubexpIndex("service")],
+				CreatorId: matched[kemonoPostIdRegex.SubexpIndex("creatorId")],
+				PostId:    matched[kemonoPostIdRegex.SubexpIndex("postId")],
+			})
+		},
+	},
+	{
+		site:        utils.KEMONO,
+		entryType:   "creator",
+		idFormat:    "<service>/<creator ID>",
+		idRegex:     kemonoCreatorIdRegex,
+		hasPageNums: true,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.KemonoDl.CreatorsToDl = append(classified.KemonoDl.CreatorsToDl, &models.KemonoCreatorToDl{
+				Service:   matched[kemonoCreatorIdRegex.SubexpIndex("service")],
+				CreatorId: matched[kemonoCreatorIdRegex.SubexpIndex("creatorId")],
+				PageNum:   pageNum,
+			})
+		},
+	},
+	{
+		site:      utils.GDRIVE,
+		entryType: "file",
+		idFormat:  "<file ID>",
+		idRegex:   gdriveIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.GdriveUrls = append(
+				classified.GdriveUrls,
+				"https://drive.google.com/file/d/"+matched[0],
+			)
+		},
+	},
+	{
+		site:      utils.GDRIVE,
+		entryType: "folder",
+		idFormat:  "<folder ID>",
+		idRegex:   gdriveIdRegex,
+		addTarget: func(matched []string, pageNum string, classified *ClassifiedUrls) {
+			classified.GdriveUrls = append(
+				classified.GdriveUrls,
+				"https://drive.google.com/drive/folders/"+matched[0],
+			)
+		},
+	},
+}
+
+// Returns the supported "site:type:id" entry formats to be shown to the user
+func GetSupportedIdEntryFormats() []string {
+	formats := make([]string, len(idEntryTypes))
+	for idx, entryType := range idEntryTypes {
+		format := fmt.Sprintf("- %s:%s:%s", entryType.site, entryType.entryType, entryType.idFormat)
+		if entryType.hasPageNums {
+			format += " (page numbers can be appended, e.g. \"; 1-3\")"
+		}
+		formats[idx] = format
+	}
+	return formats
+}
+
+// Adds the download target of the given "site:type:id" entry to the classified URLs.
+//
+// Returns an error message without the line number if the entry is malformed.
+func (c *ClassifiedUrls) addIdEntry(entry string) string {
+	matched := ID_ENTRY_REGEX.FindStringSubmatch(entry)
+	if matched == nil {
+		return fmt.Sprintf("%q is not a supported URL or \"site:type:id\" entry", entry)
+	}
+
+	site := matched[ID_ENTRY_REGEX_SITE_INDEX]
+	entryTypeName := matched[ID_ENTRY_REGEX_TYPE_INDEX]
+	id := strings.TrimSpace(matched[ID_ENTRY_REGEX_ID_INDEX])
+	pageNum := matched[ID_ENTRY_REGEX_PAGE_NUM_INDEX]
+	for _, entryType := range idEntryTypes {
+		if entryType.site != site || entryType.entryType != entryTypeName {
+			continue
+		}
+
+		if pageNum != "" && !entryType.hasPageNums {
+			return fmt.Sprintf("page numbers are not supported for %s:%s entries", site, entryTypeName)
+		}
+		idMatched := entryType.idRegex.FindStringSubmatch(id)
+		if idMatched == nil {
+			return fmt.Sprintf(
+				"invalid ID %q for %s:%s entries, expected %s",
+				id,
+				site,
+				entryTypeName,
+				entryType.idFormat,
+			)
+		}
+		entryType.addTarget(idMatched, pageNum, c)
+		c.addSite(site)
+		return ""
+	}
+	return fmt.Sprintf("unknown entry type %q", site+":"+entryTypeName)
+}
+
+// Adds the download targets in the input file at the given path to the classified URLs.
+//
+// Each line of the file must contain a supported URL or a "site:type:id" entry
+// like "fantia:post:1234". Blank lines and lines starting with "#" will be ignored.
+//
+// If any of the lines is malformed, an error listing the line
+// numbers of the malformed lines and the supported formats will be returned.
+func (c *ClassifiedUrls) addInputFile(inputFilePath string) error {
+	f, err := os.Open(inputFilePath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to open input file at %s, more info => %v",
+			utils.OS_ERROR,
+			inputFilePath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	var malformedLines []string
+	reader := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		lineBytes, err := utils.ReadLine(reader)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf(
+				"error %d: failed to read input file at %s, more info => %v",
+				utils.OS_ERROR,
+				inputFilePath,
+				err,
+			)
+		}
+
+		line := strings.TrimSpace(string(lineBytes))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if c.addUrl(line) {
+			continue
+		}
+		if errMsg := c.addIdEntry(line); errMsg != "" {
+			malformedLines = append(malformedLines, fmt.Sprintf("line %d: %s", lineNum, errMsg))
+		}
+	}
+
+	if len(malformedLines) > 0 {
+		return fmt.Errorf(
+			"error %d: malformed entries in input file at %s:\n- %s\n\nSupported URL patterns:\n%s\n\nSupported entry formats:\n%s",
+			utils.INPUT_ERROR,
+			inputFilePath,
+			strings.Join(malformedLines, "\n- "),
+			strings.Join(GetSupportedUrlPatterns(), "\n"),
+			strings.Join(GetSupportedIdEntryFormats(), "\n"),
+		)
+	}
+	return nil
+}
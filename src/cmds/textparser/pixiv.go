@@ -33,8 +33,82 @@ var (
 	)
 	P_TAG_REGEX_TAG_INDEX = P_TAG_URL_REGEX.SubexpIndex("tag")
 	P_TAG_REGEX_PAGE_NUM_INDEX = P_TAG_URL_REGEX.SubexpIndex(PAGE_NUM_REGEX_GRP_NAME)
+
+	// Unlike the URL regexes for the text file above, these also match the URLs copied from the browser
+	// that do not have the scheme or the "www." subdomain or that have a query string or fragment like "#1".
+	P_ILLUST_ARG_URL_REGEX = regexp.MustCompile(
+		`^(?:https?://)?(?:www\.)?pixiv\.net/(?:en/)?artworks/(?P<illustId>\d+)/?(?:[?#].*)?$`,
+	)
+	P_ILLUST_ARG_REGEX_ID_INDEX = P_ILLUST_ARG_URL_REGEX.SubexpIndex("illustId")
+	P_ARTIST_ARG_URL_REGEX = regexp.MustCompile(
+		`^(?:https?://)?(?:www\.)?pixiv\.net/(?:en/)?users/(?P<artistId>\d+)(?:/(?:artworks|illustrations|manga))?/?(?:[?#].*)?$`,
+	)
+	P_ARTIST_ARG_REGEX_ID_INDEX = P_ARTIST_ARG_URL_REGEX.SubexpIndex("artistId")
 )
 
+// Returns true if the flag value looks like a URL instead of an ID
+func isUrlArg(arg string) bool {
+	return strings.Contains(arg, "://") || strings.HasPrefix(arg, "www.") || strings.HasPrefix(arg, "pixiv.net")
+}
+
+// Converts the URLs in the flag values to their IDs using the given regex and keeps the other values as they are.
+//
+// Exits the program if any of the URLs does not match the expected host and path given by the example URL.
+func convertPixivUrlArgs(args []string, regex *regexp.Regexp, idIdx int, flagName, exampleUrl string) []string {
+	hasInvalid := false
+	ids := make([]string, 0, len(args))
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if !isUrlArg(arg) {
+			ids = append(ids, arg)
+			continue
+		}
+
+		matched := regex.FindStringSubmatch(arg)
+		if matched == nil {
+			hasInvalid = true
+			utils.PrintError(
+				"pixiv error %d: %q given to --%s is not a valid URL, expected a URL like %q",
+				utils.INPUT_ERROR,
+				arg,
+				flagName,
+				exampleUrl,
+			)
+			continue
+		}
+		ids = append(ids, matched[idIdx])
+	}
+
+	if hasInvalid {
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	return ids
+}
+
+// Returns the artwork IDs from the "--artwork_id" flag values
+// which can be a mix of artwork IDs and "https://www.pixiv.net/artworks/<artwork ID>" URLs.
+func ConvertPixivArtworkUrlArgs(args []string) []string {
+	return convertPixivUrlArgs(
+		args,
+		P_ILLUST_ARG_URL_REGEX,
+		P_ILLUST_ARG_REGEX_ID_INDEX,
+		"artwork_id",
+		"https://www.pixiv.net/artworks/<artwork ID>",
+	)
+}
+
+// Returns the illustrator IDs from the "--illustrator_id" flag values
+// which can be a mix of illustrator IDs and "https://www.pixiv.net/users/<illustrator ID>" URLs.
+func ConvertPixivIllustratorUrlArgs(args []string) []string {
+	return convertPixivUrlArgs(
+		args,
+		P_ARTIST_ARG_URL_REGEX,
+		P_ARTIST_ARG_REGEX_ID_INDEX,
+		"illustrator_id",
+		"https://www.pixiv.net/users/<illustrator ID>",
+	)
+}
+
 type parsedPixivArtist struct {
 	ArtistId string
 	PageNum  string
@@ -0,0 +1,53 @@
+package textparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertPixivUrlArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		convert func([]string) []string
+		want    []string
+	}{
+		{
+			name:    "artwork IDs",
+			args:    []string{"123", " 456 "},
+			convert: ConvertPixivArtworkUrlArgs,
+			want:    []string{"123", "456"},
+		},
+		{
+			name: "artwork URLs",
+			args: []string{
+				"https://www.pixiv.net/artworks/1",
+				"http://pixiv.net/en/artworks/2",
+				"https://www.pixiv.net/artworks/3#1",
+				"www.pixiv.net/artworks/4",
+				"pixiv.net/en/artworks/5?foo=bar",
+			},
+			convert: ConvertPixivArtworkUrlArgs,
+			want:    []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name: "illustrator URLs",
+			args: []string{
+				"https://www.pixiv.net/users/1",
+				"https://pixiv.net/en/users/2/illustrations",
+				"www.pixiv.net/users/3/",
+				"pixiv.net/users/4/manga",
+				"5",
+			},
+			convert: ConvertPixivIllustratorUrlArgs,
+			want:    []string{"1", "2", "3", "4", "5"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.convert(test.args); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("converted %q to %q, want %q", test.args, got, test.want)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"io"
 	"fmt"
 
-	"github.com/fatih/color"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
@@ -19,7 +18,7 @@ var PAGE_NUM_REGEX_STR = fmt.Sprintf(
 
 // openTextFile opens the text file at the given path and returns a os.File and a bufio.Reader.
 //
-// If an error occurs, the program will exit with an error message and status code 1.
+// If an error occurs, the program will exit with an error message and utils.EXIT_INPUT_ERROR.
 func openTextFile(textFilePath, website string) (*os.File, *bufio.Reader) {
 	f, err := os.Open(textFilePath)
 	if err != nil {
@@ -30,8 +29,8 @@ func openTextFile(textFilePath, website string) (*os.File, *bufio.Reader) {
 			textFilePath,
 			err,
 		)
-		color.Red(errMsg)
-		os.Exit(1)
+		utils.PrintError(errMsg)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 	return f, bufio.NewReader(f)
 }
@@ -39,7 +38,7 @@ func openTextFile(textFilePath, website string) (*os.File, *bufio.Reader) {
 // readLine reads a line from the given reader and returns the line as a slice of bytes.
 //
 // If the reader reaches EOF, the second return value will be true. Otherwise, it will be false.
-// However, if an error occurs, the program will exit with an error message and status code 1.
+// However, if an error occurs, the program will exit with an error message and utils.EXIT_INPUT_ERROR.
 func readLine(reader *bufio.Reader, textFilePath, website string) ([]byte, bool) {
 	lineBytes, err := utils.ReadLine(reader)
 	if err != nil {
@@ -53,8 +52,8 @@ func readLine(reader *bufio.Reader, textFilePath, website string) ([]byte, bool)
 			textFilePath,
 			err,
 		)
-		color.Red(errMsg)
-		os.Exit(1)
+		utils.PrintError(errMsg)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 	return lineBytes, false
 }
@@ -0,0 +1,267 @@
+package textparser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/fantia"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	G_URL_REGEX = regexp.MustCompile(
+		// ^https://drive\.google\.com/(?P<type>file/d|drive/(u/\d+/)?folders)/(?P<id>[\w-]+)(?:[/?#].*)?$
+		fmt.Sprintf(
+			`^%s(?:[/?#].*)?$`,
+			utils.GDRIVE_URL_REGEX.String(),
+		),
+	)
+	K_CREATOR_REGEX_SERVICE_INDEX = K_CREATOR_URL_REGEX.SubexpIndex(kemono.SERVICE_GROUP_NAME)
+	K_CREATOR_REGEX_TLD_INDEX     = K_CREATOR_URL_REGEX.SubexpIndex(kemono.TLD_GROUP_NAME)
+	K_POST_REGEX_TLD_INDEX        = K_POST_URL_REGEX.SubexpIndex(kemono.TLD_GROUP_NAME)
+)
+
+// ClassifiedUrls contains the download targets of the
+// classified URLs grouped by the site they belong to.
+type ClassifiedUrls struct {
+	// Sites contains the sites of the URLs in the order they first appeared
+	Sites []string
+
+	FantiaDl      *fantia.FantiaDl
+	PixivFanboxDl *pixivfanbox.PixivFanboxDl
+	PixivDl       *pixiv.PixivDl
+	KemonoDl      *kemono.KemonoDl
+	GdriveUrls    []string
+}
+
+func (c *ClassifiedUrls) addSite(site string) {
+	if !utils.SliceContains(c.Sites, site) {
+		c.Sites = append(c.Sites, site)
+	}
+}
+
+type urlPattern struct {
+	site    string
+	example string
+	regex   *regexp.Regexp
+
+	// adds the download target of the matched URL to the classified URLs
+	addTarget func(matched []string, classified *ClassifiedUrls)
+}
+
+// Note: the post URL patterns must come before the
+// creator URL patterns as the creator URL patterns are less specific.
+var urlPatterns = []*urlPattern{
+	{
+		site:    utils.FANTIA,
+		example: "https://fantia.jp/posts/<post ID>",
+		regex:   F_POST_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.FantiaDl.PostIds = append(
+				classified.FantiaDl.PostIds,
+				matched[F_POST_REGEX_POST_ID_INDEX],
+			)
+		},
+	},
+	{
+		site:    utils.FANTIA,
+		example: "https://fantia.jp/fanclubs/<fanclub ID>",
+		regex:   F_FANCLUB_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			fantiaDl := classified.FantiaDl
+			fantiaDl.FanclubIds = append(fantiaDl.FanclubIds, matched[F_FANCLUB_REGEX_FANCLUB_ID_INDEX])
+			fantiaDl.FanclubPageNums = append(fantiaDl.FanclubPageNums, matched[F_FANCLUB_REGEX_PAGE_NUM_INDEX])
+		},
+	},
+	{
+		site:    utils.PIXIV_FANBOX,
+		example: "https://<creator ID>.fanbox.cc/posts/<post ID>",
+		regex:   PF_POST_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.PixivFanboxDl.PostIds = append(
+				classified.PixivFanboxDl.PostIds,
+				matched[PF_POST_REGEX_POST_ID_INDEX],
+			)
+		},
+	},
+	{
+		site:    utils.PIXIV_FANBOX,
+		example: "https://<creator ID>.fanbox.cc",
+		regex:   PF_CREATOR_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			creatorId := matched[PF_CREATOR_REGEX_CREATOR_ID_INDEX_1]
+			if creatorId == "" {
+				creatorId = matched[PF_CREATOR_REGEX_CREATOR_ID_INDEX_2]
+			}
+
+			fanboxDl := classified.PixivFanboxDl
+			fanboxDl.CreatorIds = append(fanboxDl.CreatorIds, creatorId)
+			fanboxDl.CreatorPageNums = append(fanboxDl.CreatorPageNums, matched[PF_CREATOR_REGEX_PAGE_NUM_INDEX])
+		},
+	},
+	{
+		site:    utils.PIXIV,
+		example: "https://www.pixiv.net/artworks/<artwork ID>",
+		regex:   P_ILLUST_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.PixivDl.ArtworkIds = append(
+				classified.PixivDl.ArtworkIds,
+				matched[P_ILLUST_REGEX_ID_INDEX],
+			)
+		},
+	},
+	{
+		site:    utils.PIXIV,
+		example: "https://www.pixiv.net/users/<illustrator ID>",
+		regex:   P_ARTIST_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			pixivDl := classified.PixivDl
+			pixivDl.IllustratorIds = append(pixivDl.IllustratorIds, matched[P_ARTIST_REGEX_ID_INDEX])
+			pixivDl.IllustratorPageNums = append(pixivDl.IllustratorPageNums, matched[P_ARTIST_REGEX_PAGE_NUM_INDEX])
+		},
+	},
+	{
+		site:    utils.PIXIV,
+		example: "https://www.pixiv.net/tags/<tag name>",
+		regex:   P_TAG_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			tagName := matched[P_TAG_REGEX_TAG_INDEX]
+			if unescapedTagName, err := url.PathUnescape(tagName); err == nil {
+				tagName = unescapedTagName
+			}
+
+			pixivDl := classified.PixivDl
+			pixivDl.TagNames = append(pixivDl.TagNames, tagName)
+			pixivDl.TagNamesPageNums = append(pixivDl.TagNamesPageNums, matched[P_TAG_REGEX_PAGE_NUM_INDEX])
+		},
+	},
+	{
+		site:    utils.KEMONO,
+		example: "https://kemono.su/<service>/user/<creator ID>/post/<post ID>",
+		regex:   K_POST_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.KemonoDl.PostsToDl = append(classified.KemonoDl.PostsToDl, &models.KemonoPostToDl{
+				Service:   matched[K_POST_REGEX_SERVICE_INDEX],
+				CreatorId: matched[K_POST_REGEX_CREATOR_ID_INDEX],
+				PostId:    matched[K_POST_REGEX_POST_ID_INDEX],
+				Tld:       matched[K_POST_REGEX_TLD_INDEX],
+			})
+		},
+	},
+	{
+		site:    utils.KEMONO,
+		example: "https://kemono.su/<service>/user/<creator ID>",
+		regex:   K_CREATOR_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.KemonoDl.CreatorsToDl = append(classified.KemonoDl.CreatorsToDl, &models.KemonoCreatorToDl{
+				Service:   matched[K_CREATOR_REGEX_SERVICE_INDEX],
+				CreatorId: matched[K_CREATOR_REGEX_CREATOR_ID_INDEX],
+				PageNum:   matched[K_CREATOR_REGEX_PAGE_NUM_INDEX],
+				Tld:       matched[K_CREATOR_REGEX_TLD_INDEX],
+			})
+		},
+	},
+	{
+		site:    utils.GDRIVE,
+		example: "https://drive.google.com/drive/folders/<folder ID> or https://drive.google.com/file/d/<file ID>",
+		regex:   G_URL_REGEX,
+		addTarget: func(matched []string, classified *ClassifiedUrls) {
+			classified.GdriveUrls = append(classified.GdriveUrls, matched[0])
+		},
+	},
+}
+
+// Returns the supported URL patterns to be shown to the user
+func GetSupportedUrlPatterns() []string {
+	patterns := make([]string, len(urlPatterns))
+	for idx, pattern := range urlPatterns {
+		patterns[idx] = fmt.Sprintf(
+			"- %s: %s",
+			utils.GetReadableSiteStr(pattern.site),
+			pattern.example,
+		)
+	}
+	return patterns
+}
+
+// Returns an empty ClassifiedUrls with the download targets of each site initialised
+func newClassifiedUrls() *ClassifiedUrls {
+	return &ClassifiedUrls{
+		FantiaDl:      &fantia.FantiaDl{},
+		PixivFanboxDl: &pixivfanbox.PixivFanboxDl{},
+		PixivDl:       &pixiv.PixivDl{},
+		KemonoDl:      &kemono.KemonoDl{},
+	}
+}
+
+// Adds the download target of the given URL to the classified URLs.
+//
+// Returns false if the URL is not supported.
+func (c *ClassifiedUrls) addUrl(inputUrl string) bool {
+	for _, pattern := range urlPatterns {
+		if matched := pattern.regex.FindStringSubmatch(inputUrl); matched != nil {
+			pattern.addTarget(matched, c)
+			c.addSite(pattern.site)
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyUrls maps each of the given URLs to the site and the download target it corresponds to.
+//
+// Page numbers can be appended to the creator, fanclub, illustrator, and tag URLs
+// like in the text files, e.g. "https://fantia.jp/fanclubs/1234; 1-3".
+//
+// If any of the URLs is not supported, an error listing the
+// unsupported URLs and the supported URL patterns will be returned.
+func ClassifyUrls(urls []string) (*ClassifiedUrls, error) {
+	return ClassifyInput(urls, "")
+}
+
+// ClassifyInput is the same as ClassifyUrls but also adds the
+// download targets in the input file at the given path (if any).
+//
+// The input file contains one URL or "site:type:id" entry per line
+// where blank lines and lines starting with "#" will be ignored.
+func ClassifyInput(urls []string, inputFilePath string) (*ClassifiedUrls, error) {
+	classified := newClassifiedUrls()
+	var unsupportedUrls []string
+	for _, inputUrl := range urls {
+		inputUrl = strings.TrimSpace(inputUrl)
+		if inputUrl == "" {
+			continue
+		}
+
+		if !classified.addUrl(inputUrl) {
+			unsupportedUrls = append(unsupportedUrls, inputUrl)
+		}
+	}
+
+	if len(unsupportedUrls) > 0 {
+		return nil, fmt.Errorf(
+			"error %d: unsupported URL(s):\n- %s\n\nSupported URL patterns:\n%s",
+			utils.INPUT_ERROR,
+			strings.Join(unsupportedUrls, "\n- "),
+			strings.Join(GetSupportedUrlPatterns(), "\n"),
+		)
+	}
+	if inputFilePath != "" {
+		if err := classified.addInputFile(inputFilePath); err != nil {
+			return nil, err
+		}
+	}
+	if len(classified.Sites) == 0 {
+		return nil, fmt.Errorf(
+			"error %d: no URLs to download from were given",
+			utils.INPUT_ERROR,
+		)
+	}
+	return classified, nil
+}
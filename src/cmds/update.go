@@ -0,0 +1,82 @@
+package cmds
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateCheckOnly bool
+	updateCmd       = &cobra.Command{
+		Use:   "update",
+		Short: "Update the program to the latest version",
+		Long: utils.CombineStringsWithNewline(
+			"Check for the latest release of the program on GitHub and replace the current binary with it.",
+			"The downloaded binary will be verified against the SHA256 hashes published with the release before replacing the current binary.",
+		),
+		Run: func(cmd *cobra.Command, args []string) {
+			release, err := request.GetLatestRelease()
+			if err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.GetExitCodeFromErr(err))
+			}
+
+			outdated, err := request.IsOutdated(release.TagName)
+			if err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.GetExitCodeFromErr(err))
+			}
+			if !outdated {
+				color.Green("This program is already up to date! (%s)", utils.VERSION)
+				return
+			}
+
+			color.Yellow("A new version %q is available at %s", release.TagName, release.HtmlUrl)
+			if updateCheckOnly {
+				return
+			}
+
+			exePath, err := utils.GetExecutablePath()
+			if err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.GetExitCodeFromErr(err))
+			}
+			if err := request.UpdateExecutable(release, exePath); err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.GetExitCodeFromErr(err))
+			}
+			color.Green("Successfully updated the program from %s to %s!", utils.VERSION, release.TagName)
+		},
+	}
+)
+
+// Checks for the latest version of the program at most
+// once per day unless the user has disabled the check.
+func runPassiveUpdateCheck(cmd *cobra.Command) {
+	if disableUpdateCheck || utils.IsUpdateCheckDisabledByEnv() || cmd == updateCmd {
+		return
+	}
+	if !utils.IsUpdateCheckDue() {
+		return
+	}
+
+	if err := request.CheckVer(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+		return
+	}
+	if err := utils.SaveUpdateCheckTime(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+}
+
+func init() {
+	updateCmd.Flags().BoolVar(
+		&updateCheckOnly,
+		"check",
+		false,
+		"Only check if a new version is available without updating the program.",
+	)
+	RootCmd.AddCommand(updateCmd)
+}
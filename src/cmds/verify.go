@@ -0,0 +1,147 @@
+package cmds
+
+import (
+	"fmt"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyPath                 string
+	verifyCheckUpstream        bool
+	verifyGdriveApiKey         string
+	verifyGdriveServiceAccPath string
+	verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify downloaded GDrive files against their saved checksums",
+		Long: utils.CombineStringsWithNewline(
+			fmt.Sprintf(
+				"Verify the downloaded GDrive files recorded in the %s files in the given directory and its subdirectories.",
+				gdrive.CHECKSUMS_FILENAME,
+			),
+			"Files that are missing or do not match their md5 checksums will be reported.",
+			"Optionally, the current md5 checksums on GDrive can be retrieved to report the files that have changed upstream.",
+		),
+		// verifying the files locally does not require an internet connection
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			applyAppDataDir()
+			if verifyCheckUpstream {
+				request.CheckInternetConnection()
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if !utils.PathExists(verifyPath) {
+				utils.PrintError("The directory to verify, %q, does not exist.", verifyPath)
+				utils.Exit(utils.EXIT_INPUT_ERROR)
+			}
+
+			var gdriveClient *gdrive.GDrive
+			verifyConfig := &configs.Config{
+				UserAgent: userAgent,
+			}
+			if verifyCheckUpstream {
+				gdriveClient = gdrive.GetNewGDrive(
+					verifyGdriveApiKey,
+					verifyGdriveServiceAccPath,
+					verifyConfig,
+					utils.MAX_CONCURRENT_DOWNLOADS,
+				)
+			}
+
+			verifiedFiles, err := gdrive.VerifyChecksums(verifyPath, gdriveClient, verifyConfig)
+			if err != nil {
+				utils.PrintError(err.Error())
+				utils.Exit(utils.GetExitCodeFromErr(err))
+			}
+			if len(verifiedFiles) == 0 {
+				color.Yellow("No files recorded in any %s files were found in %q.", gdrive.CHECKSUMS_FILENAME, verifyPath)
+				return
+			}
+
+			statusCounts := make(map[string]int)
+			for _, verifiedFile := range verifiedFiles {
+				statusCounts[verifiedFile.Status]++
+				if verifiedFile.Status == gdrive.VERIFY_OK {
+					continue
+				}
+
+				msg := fmt.Sprintf("[%s] %s", verifiedFile.Status, verifiedFile.FilePath)
+				if verifiedFile.Details != "" {
+					msg += fmt.Sprintf(" (%s)", verifiedFile.Details)
+				}
+				if verifiedFile.Status == gdrive.VERIFY_CHANGED_UPSTREAM {
+					color.Yellow(msg)
+				} else {
+					utils.PrintError(msg)
+				}
+			}
+
+			fmt.Println()
+			fmt.Printf(
+				"Verified %d file(s): %d ok, %d missing, %d corrupt",
+				len(verifiedFiles),
+				statusCounts[gdrive.VERIFY_OK],
+				statusCounts[gdrive.VERIFY_MISSING],
+				statusCounts[gdrive.VERIFY_CORRUPT],
+			)
+			if verifyCheckUpstream {
+				fmt.Printf(
+					", %d changed upstream, %d failed upstream checks",
+					statusCounts[gdrive.VERIFY_CHANGED_UPSTREAM],
+					statusCounts[gdrive.VERIFY_UPSTREAM_ERROR],
+				)
+			}
+			fmt.Println()
+
+			if statusCounts[gdrive.VERIFY_OK] != len(verifiedFiles) {
+				utils.Exit(utils.EXIT_PARTIAL_ERROR)
+			}
+			color.Green("All files are intact!")
+		},
+	}
+)
+
+func init() {
+	verifyCmd.Flags().StringVar(
+		&verifyPath,
+		"path",
+		"",
+		fmt.Sprintf(
+			"Path to the directory containing the downloaded files and their %s files to verify.",
+			gdrive.CHECKSUMS_FILENAME,
+		),
+	)
+	verifyCmd.MarkFlagRequired("path")
+	verifyCmd.MarkFlagDirname("path")
+	verifyCmd.Flags().BoolVar(
+		&verifyCheckUpstream,
+		"check_upstream",
+		false,
+		utils.CombineStringsWithNewline(
+			"Also retrieve the current md5 checksums of the files on GDrive to report the files that have changed upstream.",
+			"Requires a Google Drive API key or service account credentials file.",
+		),
+	)
+	verifyCmd.Flags().StringVar(
+		&verifyGdriveApiKey,
+		"gdrive_api_key",
+		"",
+		utils.CombineStringsWithNewline(
+			"Google Drive API key to use for retrieving the current md5 checksums with --check_upstream.",
+			"Guide: https://github.com/KJHJason/Cultured-Downloader/blob/main/doc/google_api_setup_guide.md",
+		),
+	)
+	verifyCmd.Flags().StringVar(
+		&verifyGdriveServiceAccPath,
+		"gdrive_service_acc_path",
+		"",
+		"Path to the Google Drive service account JSON file to use for retrieving the current md5 checksums with --check_upstream.",
+	)
+	registerFilePathCompletion(verifyCmd, "gdrive_service_acc_path")
+	RootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,49 @@
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	versionJson bool
+	versionCmd  = &cobra.Command{
+		Use:   "version",
+		Short: "Print the version and build details of the program",
+		Long: utils.CombineStringsWithNewline(
+			"Print the version, git commit, build date, Go version, and whether HTTP/3 support was compiled.",
+			"Please include the output when reporting a bug.",
+		),
+		Run: func(cmd *cobra.Command, args []string) {
+			buildInfo := utils.GetBuildInfo()
+			if !versionJson {
+				fmt.Println(buildInfo.String())
+				return
+			}
+
+			buildInfoJson, err := json.MarshalIndent(buildInfo, "", "    ")
+			if err != nil {
+				utils.PrintError(
+					"error %d: failed to marshal the build details to JSON, more info => %v",
+					utils.JSON_ERROR,
+					err,
+				)
+				utils.Exit(utils.EXIT_GENERAL_ERROR)
+			}
+			fmt.Println(string(buildInfoJson))
+		},
+	}
+)
+
+func init() {
+	versionCmd.Flags().BoolVar(
+		&versionJson,
+		"json",
+		false,
+		"Print the build details in JSON format for scripts.",
+	)
+	RootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,311 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	DEFAULT_WATCH_INTERVAL = 6 * time.Hour
+	MIN_WATCH_INTERVAL     = time.Minute
+	DEFAULT_WATCH_JITTER   = 5 * time.Minute
+)
+
+var (
+	watchMode     bool
+	watchInterval time.Duration
+	watchJitter   time.Duration
+
+	// Set while the watch mode is running so that the site commands
+	// run by the "run" command's jobs will not start a watch mode of their own
+	isWatching bool
+
+	// Commands that download from the sites and hence can be re-run in the watch mode
+	watchableCmds = []*cobra.Command{
+		fantiaCmd,
+		pixivFanboxCmd,
+		pixivCmd,
+		kemonoCmd,
+		downloadCmd,
+		runCmd,
+	}
+)
+
+// Returns true if the given command can be re-run in the watch mode
+func isWatchableCmd(cmd *cobra.Command) bool {
+	for _, watchableCmd := range watchableCmds {
+		if cmd == watchableCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// Validates the "--watch" and "--interval" flags for the given command
+func validateWatchFlags(cmd *cobra.Command) {
+	if !watchMode {
+		return
+	}
+
+	if !isWatchableCmd(cmd) {
+		watchableCmdNames := make([]string, len(watchableCmds))
+		for idx, watchableCmd := range watchableCmds {
+			watchableCmdNames[idx] = watchableCmd.Name()
+		}
+		utils.PrintError(
+			"error %d: --watch can only be used with the %s commands",
+			utils.INPUT_ERROR,
+			strings.Join(watchableCmdNames, ", "),
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	if watchInterval < MIN_WATCH_INTERVAL {
+		utils.PrintError(
+			"error %d: --interval must be at least %s, got %s",
+			utils.INPUT_ERROR,
+			MIN_WATCH_INTERVAL,
+			watchInterval,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+	if !cmd.Flags().Changed("jitter") && watchJitter > watchInterval/10 {
+		watchJitter = watchInterval / 10
+	}
+	if watchJitter < 0 || watchJitter >= watchInterval {
+		utils.PrintError(
+			"error %d: --jitter must be between 0 and the --interval of %s, got %s",
+			utils.INPUT_ERROR,
+			watchInterval,
+			watchJitter,
+		)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
+	}
+}
+
+// cmdFlagSnapshot is the values of a command's flags before its first run.
+//
+// The site commands append the IDs from the URLs and the input file to their flag values,
+// so the flags must be restored before every cycle to avoid processing the same IDs multiple times.
+type cmdFlagSnapshot struct {
+	cmd     *cobra.Command
+	values  map[string][]string
+	changed map[string]bool
+}
+
+// Saves the current values of the local and inherited flags of the given command
+func newCmdFlagSnapshot(cmd *cobra.Command) *cmdFlagSnapshot {
+	snapshot := &cmdFlagSnapshot{
+		cmd:     cmd,
+		values:  make(map[string][]string),
+		changed: make(map[string]bool),
+	}
+	visitCmdFlags(cmd, func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			snapshot.values[flag.Name] = append([]string{}, sliceValue.GetSlice()...)
+		} else {
+			snapshot.values[flag.Name] = []string{flag.Value.String()}
+		}
+		snapshot.changed[flag.Name] = flag.Changed
+	})
+	return snapshot
+}
+
+// Restores the local and inherited flags of the command to the saved values
+func (snapshot *cmdFlagSnapshot) restore() {
+	visitCmdFlags(snapshot.cmd, func(flag *pflag.Flag) {
+		values, ok := snapshot.values[flag.Name]
+		if !ok {
+			return
+		}
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			sliceValue.Replace(values)
+		} else {
+			flag.Value.Set(values[0])
+		}
+		flag.Changed = snapshot.changed[flag.Name]
+	})
+}
+
+// Logs the one-line result of a watch cycle using the difference of the run stats before and after the cycle.
+//
+// Returns true if the cycle has finished without any failures.
+func logWatchCycleSummary(cycle int, before, after *utils.RunReport, elapsed time.Duration) bool {
+	beforeTotals := before.GetTotals()
+	afterTotals := after.GetTotals()
+	filesFailed := afterTotals.FilesFailed - beforeTotals.FilesFailed
+	itemsFailed := len(after.FailedItems) - len(before.FailedItems)
+	succeeded := itemsFailed == 0 && filesFailed == 0
+	result := "ok"
+	if !succeeded {
+		result = "finished with failures"
+	}
+	msg := fmt.Sprintf(
+		"Watch cycle #%d %s in %s: %d post(s) processed, %d file(s) downloaded, %d skipped, %d failed",
+		cycle,
+		result,
+		elapsed.Round(time.Second),
+		afterTotals.PostsProcessed-beforeTotals.PostsProcessed,
+		afterTotals.FilesDownloaded-beforeTotals.FilesDownloaded,
+		afterTotals.FilesSkipped-beforeTotals.FilesSkipped,
+		filesFailed,
+	)
+	if succeeded {
+		color.Green(msg)
+	} else {
+		color.Yellow(msg)
+	}
+	utils.LogError(nil, msg, false, utils.INFO)
+	return succeeded
+}
+
+// Returns the delay until the next watch cycle which is the interval with a random jitter added
+// so that the requests will not be sent to the sites at the exact same time every cycle.
+func getNextWatchCycleDelay() time.Duration {
+	if watchJitter <= 0 {
+		return watchInterval
+	}
+	return watchInterval + utils.GetRandomTime(0, watchJitter.Seconds())
+}
+
+// Runs the given command's Run function once or repeatedly on the interval if "--watch" is enabled.
+//
+// The cycles are scheduled from the start of the previous cycle and if the previous cycle is still running
+// when the next one is due, the next cycle will be skipped entirely instead of running concurrently.
+//
+// After a cycle has finished without failures, its start time will be used as "--since" for the next cycles
+// so that only the posts published since then will be downloaded. Otherwise, the posts since the
+// last successful cycle will be checked again.
+//
+// A SIGINT/SIGTERM signal will cancel the current cycle (if any) via utils.GetInterruptCtx() and stop the watch mode
+// after the cycle has returned. A second signal will exit the program right away.
+func runWithWatch(run func(cmd *cobra.Command, args []string)) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if !watchMode || isWatching {
+			run(cmd, args)
+			return
+		}
+		isWatching = true
+		defer func() {
+			isWatching = false
+		}()
+
+		sigs := make(chan os.Signal, 2)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+
+		snapshot := newCmdFlagSnapshot(cmd)
+		cycleDone := make(chan struct{}, 1)
+		isRunning := false
+		cycle := 0
+		startCycle := func() {
+			cycle++
+			isRunning = true
+			snapshot.restore()
+			go func(cycle int) {
+				before := utils.RUN_STATS.GetReport()
+				startTime := time.Now()
+				run(cmd, args)
+				if logWatchCycleSummary(cycle, before, utils.RUN_STATS.GetReport(), time.Since(startTime)) {
+					utils.SetSince(startTime)
+					utils.LogError(
+						nil,
+						fmt.Sprintf("Watch cycle #%d: the next cycles will only download the posts published since %s", cycle, startTime.Format(time.RFC3339)),
+						false,
+						utils.INFO,
+					)
+				}
+				cycleDone <- struct{}{}
+			}(cycle)
+		}
+
+		color.Cyan(
+			"Watch mode enabled, the downloads will be re-run every %s (with up to %s of jitter). Press Ctrl+C to stop.",
+			watchInterval,
+			watchJitter,
+		)
+		nextCycleDelay := getNextWatchCycleDelay()
+		timer := time.NewTimer(nextCycleDelay)
+		defer timer.Stop()
+		nextCycleTime := time.Now().Add(nextCycleDelay)
+		startCycle()
+		for {
+			select {
+			case <-sigs:
+				color.Yellow("Stopping the watch mode...")
+				if isRunning {
+					color.Yellow("Cancelling the current cycle, press Ctrl+C again to exit immediately...")
+					utils.CancelInterruptCtx()
+					select {
+					case <-cycleDone:
+					case <-sigs:
+						os.Exit(utils.EXIT_INTERRUPTED)
+					}
+				}
+				return
+			case <-cycleDone:
+				isRunning = false
+				color.Cyan("Next cycle at %s", nextCycleTime.Format("2006-01-02 15:04:05"))
+			case <-timer.C:
+				nextCycleDelay = getNextWatchCycleDelay()
+				timer.Reset(nextCycleDelay)
+				nextCycleTime = time.Now().Add(nextCycleDelay)
+				if isRunning {
+					cycle++
+					msg := fmt.Sprintf(
+						"Skipped watch cycle #%d as the previous cycle is still running",
+						cycle,
+					)
+					color.Yellow(msg)
+					utils.LogError(nil, msg, false, utils.INFO)
+					continue
+				}
+				startCycle()
+			}
+		}
+	}
+}
+
+func init() {
+	RootCmd.PersistentFlags().BoolVar(
+		&watchMode,
+		"watch",
+		false,
+		utils.CombineStringsWithNewline(
+			"Keep the program running and re-run the downloads on the interval set by \"--interval\".",
+			"Files that have already been downloaded will be skipped so only new posts will be downloaded in each cycle.",
+			"A cycle will be skipped if the previous cycle is still running when it is due.",
+			"Press Ctrl+C to cancel the current cycle and stop the watch mode.",
+		),
+	)
+	RootCmd.PersistentFlags().DurationVar(
+		&watchInterval,
+		"interval",
+		DEFAULT_WATCH_INTERVAL,
+		fmt.Sprintf(
+			"The interval between the cycles of the watch mode like \"30m\" or \"6h\", must be at least %s.",
+			MIN_WATCH_INTERVAL,
+		),
+	)
+	RootCmd.PersistentFlags().DurationVar(
+		&watchJitter,
+		"jitter",
+		DEFAULT_WATCH_JITTER,
+		utils.CombineStringsWithNewline(
+			"The maximum random delay added to the \"--interval\" of each cycle of the watch mode",
+			"to avoid sending requests to the sites at the exact same time every cycle, e.g. \"0\" to disable it.",
+			"If not set, it will be capped to 10% of the interval.",
+		),
+	)
+	for _, cmd := range watchableCmds {
+		cmd.Run = runWithWatch(cmd.Run)
+	}
+}
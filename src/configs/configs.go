@@ -1,10 +1,9 @@
 package configs
 
 import (
-	"os"
 	"os/exec"
 
-	"github.com/fatih/color"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
 type Config struct {
@@ -18,6 +17,10 @@ type Config struct {
 	// If false, the download process will be skipped if the file already exists
 	OverwriteFiles bool
 
+	// RedownloadIfSmaller is a flag to download existing files again if they are
+	// smaller than the file size reported by the server like truncated downloads
+	RedownloadIfSmaller bool
+
 	// Log any detected URLs of the post content that are being downloaded
 	// Despite the variable name, it only logs URLs to any supported 
 	// external file hosting providers such as MEGA, Google Drive, etc.
@@ -25,12 +28,37 @@ type Config struct {
 
 	// UserAgent is the user agent to be used in the download process
 	UserAgent      string
+
+	// PasswordList is the candidate passwords to try when extracting
+	// downloaded archives of posts where a password was detected
+	PasswordList   []string
+
+	// SampleSize limits the downloads to the first SampleSize files of each post
+	// so that the user can preview a creator cheaply (0 means unlimited)
+	SampleSize     int
+
+	// WriteXattrs is a flag to write the source metadata of the downloaded files
+	// like the post URL, creator, and tags into their extended attributes (Linux/macOS only)
+	WriteXattrs    bool
+
+	// WriteMetadata is a flag to write the details of each post like its title, creator,
+	// tags, post date, and URL into a metadata JSON file in the post folder
+	WriteMetadata  bool
+
+	// ResumeDownload is a flag to write the downloads to .part files and to resume
+	// the downloads from the existing .part files with Range requests (except GDrive)
+	ResumeDownload bool
 }
 
-func (c *Config) ValidateFfmpeg() {
+// Returns true if the FFmpeg binary at FfmpegPath can be found
+func (c *Config) HasFfmpeg() bool {
 	_, ffmpegErr := exec.LookPath(c.FfmpegPath)
-	if ffmpegErr != nil {
-		color.Red("FFmpeg is not installed.\nPlease install it from https://ffmpeg.org/ and either use the --ffmpeg_path flag or add the FFmpeg path to your PATH environment variable or alias depending on your OS.")
-		os.Exit(1)
+	return ffmpegErr == nil
+}
+
+func (c *Config) ValidateFfmpeg() {
+	if !c.HasFfmpeg() {
+		utils.PrintError("FFmpeg is not installed.\nPlease install it from https://ffmpeg.org/ and either use the --ffmpeg_path flag or add the FFmpeg path to your PATH environment variable or alias depending on your OS.")
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 }
@@ -1,20 +1,17 @@
 package main
 
 import (
+	"os"
+
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 )
 
 func main() {
-	request.CheckInternetConnection()
-	if err := request.CheckVer(); err != nil {
-		utils.LogError(err, "", false, utils.ERROR)
-	}
-
-	if err := utils.DeleteEmptyAndOldLogs(); err != nil {
-		utils.LogError(err, "", false, utils.ERROR)
+	utils.OnExit(utils.PrintErrorSummary)
+	if err := cmds.RootCmd.Execute(); err != nil {
+		// errors returned by cobra are due to invalid flags or arguments
+		os.Exit(utils.EXIT_INPUT_ERROR)
 	}
-
-	cmds.RootCmd.Execute()
+	utils.Exit(utils.GetRunExitCode())
 }
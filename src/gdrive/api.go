@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"net/http"
+	"path/filepath"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
@@ -147,7 +148,14 @@ func (gdrive *GDrive) GetFolderContents(folderId, logPath string, config *config
 }
 
 // Retrieves the content of a GDrive folder and its subfolders recursively using GDrive API v3
+//
+// The RelPath of each file will be set to the path of its subfolder relative to the given folder
+// so that the folder structure can be recreated when downloading the files.
 func (gdrive *GDrive) GetNestedFolderContents(folderId, logPath string, config *configs.Config) ([]*models.GdriveFileToDl, error) {
+	return gdrive.getNestedFolderContents(folderId, "", logPath, config)
+}
+
+func (gdrive *GDrive) getNestedFolderContents(folderId, relPath, logPath string, config *configs.Config) ([]*models.GdriveFileToDl, error) {
 	var files []*models.GdriveFileToDl
 	folderContents, err := gdrive.GetFolderContents(folderId, logPath, config)
 	if err != nil {
@@ -155,13 +163,19 @@ func (gdrive *GDrive) GetNestedFolderContents(folderId, logPath string, config *
 	}
 
 	for _, file := range folderContents {
-		if file.MimeType == "application/vnd.google-apps.folder" {
-			subFolderFiles, err := gdrive.GetNestedFolderContents(file.Id, logPath, config)
+		if file.MimeType == GDRIVE_FOLDER_MIME_TYPE {
+			subFolderFiles, err := gdrive.getNestedFolderContents(
+				file.Id,
+				filepath.Join(relPath, utils.CleanPathName(file.Name)),
+				logPath,
+				config,
+			)
 			if err != nil {
 				return nil, err
 			}
 			files = append(files, subFolderFiles...)
 		} else {
+			file.RelPath = relPath
 			files = append(files, file)
 		}
 	}
@@ -0,0 +1,252 @@
+package gdrive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Name of the manifest file saved in each folder with downloaded GDrive files.
+//
+// Each line contains the md5 checksum, the GDrive file ID, and the file name separated by a tab.
+const CHECKSUMS_FILENAME = "checksums.txt"
+
+// Used to prevent the download workers from overwriting each other's changes to the manifest files
+var checksumsMu sync.Mutex
+
+// ChecksumEntry is a file recorded in the checksums.txt manifest
+type ChecksumEntry struct {
+	Md5Checksum string
+	FileId      string
+	FileName    string
+}
+
+// Reads the entries of the checksums.txt manifest at the given path
+func ReadChecksumsFile(manifestPath string) ([]*ChecksumEntry, error) {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"gdrive error %d: failed to open checksums file at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	var entries []*ChecksumEntry
+	reader := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		lineBytes, err := utils.ReadLine(reader)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf(
+				"gdrive error %d: failed to read checksums file at %s, more info => %v",
+				utils.OS_ERROR,
+				manifestPath,
+				err,
+			)
+		}
+
+		line := strings.TrimSuffix(string(lineBytes), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf(
+				"gdrive error %d: malformed entry on line %d of checksums file at %s",
+				utils.INPUT_ERROR,
+				lineNum,
+				manifestPath,
+			)
+		}
+		entries = append(entries, &ChecksumEntry{
+			Md5Checksum: parts[0],
+			FileId:      parts[1],
+			FileName:    parts[2],
+		})
+	}
+	return entries, nil
+}
+
+// Adds or updates the checksum of the downloaded GDrive file
+// in the checksums.txt manifest of the folder it was downloaded to.
+func recordChecksum(file *models.GdriveFileToDl) error {
+	if file.Md5Checksum == "" {
+		return nil
+	}
+
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+
+	manifestPath := filepath.Join(file.FilePath, CHECKSUMS_FILENAME)
+	var entries []*ChecksumEntry
+	if utils.PathExists(manifestPath) {
+		var err error
+		if entries, err = ReadChecksumsFile(manifestPath); err != nil {
+			return err
+		}
+	}
+
+	isUpdated := false
+	for _, entry := range entries {
+		if entry.FileName == file.Name {
+			entry.Md5Checksum = file.Md5Checksum
+			entry.FileId = file.Id
+			isUpdated = true
+			break
+		}
+	}
+	if !isUpdated {
+		entries = append(entries, &ChecksumEntry{
+			Md5Checksum: file.Md5Checksum,
+			FileId:      file.Id,
+			FileName:    file.Name,
+		})
+	}
+
+	var sb strings.Builder
+	for _, entry := range entries {
+		sb.WriteString(fmt.Sprintf("%s\t%s\t%s\n", entry.Md5Checksum, entry.FileId, entry.FileName))
+	}
+	if err := os.WriteFile(manifestPath, []byte(sb.String()), 0666); err != nil {
+		return fmt.Errorf(
+			"gdrive error %d: failed to write checksums file at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	return nil
+}
+
+// Statuses of a file verified against the checksums.txt manifest
+const (
+	VERIFY_OK               = "ok"
+	VERIFY_MISSING          = "missing"
+	VERIFY_CORRUPT          = "corrupt"
+	VERIFY_CHANGED_UPSTREAM = "changed upstream"
+	VERIFY_UPSTREAM_ERROR   = "upstream check failed"
+)
+
+// VerifiedFile is the result of verifying a file recorded in a checksums.txt manifest
+type VerifiedFile struct {
+	FilePath string
+	Status   string
+	Details  string
+}
+
+// Returns the paths of the checksums.txt manifests in the given directory and its subdirectories
+func findChecksumsFiles(dirPath string) ([]string, error) {
+	var manifestPaths []string
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == CHECKSUMS_FILENAME {
+			manifestPaths = append(manifestPaths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"gdrive error %d: failed to search for checksums files in %s, more info => %v",
+			utils.OS_ERROR,
+			dirPath,
+			err,
+		)
+	}
+	sort.Strings(manifestPaths)
+	return manifestPaths, nil
+}
+
+// Verifies the file of the given entry against the md5 checksum in the manifest
+func verifyChecksumEntry(dirPath string, entry *ChecksumEntry) *VerifiedFile {
+	filePath := filepath.Join(dirPath, entry.FileName)
+	verifiedFile := &VerifiedFile{
+		FilePath: filePath,
+		Status:   VERIFY_OK,
+	}
+	if !utils.PathExists(filePath) {
+		verifiedFile.Status = VERIFY_MISSING
+		return verifiedFile
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		verifiedFile.Status = VERIFY_CORRUPT
+		verifiedFile.Details = err.Error()
+		return verifiedFile
+	}
+	defer file.Close()
+
+	md5Checksum, err := md5HashFile(file)
+	if err != nil {
+		verifiedFile.Status = VERIFY_CORRUPT
+		verifiedFile.Details = err.Error()
+	} else if md5Checksum != entry.Md5Checksum {
+		verifiedFile.Status = VERIFY_CORRUPT
+		verifiedFile.Details = fmt.Sprintf("expected md5 %s but got %s", entry.Md5Checksum, md5Checksum)
+	}
+	return verifiedFile
+}
+
+// Verifies the files recorded in the checksums.txt manifests in the given directory and its subdirectories.
+//
+// If gdrive is not nil, the current md5 checksums of the files on GDrive will be retrieved
+// to flag the files that have changed upstream since they were downloaded.
+func VerifyChecksums(dirPath string, gdrive *GDrive, config *configs.Config) ([]*VerifiedFile, error) {
+	manifestPaths, err := findChecksumsFiles(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifiedFiles []*VerifiedFile
+	for _, manifestPath := range manifestPaths {
+		entries, err := ReadChecksumsFile(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+
+		manifestDir := filepath.Dir(manifestPath)
+		for _, entry := range entries {
+			verifiedFile := verifyChecksumEntry(manifestDir, entry)
+			if verifiedFile.Status == VERIFY_OK && gdrive != nil {
+				fileInfo, err := gdrive.GetFileDetails(
+					&models.GDriveToDl{
+						Id:       entry.FileId,
+						Type:     "file",
+						FilePath: manifestDir,
+					},
+					config,
+				)
+				if err != nil {
+					verifiedFile.Status = VERIFY_UPSTREAM_ERROR
+					verifiedFile.Details = censorApiKeyFromStr(err.Error())
+				} else if fileInfo.Md5Checksum != entry.Md5Checksum {
+					verifiedFile.Status = VERIFY_CHANGED_UPSTREAM
+					verifiedFile.Details = fmt.Sprintf(
+						"md5 on GDrive is now %s instead of %s",
+						fileInfo.Md5Checksum,
+						entry.Md5Checksum,
+					)
+				}
+			}
+			verifiedFiles = append(verifiedFiles, verifiedFile)
+		}
+	}
+	return verifiedFiles, nil
+}
@@ -2,17 +2,15 @@ package gdrive
 
 import (
 	"context"
-	"crypto/md5"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
+	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive/models"
@@ -22,8 +20,7 @@ import (
 )
 
 func md5HashFile(file *os.File) (string, error) {
-	md5Checksum := md5.New()
-	_, err := io.Copy(md5Checksum, file)
+	md5Checksum, err := utils.Md5Checksum(file)
 	if err != nil {
 		return "", fmt.Errorf(
 			"gdrive error %d: failed to calculate file's md5 checksum, more info => %v",
@@ -31,18 +28,26 @@ func md5HashFile(file *os.File) (string, error) {
 			err,
 		)
 	}
-	return fmt.Sprintf("%x", md5Checksum.Sum(nil)), nil
+	return md5Checksum, nil
 }
 
-func checkIfCanSkipDl(filePath string, fileInfo *models.GdriveFileToDl) (bool, error) {
+// Checks if the GDrive file has already been downloaded by comparing the file size and the md5 checksum.
+//
+// If verifyChecksum is false or GDrive did not return a md5 checksum for the file,
+// an existing file with the same size will be treated as already downloaded without hashing it.
+//
+// If config.RedownloadIfSmaller is enabled, the second return value will be true if the existing file is
+// smaller than the file on GDrive and will be downloaded again while existing files that are
+// larger than the file on GDrive will be treated as complete instead of being downloaded again.
+func checkIfCanSkipDl(filePath string, fileInfo *models.GdriveFileToDl, verifyChecksum bool, config *configs.Config) (bool, bool, error) {
 	if !utils.PathExists(filePath) {
-		return false, nil
+		return false, false, nil
 	}
 
 	// check the md5 checksum and the file size
 	file, err := os.OpenFile(filePath, os.O_RDONLY, 0666)
 	if err != nil {
-		return false, fmt.Errorf(
+		return false, false, fmt.Errorf(
 			"gdrive error %d: failed to open file %q, more info => %v",
 			utils.OS_ERROR,
 			filePath,
@@ -53,7 +58,7 @@ func checkIfCanSkipDl(filePath string, fileInfo *models.GdriveFileToDl) (bool, e
 
 	fileStatInfo, err := file.Stat()
 	if err != nil {
-		return false, fmt.Errorf(
+		return false, false, fmt.Errorf(
 			"gdrive error %d: failed to get file stat info of %q, more info => %v",
 			utils.OS_ERROR,
 			filePath,
@@ -63,43 +68,73 @@ func checkIfCanSkipDl(filePath string, fileInfo *models.GdriveFileToDl) (bool, e
 
 	fileSize := fileStatInfo.Size()
 	if strconv.FormatInt(fileSize, 10) != fileInfo.Size {
-		return false, nil
+		expectedSize, err := strconv.ParseInt(fileInfo.Size, 10, 64)
+		if err != nil {
+			return false, false, nil
+		}
+		if fileSize < expectedSize {
+			return false, config.RedownloadIfSmaller, nil
+		}
+		return config.RedownloadIfSmaller, false, nil
+	}
+
+	if !verifyChecksum || fileInfo.Md5Checksum == "" {
+		return true, false, nil
 	}
 
 	md5Checksum, err := md5HashFile(file)
 	if err != nil {
-		return false, err
+		return false, false, err
 	}
-	return md5Checksum == fileInfo.Md5Checksum, nil
+	return md5Checksum == fileInfo.Md5Checksum, false, nil
 }
 
 // Downloads the given GDrive file using GDrive API v3
 //
 // If the md5Checksum has a mismatch, the file will be overwritten and downloaded again
-func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath string, config *configs.Config, queue chan struct{}) error {
-	skipDl, err := checkIfCanSkipDl(filePath, fileInfo)
+//
+// The bytes written will be reported to the given download progress (if any).
+func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath string, config *configs.Config, queue chan struct{}, dlProgress *spinner.DlProgress) error {
+	skipDl, isRedownload, err := checkIfCanSkipDl(filePath, fileInfo, gdrive.verifyChecksum, config)
+	if skipDl {
+		utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_EXISTS)
+		utils.LogError(
+			nil,
+			fmt.Sprintf("GDrive file %q has already been downloaded to %q, skipping", fileInfo.Name, filePath),
+			false,
+			utils.INFO,
+		)
+	}
 	if skipDl || err != nil {
 		return err
 	}
 
-	// Create a context that can be cancelled when SIGINT/SIGTERM signal is received
-	ctx, cancel := context.WithCancel(context.Background())
+	// Create a context that will be cancelled when SIGINT/SIGTERM signal is received
+	ctx, cancel := context.WithCancel(utils.GetInterruptCtx())
 	defer cancel()
 
-	// Catch SIGINT/SIGTERM signal and cancel the context when received
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigs
-		cancel()
+	queue <- struct{}{}
+	if !utils.AcquireFileSlot() {
+		return utils.ErrMaxFilesReached
+	}
+	downloaded := false
+	defer func() {
+		utils.ReleaseFileSlot(downloaded)
 	}()
-	defer signal.Stop(sigs)
 
-	queue <- struct{}{}
+	// add a small random delay before each request
+	// to avoid sending the requests to Google at the same time
+	if gdrive.dlMaxJitter > 0 {
+		if err := utils.SleepWithContext(ctx, utils.GetRandomTime(0, gdrive.dlMaxJitter)); err != nil {
+			return err
+		}
+	}
 
 	var res *http.Response
 	url := fmt.Sprintf("%s/%s", gdrive.apiUrl, fileInfo.Id)
 	if gdrive.client != nil {
+		// the requests sent by the Google API client are not rate limited by request.CallRequest
+		utils.WaitForRateLimit(utils.GDRIVE)
 		res, err = gdrive.client.Files.Get(fileInfo.Id).AcknowledgeAbuse(true).Context(ctx).Download()
 	} else {
 		params := map[string]string{
@@ -127,14 +162,27 @@ func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath str
 	if res.StatusCode != 200 {
 		return getFailedApiCallErr(res)
 	}
-	return request.DlToFile(res, url, filePath)
+	if res.ContentLength < 0 {
+		// use the file size from the file's details for the download progress
+		if size, err := strconv.ParseInt(fileInfo.Size, 10, 64); err == nil {
+			res.ContentLength = size
+		}
+	}
+	if err := request.DlToFile(res, url, filePath, dlProgress); err != nil {
+		return err
+	}
+	downloaded = utils.PathExists(filePath)
+	if isRedownload && downloaded {
+		utils.RUN_STATS.AddRedownloadedFile()
+	}
+	return nil
 }
 
 func filterDownloads(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
 	var notAllowedForDownload []*models.GdriveFileToDl
 	allowedForDownload := make([]*models.GdriveFileToDl, 0, len(files))
 	for _, file := range files {
-		if strings.Contains(file.MimeType, "application/vnd.google-apps") {
+		if strings.HasPrefix(file.MimeType, GDRIVE_APPS_MIME_TYPE_PREFIX) {
 			notAllowedForDownload = append(notAllowedForDownload, file)
 		} else {
 			allowedForDownload = append(allowedForDownload, file)
@@ -146,7 +194,7 @@ func filterDownloads(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
 		for _, file := range notAllowedForDownload {
 			noticeMsg += fmt.Sprintf(
 				"Filename: %s (ID: %s, MIME Type: %s)\n",
-				file.Name, file.Id, file.MimeType,
+				filepath.Join(file.RelPath, file.Name), file.Id, file.MimeType,
 			)
 		}
 		utils.LogError(nil, noticeMsg, false, utils.INFO)
@@ -154,7 +202,43 @@ func filterDownloads(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
 	return allowedForDownload
 }
 
+// Renames the files that would be saved to the same file path by suffixing the file ID to the filename
+// as GDrive allows files with the same name in a folder. Files with the same ID as an earlier file
+// at the same path are removed as they would be downloaded twice.
+//
+// File paths are compared case-insensitively as the filesystem may be case-insensitive.
+func resolveFilenameCollisions(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
+	seenPaths := make(map[string]string, len(files)) // file path => file ID
+	resolvedFiles := make([]*models.GdriveFileToDl, 0, len(files))
+	for _, file := range files {
+		pathKey := strings.ToLower(filepath.Join(file.FilePath, file.Name))
+		fileId, exists := seenPaths[pathKey]
+		if exists && fileId == file.Id {
+			continue
+		}
+		if exists {
+			ext := filepath.Ext(file.Name)
+			newName := fmt.Sprintf("%s (%s)%s", strings.TrimSuffix(file.Name, ext), file.Id, ext)
+			utils.LogError(
+				nil,
+				fmt.Sprintf(
+					"GDrive file %q (ID: %s) has the same name as another file in %q and will be saved as %q",
+					file.Name, file.Id, file.FilePath, newName,
+				),
+				false,
+				utils.INFO,
+			)
+			file.Name = newName
+			pathKey = strings.ToLower(filepath.Join(file.FilePath, file.Name))
+		}
+		seenPaths[pathKey] = file.Id
+		resolvedFiles = append(resolvedFiles, file)
+	}
+	return resolvedFiles
+}
+
 func processGdriveDlError(errChan chan *models.GdriveError, progress *spinner.Spinner) {
+	utils.RecordFailedItems()
 	killProgram := false
 	for errInfo := range errChan {
 		errMsg := censorApiKeyFromStr(errInfo.Err.Error())
@@ -181,7 +265,7 @@ func processGdriveDlError(errChan chan *models.GdriveError, progress *spinner.Sp
 
 // Downloads the multiple GDrive file in parallel using GDrive API v3
 func (gdrive *GDrive) DownloadMultipleFiles(files []*models.GdriveFileToDl, config *configs.Config) {
-	allowedForDownload := filterDownloads(files)
+	allowedForDownload := resolveFilenameCollisions(filterDownloads(files))
 	if len(allowedForDownload) == 0 {
 		return
 	}
@@ -212,24 +296,53 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*models.GdriveFileToDl, conf
 		),
 		len(allowedForDownload),
 	)
+	dlProgress := spinner.NewDlProgress(progress)
 	progress.Start()
-	for _, file := range allowedForDownload {
+	for idx, file := range allowedForDownload {
+		if utils.IsMaxFilesReached() {
+			// stop queuing the remaining downloads as "--max-files" has been reached
+			utils.AddCancelledFiles(len(allowedForDownload) - idx)
+			break
+		}
+
 		wg.Add(1)
-		go func(file *models.GdriveFileToDl) {
+		go func(idx int, file *models.GdriveFileToDl) {
 			defer func() {
 				wg.Done()
 				<-queue
 			}()
 
+			// stagger the start of the initial download workers
+			// instead of sending all the requests at once
+			if idx < maxConcurrency {
+				utils.Sleep(time.Duration(float64(idx) * gdrive.dlStaggerDelay * float64(time.Second)))
+			}
+
 			os.MkdirAll(file.FilePath, 0755)
 			filePath := filepath.Join(file.FilePath, file.Name)
 
-			err := gdrive.DownloadFile(file, filePath, config, queue)
-			if err != nil && err != context.Canceled {
+			err := gdrive.DownloadFile(file, filePath, config, queue, dlProgress)
+			if err == nil {
+				// record the checksum so that the file can be verified later with the "verify" command
+				if checksumErr := recordChecksum(file); checksumErr != nil {
+					utils.LogError(checksumErr, "", false, utils.ERROR)
+				}
+				if config.WriteXattrs && utils.PathExists(filePath) {
+					xattrsErr := utils.WriteXattrs(filePath, GDRIVE_FILE_URL+file.Id, file.Metadata)
+					if xattrsErr != nil {
+						utils.LogError(xattrsErr, "", false, utils.ERROR)
+					}
+				}
+			}
+			if err != nil && err != context.Canceled && err != utils.ErrMaxFilesReached {
 				err = fmt.Errorf(
 					"failed to download file: %s (ID: %s, MIME Type: %s)\nRefer to error details below:\n%v",
 					file.Name, file.Id, file.MimeType, err,
 				)
+				utils.RUN_STATS.AddFailedFile(
+					fmt.Sprintf("%s (ID: %s)", file.Name, file.Id),
+					errors.New(censorApiKeyFromStr(err.Error())),
+				)
 				errChan <- &models.GdriveError{
 					Err: err,
 					FilePath: filepath.Join(
@@ -239,7 +352,7 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*models.GdriveFileToDl, conf
 				}
 			}
 			progress.MsgIncrement(baseMsg)
-		}(file)
+		}(idx, file)
 	}
 	wg.Wait()
 	close(queue)
@@ -292,6 +405,7 @@ func (gdrive *GDrive) getGdriveFileInfo(gdriveId *models.GDriveToDl, config *con
 			}
 		}
 		fileInfo.FilePath = gdriveId.FilePath
+		fileInfo.Metadata = gdriveId.Metadata
 		return []*models.GdriveFileToDl{fileInfo}, nil
 	case "folder":
 		filesInfo, err := gdrive.GetNestedFolderContents(
@@ -307,7 +421,9 @@ func (gdrive *GDrive) getGdriveFileInfo(gdriveId *models.GDriveToDl, config *con
 		}
 		var gdriveFilesInfo []*models.GdriveFileToDl
 		for _, fileInfo := range filesInfo {
-			fileInfo.FilePath = gdriveId.FilePath
+			// recreate the subfolders of the GDrive folder in the post's gdrive folder
+			fileInfo.FilePath = filepath.Join(gdriveId.FilePath, fileInfo.RelPath)
+			fileInfo.Metadata = gdriveId.Metadata
 			gdriveFilesInfo = append(gdriveFilesInfo, fileInfo)
 		}
 		return gdriveFilesInfo, nil
@@ -338,6 +454,7 @@ func (gdrive *GDrive) DownloadGdriveUrls(gdriveUrls []*request.ToDownload, confi
 				Id:       fileId,
 				Type:     fileType,
 				FilePath: gdriveUrl.FilePath,
+				Metadata: gdriveUrl.Metadata,
 			})
 		}
 	}
@@ -367,6 +484,7 @@ func (gdrive *GDrive) DownloadGdriveUrls(gdriveUrls []*request.ToDownload, confi
 	for _, gdriveId := range gdriveIds {
 		fileInfo, err := gdrive.getGdriveFileInfo(gdriveId, config)
 		if err != nil {
+			utils.RecordFailure(errors.New(censorApiKeyFromStr(err.Err.Error())))
 			errSlice = append(errSlice, err)
 		} else {
 			gdriveFilesInfo = append(gdriveFilesInfo, fileInfo...)
@@ -377,6 +495,7 @@ func (gdrive *GDrive) DownloadGdriveUrls(gdriveUrls []*request.ToDownload, confi
 	hasErr := false
 	if len(errSlice) > 0 {
 		hasErr = true
+		utils.RecordFailedItems()
 		for _, err := range errSlice {
 			utils.LogMessageToPath(
 				censorApiKeyFromStr(err.Err.Error()),
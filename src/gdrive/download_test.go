@@ -0,0 +1,47 @@
+package gdrive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive/models"
+)
+
+func TestCheckIfCanSkipDl(t *testing.T) {
+	tests := []struct {
+		name                string
+		existingSize        int
+		redownloadIfSmaller bool
+		wantSkip            bool
+		wantRedownload      bool
+	}{
+		{name: "same size", existingSize: 10, wantSkip: true},
+		{name: "smaller", existingSize: 5},
+		{name: "smaller with redownload_if_smaller", existingSize: 5, redownloadIfSmaller: true, wantRedownload: true},
+		{name: "larger", existingSize: 15},
+		{name: "larger with redownload_if_smaller", existingSize: 15, redownloadIfSmaller: true, wantSkip: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "file.bin")
+			if err := os.WriteFile(filePath, make([]byte, test.existingSize), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			fileInfo := &models.GdriveFileToDl{Name: "file.bin", Size: "10"}
+			config := &configs.Config{RedownloadIfSmaller: test.redownloadIfSmaller}
+			skipDl, isRedownload, err := checkIfCanSkipDl(filePath, fileInfo, false, config)
+			if err != nil {
+				t.Fatalf("checkIfCanSkipDl() returned %v", err)
+			}
+			if skipDl != test.wantSkip || isRedownload != test.wantRedownload {
+				t.Errorf(
+					"checkIfCanSkipDl() = (%v, %v), want (%v, %v)",
+					skipDl, isRedownload, test.wantSkip, test.wantRedownload,
+				)
+			}
+		})
+	}
+}
@@ -3,13 +3,11 @@ package gdrive
 import (
 	"context"
 	"fmt"
-	"os"
 	"regexp"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
-	"github.com/fatih/color"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 )
@@ -19,10 +17,23 @@ const (
 	GDRIVE_ERROR_FILENAME  = "gdrive_download.log"
 	BASE_API_KEY_REGEX_STR = `AIza[\w-]{35}`
 
+	// the base URL of a GDrive file to be suffixed with the file ID
+	GDRIVE_FILE_URL = "https://drive.google.com/file/d/"
+
 	// file fields to fetch from GDrive API:
 	// https://developers.google.com/drive/api/v3/reference/files
 	GDRIVE_FILE_FIELDS = "id,name,size,mimeType,md5Checksum"
 	GDRIVE_FOLDER_FIELDS = "nextPageToken,files(id,name,size,mimeType,md5Checksum)"
+
+	// Google Workspace files such as Google Docs and Sheets
+	// have no binary content and cannot be downloaded as-is
+	GDRIVE_APPS_MIME_TYPE_PREFIX = "application/vnd.google-apps"
+	GDRIVE_FOLDER_MIME_TYPE      = GDRIVE_APPS_MIME_TYPE_PREFIX + ".folder"
+
+	// delays in seconds to smooth out the download requests to
+	// Google's servers to reduce the chance of getting rate limited
+	DEFAULT_DL_STAGGER_DELAY = 0.5
+	DEFAULT_DL_MAX_JITTER    = 0.75
 )
 
 var (
@@ -37,50 +48,89 @@ type GDrive struct {
 	timeout            int            // timeout in seconds for GDrive API v3
 	downloadTimeout    int            // timeout in seconds for GDrive file downloads
 	maxDownloadWorkers int            // max concurrent workers for downloading files
+	dlStaggerDelay     float64        // delay in seconds between the start of each download worker
+	dlMaxJitter        float64        // max random delay in seconds before each download request
+	verifyChecksum     bool           // compare the md5 checksum of existing files before skipping them
 }
 
 // Returns a GDrive structure with the given API key and max download workers
 func GetNewGDrive(apiKey, jsonPath string, config *configs.Config, maxDownloadWorkers int) *GDrive {
 	if jsonPath != "" && apiKey != "" {
-		color.Red("Both Google Drive API key and service account credentials file cannot be used at the same time.")
-		os.Exit(1)
+		utils.PrintError("Both Google Drive API key and service account credentials file cannot be used at the same time.")
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	} else if jsonPath == "" && apiKey == "" {
-		color.Red("Google Drive API key or service account credentials file is required.")
-		os.Exit(1)
+		utils.PrintError("Google Drive API key or service account credentials file is required.")
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 
 	gdrive := &GDrive{
 		apiUrl:             "https://www.googleapis.com/drive/v3/files",
 		timeout:            15,
 		downloadTimeout:    900, // 15 minutes
-		maxDownloadWorkers: maxDownloadWorkers,
+		maxDownloadWorkers: utils.GetSiteConcurrency(utils.GDRIVE, maxDownloadWorkers),
+		dlStaggerDelay:     DEFAULT_DL_STAGGER_DELAY,
+		dlMaxJitter:        DEFAULT_DL_MAX_JITTER,
+		verifyChecksum:     true,
 	}
 	if apiKey != "" {
 		gdrive.apiKey = apiKey
 		gdriveIsValid, err := gdrive.GDriveKeyIsValid(config.UserAgent)
 		if err != nil {
-			color.Red(err.Error())
-			os.Exit(1)
+			utils.PrintError(err.Error())
+			utils.Exit(utils.GetExitCodeFromErr(err))
 		} else if !gdriveIsValid {
-			color.Red("Google Drive API key is invalid.")
-			os.Exit(1)
+			utils.PrintError("Google Drive API key is invalid.")
+			utils.Exit(utils.EXIT_AUTH_ERROR)
 		}
 		return gdrive
 	} 
 
 	if !utils.PathExists(jsonPath) {
-		color.Red("Unable to access Drive API due to missing credentials file: %s", jsonPath)
-		os.Exit(1)
+		utils.PrintError("Unable to access Drive API due to missing credentials file: %s", jsonPath)
+		utils.Exit(utils.EXIT_INPUT_ERROR)
 	}
 	srv, err := drive.NewService(context.Background(), option.WithCredentialsFile(jsonPath))
 	if err != nil {
-		color.Red("Unable to access Drive API due to %v", err)
-		os.Exit(1)
+		utils.PrintError("Unable to access Drive API due to %v", err)
+		utils.Exit(utils.EXIT_AUTH_ERROR)
 	}
 	gdrive.client = srv
 	return gdrive
 }
 
+// Configures the delays used to pace the download requests to Google's servers.
+//
+// staggerDelay is the delay in seconds between the start of each download worker
+// and maxJitter is the max random delay in seconds before each download request.
+// Setting both to 0 will disable the pacing.
+func (gdrive *GDrive) SetDlPacing(staggerDelay, maxJitter float64) {
+	if staggerDelay < 0 {
+		staggerDelay = 0
+	}
+	if maxJitter < 0 {
+		maxJitter = 0
+	}
+	gdrive.dlStaggerDelay = staggerDelay
+	gdrive.dlMaxJitter = maxJitter
+}
+
+// Configures whether the md5 checksum of an existing file with the same size as the file on GDrive
+// is compared before skipping the download. If disabled, the file size alone is compared which is faster.
+func (gdrive *GDrive) SetVerifyChecksum(verifyChecksum bool) {
+	gdrive.verifyChecksum = verifyChecksum
+}
+
+// Checks if the given Google Drive API key is valid without initialising the GDrive structure
+// which exits the program if the key is invalid, e.g. to check the user's credentials only.
+func ApiKeyIsValid(apiKey, userAgent string) (bool, error) {
+	gdrive := &GDrive{
+		apiKey:  apiKey,
+		apiUrl:  "https://www.googleapis.com/drive/v3/files",
+		timeout: 15,
+	}
+	return gdrive.GDriveKeyIsValid(userAgent)
+}
+
 // Checks if the given Google Drive API key is valid
 //
 // Will return true if the given Google Drive API key is valid
@@ -1,5 +1,7 @@
 package models
 
+import "github.com/KJHJason/Cultured-Downloader-CLI/utils"
+
 type GDriveFile struct {
 	Kind        string `json:"kind"`
 	Id          string `json:"id"`
@@ -20,6 +22,7 @@ type GDriveToDl struct {
 	Id 	     string
 	Type     string
 	FilePath string
+	Metadata *utils.FileMetadata
 }
 
 type GdriveFileToDl struct {
@@ -29,6 +32,8 @@ type GdriveFileToDl struct {
 	MimeType    string
 	Md5Checksum string
 	FilePath    string
+	RelPath     string // path of the subfolder relative to the downloaded GDrive folder
+	Metadata    *utils.FileMetadata
 }
 
 type GdriveError struct {
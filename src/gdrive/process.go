@@ -40,6 +40,7 @@ func ProcessPostText(postBodyStr, postFolderPath string, downloadGdrive bool, lo
 		if logUrls {
 			utils.DetectOtherExtDLLink(text, postFolderPath)
 		}	
+		utils.DetectMegaLinks(text, postFolderPath)
 		if utils.DetectGDriveLinks(text, postFolderPath, false, logUrls) && downloadGdrive {
 			detectedGdriveLinks = append(detectedGdriveLinks, &request.ToDownload{
 				Url:      text,
@@ -0,0 +1,67 @@
+// Package testutils contains the helpers that are shared by the tests of the other packages.
+package testutils
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Runs the tests with the app path set to a temporary folder that will be removed afterwards
+// so that the logs of the tests will be kept out of the user's app data directory.
+//
+// Should be called from the TestMain function of the package as it will exit the program.
+func RunWithTempAppPath(m *testing.M) {
+	appPath, err := os.MkdirTemp("", "cultured-downloader-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := utils.SetAppPath(appPath); err != nil {
+		panic(err)
+	}
+	exitCode := m.Run()
+	os.RemoveAll(appPath)
+	os.Exit(exitCode)
+}
+
+// Records the delays instead of sleeping
+type SleepRecorder struct {
+	mu     sync.Mutex
+	sleeps []time.Duration
+}
+
+func (r *SleepRecorder) Sleep(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sleeps = append(r.sleeps, d)
+}
+
+// Returns a copy of the recorded delays
+func (r *SleepRecorder) Sleeps() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration{}, r.sleeps...)
+}
+
+// Replaces the sleeper of the utils package with a SleepRecorder until the end of the test
+func RecordSleeps(t *testing.T) *SleepRecorder {
+	recorder := &SleepRecorder{}
+	t.Cleanup(utils.SetSleeper(recorder.Sleep))
+	return recorder
+}
+
+// Returns the buffer where the colored output will be written to without the color codes until the test ends
+func CaptureColorOutput(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	oldOutput, oldNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &buf, true
+	t.Cleanup(func() {
+		color.Output, color.NoColor = oldOutput, oldNoColor
+	})
+	return &buf
+}
@@ -24,6 +24,11 @@ function GetHash($path, $os, $arch) {
     $hashMsg | Out-File -FilePath "bin/hash.txt" -Append
 }
 
+# build details shown by the "version" command and in the log files
+$commit = git rev-parse --short HEAD
+$buildDate = Get-Date -Format "yyyy-MM-ddTHH:mm:ssK"
+$ldflags = "-X github.com/KJHJason/Cultured-Downloader-CLI/utils.Commit=$commit -X github.com/KJHJason/Cultured-Downloader-CLI/utils.BuildDate=$buildDate"
+
 # github.com/josephspurrier/goversioninfo/cmd/goversioninfo
 $verInfoName = "versioninfo.syso"
 $verInfoRc = "versioninfo.rc"
@@ -32,18 +37,18 @@ windres -i $verInfoRc -O coff -o $verInfoName
 $env:GOOS = "windows"
 $env:GOARCH = "amd64"
 $binaryPath = "bin/cultured-downloader-cli.exe"
-go build -o $binaryPath
+go build -ldflags $ldflags -o $binaryPath
 GetHash $binaryPath "windows" "amd64"
 Remove-Item -Path $verInfoName -Force -ErrorAction SilentlyContinue
 
 $env:GOOS = "linux"
 $binaryPath = "bin/cultured-downloader-cli-linux"
-go build -o $binaryPath
+go build -ldflags $ldflags -o $binaryPath
 GetHash $binaryPath "linux" "amd64"
 
 $env:GOOS = "darwin"
 $binaryPath = "bin/cultured-downloader-cli-darwin"
-go build -o $binaryPath
+go build -ldflags $ldflags -o $binaryPath
 GetHash $binaryPath "darwin" "amd64"
 
 # reset the environment variables
@@ -0,0 +1,120 @@
+package mega
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// The megadl binary from megatools (https://megatools.megous.com/) is used to download the MEGA links
+// as MEGA's files are encrypted on the client side.
+const MEGADL_BINARY = "megadl"
+
+// Returns the path to the megadl binary or an error if it could not be found in the PATH
+func GetMegadlPath() (string, error) {
+	megadlPath, err := exec.LookPath(MEGADL_BINARY)
+	if err != nil {
+		return "", fmt.Errorf(
+			"mega error %d: %q was not found in your PATH, please install megatools from https://megatools.megous.com/ to use \"--dl_mega\", more info => %v",
+			utils.INPUT_ERROR,
+			MEGADL_BINARY,
+			err,
+		)
+	}
+	return megadlPath, nil
+}
+
+// Downloads the MEGA link into its directory using megadl
+func downloadMegaLink(megadlPath string, megaLink *utils.MegaLink) error {
+	if err := os.MkdirAll(megaLink.DirPath, 0755); err != nil {
+		return fmt.Errorf(
+			"mega error %d: failed to create the directory %q for %s, more info => %v",
+			utils.OS_ERROR,
+			megaLink.DirPath,
+			megaLink.Url,
+			err,
+		)
+	}
+
+	// megadl will be killed if the run is interrupted
+	ctx := utils.GetInterruptCtx()
+	cmd := exec.CommandContext(ctx, megadlPath, "--path", megaLink.DirPath, "--no-progress", megaLink.Url)
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return fmt.Errorf(
+			"mega error %d: failed to download %s, more info => %v\noutput: %s",
+			utils.DOWNLOAD_ERROR,
+			megaLink.Url,
+			err,
+			strings.TrimSpace(string(output)),
+		)
+	}
+	return nil
+}
+
+// Downloads the MEGA links detected by utils.DetectMegaLinks() if "--dl_mega" is enabled.
+//
+// Returns true if there were any MEGA links to download.
+func DownloadQueuedLinks() bool {
+	megaLinks := utils.PopQueuedMegaLinks()
+	if len(megaLinks) == 0 {
+		return false
+	}
+
+	megadlPath, err := GetMegadlPath()
+	if err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+		return true
+	}
+
+	megaLinksLen := len(megaLinks)
+	baseMsg := "Downloading MEGA links [%d/" + fmt.Sprintf("%d]...", megaLinksLen)
+	progress := spinner.New(
+		spinner.DL_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished downloading %d MEGA links!",
+			megaLinksLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while downloading %d MEGA links.\nPlease refer to the logs for more details.",
+			megaLinksLen,
+		),
+		megaLinksLen,
+	)
+	progress.Start()
+
+	var errSlice []error
+	for _, megaLink := range megaLinks {
+		progress.SetDetail(megaLink.Url)
+		err := downloadMegaLink(megadlPath, megaLink)
+		if err == context.Canceled {
+			break
+		}
+		if err != nil {
+			utils.RecordFailure(err)
+			errSlice = append(errSlice, err)
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	return true
+}
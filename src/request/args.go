@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"regexp"
 
@@ -25,6 +26,10 @@ type RequestArgs struct {
 	UserAgent          string
 	DisableCompression bool
 
+	// Proxy is the URL of the proxy to send the request through, e.g. "socks5://127.0.0.1:1080".
+	// Defaults to the proxy given by the "--proxy" flag (if any).
+	Proxy string
+
 	// HTTP/2 and HTTP/3 Options
 	Http2 bool
 	Http3 bool
@@ -35,8 +40,9 @@ type RequestArgs struct {
 	// Otherwise, it will return the response regardless of the status code.
 	CheckStatus bool
 
-	// Context is used to cancel the request if needed.
-	// E.g. if the user presses Ctrl+C, we can use context.WithCancel(context.Background())
+	// Context is used to cancel the request if needed and defaults to context.Background().
+	// E.g. utils.GetInterruptCtx() to cancel the request when the user presses Ctrl+C
+	// or context.WithTimeout() for a deadline that covers the retries.
 	Context context.Context
 
 	// RequestHandler is the main function that will be called to make the request.
@@ -113,6 +119,10 @@ func (args *RequestArgs) getDefaultArgs() {
 		args.UserAgent = utils.USER_AGENT
 	}
 
+	if args.Proxy == "" {
+		args.Proxy = utils.GetProxy()
+	}
+
 	if args.Context == nil {
 		args.Context = context.Background()
 	}
@@ -143,6 +153,18 @@ func (args *RequestArgs) ValidateArgs() {
 		)
 	}
 
+	if args.Proxy != "" {
+		if _, err := url.Parse(args.Proxy); err != nil {
+			panic(
+				fmt.Errorf(
+					"error %d: invalid proxy URL, %q",
+					utils.DEV_ERROR,
+					args.Proxy,
+				),
+			)
+		}
+	}
+
 	if args.Timeout < 0 {
 		panic(
 			fmt.Errorf(
@@ -7,17 +7,20 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
+// Name of the file in the application's directory that the remaining
+// URLs will be saved to when the downloads are stopped as the disk is full
+const DISK_FULL_FILENAME = "failed.txt"
+
 func getFullFilePath(res *http.Response, filePath string) (string, error) {
 	// check if filepath already have a filename attached
 	if filepath.Ext(filePath) != "" {
@@ -49,7 +52,10 @@ func getFullFilePath(res *http.Response, filePath string) (string, error) {
 
 // check if the file size matches the content length
 // if not, then the file does not exist or is corrupted and should be re-downloaded
-func checkIfCanSkipDl(contentLength int64, filePath string, forceOverwrite bool) bool {
+//
+// The second return value will be true if the existing file will be
+// downloaded again as it is smaller than the content length due to config.RedownloadIfSmaller.
+func checkIfCanSkipDl(contentLength int64, filePath string, config *configs.Config) (bool, bool) {
 	fileSize, err := utils.GetFileSize(filePath)
 	if err != nil {
 		if err != os.ErrNotExist {
@@ -57,27 +63,50 @@ func checkIfCanSkipDl(contentLength int64, filePath string, forceOverwrite bool)
 			// then log the error and continue with the download process
 			utils.LogError(err, "", false, utils.ERROR)
 		}
-		return false
+		return false, false
 	}
 
 	if fileSize == contentLength {
 		// If the file already exists and the file size
 		// matches the expected file size in the Content-Length header,
 		// then skip the download process.
-		return true
-	} else if !forceOverwrite && fileSize > 0 {
+		return true, false
+	} else if config.RedownloadIfSmaller && contentLength > 0 {
+		// If the existing file is smaller than the expected file size,
+		// it is likely a truncated download and will be downloaded again.
+		// Otherwise, the file will be treated as complete.
+		return fileSize >= contentLength, fileSize < contentLength
+	} else if !config.OverwriteFiles && fileSize > 0 {
 		// If the file already exists and have more than 0 bytes
 		// but the Content-Length header does not exist in the response,
 		// we will assume that the file is already downloaded
 		// and skip the download process if the overwrite flag is false.
-		return true
+		return true, false
 	}
-	return false
+	return false, false
+}
+
+// Returns the error for a file that could not be written as the disk is full
+func getDiskFullErr(filePath string) error {
+	return fmt.Errorf(
+		"error %d: failed to write file, more info => %w\nfile path: %s",
+		utils.OS_ERROR,
+		utils.ErrDiskFull,
+		filePath,
+	)
 }
 
-func DlToFile(res *http.Response, url, filePath string) error {
+// Writes the response body to the given file path.
+//
+// The bytes written will be reported to the given download progress (if any).
+//
+// Errors other than the disk being full or context.Canceled will be logged instead of being returned.
+func DlToFile(res *http.Response, url, filePath string, dlProgress *spinner.DlProgress) error {
 	file, err := os.Create(filePath) // create the file
 	if err != nil {
+		if utils.IsDiskFullErr(err) {
+			return getDiskFullErr(filePath)
+		}
 		return fmt.Errorf(
 			"error %d: failed to create file, more info => %v\nfile path: %s",
 			utils.OS_ERROR,
@@ -86,9 +115,15 @@ func DlToFile(res *http.Response, url, filePath string) error {
 		)
 	}
 
+	// deleted on exit if the program is interrupted before the download has been completed
+	utils.AddIncompleteFile(filePath)
+	defer utils.RemoveIncompleteFile(filePath)
+
 	// write the body to file
 	// https://stackoverflow.com/a/11693049/16377492
-	_, err = io.Copy(file, res.Body)
+	fileProgress := dlProgress.AddFile(filepath.Base(filePath), res.ContentLength)
+	defer fileProgress.Done()
+	bytesWritten, err := io.Copy(io.MultiWriter(file, fileProgress), res.Body)
 	if err != nil {
 		file.Close()
 		if fileErr := os.Remove(filePath); fileErr != nil {
@@ -105,35 +140,46 @@ func DlToFile(res *http.Response, url, filePath string) error {
 			)
 		}
 
+		if utils.IsDiskFullErr(err) {
+			// returned instead of being logged to stop the remaining downloads
+			return getDiskFullErr(filePath)
+		}
 		if err != context.Canceled {
 			errorMsg := fmt.Sprintf("failed to download %s due to %v", url, err)
 			utils.LogError(err, errorMsg, false, utils.ERROR)
+			utils.RUN_STATS.AddFailedFile(url, err)
 			err = nil
 		}
 		return err
 	}
 	file.Close()
+	utils.RUN_STATS.AddDownloadedFile(bytesWritten)
 	return nil
 }
 
 // DownloadUrl is used to download a file from a URL
 //
+// Returns the full file path of the downloaded file.
+//
 // Note: If the file already exists, the download process will be skipped
-func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, overwriteExistingFile bool) error {
-	// Create a context that can be cancelled when SIGINT/SIGTERM signal is received
-	ctx, cancel := context.WithCancel(context.Background())
+// unless the server reports that the file has changed via a conditional request.
+func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, config *configs.Config, dlProgress *spinner.DlProgress) (string, error) {
+	// Create a context that will be cancelled when SIGINT/SIGTERM signal is received
+	// or when the given context is cancelled like when "--fail_fast" is enabled
+	parentCtx := reqArgs.Context
+	if parentCtx == nil {
+		parentCtx = utils.GetInterruptCtx()
+	}
+	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 
-	// Catch SIGINT/SIGTERM signal and cancel the context when received
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigs
-		cancel()
-	}()
-	defer signal.Stop(sigs)
-
 	queue <- struct{}{}
+	if utils.IsMaxFilesReached() {
+		// cancel the queued download without sending any requests
+		utils.AddCancelledFiles(1)
+		return "", utils.ErrMaxFilesReached
+	}
+
 	// Send a HEAD request first to get the expected file size from the Content-Length header.
 	// A GET request might work but most of the time
 	// as the Content-Length header may not present due to chunked encoding.
@@ -145,6 +191,7 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 			Cookies:     reqArgs.Cookies,
 			Headers:     reqArgs.Headers,
 			UserAgent:   reqArgs.UserAgent,
+			Proxy:       reqArgs.Proxy,
 			CheckStatus: true,
 			Http3:       reqArgs.Http3,
 			Http2:       reqArgs.Http2,
@@ -152,13 +199,64 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 		},
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
 	fileReqContentLength := headRes.ContentLength
+
+	// If the file exists and its ETag/Last-Modified headers were saved when it was downloaded,
+	// send a conditional GET request so that the file will only be downloaded again if it has changed.
+	var validators *httpValidators
+	existingFilePath, existingFilePathErr := getFullFilePath(headRes, filePath)
+	if existingFilePathErr == nil && utils.PathExists(existingFilePath) {
+		validators = getSavedValidators(existingFilePath)
+	}
 	headRes.Body.Close()
+	if validators != nil {
+		reqArgs.Headers = validators.addToHeaders(reqArgs.Headers)
+		reqArgs.CheckStatus = false
+	}
+
+	// If the file has not been downloaded yet and a previous download left a .part file,
+	// send a Range request to only download the remaining bytes.
+	var resumeFrom int64
+	originalHeaders := reqArgs.Headers
+	if config.ResumeDownload && validators == nil && existingFilePathErr == nil {
+		if canSkipDl, _ := checkIfCanSkipDl(fileReqContentLength, existingFilePath, config); !canSkipDl {
+			resumeFrom = getResumableSize(getPartFilePath(existingFilePath), fileReqContentLength)
+		}
+	}
+	if resumeFrom > 0 {
+		reqArgs.Headers = addRangeToHeaders(originalHeaders, resumeFrom)
+		reqArgs.CheckStatus = false
+	}
+
+	// reserved before the download so that the concurrent downloads will not exceed "--max-files"
+	if !utils.AcquireFileSlot() {
+		return "", utils.ErrMaxFilesReached
+	}
+	downloaded := false
+	defer func() {
+		utils.ReleaseFileSlot(downloaded)
+	}()
 
 	reqArgs.Context = ctx
 	res, err := reqArgs.RequestHandler(reqArgs)
+	if err == nil && resumeFrom > 0 && res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// the .part file does not match the file on the server anymore so it is downloaded again from the start
+		res.Body.Close()
+		os.Remove(getPartFilePath(existingFilePath))
+		resumeFrom = 0
+		reqArgs.Headers = originalHeaders
+		reqArgs.CheckStatus = true
+		res, err = reqArgs.RequestHandler(reqArgs)
+	}
+	if err == nil && validators != nil && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotModified {
+		res.Body.Close()
+		err = fmt.Errorf("unexpected %s response to the conditional request", res.Status)
+	} else if err == nil && resumeFrom > 0 && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		res.Body.Close()
+		err = fmt.Errorf("unexpected %s response to the range request", res.Status)
+	}
 	if err != nil {
 		if err != context.Canceled {
 			err = fmt.Errorf(
@@ -168,29 +266,56 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 				reqArgs.Url,
 			)
 		}
-		return err
+		return "", err
 	}
 	defer res.Body.Close()
 
 	filePath, err = getFullFilePath(res, filePath)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if res.StatusCode == http.StatusNotModified {
+		utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_EXISTS)
+		return filePath, nil
 	}
 
-	if !checkIfCanSkipDl(fileReqContentLength, filePath, overwriteExistingFile) {
-		err = DlToFile(res, reqArgs.Url, filePath)
+	// the file has changed if the server did not respond with 304 Not Modified to the conditional request
+	canSkipDl, isRedownload := checkIfCanSkipDl(fileReqContentLength, filePath, config)
+	if validators != nil || !canSkipDl {
+		if config.ResumeDownload {
+			err = dlToPartFile(res, reqArgs.Url, filePath, resumeFrom, fileReqContentLength, dlProgress)
+		} else {
+			err = DlToFile(res, reqArgs.Url, filePath, dlProgress)
+		}
+		if err == nil && utils.PathExists(filePath) {
+			downloaded = true
+			if isRedownload {
+				utils.RUN_STATS.AddRedownloadedFile()
+			}
+			if validatorsErr := saveValidators(filePath, res.Header); validatorsErr != nil {
+				utils.LogError(validatorsErr, "", false, utils.ERROR)
+			}
+		}
+	} else {
+		utils.RUN_STATS.AddSkippedFile(utils.SKIP_REASON_EXISTS)
 	}
-	return err
+	return filePath, err
 }
 
 // DownloadUrls is used to download multiple files from URLs concurrently
 //
 // Note: If the file already exists, the download process will be skipped
 func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) {
+	urlInfoSlice = RemoveDuplicateToDownload(urlInfoSlice)
 	urlsLen := len(urlInfoSlice)
 	if urlsLen == 0 {
 		return
 	}
+	dlOptions.MaxConcurrency = utils.GetSiteConcurrency(
+		utils.GetSiteFromUrl(urlInfoSlice[0].Url),
+		dlOptions.MaxConcurrency,
+	)
 	if urlsLen < dlOptions.MaxConcurrency {
 		dlOptions.MaxConcurrency = urlsLen
 	}
@@ -198,6 +323,20 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 	var wg sync.WaitGroup
 	queue := make(chan struct{}, dlOptions.MaxConcurrency)
 	errChan := make(chan error, urlsLen)
+	dlPathChan := make(chan string, urlsLen)
+
+	// limits the number of goroutines waiting on the queue so that
+	// the downloads will be started in the order of the given slice
+	dispatchQueue := make(chan struct{}, dlOptions.MaxConcurrency)
+
+	// used to stop the remaining downloads on the first error if "--fail_fast" is enabled,
+	// when the disk is full, or when SIGINT/SIGTERM signal is received
+	failFastCtx, failFastCancel := context.WithCancel(utils.GetInterruptCtx())
+	defer failFastCancel()
+
+	var diskFull atomic.Bool
+	var diskFullRemaining []*ToDownload
+	var diskFullRemainingMu sync.Mutex
 
 	baseMsg := "Downloading files [%d/" + fmt.Sprintf("%d]...", urlsLen)
 	progress := spinner.New(
@@ -217,46 +356,95 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 		),
 		urlsLen,
 	)
+	dlProgress := spinner.NewDlProgress(progress)
 	progress.Start()
-	for _, urlInfo := range urlInfoSlice {
+	for idx, urlInfo := range urlInfoSlice {
 		wg.Add(1)
-		go func(fileUrl, filePath string) {
+		dispatchQueue <- struct{}{}
+		if utils.IsMaxFilesReached() {
+			// stop queuing the remaining downloads as "--max-files" has been reached
+			utils.AddCancelledFiles(urlsLen - idx)
+			wg.Done()
+			<-dispatchQueue
+			break
+		}
+		go func(urlInfo *ToDownload) {
 			defer func() {
 				wg.Done()
 				<-queue
+				<-dispatchQueue
 			}()
-			err := DownloadUrl(
-				filePath,
+			dlFilePath, err := DownloadUrl(
+				urlInfo.FilePath,
 				queue,
 				&RequestArgs{
-					Url:            fileUrl,
+					Url:            urlInfo.Url,
 					Method:         "GET",
 					Timeout:        utils.DOWNLOAD_TIMEOUT,
 					Cookies:        dlOptions.Cookies,
-					Headers:        dlOptions.Headers,
+					Headers:        urlInfo.GetHeaders(dlOptions.Headers),
 					Http2:          !dlOptions.UseHttp3,
 					Http3:          dlOptions.UseHttp3,
 					UserAgent:      config.UserAgent,
+					Proxy:          dlOptions.Proxy,
 					RequestHandler: reqHandler,
+					Context:        failFastCtx,
 				},
-				config.OverwriteFiles,
+				config,
+				dlProgress,
 			)
-			if err != nil {
+			if utils.IsDiskFullErr(err) || (err == context.Canceled && diskFull.Load()) {
+				// stop queuing the remaining downloads as they will fail too
+				diskFull.Store(true)
+				failFastCancel()
+				diskFullRemainingMu.Lock()
+				diskFullRemaining = append(diskFullRemaining, urlInfo)
+				diskFullRemainingMu.Unlock()
+			} else if err == utils.ErrMaxFilesReached {
+				// not an error as the download was cancelled due to "--max-files"
+			} else if err != nil {
 				errChan <- err
+				if utils.IsFailFast() && err != context.Canceled {
+					failFastCancel()
+				}
+				if err != context.Canceled {
+					utils.RUN_STATS.AddFailedFile(urlInfo.Url, err)
+				}
+				if dlOptions.RecordFailures && err != context.Canceled {
+					RecordFailedDownload(urlInfo)
+				}
+			} else {
+				if config.WriteXattrs && utils.PathExists(dlFilePath) {
+					if xattrsErr := utils.WriteXattrs(dlFilePath, urlInfo.Url, urlInfo.Metadata); xattrsErr != nil {
+						utils.LogError(xattrsErr, "", false, utils.ERROR)
+					}
+				}
+				dlPathChan <- dlFilePath
 			}
 
 			if err != context.Canceled {
 				progress.MsgIncrement(baseMsg)
 			}
-		}(urlInfo.Url, urlInfo.FilePath)
+		}(urlInfo)
 	}
 	wg.Wait()
 	close(queue)
+	close(dispatchQueue)
 	close(errChan)
+	close(dlPathChan)
+
+	if diskFull.Load() {
+		progress.Stop(true)
+		handleDiskFull(diskFullRemaining, dlOptions.RecordFailures)
+	}
 
 	hasErr := false
 	if len(errChan) > 0 {
 		hasErr = true
+		if utils.IsFailFast() {
+			// stop the spinner first as the errors below will exit the program
+			progress.Stop(hasErr)
+		}
 		if kill := utils.LogErrors(false, errChan, utils.ERROR); kill {
 			progress.KillProgram(
 				"Stopped downloading files (incomplete downloads will be deleted)...",
@@ -264,6 +452,51 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 		}
 	}
 	progress.Stop(hasErr)
+
+	if len(config.PasswordList) > 0 {
+		var dlFilePaths []string
+		for dlFilePath := range dlPathChan {
+			dlFilePaths = append(dlFilePaths, dlFilePath)
+		}
+		extractPasswordProtectedArchives(dlFilePaths, config.PasswordList)
+	}
+}
+
+// Saves the downloads that were not completed as the disk is full
+// to failed.txt and exits the program with EXIT_DISK_FULL.
+func handleDiskFull(remaining []*ToDownload, recordFailures bool) {
+	urls := make([]string, len(remaining))
+	for idx, toDownload := range remaining {
+		urls[idx] = toDownload.Url
+		utils.RUN_STATS.AddFailedFile(toDownload.Url, utils.ErrDiskFull)
+		if recordFailures {
+			RecordFailedDownload(toDownload)
+		}
+	}
+
+	utils.PrintError(
+		"Disk full: there is no space left on the disk to download to.\nStopped the remaining %d download(s), please free up some space and try again.",
+		len(remaining),
+	)
+	failedFilePath := filepath.Join(utils.APP_PATH, DISK_FULL_FILENAME)
+	err := os.WriteFile(failedFilePath, []byte(strings.Join(urls, "\n")+"\n"), 0666)
+	if err != nil {
+		// the app data directory is likely on the same disk
+		utils.PrintError("Failed to save the remaining URLs to %s, the URLs are listed below:\n%s", failedFilePath, strings.Join(urls, "\n"))
+	} else {
+		utils.PrintError("The URLs of the remaining downloads have been saved to %s", failedFilePath)
+	}
+	utils.Exit(utils.EXIT_DISK_FULL)
+}
+
+// Tries to extract the downloaded archives of posts with detected passwords
+// using the candidate passwords from the user's password list.
+func extractPasswordProtectedArchives(filePaths, passwords []string) {
+	// Create a context that will be cancelled when SIGINT/SIGTERM signal is received
+	ctx, cancel := context.WithCancel(utils.GetInterruptCtx())
+	defer cancel()
+
+	utils.ExtractPasswordProtectedArchives(ctx, filePaths, passwords)
 }
 
 // Same as DownloadUrlsWithHandler but uses the default request handler (CallRequest)
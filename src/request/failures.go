@@ -0,0 +1,269 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// FailuresFile is the format of the JSON file containing
+// the failed downloads of a run which can be retried with the "--retry_failed" flag.
+type FailuresFile struct {
+	Site    string        `json:"site"`
+	Version string        `json:"version"`
+	Failed  []*ToDownload `json:"failed"`
+}
+
+// The sites that save the failed downloads to a failures file
+var FAILURES_FILE_SITES = []string{
+	utils.FANTIA,
+	utils.PIXIV_FANBOX,
+	utils.PIXIV,
+	utils.KEMONO,
+}
+
+var (
+	failedDownloads   []*ToDownload
+	failedDownloadsMu sync.Mutex
+
+	onExitHookOnce         sync.Once
+	onExitFailuresFilePath string
+	onExitSite             string
+)
+
+// Thread-safe function to record a failed download for the failures file
+func RecordFailedDownload(toDownload *ToDownload) {
+	failedDownloadsMu.Lock()
+	defer failedDownloadsMu.Unlock()
+	failedDownloads = append(failedDownloads, toDownload)
+}
+
+// Returns the failed downloads recorded so far and resets the records
+func popFailedDownloads() []*ToDownload {
+	failedDownloadsMu.Lock()
+	defer failedDownloadsMu.Unlock()
+	failed := failedDownloads
+	failedDownloads = nil
+	return failed
+}
+
+func hasFailedDownloads() bool {
+	failedDownloadsMu.Lock()
+	defer failedDownloadsMu.Unlock()
+	return len(failedDownloads) > 0
+}
+
+// Saves the recorded failed downloads when the program exits
+// in case the run is aborted before SaveFailedDownloads() is called
+// like when "--fail_fast" is enabled.
+//
+// When downloading from multiple sites in one run, call it again before each site
+// to update the site and the file path that the failed downloads will be saved to.
+func SaveFailedDownloadsOnExit(failuresFilePath, site string) {
+	failedDownloadsMu.Lock()
+	onExitFailuresFilePath = failuresFilePath
+	onExitSite = site
+	failedDownloadsMu.Unlock()
+
+	onExitHookOnce.Do(func() {
+		utils.OnExit(func() {
+			if !hasFailedDownloads() {
+				return
+			}
+
+			failedDownloadsMu.Lock()
+			failuresFilePath, site := onExitFailuresFilePath, onExitSite
+			failedDownloadsMu.Unlock()
+			SaveFailedDownloads(failuresFilePath, site)
+		})
+	})
+}
+
+// Returns the directory in the application's directory that the failures files are saved to
+func getFailuresDir() string {
+	return filepath.Join(utils.APP_PATH, "failures")
+}
+
+// Returns the default file path to save the failures file of the given site to
+func getDefaultFailuresFilePath(site string) string {
+	return filepath.Join(
+		getFailuresDir(),
+		fmt.Sprintf("%s_%s.json", site, time.Now().Format("2006-01-02_15-04-05")),
+	)
+}
+
+// Returns the paths of the failures files saved in the application's directory from the oldest to the newest
+func GetFailuresFilePaths() ([]string, error) {
+	filePaths, err := filepath.Glob(filepath.Join(getFailuresDir(), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to list the failures files in %s, more info => %v",
+			utils.OS_ERROR,
+			getFailuresDir(),
+			err,
+		)
+	}
+
+	// the file names start with the site followed by the time they were saved
+	sort.SliceStable(filePaths, func(i, j int) bool {
+		return getFailuresFileTime(filePaths[i]) < getFailuresFileTime(filePaths[j])
+	})
+	return filePaths, nil
+}
+
+// Returns the time in the file name of the failures file like "2006-01-02_15-04-05" for sorting
+func getFailuresFileTime(filePath string) string {
+	filename := strings.TrimSuffix(filepath.Base(filePath), ".json")
+	if idx := strings.LastIndex(filename, "_"); idx != -1 {
+		if idx = strings.LastIndex(filename[:idx], "_"); idx != -1 {
+			return filename[idx+1:]
+		}
+	}
+	return filename
+}
+
+func writeFailuresFile(filePath, site string, failed []*ToDownload) error {
+	failuresJson, err := utils.PrettifyJson(&FailuresFile{
+		Site:    site,
+		Version: utils.VERSION,
+		Failed:  failed,
+	})
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err := os.WriteFile(filePath, failuresJson, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write failures file to %s, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return nil
+}
+
+// Reads and validates the failures file at the given file path
+// and returns the failed downloads in it.
+func ReadFailuresFile(filePath, site string) ([]*ToDownload, error) {
+	failuresFile, err := LoadFailuresFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if failuresFile.Site != site {
+		return nil, fmt.Errorf(
+			"error %d: failures file at %s is for %q but expected %q",
+			utils.INPUT_ERROR,
+			filePath,
+			failuresFile.Site,
+			site,
+		)
+	}
+	return failuresFile.Failed, nil
+}
+
+// Reads and validates the failures file at the given file path regardless of its site
+func LoadFailuresFile(filePath string) (*FailuresFile, error) {
+	failuresJson, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to read failures file at %s, more info => %v",
+			utils.INPUT_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	var failuresFile FailuresFile
+	if err := json.Unmarshal(failuresJson, &failuresFile); err != nil {
+		return nil, fmt.Errorf(
+			"error %d: %s is not a valid failures file, more info => %v",
+			utils.INPUT_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	if !utils.SliceContains(FAILURES_FILE_SITES, failuresFile.Site) {
+		return nil, fmt.Errorf(
+			"error %d: failures file at %s is for an unsupported site, %q",
+			utils.INPUT_ERROR,
+			filePath,
+			failuresFile.Site,
+		)
+	}
+	if failuresFile.Version != utils.VERSION {
+		return nil, fmt.Errorf(
+			"error %d: failures file at %s was generated by v%s but the current version is v%s, please re-run the download instead",
+			utils.INPUT_ERROR,
+			filePath,
+			failuresFile.Version,
+			utils.VERSION,
+		)
+	}
+
+	for _, failed := range failuresFile.Failed {
+		if failed == nil || failed.Url == "" || failed.FilePath == "" {
+			return nil, fmt.Errorf(
+				"error %d: failures file at %s contains an entry without a URL or file path",
+				utils.INPUT_ERROR,
+				filePath,
+			)
+		}
+	}
+	return &failuresFile, nil
+}
+
+// Saves the failed downloads recorded during the run to the failures file.
+//
+// If failuresFilePath is empty, a new failures file will be created in the application's directory.
+// Otherwise, the given failures file will be overwritten or removed if there are no more failed downloads.
+func SaveFailedDownloads(failuresFilePath, site string) {
+	failed := popFailedDownloads()
+	if len(failed) == 0 {
+		if failuresFilePath != "" {
+			os.Remove(failuresFilePath)
+			color.Green("All previously failed downloads have been downloaded!")
+		}
+		return
+	}
+
+	if failuresFilePath == "" {
+		failuresFilePath = getDefaultFailuresFilePath(site)
+	}
+	if err := writeFailuresFile(failuresFilePath, site, failed); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+		return
+	}
+	color.Yellow(
+		"%d download(s) failed, to retry them, run the same command with \"--retry_failed %s\"\nor run \"retry-failed --report %s\"",
+		len(failed),
+		failuresFilePath,
+		failuresFilePath,
+	)
+}
+
+// Re-downloads the failed downloads in the given failures file
+// and regenerates the failures file with the downloads that still failed.
+func RetryFailedDownloads(failuresFilePath, site string, dlOptions *DlOptions, config *configs.Config) {
+	failed, err := ReadFailuresFile(failuresFilePath, site)
+	if err != nil {
+		utils.LogError(err, "", true, utils.ERROR)
+	}
+
+	dlOptions.RecordFailures = true
+	SaveFailedDownloadsOnExit(failuresFilePath, site)
+	DownloadUrls(failed, dlOptions, config)
+	SaveFailedDownloads(failuresFilePath, site)
+}
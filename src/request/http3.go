@@ -0,0 +1,16 @@
+//go:build !nohttp3
+
+package request
+
+import (
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Returns the transport to send the requests over HTTP/3
+func getHttp3Transport(disableCompression bool) http.RoundTripper {
+	return &http3.RoundTripper{
+		DisableCompression: disableCompression,
+	}
+}
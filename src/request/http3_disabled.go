@@ -0,0 +1,13 @@
+//go:build nohttp3
+
+package request
+
+import "net/http"
+
+// Returns an HTTP/2 transport instead as the program was built
+// with the "nohttp3" build tag to leave out the HTTP/3 client
+func getHttp3Transport(disableCompression bool) http.RoundTripper {
+	return &http.Transport{
+		DisableCompression: disableCompression,
+	}
+}
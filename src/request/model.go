@@ -1,10 +1,85 @@
 package request
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
 
 type ToDownload struct {
-	Url      string
-	FilePath string
+	Url      string `json:"url"`
+	FilePath string `json:"file_path"`
+
+	// Referer overrides the Referer header of DlOptions.Headers for this file if not empty
+	Referer string `json:"referer,omitempty"`
+
+	// CacheKey identifies the file regardless of its URL like when the URL is signed
+	// and is used to download the file only once if it is queued multiple times (optional)
+	CacheKey string `json:"cache_key,omitempty"`
+
+	// Metadata is the source metadata of the post to write into the file's extended attributes
+	Metadata *utils.FileMetadata `json:"metadata,omitempty"`
+}
+
+// Returns the headers to download the file with which are
+// the given headers with the Referer header overridden by the file's Referer
+func (t *ToDownload) GetHeaders(headers map[string]string) map[string]string {
+	if t.Referer == "" {
+		return headers
+	}
+
+	fileHeaders := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		fileHeaders[key] = value
+	}
+	fileHeaders["Referer"] = t.Referer
+	return fileHeaders
+}
+
+// Removes the files to download with the same cache key while keeping their order.
+//
+// Files without a cache key are always kept as the same URL
+// may be downloaded to different file paths on purpose.
+func RemoveDuplicateToDownload(toDownload []*ToDownload) []*ToDownload {
+	seen := make(map[string]struct{}, len(toDownload))
+	uniqueToDownload := make([]*ToDownload, 0, len(toDownload))
+	for _, urlInfo := range toDownload {
+		if urlInfo.CacheKey != "" {
+			if _, ok := seen[urlInfo.CacheKey]; ok {
+				continue
+			}
+			seen[urlInfo.CacheKey] = struct{}{}
+		}
+		uniqueToDownload = append(uniqueToDownload, urlInfo)
+	}
+	return uniqueToDownload
+}
+
+// Sets the source metadata of the post to all of the given files to download
+func SetMetadata(toDownload []*ToDownload, metadata *utils.FileMetadata) []*ToDownload {
+	for _, urlInfo := range toDownload {
+		urlInfo.Metadata = metadata
+	}
+	return toDownload
+}
+
+// Sets the Referer header to download all of the given files with, usually the post's URL
+func SetReferer(toDownload []*ToDownload, referer string) []*ToDownload {
+	for _, urlInfo := range toDownload {
+		urlInfo.Referer = referer
+	}
+	return toDownload
+}
+
+// Returns the first sampleSize files of a post to download.
+//
+// If sampleSize is 0 or less, all of the files will be returned.
+func SampleToDownload(toDownload []*ToDownload, sampleSize int) []*ToDownload {
+	if sampleSize <= 0 || len(toDownload) <= sampleSize {
+		return toDownload
+	}
+	utils.RUN_STATS.AddSkippedFiles(utils.SKIP_REASON_FILTERED, len(toDownload)-sampleSize)
+	return toDownload[:sampleSize]
 }
 
 type DlOptions struct {
@@ -20,4 +95,12 @@ type DlOptions struct {
 	// UseHttp3 is a flag to enable HTTP/3
 	// Otherwise, HTTP/2 will be used by default
 	UseHttp3 bool
+
+	// RecordFailures is a flag to record the failed downloads
+	// so that they can be retried later with the "--retry_failed" flag
+	RecordFailures bool
+
+	// Proxy is the URL of the proxy to download the files through.
+	// Defaults to the proxy given by the "--proxy" flag (if any).
+	Proxy string
 }
@@ -0,0 +1,56 @@
+package request
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToDownloadGetHeaders(t *testing.T) {
+	headers := map[string]string{
+		"Origin":  "https://www.pixiv.net",
+		"Referer": "https://www.pixiv.net",
+	}
+
+	urlInfo := &ToDownload{Url: "https://i.pximg.net/img-original/img/1_p0.png"}
+	if got := urlInfo.GetHeaders(headers); !reflect.DeepEqual(got, headers) {
+		t.Errorf("GetHeaders() without a referer = %v, want %v", got, headers)
+	}
+
+	urlInfo.Referer = "https://www.pixiv.net/artworks/1"
+	want := map[string]string{
+		"Origin":  "https://www.pixiv.net",
+		"Referer": "https://www.pixiv.net/artworks/1",
+	}
+	if got := urlInfo.GetHeaders(headers); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetHeaders() with a referer = %v, want %v", got, want)
+	}
+	if headers["Referer"] != "https://www.pixiv.net" {
+		t.Errorf("GetHeaders() modified the shared headers to %v", headers)
+	}
+}
+
+func TestRemoveDuplicateToDownload(t *testing.T) {
+	toDownload := []*ToDownload{
+		{Url: "https://example.com/1?sig=a", FilePath: "a", CacheKey: "1"},
+		{Url: "https://example.com/2", FilePath: "b"},
+		{Url: "https://example.com/1?sig=b", FilePath: "a", CacheKey: "1"},
+		{Url: "https://example.com/2", FilePath: "c"},
+		{Url: "https://example.com/3", FilePath: "d", CacheKey: "3"},
+	}
+
+	// files without a cache key are kept even if they have the same URL
+	want := []*ToDownload{toDownload[0], toDownload[1], toDownload[3], toDownload[4]}
+	if got := RemoveDuplicateToDownload(toDownload); !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveDuplicateToDownload() kept %d files, want %d", len(got), len(want))
+	}
+}
+
+func TestSetReferer(t *testing.T) {
+	toDownload := []*ToDownload{{Url: "https://example.com/1"}, {Url: "https://example.com/2"}}
+	referer := "https://www.fanbox.cc/@creator/posts/1"
+	for _, urlInfo := range SetReferer(toDownload, referer) {
+		if urlInfo.Referer != referer {
+			t.Errorf("%s has the referer %q, want %q", urlInfo.Url, urlInfo.Referer, referer)
+		}
+	}
+}
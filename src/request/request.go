@@ -6,30 +6,33 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"strconv"
 	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
-	"github.com/fatih/color"
-	"github.com/quic-go/quic-go/http3"
 )
 
 // Get a new HTTP/2 or HTTP/3 client based on the request arguments
+//
+// HTTP/2 will be used if a proxy is given as HTTP/3 runs over UDP which cannot go through the proxy.
 func GetHttpClient(reqArgs *RequestArgs) *http.Client {
-	if reqArgs.Http2 {
+	if reqArgs.Http2 || reqArgs.Proxy != "" {
+		transport := &http.Transport{
+			DisableCompression: reqArgs.DisableCompression,
+		}
+		if reqArgs.Proxy != "" {
+			// the proxy URL has already been validated in ValidateArgs()
+			proxyUrl, _ := url.Parse(reqArgs.Proxy)
+			transport.Proxy = http.ProxyURL(proxyUrl)
+		}
 		return &http.Client{
-			Transport: &http.Transport{
-				DisableCompression: reqArgs.DisableCompression,
-			},
+			Transport: transport,
 		}
 	}
 	return &http.Client{
-		Transport: &http3.RoundTripper{
-			DisableCompression: reqArgs.DisableCompression,
-		},
+		Transport: getHttp3Transport(reqArgs.DisableCompression),
 	}
 }
 
@@ -85,12 +88,30 @@ func sendRequest(req *http.Request, reqArgs *RequestArgs) (*http.Response, error
 
 	client := GetHttpClient(reqArgs)
 	client.Timeout = time.Duration(reqArgs.Timeout) * time.Second
-	for i := 1; i <= utils.RETRY_COUNTER; i++ {
+	site := utils.GetSiteFromUrl(reqArgs.Url)
+	retryCount := utils.GetRetryCount(site)
+	rateLimitBackoffs := 0
+	for i := 1; i <= retryCount; i++ {
+		utils.WaitForRateLimit(site)
 		res, err = client.Do(req)
 		if err == nil {
-			if !reqArgs.CheckStatus {
+			if res.StatusCode == 200 {
 				return res, nil
-			} else if res.StatusCode == 200 {
+			}
+			if res.StatusCode == http.StatusTooManyRequests {
+				// wait for the site's rate limit to reset without using up the retries
+				// so that the request can continue from where it left off
+				rateLimitBackoffs++
+				if backoff, ok := utils.GetRateLimitBackoff(site, rateLimitBackoffs); ok {
+					res.Body.Close()
+					waitForRateLimitBackoff(req.URL.Host, max(backoff, utils.ParseRetryAfter(res.Header.Get("Retry-After"), time.Now())))
+					i--
+					continue
+				}
+			}
+			// other responses are left for the caller to handle if the status is not checked
+			// but 429 Too Many Requests will still be retried after waiting for the rate limit
+			if !reqArgs.CheckStatus && (res.StatusCode != http.StatusTooManyRequests || i == retryCount) {
 				return res, nil
 			}
 			res.Body.Close()
@@ -100,15 +121,15 @@ func sendRequest(req *http.Request, reqArgs *RequestArgs) (*http.Response, error
 			break
 		}
 
-		if i < utils.RETRY_COUNTER {
-			time.Sleep(utils.GetRandomDelay())
+		if i < retryCount {
+			utils.Sleep(utils.GetRetryDelayFromRes(site, res))
 		}
 	}
 
 	errMsg := fmt.Sprintf(
 		"the request to %s failed after %d retries",
 		reqArgs.Url,
-		utils.RETRY_COUNTER,
+		retryCount,
 	)
 	if err != nil {
 		err = fmt.Errorf("%s, more info => %v",
@@ -126,6 +147,35 @@ func sendRequest(req *http.Request, reqArgs *RequestArgs) (*http.Response, error
 	return nil, err
 }
 
+// Waits for the given delay after getting a 429 Too Many Requests response from the host
+// while showing the remaining time below the active spinners so that they do not appear frozen.
+func waitForRateLimitBackoff(host string, delay time.Duration) {
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"Received 429 Too Many Requests response from %s, waiting %s before retrying...",
+			host,
+			delay.Round(time.Second),
+		),
+		false,
+		utils.INFO,
+	)
+	defer spinner.SetNotice("")
+
+	if utils.IsPlainOutput() {
+		// only print the notice once instead of every second
+		spinner.SetNotice(fmt.Sprintf("Rate limited by %s, waiting %s before retrying...", host, delay.Round(time.Second)))
+		utils.Sleep(delay)
+		return
+	}
+
+	deadline := time.Now().Add(delay)
+	for remaining := time.Until(deadline); remaining > 0; remaining = time.Until(deadline) {
+		spinner.SetNotice(fmt.Sprintf("Rate limited by %s, retrying in %s...", host, remaining.Round(time.Second)))
+		utils.Sleep(min(remaining, time.Second))
+	}
+}
+
 // CallRequest is used to make a request to a URL and return the response
 //
 // If the request fails, it will retry the request again up
@@ -161,14 +211,14 @@ func CheckInternetConnection() {
 		},
 	)
 	if err != nil {
-		color.Red(
+		utils.PrintError(
 			fmt.Sprintf(
 				"error %d: unable to connect to the internet, more info => %v",
-				utils.DEV_ERROR,
+				utils.CONNECTION_ERROR,
 				err,
 			),
 		)
-		os.Exit(1)
+		utils.Exit(utils.EXIT_NETWORK_ERROR)
 	}
 }
 
@@ -210,23 +260,19 @@ func processVer(apiResVer string) (*versionInfo, error) {
 	}, nil
 }
 
-type GithubApiRes struct {
-	TagName string `json:"tag_name"`
-	HtmlUrl string `json:"html_url"`
+type GithubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
 }
 
-// check for the latest version of the program
-func CheckVer() error {
-	progress := spinner.New(
-		spinner.REQ_SPINNER,
-		"fgHiYellow",
-		"Checking for the latest version...",
-		"",
-		"Failed to check for the latest version, please refer to the logs for more details...",
-		0,
-	)
-	progress.Start()
+type GithubApiRes struct {
+	TagName string        `json:"tag_name"`
+	HtmlUrl string        `json:"html_url"`
+	Assets  []GithubAsset `json:"assets"`
+}
 
+// Returns the details of the latest release of the program on GitHub
+func GetLatestRelease() (*GithubApiRes, error) {
 	url := "https://api.github.com/repos/KJHJason/Cultured-Downloader-CLI/releases/latest"
 	res, err := CallRequest(
 		&RequestArgs{
@@ -245,30 +291,30 @@ func CheckVer() error {
 		)
 		if err != nil {
 			errMsg += fmt.Sprintf(", more info => %v", err)
+		} else {
+			res.Body.Close()
 		}
-
-		progress.Stop(true)
-		return errors.New(errMsg)
+		return nil, errors.New(errMsg)
 	}
 
 	var apiRes GithubApiRes
 	if err := utils.LoadJsonFromResponse(res, &apiRes); err != nil {
-		errMsg := fmt.Sprintf(
+		return nil, fmt.Errorf(
 			"github error %d: unable to marshal the response from the API into an interface",
 			utils.UNEXPECTED_ERROR,
 		)
-		progress.Stop(true)
-		return errors.New(errMsg)
 	}
+	return &apiRes, nil
+}
 
-	latestVer, err := processVer(apiRes.TagName)
+// Checks if the given version of the latest release is newer than the program's version
+func IsOutdated(latestVerStr string) (bool, error) {
+	latestVer, err := processVer(latestVerStr)
 	if err != nil {
-		errMsg := fmt.Sprintf(
+		return false, fmt.Errorf(
 			"github error %d: unable to process the latest version",
 			utils.UNEXPECTED_ERROR,
 		)
-		progress.Stop(true)
-		return errors.New(errMsg)
 	}
 
 	programVer, err := processVer(utils.VERSION)
@@ -294,10 +340,36 @@ func CheckVer() error {
 			}
 		}
 	}
+	return outdated, nil
+}
+
+// check for the latest version of the program
+func CheckVer() error {
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		"Checking for the latest version...",
+		"",
+		"Failed to check for the latest version, please refer to the logs for more details...",
+		0,
+	)
+	progress.Start()
+
+	apiRes, err := GetLatestRelease()
+	if err != nil {
+		progress.Stop(true)
+		return err
+	}
+
+	outdated, err := IsOutdated(apiRes.TagName)
+	if err != nil {
+		progress.Stop(true)
+		return err
+	}
 
 	if outdated {
 		progress.ErrMsg = fmt.Sprintf(
-			"Warning: this program is outdated, the latest version %q is available at %s",
+			"Warning: a new version %q is available at %s\nRun the \"update\" command to update the program.",
 			apiRes.TagName,
 			apiRes.HtmlUrl,
 		)
@@ -0,0 +1,106 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestMain(m *testing.M) {
+	testutils.RunWithTempAppPath(m)
+}
+
+func TestCallRequestRetryDelays(t *testing.T) {
+	minDelay := time.Duration(utils.MIN_RETRY_DELAY) * time.Second
+	maxDelay := time.Duration(utils.MAX_RETRY_DELAY) * time.Second
+	tests := []struct {
+		name         string
+		failures     int // the number of 500 responses before a 200 response
+		retryAfter   string
+		wantErr      bool
+		wantSleeps   int
+		wantMinSleep time.Duration
+		wantMaxSleep time.Duration
+		wantRequests int32
+	}{
+		{
+			name:         "succeeds first try",
+			failures:     0,
+			wantSleeps:   0,
+			wantRequests: 1,
+		},
+		{
+			name:         "succeeds after two failures",
+			failures:     2,
+			wantSleeps:   2,
+			wantMinSleep: minDelay,
+			wantMaxSleep: maxDelay,
+			wantRequests: 3,
+		},
+		{
+			name:         "fails after all retries without sleeping after the last one",
+			failures:     utils.RETRY_COUNTER,
+			wantErr:      true,
+			wantSleeps:   utils.RETRY_COUNTER - 1,
+			wantMinSleep: minDelay,
+			wantMaxSleep: maxDelay,
+			wantRequests: utils.RETRY_COUNTER,
+		},
+		{
+			name:         "waits for the Retry-After header",
+			failures:     1,
+			retryAfter:   "30",
+			wantSleeps:   1,
+			wantMinSleep: 30 * time.Second,
+			wantMaxSleep: 30 * time.Second,
+			wantRequests: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := testutils.RecordSleeps(t)
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= test.failures {
+					if test.retryAfter != "" {
+						w.Header().Set("Retry-After", test.retryAfter)
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			res, err := CallRequest(&RequestArgs{
+				Url:         server.URL,
+				Method:      "GET",
+				CheckStatus: true,
+				Http2:       true,
+			})
+			if test.wantErr != (err != nil) {
+				t.Fatalf("CallRequest() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err == nil {
+				res.Body.Close()
+			}
+			if got := requests.Load(); got != test.wantRequests {
+				t.Errorf("sent %d request(s), want %d", got, test.wantRequests)
+			}
+			sleeps := recorder.Sleeps()
+			if len(sleeps) != test.wantSleeps {
+				t.Fatalf("slept %v, want %d sleep(s)", sleeps, test.wantSleeps)
+			}
+			for _, d := range sleeps {
+				if d < test.wantMinSleep || d > test.wantMaxSleep {
+					t.Errorf("slept %s, want between %s and %s", d, test.wantMinSleep, test.wantMaxSleep)
+				}
+			}
+		})
+	}
+}
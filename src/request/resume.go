@@ -0,0 +1,181 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// The extension appended to the file path of a resumable download until it has been completed
+const PART_FILE_EXT = ".part"
+
+// Returns the path of the partial file that a resumable download is written to
+func getPartFilePath(filePath string) string {
+	return filePath + PART_FILE_EXT
+}
+
+// Returns the size of the partial file left by a previous download that can be resumed
+// or 0 if there is none or if it is not smaller than the expected file size.
+func getResumableSize(partFilePath string, contentLength int64) int64 {
+	partFileSize, err := utils.GetFileSize(partFilePath)
+	if err != nil {
+		return 0
+	}
+	if contentLength > 0 && partFileSize >= contentLength {
+		return 0
+	}
+	return partFileSize
+}
+
+// Returns the headers with the Range header to resume the download from the given byte offset
+func addRangeToHeaders(headers map[string]string, resumeFrom int64) map[string]string {
+	rangeHeaders := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		rangeHeaders[key] = value
+	}
+	rangeHeaders["Range"] = fmt.Sprintf("bytes=%d-", resumeFrom)
+	return rangeHeaders
+}
+
+// Parses the Content-Range header like "bytes 100-999/1000"
+// and returns the first byte position and the total size (-1 if unknown).
+func parseContentRange(contentRange string) (int64, int64, error) {
+	rangeStr, found := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range header, %q", contentRange)
+	}
+
+	byteRange, totalStr, found := strings.Cut(rangeStr, "/")
+	startStr, _, hasEnd := strings.Cut(byteRange, "-")
+	if !found || !hasEnd {
+		return 0, 0, fmt.Errorf("invalid Content-Range header, %q", contentRange)
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range header, %q", contentRange)
+	}
+	if totalStr == "*" {
+		return start, -1, nil
+	}
+	total, err := strconv.ParseInt(totalStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range header, %q", contentRange)
+	}
+	return start, total, nil
+}
+
+// Logs the error of a download that could not be completed
+func logPartFileErr(url string, err error) {
+	errorMsg := fmt.Sprintf("failed to download %s due to %v", url, err)
+	utils.LogError(err, errorMsg, false, utils.ERROR)
+	utils.RUN_STATS.AddFailedFile(url, err)
+}
+
+// Writes the response body to the .part file of the given file path and renames it
+// to the file path once the download has been completed and its size has been verified.
+//
+// The response body will be appended to the .part file if the server responded with 206 Partial Content
+// to the Range request. Otherwise, the server has ignored the Range header and the .part file will be overwritten.
+//
+// Unlike DlToFile, the .part file is kept if the download was interrupted so that it can be resumed on the next run.
+// Errors other than the disk being full or context.Canceled will be logged instead of being returned.
+func dlToPartFile(res *http.Response, url, filePath string, resumeFrom, expectedSize int64, dlProgress *spinner.DlProgress) error {
+	partFilePath := getPartFilePath(filePath)
+	fileFlags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if res.StatusCode == http.StatusPartialContent {
+		start, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err == nil && start != resumeFrom {
+			err = fmt.Errorf("expected the response to start at byte %d but it started at byte %d", resumeFrom, start)
+		}
+		if err != nil {
+			logPartFileErr(
+				url,
+				fmt.Errorf("download error %d: %v\nfile path: %s", utils.DOWNLOAD_ERROR, err, filePath),
+			)
+			return nil
+		}
+
+		fileFlags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if total > 0 {
+			expectedSize = total
+		} else if res.ContentLength > 0 {
+			expectedSize = resumeFrom + res.ContentLength
+		}
+	} else {
+		resumeFrom = 0
+		if res.ContentLength > 0 {
+			expectedSize = res.ContentLength
+		}
+	}
+
+	file, err := os.OpenFile(partFilePath, fileFlags, 0666)
+	if err != nil {
+		if utils.IsDiskFullErr(err) {
+			return getDiskFullErr(filePath)
+		}
+		return fmt.Errorf(
+			"error %d: failed to create file, more info => %v\nfile path: %s",
+			utils.OS_ERROR,
+			err,
+			partFilePath,
+		)
+	}
+
+	fileProgress := dlProgress.AddFile(filepath.Base(filePath), res.ContentLength)
+	defer fileProgress.Done()
+	bytesWritten, err := io.Copy(io.MultiWriter(file, fileProgress), res.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// the .part file is kept so that the download can be resumed on the next run
+		if utils.IsDiskFullErr(err) {
+			// returned instead of being logged to stop the remaining downloads
+			return getDiskFullErr(filePath)
+		}
+		if err != context.Canceled {
+			logPartFileErr(url, err)
+			err = nil
+		}
+		return err
+	}
+
+	if totalSize := resumeFrom + bytesWritten; expectedSize > 0 && totalSize != expectedSize {
+		if totalSize > expectedSize {
+			// cannot be resumed as the .part file is not part of the file on the server
+			os.Remove(partFilePath)
+		}
+		logPartFileErr(
+			url,
+			fmt.Errorf(
+				"download error %d: expected %d bytes but got %d bytes\nfile path: %s",
+				utils.DOWNLOAD_ERROR,
+				expectedSize,
+				totalSize,
+				filePath,
+			),
+		)
+		return nil
+	}
+
+	if err := os.Rename(partFilePath, filePath); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to rename %s to %s, more info => %v",
+			utils.OS_ERROR,
+			partFilePath,
+			filePath,
+			err,
+		)
+	}
+	utils.RUN_STATS.AddDownloadedFile(bytesWritten)
+	return nil
+}
@@ -0,0 +1,220 @@
+package request
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Name of the release asset containing the SHA256 hashes of the binaries
+const RELEASE_HASH_FILENAME = "hash.txt"
+
+// Returns the name of the release asset for the given OS and architecture
+//
+// The names of the amd64 binaries follow the names used in make.ps1.
+func GetReleaseAssetName(goos, goarch string) string {
+	if goarch == "amd64" {
+		switch goos {
+		case "windows":
+			return "cultured-downloader-cli.exe"
+		case "linux", "darwin":
+			return "cultured-downloader-cli-" + goos
+		}
+	}
+
+	assetName := fmt.Sprintf("cultured-downloader-cli-%s-%s", goos, goarch)
+	if goos == "windows" {
+		assetName += ".exe"
+	}
+	return assetName
+}
+
+func getReleaseAsset(release *GithubApiRes, assetName string) *GithubAsset {
+	for idx := range release.Assets {
+		if release.Assets[idx].Name == assetName {
+			return &release.Assets[idx]
+		}
+	}
+	return nil
+}
+
+// Decodes the hash file which may be encoded in UTF-16 as it is generated by PowerShell's Out-File
+func decodeHashFile(data []byte) string {
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+		data = data[2:]
+		u16 := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			u16 = append(u16, uint16(data[i])|uint16(data[i+1])<<8)
+		}
+		return string(utf16.Decode(u16))
+	}
+	return strings.TrimPrefix(string(data), "\ufeff")
+}
+
+// Returns the SHA256 hash of the given asset from the contents of the release's hash file.
+//
+// Both the "<asset> (<os>-<arch>/...):" followed by a "- <hash>" line format
+// used by make.ps1 and the "<hash>  <asset>" format used by sha256sum are supported.
+func getAssetHash(hashFile, assetName string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(hashFile, "\r", ""), "\n")
+	for idx, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, assetName+" (") && idx+1 < len(lines) {
+			hash := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[idx+1]), "-"))
+			return strings.ToLower(hash), nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf(
+		"github error %d: unable to find the SHA256 hash of %s in the release's %s",
+		utils.RESPONSE_ERROR,
+		assetName,
+		RELEASE_HASH_FILENAME,
+	)
+}
+
+// Downloads the hash file of the release and returns the SHA256 hash of the given asset
+func getExpectedAssetHash(release *GithubApiRes, assetName string) (string, error) {
+	hashAsset := getReleaseAsset(release, RELEASE_HASH_FILENAME)
+	if hashAsset == nil {
+		return "", fmt.Errorf(
+			"github error %d: the release %s does not have a %s file to verify the download with",
+			utils.RESPONSE_ERROR,
+			release.TagName,
+			RELEASE_HASH_FILENAME,
+		)
+	}
+
+	res, err := CallRequest(
+		&RequestArgs{
+			Url:         hashAsset.BrowserDownloadUrl,
+			Method:      "GET",
+			Timeout:     15,
+			CheckStatus: true,
+			Http2:       true,
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf(
+			"github error %d: failed to download the release's %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			RELEASE_HASH_FILENAME,
+			err,
+		)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf(
+			"github error %d: failed to read the release's %s, more info => %v",
+			utils.RESPONSE_ERROR,
+			RELEASE_HASH_FILENAME,
+			err,
+		)
+	}
+	return getAssetHash(decodeHashFile(data), assetName)
+}
+
+// Downloads the binary of the given release for the current OS and architecture,
+// verifies it against the release's hash file, and replaces the binary at exePath with it.
+func UpdateExecutable(release *GithubApiRes, exePath string) error {
+	assetName := GetReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+	asset := getReleaseAsset(release, assetName)
+	if asset == nil {
+		return fmt.Errorf(
+			"github error %d: the release %s does not have a binary for %s/%s, please download it manually from %s",
+			utils.INPUT_ERROR,
+			release.TagName,
+			runtime.GOOS,
+			runtime.GOARCH,
+			release.HtmlUrl,
+		)
+	}
+
+	expectedHash, err := getExpectedAssetHash(release, assetName)
+	if err != nil {
+		return err
+	}
+
+	res, err := CallRequest(
+		&RequestArgs{
+			Url:         asset.BrowserDownloadUrl,
+			Method:      "GET",
+			Timeout:     utils.DOWNLOAD_TIMEOUT,
+			CheckStatus: true,
+			Http2:       true,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"github error %d: failed to download %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			assetName,
+			err,
+		)
+	}
+	defer res.Body.Close()
+
+	// the new binary is written to the same directory so that it can be renamed atomically
+	tmpFile, err := os.CreateTemp(filepath.Dir(exePath), ".cultured_downloader_update_*")
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create a temporary file for the update next to %q, more info => %v",
+			utils.OS_ERROR,
+			exePath,
+			err,
+		)
+	}
+	tmpPath := tmpFile.Name()
+
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmpFile, hasher), res.Body)
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf(
+			"github error %d: failed to download %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			assetName,
+			err,
+		)
+	}
+
+	if actualHash := hex.EncodeToString(hasher.Sum(nil)); actualHash != expectedHash {
+		os.Remove(tmpPath)
+		return fmt.Errorf(
+			"github error %d: the SHA256 hash of the downloaded %s, %s, does not match the expected hash, %s",
+			utils.RESPONSE_ERROR,
+			assetName,
+			actualHash,
+			expectedHash,
+		)
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf(
+			"error %d: failed to make the downloaded update executable, more info => %v",
+			utils.OS_ERROR,
+			err,
+		)
+	}
+	if err := utils.ReplaceExecutable(exePath, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
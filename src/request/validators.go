@@ -0,0 +1,119 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Name of the manifest file saved in each folder with downloaded files that
+// records the ETag and Last-Modified headers of the files for conditional requests.
+const VALIDATORS_FILENAME = "http_validators.json"
+
+// Used to prevent the download workers from overwriting each other's changes to the manifest files
+var validatorsMu sync.Mutex
+
+// httpValidators are the headers of a downloaded file that can be used
+// to check if the file has changed on the server via a conditional GET request.
+type httpValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Returns a copy of the given headers with the conditional request headers of the validators
+func (v *httpValidators) addToHeaders(headers map[string]string) map[string]string {
+	conditionalHeaders := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		conditionalHeaders[key] = value
+	}
+	if v.ETag != "" {
+		conditionalHeaders["If-None-Match"] = v.ETag
+	}
+	if v.LastModified != "" {
+		conditionalHeaders["If-Modified-Since"] = v.LastModified
+	}
+	return conditionalHeaders
+}
+
+// Reads the validators manifest in the given folder which maps the file names to their validators
+func readValidatorsFile(folderPath string) (map[string]*httpValidators, error) {
+	manifestPath := filepath.Join(folderPath, VALIDATORS_FILENAME)
+	validators := make(map[string]*httpValidators)
+	if !utils.PathExists(manifestPath) {
+		return validators, nil
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to read validators file at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	if err := json.Unmarshal(data, &validators); err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to parse validators file at %s, more info => %v",
+			utils.JSON_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	return validators, nil
+}
+
+// Returns the validators saved for the file at the given path or nil if there are none
+func getSavedValidators(filePath string) *httpValidators {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	validators, err := readValidatorsFile(filepath.Dir(filePath))
+	if err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+		return nil
+	}
+	return validators[filepath.Base(filePath)]
+}
+
+// Saves the ETag and Last-Modified headers of the response
+// for the downloaded file at the given path (if the server sent any).
+func saveValidators(filePath string, header http.Header) error {
+	fileValidators := &httpValidators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if fileValidators.ETag == "" && fileValidators.LastModified == "" {
+		return nil
+	}
+
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	folderPath := filepath.Dir(filePath)
+	validators, err := readValidatorsFile(folderPath)
+	if err != nil {
+		return err
+	}
+	validators[filepath.Base(filePath)] = fileValidators
+
+	validatorsJson, err := utils.PrettifyJson(validators)
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(folderPath, VALIDATORS_FILENAME)
+	if err := os.WriteFile(manifestPath, validatorsJson, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write validators file at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	return nil
+}
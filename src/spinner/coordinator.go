@@ -0,0 +1,155 @@
+package spinner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// The minimum delay between the frames rendered by the coordinator
+const MIN_RENDER_INTERVAL = 10 * time.Millisecond
+
+// renderCoordinator owns the terminal while spinners are active and renders all of them
+// stacked on separate lines, in the order they were started, from a single goroutine.
+//
+// Everything written to the terminal by the spinners goes through the coordinator's lock
+// so that spinners running at the same time, e.g. the GDrive downloads and the regular downloads,
+// will not interleave their output.
+//
+// Lock order: the coordinator's lock must be acquired before any spinner's lock.
+type renderCoordinator struct {
+	mu         sync.Mutex
+	sources    []*Spinner
+	running    bool
+	extraLines int    // the number of lines rendered below the first line in the last frame
+	notice     string // rendered below the spinners like when the requests are paused due to rate limiting
+}
+
+var coordinator = &renderCoordinator{}
+
+// Adds the spinner to the rendered spinners and starts the render loop if it is not running.
+//
+// The coordinator's lock must be held by the caller.
+func (c *renderCoordinator) add(s *Spinner) {
+	c.sources = append(c.sources, s)
+	if !c.running {
+		c.running = true
+		go c.renderLoop()
+	}
+}
+
+// Removes the spinner from the rendered spinners and clears the lines rendered in the last frame
+// so that the output printed afterwards like the spinner's outcome message will not be overwritten.
+// The remaining spinners (if any) will be rendered again below it.
+//
+// The coordinator's lock must be held by the caller.
+func (c *renderCoordinator) remove(s *Spinner) {
+	for idx, source := range c.sources {
+		if source == s {
+			c.sources = append(c.sources[:idx], c.sources[idx+1:]...)
+			break
+		}
+	}
+
+	fmt.Print(getCursorUp(c.extraLines) + "\r" + CLEAR_BELOW)
+	c.extraLines = 0
+}
+
+// Stops all the active spinners without printing their outcome messages
+// so that the terminal will be left on a clean line, e.g. when the run has been interrupted.
+func stopAllSpinners() {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+	for len(coordinator.sources) > 0 {
+		s := coordinator.sources[0]
+		s.mu.Lock()
+		s.stopSpinner()
+		s.mu.Unlock()
+	}
+	coordinator.notice = ""
+}
+
+// Renders the frames of the spinners until there are no spinners left
+func (c *renderCoordinator) renderLoop() {
+	for {
+		c.mu.Lock()
+		if len(c.sources) == 0 {
+			c.running = false
+			c.mu.Unlock()
+			return
+		}
+		delay := c.render()
+		c.mu.Unlock()
+		time.Sleep(delay)
+	}
+}
+
+// Renders the current frame of every spinner and returns the delay until the next frame is due.
+//
+// The cursor will be moved back to the first line of the last frame
+// to overwrite it and will be left at the end of the last line.
+//
+// The coordinator's lock must be held by the caller.
+func (c *renderCoordinator) render() time.Duration {
+	if len(c.sources) == 0 {
+		return MIN_RENDER_INTERVAL
+	}
+
+	now := time.Now()
+	delay := time.Duration(-1)
+	lineCount := 0
+	var sb strings.Builder
+	sb.WriteString(getCursorUp(c.extraLines))
+	for _, s := range c.sources {
+		s.mu.Lock()
+		frame, nextFrameDelay := s.advanceFrame(now)
+		lines := s.getFrameLines(frame)
+		s.mu.Unlock()
+
+		if delay < 0 || nextFrameDelay < delay {
+			delay = nextFrameDelay
+		}
+		for _, line := range lines {
+			if lineCount > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString("\r" + s.Colour.Sprint(line) + CLEAR_LINE)
+			lineCount++
+		}
+	}
+	if c.notice != "" {
+		sb.WriteString("\n\r" + color.YellowString(c.notice) + CLEAR_LINE)
+		lineCount++
+	}
+	if c.extraLines > lineCount-1 {
+		sb.WriteString(CLEAR_BELOW)
+	}
+	c.extraLines = lineCount - 1
+	fmt.Print(sb.String())
+
+	if delay < MIN_RENDER_INTERVAL {
+		delay = MIN_RENDER_INTERVAL
+	}
+	return delay
+}
+
+// SetNotice shows the given message below the active spinners until it is cleared with an empty string
+// so that the user knows why the spinners are not progressing, e.g. when waiting due to rate limiting.
+//
+// If the output is plain text, the message will be printed as a line instead whenever it changes.
+func SetNotice(msg string) {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+	if coordinator.notice == msg {
+		return
+	}
+
+	coordinator.notice = msg
+	if msg != "" && utils.IsPlainOutput() && !utils.IsQuiet() {
+		fmt.Println(msg)
+	}
+}
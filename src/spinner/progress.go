@@ -0,0 +1,208 @@
+package spinner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	PROGRESS_BAR_WIDTH       = 20
+	PROGRESS_NAME_WIDTH      = 30
+	MAX_PROGRESS_FILES_SHOWN = 5
+
+	// ANSI escape code to clear everything below the cursor
+	CLEAR_BELOW = "\033[J"
+)
+
+// progressRenderer renders extra progress information along with the spinner's message
+type progressRenderer interface {
+	// Returns the lines to render below the spinner's message given the spinner's count
+	lines(count, maxCount int) []string
+
+	// Returns a one-line summary to append to the spinner's message when the output is plain text
+	summary() string
+}
+
+// DlProgress tracks the byte progress of the files being downloaded concurrently
+// and renders a bar for each active file with a known size and an aggregate bar below the spinner.
+//
+// Files with an unknown size are only counted towards the downloaded bytes and if none of the
+// active files have a known size, only the spinner's "[x/y]" counter will be shown.
+//
+// A nil *DlProgress can be used safely and will not track anything.
+type DlProgress struct {
+	mu         sync.Mutex
+	files      []*FileProgress
+	startTime  time.Time
+	downloaded atomic.Int64
+}
+
+// FileProgress is the byte progress of a file being downloaded.
+//
+// It implements io.Writer to count the bytes written to the file, e.g. with io.MultiWriter.
+type FileProgress struct {
+	dlProgress *DlProgress
+	name       string
+	size       int64
+	written    atomic.Int64
+}
+
+// Creates a DlProgress and attaches it to the given spinner
+// so that the progress will be rendered along with the spinner.
+//
+// Should be called before starting the spinner.
+func NewDlProgress(s *Spinner) *DlProgress {
+	dlProgress := &DlProgress{startTime: time.Now()}
+	s.mu.Lock()
+	s.renderer = dlProgress
+	s.mu.Unlock()
+	return dlProgress
+}
+
+// Starts tracking a file with the given name and size in bytes (-1 if unknown)
+func (p *DlProgress) AddFile(name string, size int64) *FileProgress {
+	if p == nil {
+		return nil
+	}
+
+	fileProgress := &FileProgress{
+		dlProgress: p,
+		name:       name,
+		size:       size,
+	}
+	p.mu.Lock()
+	p.files = append(p.files, fileProgress)
+	p.mu.Unlock()
+	return fileProgress
+}
+
+// Write counts the bytes written to the file
+func (f *FileProgress) Write(b []byte) (int, error) {
+	if f == nil {
+		return len(b), nil
+	}
+	f.written.Add(int64(len(b)))
+	f.dlProgress.downloaded.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Done stops tracking the file after it has been downloaded or has failed
+func (f *FileProgress) Done() {
+	if f == nil {
+		return
+	}
+
+	p := f.dlProgress
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for idx, file := range p.files {
+		if file == f {
+			p.files = append(p.files[:idx], p.files[idx+1:]...)
+			break
+		}
+	}
+}
+
+// Returns the fraction of the file that has been downloaded or -1 if its size is unknown
+func (f *FileProgress) fraction() float64 {
+	if f.size <= 0 {
+		return -1
+	}
+
+	fraction := float64(f.written.Load()) / float64(f.size)
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// Returns a bar like "[#####---------------]" for the given fraction between 0 and 1
+func renderBar(fraction float64) string {
+	filled := int(fraction * PROGRESS_BAR_WIDTH)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", PROGRESS_BAR_WIDTH-filled) + "]"
+}
+
+// Returns the name padded or truncated to PROGRESS_NAME_WIDTH characters
+func formatProgressName(name string) string {
+	runes := []rune(name)
+	if len(runes) > PROGRESS_NAME_WIDTH {
+		return string(runes[:PROGRESS_NAME_WIDTH-3]) + "..."
+	}
+	return name + strings.Repeat(" ", PROGRESS_NAME_WIDTH-len(runes))
+}
+
+// Returns the average download speed since the progress was created
+func (p *DlProgress) speed() int64 {
+	elapsed := time.Since(p.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(p.downloaded.Load()) / elapsed)
+}
+
+func (p *DlProgress) lines(count, maxCount int) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var lines []string
+	activeFraction := 0.0
+	for _, file := range p.files {
+		fraction := file.fraction()
+		if fraction < 0 {
+			continue
+		}
+
+		activeFraction += fraction
+		if len(lines) < MAX_PROGRESS_FILES_SHOWN {
+			lines = append(lines, fmt.Sprintf(
+				"  %s %s %3.0f%% %s/%s",
+				formatProgressName(file.name),
+				renderBar(fraction),
+				fraction*100,
+				utils.FormatBytes(file.written.Load()),
+				utils.FormatBytes(file.size),
+			))
+		}
+	}
+	if len(lines) == 0 {
+		// none of the active files have a known size so only the spinner's counter is useful
+		return nil
+	}
+	if hidden := len(p.files) - len(lines); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ...and %d more file(s)", hidden))
+	}
+
+	totalFraction := 0.0
+	if maxCount > 0 {
+		totalFraction = (float64(count) + activeFraction) / float64(maxCount)
+		if totalFraction > 1 {
+			totalFraction = 1
+		}
+	}
+	lines = append(lines, fmt.Sprintf(
+		"  %s %s %3.0f%% %s at %s/s",
+		formatProgressName("Total"),
+		renderBar(totalFraction),
+		totalFraction*100,
+		utils.FormatBytes(p.downloaded.Load()),
+		utils.FormatBytes(p.speed()),
+	))
+	return lines
+}
+
+func (p *DlProgress) summary() string {
+	downloaded := p.downloaded.Load()
+	if downloaded == 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"(%s downloaded at %s/s)",
+		utils.FormatBytes(downloaded),
+		utils.FormatBytes(p.speed()),
+	)
+}
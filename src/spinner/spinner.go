@@ -1,10 +1,12 @@
 package spinner
 
 import (
-	"os"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
@@ -13,10 +15,16 @@ import (
 const (
 	CLEAR_LINE = "\033[K"
 
+	// The terminal width to keep the spinner's line within if the COLUMNS environment variable is not set
+	DEFAULT_TERMINAL_WIDTH = 80
+
 	// Common spinner types used in this program
 	REQ_SPINNER  = "pong"
 	JSON_SPINNER = "aesthetic"
 	DL_SPINNER   = "material"
+
+	// The minimum interval between the progress lines printed when the output is plain text
+	PLAIN_PROGRESS_INTERVAL = 10 * time.Second
 )
 
 var (
@@ -45,6 +53,7 @@ var (
 func init() {
 	spinnerTypes = GetSpinnerTypes()
 	spinnersJson = nil // free up memory since it is no longer needed
+	utils.OnInterrupt(stopAllSpinners)
 }
 
 // ListSpinnerTypes lists all the supported spinner types
@@ -96,11 +105,20 @@ type Spinner struct {
 	SuccessMsg string
 	ErrMsg     string
 
+	// Optional suffix of the message like the post currently being processed
+	detail string
+
 	count    int
 	maxCount int
 	active   bool
 	mu       *sync.RWMutex
 	stop     chan struct{}
+
+	// Optional renderer of extra progress information like the byte progress of the downloads
+	renderer progressRenderer
+
+	frameIdx  int
+	frameTime time.Time // when the current frame was first rendered
 }
 
 // New creates a new spinner with the given spinner type, 
@@ -136,44 +154,157 @@ func New(spinnerType, colour, message, successMsg, errMsg string, maxCount int)
 	}
 }
 
+// Returns the carriage return to overwrite the spinner's line
+// or an empty string if the output is plain text.
+func getLineStart() string {
+	if utils.IsPlainOutput() {
+		return ""
+	}
+	return "\r"
+}
+
+// Returns the ANSI escape code to clear the rest of the line
+// or an empty string if the output is plain text.
+func getClearLine() string {
+	if utils.IsPlainOutput() {
+		return ""
+	}
+	return CLEAR_LINE
+}
+
+// Returns the width of the terminal from the COLUMNS environment variable or DEFAULT_TERMINAL_WIDTH
+func getTerminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return DEFAULT_TERMINAL_WIDTH
+}
+
+// Returns the spinner's message with the detail (if any) like "[37/214]... (post 1234567 - Title)".
+//
+// The detail will be truncated so that the line will be within the terminal width
+// after the given number of characters used by the spinner's frame.
+func (s *Spinner) getMsgWithDetail(frameWidth int) string {
+	if s.detail == "" {
+		return s.Msg
+	}
+
+	const minDetailWidth = 10
+	maxDetailWidth := getTerminalWidth() - frameWidth - utf8.RuneCountInString(s.Msg) - len(" ()")
+	if maxDetailWidth < minDetailWidth {
+		return s.Msg
+	}
+
+	detail := []rune(s.detail)
+	if len(detail) > maxDetailWidth {
+		detail = append(detail[:maxDetailWidth-3], []rune("...")...)
+	}
+	return fmt.Sprintf("%s (%s)", s.Msg, string(detail))
+}
+
+// Returns the ANSI escape code to move the cursor up by the given number of lines
+func getCursorUp(lines int) string {
+	if lines <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\033[%dA", lines)
+}
+
+// Returns the spinner's message with the renderer's summary (if any) for the plain text output
+func (s *Spinner) getPlainMsg() string {
+	msg := s.getMsgWithDetail(0)
+	if s.renderer == nil {
+		return msg
+	}
+	if summary := s.renderer.summary(); summary != "" {
+		return msg + " " + summary
+	}
+	return msg
+}
+
+// Advances the spinner to its next frame if the current frame has been rendered for the spinner's interval.
+//
+// Returns the frame to render and the delay until the next frame is due.
+func (s *Spinner) advanceFrame(now time.Time) (string, time.Duration) {
+	interval := time.Duration(s.Spinner.Interval) * time.Millisecond
+	if s.frameTime.IsZero() {
+		s.frameTime = now
+	} else if now.Sub(s.frameTime) >= interval {
+		s.frameIdx = (s.frameIdx + 1) % len(s.Spinner.Frames)
+		s.frameTime = now
+	}
+	return s.Spinner.Frames[s.frameIdx], interval - now.Sub(s.frameTime)
+}
+
+// Returns the lines of the spinner's frame which are the message and the renderer's lines (if any) below it
+func (s *Spinner) getFrameLines(frame string) []string {
+	msg := s.getMsgWithDetail(utf8.RuneCountInString(frame) + 1)
+	lines := []string{fmt.Sprintf("%s %s", frame, msg)}
+	if s.renderer != nil {
+		lines = append(lines, s.renderer.lines(s.count, s.maxCount)...)
+	}
+	return lines
+}
+
+// Prints the spinner's message as a new line whenever it has changed,
+// at most once every PLAIN_PROGRESS_INTERVAL, until the spinner is stopped.
+//
+// Used instead of the animation when the output is plain text so that the
+// progress like "Processed 120/500 posts..." can still be seen in the logs.
+func (s *Spinner) printPlainProgress(lastPrintedMsg string) {
+	ticker := time.NewTicker(PLAIN_PROGRESS_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			coordinator.mu.Lock()
+			s.mu.Lock()
+			if !s.active {
+				s.mu.Unlock()
+				coordinator.mu.Unlock()
+				return
+			}
+			if msg := s.getPlainMsg(); msg != lastPrintedMsg {
+				fmt.Println(msg)
+				lastPrintedMsg = msg
+			}
+			s.mu.Unlock()
+			coordinator.mu.Unlock()
+		}
+	}
+}
+
 // Starts the spinner
+//
+// The spinner will be rendered by the render coordinator on its own line below
+// the other active spinners (if any) so that their output will not interleave.
+//
+// If the output is plain text (e.g. "--no-color" or stdout is not a terminal like when
+// the output is redirected to a file), the message will be printed as a line instead of
+// animating the spinner and any changes to the message will be printed periodically.
+//
+// Nothing will be printed if "--quiet" is enabled.
 func (s *Spinner) Start() {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.active {
-		s.mu.Unlock()
 		return
 	}
 
 	s.active = true
-	s.mu.Unlock()
-
-	go func() {
-		for {
-			for _, frame := range s.Spinner.Frames {
-				select {
-				case <-s.stop:
-					return
-				default:
-					s.mu.Lock()
-					if !s.active {
-						s.mu.Unlock()
-						return
-					}
-
-					s.Colour.Printf(
-						"\r%s %s%s", 
-						frame, 
-						s.Msg, 
-						CLEAR_LINE,
-					)
-					s.mu.Unlock()
-					time.Sleep(
-						time.Duration(s.Spinner.Interval) * time.Millisecond,
-					)
-				}
-			}
-		}
-	}()
+	if utils.IsQuiet() {
+		return
+	}
+	if utils.IsPlainOutput() {
+		fmt.Println(s.Msg)
+		go s.printPlainProgress(s.Msg)
+		return
+	}
+	coordinator.add(s)
 }
 
 // Add adds i to the spinner count
@@ -197,6 +328,18 @@ func (s *Spinner) UpdateMsg(msg string) {
 	s.Msg = msg
 }
 
+// SetDetail sets the suffix of the message like the item currently being processed,
+// e.g. s.SetDetail("post 1234567 - Title") will display "Getting post details [37/214]... (post 1234567 - Title)".
+//
+// Long details will be truncated to keep the line within the terminal width.
+// An empty string will remove the suffix.
+func (s *Spinner) SetDetail(detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.detail = detail
+}
+
 // MsgIncrement increments the spinner count and 
 // updates the message with the new count based onthe baseMsg.
 //
@@ -211,7 +354,13 @@ func (s* Spinner) MsgIncrement(baseMsg string) {
 	)
 }
 
+// Stops the spinner and removes it from the render coordinator.
+//
+// The coordinator's lock and the spinner's lock must be held by the caller.
 func (s *Spinner) stopSpinner() {
+	if !utils.IsPlainOutput() {
+		coordinator.remove(s)
+	}
 	s.active = false
 	if s.count != 0 {
 		s.count = 0
@@ -224,49 +373,62 @@ func (s *Spinner) stopSpinner() {
 func (s *Spinner) Stop(hasErr bool) {
 	s.StopWithFn(func () {
 		if hasErr && s.ErrMsg != "" {
-			color.Red(
-				"\r✗ %s%s\n",
+			utils.PrintError(
+				"%s✗ %s%s\n",
+				getLineStart(),
 				s.ErrMsg,
-				CLEAR_LINE,
+				getClearLine(),
 			)
 		} else if s.SuccessMsg != "" {
 			color.Green(
-				"\r✓ %s%s", 
+				"%s✓ %s%s",
+				getLineStart(),
 				s.SuccessMsg,
-				CLEAR_LINE,
+				getClearLine(),
 			)
 		}
 	})
 }
 
 // Stop spinner with the given action function that will be called
+//
+// The output of the action will be printed above the other active spinners (if any).
 func (s *Spinner) StopWithFn(action func()) {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if !s.active {
+		s.mu.Unlock()
 		return
 	}
 
 	s.stopSpinner()
 	action()
+	s.mu.Unlock()
+
+	// render the remaining spinners below the action's output right away
+	coordinator.render()
 }
 
 // KillProgram stops the spinner, 
-// prints the given message and exits the program with code 2.
+// prints the given message and exits the program with utils.EXIT_INTERRUPTED.
 //
 // Used for Ctrl + C interrupts.
 func (s *Spinner) KillProgram(msg string) {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if !s.active {
-		os.Exit(2)
+		utils.Exit(utils.EXIT_INTERRUPTED)
 	}
 
 	s.stopSpinner()
-	color.Red(
-		"\r✗ %s%s\n",
+	utils.PrintError(
+		"%s✗ %s%s\n",
+		getLineStart(),
 		msg,
-		CLEAR_LINE,
+		getClearLine(),
 	)
-	os.Exit(2)
+	utils.Exit(utils.EXIT_INTERRUPTED)
 }
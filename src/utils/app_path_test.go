@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	appPathHelperEnvVar     = "CULTURED_DOWNLOADER_TEST_HELPER"
+	appPathHelperSetPathVar = "CULTURED_DOWNLOADER_TEST_SET_APP_PATH"
+)
+
+// Run in a separate process by TestAppPathOverride as APP_PATH is set when the package is initialised.
+//
+// Writes the log file and the config file like a normal run after applying the "--app-data-dir" override (if any).
+func TestAppPathHelperProcess(t *testing.T) {
+	if os.Getenv(appPathHelperEnvVar) != "1" {
+		t.Skip("only run as a helper process")
+	}
+
+	if appPath := os.Getenv(appPathHelperSetPathVar); appPath != "" {
+		if err := SetAppPath(appPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	LogError(nil, "test log", false, INFO)
+	if err := SaveConfig(&ConfigFile{Language: DEFAULT_LANGUAGE}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppPathOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		envAppPath bool
+		setAppPath bool
+		wantEnvDir bool // true if the files are expected in the directory from the environment variable
+	}{
+		{name: "environment variable", envAppPath: true, wantEnvDir: true},
+		{name: "app data dir flag", setAppPath: true},
+		{name: "flag overrides environment variable", envAppPath: true, setAppPath: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			defaultDir := filepath.Join(dir, "default")
+			envDir := filepath.Join(dir, "env")
+			flagDir := filepath.Join(dir, "flag")
+
+			cmd := exec.Command(os.Args[0], "-test.run=^TestAppPathHelperProcess$")
+			cmd.Env = append(
+				os.Environ(),
+				appPathHelperEnvVar+"=1",
+				// redirect the user's config directory used by default on every OS
+				"HOME="+defaultDir,
+				"XDG_CONFIG_HOME="+defaultDir,
+				"AppData="+defaultDir,
+				APP_PATH_ENV_VAR+"=",
+			)
+			if test.envAppPath {
+				cmd.Env = append(cmd.Env, APP_PATH_ENV_VAR+"="+envDir)
+			}
+			if test.setAppPath {
+				cmd.Env = append(cmd.Env, appPathHelperSetPathVar+"="+flagDir)
+			}
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("helper process failed: %v\n%s", err, output)
+			}
+
+			wantDir, otherDir := flagDir, envDir
+			if test.wantEnvDir {
+				wantDir, otherDir = envDir, flagDir
+			}
+			if !PathExists(filepath.Join(wantDir, "config.json")) {
+				t.Errorf("config file was not written to %s", wantDir)
+			}
+			logFiles, _ := os.ReadDir(filepath.Join(wantDir, "logs"))
+			if len(logFiles) == 0 {
+				t.Errorf("log file was not written to %s", filepath.Join(wantDir, "logs"))
+			}
+			for _, unwantedDir := range []string{defaultDir, otherDir} {
+				if PathExists(unwantedDir) {
+					t.Errorf("files were written to %s", unwantedDir)
+				}
+			}
+		})
+	}
+}
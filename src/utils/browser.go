@@ -39,6 +39,9 @@ func GetDefaultChromedpAlloc(userAgent string) (context.Context, context.CancelF
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.UserAgent(userAgent),
 	)
+	if proxy := GetProxy(); proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(proxy))
+	}
 	return chromedp.NewExecAllocator(context.Background(), opts...)
 }
 
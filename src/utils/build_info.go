@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set at build time via -ldflags, e.g.
+//
+//	-ldflags "-X github.com/KJHJason/Cultured-Downloader-CLI/utils.Commit=<sha> -X github.com/KJHJason/Cultured-Downloader-CLI/utils.BuildDate=<date>"
+//
+// If not set, the values recorded by the Go toolchain will be used instead (if available).
+//
+// Build with "-tags nohttp3" to leave out the HTTP/3 client which will be reported as not compiled.
+var (
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo contains the details of how the program was built for bug reports
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Http3     bool   `json:"http3"`
+}
+
+// Returns the build details of the program from the -ldflags variables
+// with a fallback to the details embedded by the Go toolchain.
+func GetBuildInfo() *BuildInfo {
+	info := &BuildInfo{
+		Version:   VERSION,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		Http3:     HTTP3_COMPILED,
+	}
+
+	if debugInfo, ok := debug.ReadBuildInfo(); ok {
+		var modified bool
+		for _, setting := range debugInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if modified && Commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// Returns the build details in a human-readable format
+func (info *BuildInfo) String() string {
+	http3Support := "not compiled"
+	if info.Http3 {
+		http3Support = "compiled"
+	}
+	return strings.Join(
+		[]string{
+			fmt.Sprintf("Version: %s", info.Version),
+			fmt.Sprintf("Commit: %s", info.Commit),
+			fmt.Sprintf("Build Date: %s", info.BuildDate),
+			fmt.Sprintf("Go Version: %s", info.GoVersion),
+			fmt.Sprintf("Platform: %s", info.Platform),
+			fmt.Sprintf("HTTP/3 Support: %s", http3Support),
+		},
+		"\n",
+	)
+}
@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	CONFIG_DOWNLOAD_DIR_KEY = "download_directory"
+	CONFIG_LANGUAGE_KEY     = "language"
+	DEFAULT_LANGUAGE        = "en"
+	CONFIG_DEFAULTS_KEY     = "defaults"
+)
+
+// The languages that can be set for the "language" key in the config file
+var ACCEPTED_LANGUAGES = []string{"en", "ja"}
+
+// The dl_* toggles of each site that can be given a default value
+// in the "defaults" section of the config file
+var CONFIG_DL_TOGGLES = map[string][]string{
+	FANTIA:       {"dl_thumbnails", "dl_images", "dl_attachments", "dl_gdrive"},
+	PIXIV_FANBOX: {"dl_thumbnails", "dl_images", "dl_attachments", "dl_gdrive"},
+	KEMONO:       {"dl_attachments", "dl_gdrive"},
+}
+
+type ConfigFile struct {
+	DownloadDir string `json:"download_directory"`
+	Language    string `json:"language"`
+
+	// The default values of the dl_* toggles per site like {"fantia": {"dl_thumbnails": false}}
+	// which are used when the flags are not given.
+	//
+	// The values are only validated when they are used so that
+	// unknown sites and keys from other versions of the program can be ignored.
+	Defaults map[string]map[string]any `json:"defaults,omitempty"`
+
+	// The refresh token saved after the Pixiv OAuth flow to be reused for Pixiv's mobile API.
+	//
+	// Not accessible via the config keys so that it will not be printed by the config command.
+	PixivRefreshToken string `json:"pixiv_refresh_token,omitempty"`
+}
+
+type configKey struct {
+	get func(config *ConfigFile) string
+
+	// Validates the value and sets it on the config,
+	// returning the normalised value that was saved.
+	set func(config *ConfigFile, value string) (string, error)
+}
+
+var configKeys = map[string]configKey{
+	CONFIG_DOWNLOAD_DIR_KEY: {
+		get: func(config *ConfigFile) string {
+			return config.DownloadDir
+		},
+		set: func(config *ConfigFile, value string) (string, error) {
+			normalizedPath, err := NormalizeDownloadPath(value)
+			if err != nil {
+				return "", err
+			}
+			config.DownloadDir = normalizedPath
+			return normalizedPath, nil
+		},
+	},
+	CONFIG_LANGUAGE_KEY: {
+		get: func(config *ConfigFile) string {
+			return config.Language
+		},
+		set: func(config *ConfigFile, value string) (string, error) {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if !SliceContains(ACCEPTED_LANGUAGES, value) {
+				return "", fmt.Errorf(
+					"error %d: invalid language, %q, must be one of %s",
+					INPUT_ERROR,
+					value,
+					strings.Join(ACCEPTED_LANGUAGES, ", "),
+				)
+			}
+			config.Language = value
+			return value, nil
+		},
+	},
+}
+
+// Returns the config key of the default value of the site's dl_* toggle, e.g. "defaults.fantia.dl_thumbnails"
+func getDlDefaultConfigKey(site, toggle string) string {
+	return fmt.Sprintf("%s.%s.%s", CONFIG_DEFAULTS_KEY, site, toggle)
+}
+
+// Returns the config key to get and set the default value of the site's dl_* toggle
+func newDlDefaultConfigKey(site, toggle string) configKey {
+	return configKey{
+		get: func(config *ConfigFile) string {
+			if value, ok := config.Defaults[site][toggle]; ok {
+				return fmt.Sprint(value)
+			}
+			return ""
+		},
+		set: func(config *ConfigFile, value string) (string, error) {
+			boolValue, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return "", fmt.Errorf(
+					"error %d: invalid value, %q, for %s, must be true or false",
+					INPUT_ERROR,
+					value,
+					getDlDefaultConfigKey(site, toggle),
+				)
+			}
+
+			if config.Defaults == nil {
+				config.Defaults = make(map[string]map[string]any)
+			}
+			if config.Defaults[site] == nil {
+				config.Defaults[site] = make(map[string]any)
+			}
+			config.Defaults[site][toggle] = boolValue
+			return strconv.FormatBool(boolValue), nil
+		},
+	}
+}
+
+func init() {
+	for site, toggles := range CONFIG_DL_TOGGLES {
+		for _, toggle := range toggles {
+			configKeys[getDlDefaultConfigKey(site, toggle)] = newDlDefaultConfigKey(site, toggle)
+		}
+	}
+}
+
+// Returns the keys that can be used with GetConfigValue and SetConfigValue in sorted order
+func GetConfigKeys() []string {
+	keys := make([]string, 0, len(configKeys))
+	for key := range configKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getConfigKey(key string) (configKey, error) {
+	ck, ok := configKeys[key]
+	if !ok {
+		return configKey{}, fmt.Errorf(
+			"error %d: unknown config key, %q, valid keys are %s",
+			INPUT_ERROR,
+			key,
+			strings.Join(GetConfigKeys(), ", "),
+		)
+	}
+	return ck, nil
+}
+
+// Returns the path to the config file in the application's directory
+func GetConfigFilePath() string {
+	return filepath.Join(APP_PATH, "config.json")
+}
+
+// Reads the config file in the application's directory.
+//
+// If the config file does not exist, a config with the default values is returned.
+func LoadConfig() (*ConfigFile, error) {
+	config := &ConfigFile{Language: DEFAULT_LANGUAGE}
+	configFilePath := GetConfigFilePath()
+	if !PathExists(configFilePath) {
+		return config, nil
+	}
+
+	configFile, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to read config file, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+
+	if err := json.Unmarshal(configFile, config); err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to unmarshal config file at %s, more info => %v",
+			JSON_ERROR,
+			configFilePath,
+			err,
+		)
+	}
+	return config, nil
+}
+
+// Writes the given config to the config file in the application's directory
+//
+// The config file is only readable and writable by the current user as it may contain the Pixiv refresh token.
+func SaveConfig(config *ConfigFile) error {
+	configFile, err := PrettifyJson(config)
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(APP_PATH, 0755)
+	configFilePath := GetConfigFilePath()
+	if err := os.WriteFile(configFilePath, configFile, 0600); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write config file, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+
+	// os.WriteFile does not change the permissions of an existing file
+	if err := os.Chmod(configFilePath, 0600); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to change the permissions of the config file, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+	return nil
+}
+
+// Saves the Pixiv refresh token to the config file so that it can be reused without the "--refresh_token" flag
+func SavePixivRefreshToken(token string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	config.PixivRefreshToken = strings.TrimSpace(token)
+	return SaveConfig(config)
+}
+
+// Returns the Pixiv refresh token saved in the config file or an empty string if there is none
+func LoadPixivRefreshToken() (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	return config.PixivRefreshToken, nil
+}
+
+// Returns the value of the given key in the config file
+func GetConfigValue(key string) (string, error) {
+	ck, err := getConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	return ck.get(config), nil
+}
+
+// Validates and saves the value of the given key to the config file
+//
+// Returns the normalised value that was saved to the config file.
+func SetConfigValue(key, value string) (string, error) {
+	ck, err := getConfigKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	savedValue, err := ck.set(config, value)
+	if err != nil {
+		return "", err
+	}
+
+	if err := SaveConfig(config); err != nil {
+		return "", err
+	}
+
+	if key == CONFIG_DOWNLOAD_DIR_KEY {
+		DOWNLOAD_PATH = savedValue
+	}
+	return savedValue, nil
+}
+
+// Returns the download path from the config file
+func GetDefaultDownloadPath() string {
+	config, err := LoadConfig()
+	if err != nil {
+		return ""
+	}
+
+	if config.DownloadDir == "" || !PathExists(config.DownloadDir) {
+		return ""
+	}
+	return config.DownloadDir
+}
+
+// Returns the default values of the site's dl_* toggles from the "defaults" section of the config file.
+//
+// Unknown keys are ignored but an error is returned if any of
+// the known toggles has a value that is not true or false.
+func GetConfigDlDefaults(site string) (map[string]bool, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dlDefaults := make(map[string]bool)
+	for key, value := range config.Defaults[site] {
+		if !SliceContains(CONFIG_DL_TOGGLES[site], key) {
+			continue
+		}
+
+		boolValue, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf(
+				"error %d: invalid value, %v, for %s in the config file at %s, must be true or false",
+				INPUT_ERROR,
+				value,
+				getDlDefaultConfigKey(site, key),
+				GetConfigFilePath(),
+			)
+		}
+		dlDefaults[key] = boolValue
+	}
+	return dlDefaults, nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const NO_COLOR_ENV_VAR = "NO_COLOR"
+
+// set via SetQuiet() for the "--quiet" flag
+var quiet bool
+
+// Disables the coloured output and the ANSI escape codes like the animated spinners
+// for the rest of the program if the given value is true.
+//
+// Note: The fatih/color package already disables the colours by default if the
+// NO_COLOR environment variable is set, TERM is "dumb", or if stdout is not a terminal
+// like when the output is redirected to a file or when running under CI/systemd.
+func SetNoColor(noColor bool) {
+	if noColor {
+		color.NoColor = true
+	}
+}
+
+// Returns true if the output should be plain text without any colours or ANSI escape codes
+func IsPlainOutput() bool {
+	return color.NoColor
+}
+
+// Suppresses the spinners and the coloured status messages for the rest of the program
+// if the given value is true so that only the run summary is printed to stdout.
+//
+// The coloured messages printed via the fatih/color package are discarded
+// while the errors printed via PrintError() are still printed to stderr.
+func SetQuiet(enabled bool) {
+	if !enabled {
+		return
+	}
+	quiet = true
+	color.NoColor = true
+	color.Output = io.Discard
+}
+
+// Returns true if the user has enabled "--quiet"
+func IsQuiet() bool {
+	return quiet
+}
+
+// Prints the error message in red like color.Red() or
+// to stderr if "--quiet" is enabled as the coloured output is discarded.
+func PrintError(format string, args ...any) {
+	if !quiet {
+		color.Red(format, args...)
+		return
+	}
+
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, format)
+	} else {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
@@ -24,8 +24,20 @@ const (
 	CAPTCHA_ERROR
 )
 
+// Environment variable that can be used to override the application's config directory
+const APP_PATH_ENV_VAR = "CULTURED_DOWNLOADER_HOME"
+
 // Returns the path to the application's config directory
+//
+// If the CULTURED_DOWNLOADER_HOME environment variable is set, its value will be used instead.
 func getAppPath() string {
+	if envAppPath := os.Getenv(APP_PATH_ENV_VAR); envAppPath != "" {
+		if absPath, err := filepath.Abs(envAppPath); err == nil {
+			return absPath
+		}
+		return envAppPath
+	}
+
 	appPath, err := os.UserConfigDir()
 	if err != nil {
 		panic(
@@ -94,6 +106,8 @@ const (
 	KEMONO_EMBEDS_FOLDER   = "embeds"
 	KEMONO_CONTENT_FOLDER  = "post_content"
 
+	GDRIVE               = "gdrive"
+	GDRIVE_TITLE         = "Google Drive"
 	GDRIVE_URL 	         = "https://drive.google.com"
 	GDRIVE_FOLDER        = "gdrive"
 	GDRIVE_FILENAME      = "detected_gdrive_links.txt"
@@ -128,6 +142,9 @@ var (
 	)
 	FANTIA_REGEX_URL_INDEX = FANTIA_IMAGE_URL_REGEX.SubexpIndex("url")
 
+	// Matches the URLs in a post's description like the links to the external file hosting providers
+	URL_REGEX = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
 	// For Pixiv Fanbox
 	PASSWORD_TEXTS              = []string{"パス", "Pass", "pass", "密码"}
 	EXTERNAL_DOWNLOAD_PLATFORMS = []string{"mega", "gigafile", "dropbox", "mediafire"}
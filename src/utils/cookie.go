@@ -11,8 +11,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 // Returns the cookie info for the specified site
@@ -61,16 +59,153 @@ func GetSessionCookieInfo(site string) *cookieInfo {
 	}
 }
 
-// For the exported cookies in JSON instead of Netscape format
-type ExportedCookies []struct {
-	Domain   string  `json:"domain"`
-	Expire   float64 `json:"expirationDate"`
-	HttpOnly bool    `json:"httpOnly"`
-	Name     string  `json:"name"`
-	Path     string  `json:"path"`
-	Secure   bool    `json:"secure"`
-	Value    string  `json:"value"`
-	Session  bool    `json:"session"`
+// For the exported cookies in JSON instead of Netscape format.
+//
+// Since the browser extensions and tools export the cookies with slightly different field names,
+// the alternate names of the expiry time like "expiry" (Selenium) and "expires" (Playwright) are also accepted.
+type ExportedCookie struct {
+	Domain         string        `json:"domain"`
+	ExpirationDate *cookieExpiry `json:"expirationDate"`
+	Expiry         *cookieExpiry `json:"expiry"`
+	Expires        *cookieExpiry `json:"expires"`
+	HttpOnly       bool          `json:"httpOnly"`
+	Name           string        `json:"name"`
+	Path           string        `json:"path"`
+	Secure         bool          `json:"secure"`
+	Value          string        `json:"value"`
+	Session        *bool         `json:"session"`
+}
+
+type ExportedCookies []*ExportedCookie
+
+// cookieExpiry is the expiry time of an exported cookie which
+// can be a Unix timestamp in seconds or a date string depending on the exporter.
+type cookieExpiry struct {
+	time.Time
+}
+
+func (expiry *cookieExpiry) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case float64:
+		expiry.Time = parseCookieUnixTime(v)
+		return nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil
+		}
+		if unixTime, err := strconv.ParseFloat(v, 64); err == nil {
+			expiry.Time = parseCookieUnixTime(unixTime)
+			return nil
+		}
+		for _, layout := range []string{time.RFC3339, http.TimeFormat, time.RFC1123, time.RFC1123Z} {
+			if parsedTime, err := time.Parse(layout, v); err == nil {
+				expiry.Time = parsedTime
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown cookie expiry time format, %q", v)
+	default:
+		return fmt.Errorf("unknown cookie expiry time, %s", string(data))
+	}
+}
+
+// Converts the Unix timestamp of an exported cookie to time.Time.
+//
+// Zero or negative values are used by some exporters for session cookies and hence will return a zero time.
+func parseCookieUnixTime(unixTime float64) time.Time {
+	if unixTime <= 0 {
+		return time.Time{}
+	}
+	// some exporters use milliseconds instead of seconds
+	if unixTime > 1e11 {
+		unixTime /= 1000
+	}
+	return time.Unix(int64(unixTime), 0)
+}
+
+// Returns the expiry time of the cookie or a zero time if it is a session cookie
+func (cookie *ExportedCookie) getExpiry() time.Time {
+	if cookie.Session != nil && *cookie.Session {
+		return time.Time{}
+	}
+	for _, expiry := range []*cookieExpiry{cookie.ExpirationDate, cookie.Expiry, cookie.Expires} {
+		if expiry != nil && !expiry.IsZero() {
+			return expiry.Time
+		}
+	}
+	return time.Time{}
+}
+
+// Returns true if the given JSON value looks like an exported cookie
+func isJsonCookie(value any) bool {
+	cookie, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, hasName := cookie["name"]
+	_, hasValue := cookie["value"]
+	return hasName && hasValue
+}
+
+// Collects the exported cookies from the decoded JSON value.
+//
+// Other than a plain array of cookies, the cookies may be nested in an object
+// like {"url": "...", "cookies": [...]} or be grouped by their domain like {"fanbox.cc": [...]}.
+func collectJsonCookies(value any, cookies []any) []any {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if isJsonCookie(item) {
+				cookies = append(cookies, item)
+			} else {
+				cookies = collectJsonCookies(item, cookies)
+			}
+		}
+	case map[string]any:
+		if isJsonCookie(v) {
+			return append(cookies, v)
+		}
+		for _, item := range v {
+			cookies = collectJsonCookies(item, cookies)
+		}
+	}
+	return cookies
+}
+
+// Parses the exported cookies in the given JSON data which can be in any of the shapes supported by collectJsonCookies
+func parseExportedCookies(data []byte) (ExportedCookies, error) {
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	jsonCookies := collectJsonCookies(decoded, nil)
+	if len(jsonCookies) == 0 {
+		return nil, fmt.Errorf("no cookies found in the JSON data")
+	}
+
+	exportedCookies := make(ExportedCookies, 0, len(jsonCookies))
+	for _, jsonCookie := range jsonCookies {
+		cookieJson, err := json.Marshal(jsonCookie)
+		if err != nil {
+			return nil, err
+		}
+
+		var exportedCookie ExportedCookie
+		if err := json.Unmarshal(cookieJson, &exportedCookie); err != nil {
+			return nil, err
+		}
+		exportedCookies = append(exportedCookies, &exportedCookie)
+	}
+	return exportedCookies, nil
 }
 
 type cookieInfoArgs struct {
@@ -133,7 +268,7 @@ func parseTxtCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs)
 					expiresUnixStr,
 					err,
 				)
-				color.Red(errMsg)
+				PrintError(errMsg)
 				continue
 			}
 			if expiresUnixInt > 0 {
@@ -146,9 +281,18 @@ func parseTxtCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs)
 }
 
 func parseJsonCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs) ([]*http.Cookie, error) {
-	var cookies []*http.Cookie
-	var exportedCookies ExportedCookies
-	if err := json.NewDecoder(f).Decode(&exportedCookies); err != nil {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: reading cookie file at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	exportedCookies, err := parseExportedCookies(data)
+	if err != nil {
 		return nil, fmt.Errorf(
 			"error %d: failed to decode cookie JSON file at %s, more info => %v",
 			JSON_ERROR,
@@ -157,13 +301,14 @@ func parseJsonCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs
 		)
 	}
 
+	var cookies []*http.Cookie
 	for _, cookie := range exportedCookies {
 		if cookie.Name != cookieArgs.name {
 			// not the session cookie
 			continue
 		}
 
-		parsedCookie := &http.Cookie{
+		cookies = append(cookies, &http.Cookie{
 			Name:     cookie.Name,
 			Value:    cookie.Value,
 			Domain:   cookie.Domain,
@@ -171,16 +316,25 @@ func parseJsonCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs
 			Secure:   cookie.Secure,
 			HttpOnly: cookie.HttpOnly,
 			SameSite: cookieArgs.sameSite,
-		}
-		if !cookie.Session {
-			parsedCookie.Expires = time.Unix(int64(cookie.Expire), 0)
-		}
-
-		cookies = append(cookies, parsedCookie)
+			Expires:  cookie.getExpiry(),
+		})
 	}
 	return cookies, nil
 }
 
+// Name of the folder in the application's directory with the cookie files that will be loaded automatically
+const COOKIES_FOLDER_NAME = "cookies"
+
+// Returns the paths of the cookie files with the well-known names for the site
+// in the application's cookies folder, e.g. "cookies/fantia.txt" and "cookies/fantia.json" for Fantia.
+func GetAutoloadCookieFilePaths(site string) []string {
+	cookiesFolder := filepath.Join(APP_PATH, COOKIES_FOLDER_NAME)
+	return []string{
+		filepath.Join(cookiesFolder, site+".txt"),
+		filepath.Join(cookiesFolder, site+".json"),
+	}
+}
+
 // parse the Netscape cookie file generated by extensions like Get cookies.txt LOCALLY
 func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cookie, error) {
 	if filePath != "" && sessionId != "" {
@@ -210,9 +364,9 @@ func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cooki
 		sameSite: sessionCookieSameSite,
 	}
 	var cookies []*http.Cookie
-	if ext := filepath.Ext(filePath); ext == ".txt" {
+	if ext := NormalizeExt(filepath.Ext(filePath)); ext == "txt" {
 		cookies, err = parseTxtCookieFile(f, filePath, cookieArgs)
-	} else if ext == ".json" {
+	} else if ext == "json" {
 		cookies, err = parseJsonCookieFile(f, filePath, cookieArgs)
 	} else {
 		err = fmt.Errorf(
@@ -237,3 +391,20 @@ func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cooki
 	}
 	return cookies, nil
 }
+
+// Returns the earliest expiry time of the given cookies.
+//
+// The second return value will be false if none of the cookies
+// have an expiry time like session cookies that expire when the browser is closed.
+func GetEarliestCookieExpiry(cookies []*http.Cookie) (time.Time, bool) {
+	var earliest time.Time
+	for _, cookie := range cookies {
+		if cookie.Expires.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || cookie.Expires.Before(earliest) {
+			earliest = cookie.Expires
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
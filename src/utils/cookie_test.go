@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseExportedCookies(t *testing.T) {
+	expiry := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	type wantCookie struct {
+		name   string
+		value  string
+		domain string
+		expiry time.Time
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    []wantCookie
+		wantErr bool
+	}{
+		{
+			name: "array of cookies",
+			data: `[{"domain": ".fanbox.cc", "expirationDate": 1893553445, "name": "FANBOXSESSID", "value": "abc"}]`,
+			want: []wantCookie{{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc", expiry: expiry}},
+		},
+		{
+			name: "expiry in milliseconds",
+			data: `[{"domain": ".fanbox.cc", "expiry": 1893553445000, "name": "FANBOXSESSID", "value": "abc"}]`,
+			want: []wantCookie{{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc", expiry: expiry}},
+		},
+		{
+			name: "expiry as a date string",
+			data: `[{"domain": ".fanbox.cc", "expires": "2030-01-02T03:04:05Z", "name": "FANBOXSESSID", "value": "abc"}]`,
+			want: []wantCookie{{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc", expiry: expiry}},
+		},
+		{
+			name: "session cookie",
+			data: `[{"domain": ".fanbox.cc", "expirationDate": 1893553445, "session": true, "name": "FANBOXSESSID", "value": "abc"}]`,
+			want: []wantCookie{{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc"}},
+		},
+		{
+			name: "negative expiry",
+			data: `[{"domain": ".fanbox.cc", "expirationDate": -1, "name": "FANBOXSESSID", "value": "abc"}]`,
+			want: []wantCookie{{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc"}},
+		},
+		{
+			name: "single cookie object",
+			data: `{"domain": "fantia.jp", "name": "_session_id", "value": "def"}`,
+			want: []wantCookie{{name: "_session_id", value: "def", domain: "fantia.jp"}},
+		},
+		{
+			name: "nested in an object",
+			data: `{"url": "https://fantia.jp", "cookies": [{"domain": "fantia.jp", "name": "_session_id", "value": "def"}]}`,
+			want: []wantCookie{{name: "_session_id", value: "def", domain: "fantia.jp"}},
+		},
+		{
+			name: "grouped by domain",
+			data: `{"fanbox.cc": [{"domain": ".fanbox.cc", "name": "FANBOXSESSID", "value": "abc"}], "pixiv.net": [{"domain": ".pixiv.net", "name": "PHPSESSID", "value": "ghi"}]}`,
+			want: []wantCookie{
+				{name: "FANBOXSESSID", value: "abc", domain: ".fanbox.cc"},
+				{name: "PHPSESSID", value: "ghi", domain: ".pixiv.net"},
+			},
+		},
+		{
+			name:    "no cookies",
+			data:    `{"url": "https://fantia.jp", "cookies": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			data:    `[{"name": "FANBOXSESSID",`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown expiry format",
+			data:    `[{"name": "FANBOXSESSID", "value": "abc", "expires": "next week"}]`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookies, err := parseExportedCookies([]byte(test.data))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseExportedCookies() returned %d cookies, want an error", len(cookies))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExportedCookies() error = %v", err)
+			}
+
+			// the cookies grouped by their domain are collected in no particular order
+			sort.Slice(cookies, func(i, j int) bool {
+				return cookies[i].Name < cookies[j].Name
+			})
+			if len(cookies) != len(test.want) {
+				t.Fatalf("got %d cookies, want %d", len(cookies), len(test.want))
+			}
+			for idx, cookie := range cookies {
+				want := test.want[idx]
+				if cookie.Name != want.name || cookie.Value != want.value || cookie.Domain != want.domain {
+					t.Errorf(
+						"cookie %d = {%q, %q, %q}, want {%q, %q, %q}",
+						idx, cookie.Name, cookie.Value, cookie.Domain, want.name, want.value, want.domain,
+					)
+				}
+				if gotExpiry := cookie.getExpiry(); !gotExpiry.Equal(want.expiry) {
+					t.Errorf("cookie %d expires at %v, want %v", idx, gotExpiry, want.expiry)
+				}
+			}
+		})
+	}
+}
+
+// The fixtures in testdata/cookies follow the export formats of the browser extensions they are named after,
+// with the values of the cookies replaced by placeholders.
+func TestParseNetscapeCookieFileExports(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		website    string
+		wantValue  string
+		wantDomain string
+		wantSecure bool
+		// the Unix time in seconds or 0 for a session cookie
+		wantExpiry int64
+	}{
+		{
+			name:       "EditThisCookie",
+			file:       "editthiscookie_fanbox.json",
+			website:    PIXIV_FANBOX,
+			wantValue:  "12345678_REDACTEDREDACTEDREDACTEDREDACT",
+			wantDomain: ".fanbox.cc",
+			wantSecure: true,
+			wantExpiry: 1893553445,
+		},
+		{
+			name:       "Cookie-Editor",
+			file:       "cookie_editor_fantia.json",
+			website:    FANTIA,
+			wantValue:  "REDACTEDREDACTEDREDACTEDREDACTED",
+			wantDomain: "fantia.jp",
+			wantSecure: true,
+		},
+		{
+			name:       "Get cookies.txt LOCALLY",
+			file:       "get_cookies_txt_pixiv.txt",
+			website:    PIXIV,
+			wantValue:  "12345678_REDACTEDREDACTEDREDACTEDREDACT",
+			wantDomain: ".pixiv.net",
+			wantSecure: true,
+			wantExpiry: 1893553445,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookies, err := ParseNetscapeCookieFile(filepath.Join("testdata", "cookies", test.file), "", test.website)
+			if err != nil {
+				t.Fatalf("ParseNetscapeCookieFile() error = %v", err)
+			}
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want only the session cookie", len(cookies))
+			}
+
+			cookie := cookies[0]
+			wantName := GetSessionCookieInfo(test.website).Name
+			if cookie.Name != wantName || cookie.Value != test.wantValue || cookie.Domain != test.wantDomain {
+				t.Errorf(
+					"cookie = {%q, %q, %q}, want {%q, %q, %q}",
+					cookie.Name, cookie.Value, cookie.Domain, wantName, test.wantValue, test.wantDomain,
+				)
+			}
+			if cookie.Path != "/" || cookie.Secure != test.wantSecure {
+				t.Errorf("cookie path = %q, secure = %v, want \"/\", %v", cookie.Path, cookie.Secure, test.wantSecure)
+			}
+			if test.wantExpiry == 0 {
+				if !cookie.Expires.IsZero() {
+					t.Errorf("session cookie expires at %v, want no expiry", cookie.Expires)
+				}
+			} else if gotExpiry := cookie.Expires.Unix(); gotExpiry != test.wantExpiry {
+				t.Errorf("cookie expires at %d, want %d", gotExpiry, test.wantExpiry)
+			}
+		})
+	}
+}
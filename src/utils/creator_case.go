@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Modes for normalising the casing of the creator folder names
+// as sites like Pixiv can return a creator's name with inconsistent casing across posts.
+const (
+	CREATOR_CASE_OFF        = "off"
+	CREATOR_CASE_LOWER      = "lower"
+	CREATOR_CASE_FIRST_SEEN = "first_seen"
+)
+
+// The accepted values for the "--normalize_creator_case" flag
+var ACCEPTED_CREATOR_CASE_MODES = []string{
+	CREATOR_CASE_OFF,
+	CREATOR_CASE_LOWER,
+	CREATOR_CASE_FIRST_SEEN,
+}
+
+var (
+	creatorCaseMode = CREATOR_CASE_OFF
+
+	// Maps the lowercased creator folder path to the first seen casing of the folder name
+	creatorFolderNamesMu sync.Mutex
+	creatorFolderNames   = make(map[string]string)
+)
+
+// Sets how the casing of the creator folder names will be normalised for the current run
+func SetCreatorCaseMode(mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if !SliceContains(ACCEPTED_CREATOR_CASE_MODES, mode) {
+		return fmt.Errorf(
+			"error %d: invalid creator case mode, %q, must be one of %s",
+			INPUT_ERROR,
+			mode,
+			strings.Join(ACCEPTED_CREATOR_CASE_MODES, ", "),
+		)
+	}
+	creatorCaseMode = mode
+	return nil
+}
+
+// Returns the name of an existing folder in parentPath that only differs from folderName by its casing (if any)
+func findExistingFolderName(parentPath, folderName string) string {
+	entries, err := os.ReadDir(parentPath)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), folderName) {
+			return entry.Name()
+		}
+	}
+	return ""
+}
+
+// Normalises the casing of the cleaned creator folder name in parentPath based on the creator case mode.
+//
+// For the first seen mode, the casing of an existing folder on disk takes precedence over
+// the casing first seen in the current run so that existing archives will not be fragmented.
+func normalizeCreatorFolderName(parentPath, folderName string) string {
+	switch creatorCaseMode {
+	case CREATOR_CASE_LOWER:
+		return strings.ToLower(folderName)
+	case CREATOR_CASE_FIRST_SEEN:
+		key := strings.ToLower(filepath.Join(parentPath, folderName))
+		creatorFolderNamesMu.Lock()
+		defer creatorFolderNamesMu.Unlock()
+		if cachedName, ok := creatorFolderNames[key]; ok {
+			return cachedName
+		}
+
+		if existingName := findExistingFolderName(parentPath, folderName); existingName != "" {
+			folderName = existingName
+		}
+		creatorFolderNames[key] = folderName
+		return folderName
+	default:
+		return folderName
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package utils
+
+import "errors"
+
+func getFreeDiskSpace(path string) (int64, error) {
+	return 0, errors.New("checking the free disk space is not supported on this OS")
+}
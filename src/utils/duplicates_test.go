@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestRemoveDuplicateIds(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       []string
+		want      []string
+		wantWarns []string
+	}{
+		{
+			name: "no duplicates",
+			ids:  []string{"1", "2", "3"},
+			want: []string{"1", "2", "3"},
+		},
+		{
+			name:      "one duplicate",
+			ids:       []string{"1", "2", "1"},
+			want:      []string{"1", "2"},
+			wantWarns: []string{"Removed duplicate post ID 1 at position(s) 3 (kept the one at position 1)"},
+		},
+		{
+			name: "multiple duplicates",
+			ids:  []string{"5", "6", "5", "6", "5"},
+			want: []string{"5", "6"},
+			wantWarns: []string{
+				"Removed duplicate post ID 5 at position(s) 3, 5 (kept the one at position 1)",
+				"Removed duplicate post ID 6 at position(s) 4 (kept the one at position 2)",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := testutils.CaptureColorOutput(t)
+			got := utils.RemoveDuplicateIds("post ID", test.ids)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("RemoveDuplicateIds(%q) = %q, want %q", test.ids, got, test.want)
+			}
+
+			var wantOutput string
+			for _, warn := range test.wantWarns {
+				wantOutput += warn + "\n"
+			}
+			if output.String() != wantOutput {
+				t.Errorf("warned %q, want %q", output.String(), wantOutput)
+			}
+		})
+	}
+}
+
+func TestRemoveDuplicateIdAndPageNum(t *testing.T) {
+	output := testutils.CaptureColorOutput(t)
+	ids, pageNums := utils.RemoveDuplicateIdAndPageNum(
+		"creator ID",
+		[]string{"1", "2", "1", "3"},
+		[]string{"1-2", "", "3-4", "5"},
+	)
+
+	// the page numbers of the removed duplicates should be removed as well
+	if wantIds := []string{"1", "2", "3"}; !reflect.DeepEqual(ids, wantIds) {
+		t.Errorf("got IDs %q, want %q", ids, wantIds)
+	}
+	if wantPageNums := []string{"1-2", "", "5"}; !reflect.DeepEqual(pageNums, wantPageNums) {
+		t.Errorf("got page numbers %q, want %q", pageNums, wantPageNums)
+	}
+	if wantWarn := "Removed duplicate creator ID 1 at position(s) 3 (kept the one at position 1)\n"; output.String() != wantWarn {
+		t.Errorf("warned %q, want %q", output.String(), wantWarn)
+	}
+}
+
+func TestRemoveDuplicatesFunc(t *testing.T) {
+	type creator struct {
+		service string
+		id      string
+	}
+	output := testutils.CaptureColorOutput(t)
+	creators := []creator{{"fanbox", "1"}, {"patreon", "1"}, {"fanbox", "1"}}
+	got := utils.RemoveDuplicatesFunc("creator", creators, func(c creator) string {
+		return c.service + "/" + c.id
+	})
+
+	// the items are only duplicates if their keys are the same
+	if want := creators[:2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveDuplicatesFunc() = %v, want %v", got, want)
+	}
+	if wantWarn := "Removed duplicate creator fanbox/1 at position(s) 3 (kept the one at position 1)\n"; output.String() != wantWarn {
+		t.Errorf("warned %q, want %q", output.String(), wantWarn)
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error types used to group the errors in the summary printed at the end of a run
+const (
+	ERR_TYPE_AUTH      = "auth"
+	ERR_TYPE_NOT_FOUND = "not found"
+	ERR_TYPE_NETWORK   = "network"
+	ERR_TYPE_JSON      = "json"
+	ERR_TYPE_OTHER     = "other"
+
+	// max number of example IDs/URLs to show for each error group in the summary
+	MAX_SUMMARY_EXAMPLES = 5
+)
+
+var (
+	errSiteRegex    = regexp.MustCompile(`^(?:([a-z][a-z ]*?) )?error \d{4}`)
+	errExampleRegex = regexp.MustCompile(`(?i)\bID:? ?([\w-]+)|url: (\S+)`)
+	errStatusRegex  = regexp.MustCompile(`\b(401|403|404|410)\b`)
+
+	errSummaryMu sync.Mutex
+	errSummary   = make(map[errSummaryKey]*errSummaryGroup)
+)
+
+type errSummaryKey struct {
+	site    string
+	errType string
+}
+
+type errSummaryGroup struct {
+	count    int
+	examples []string
+}
+
+// Returns the site that the error is from based on the prefix of the error message.
+//
+// E.g. "pixiv error 1005: ..." will return "pixiv" and "error 1005: ..." will return "general".
+func GetErrorSite(err error) string {
+	matched := errSiteRegex.FindStringSubmatch(err.Error())
+	if matched == nil || matched[1] == "" {
+		return "general"
+	}
+	return matched[1]
+}
+
+// Classifies the error into one of the error types like ERR_TYPE_AUTH based on its error code and message
+func ClassifyError(err error) string {
+	errMsg := err.Error()
+	errCode := -1
+	if matched := errCodeRegex.FindStringSubmatch(errMsg); matched != nil {
+		errCode, _ = strconv.Atoi(matched[1])
+	}
+
+	switch errCode {
+	case CAPTCHA_ERROR:
+		return ERR_TYPE_AUTH
+	case JSON_ERROR:
+		return ERR_TYPE_JSON
+	case CONNECTION_ERROR, RESPONSE_ERROR, DOWNLOAD_ERROR:
+		switch errStatusRegex.FindString(errMsg) {
+		case "401", "403":
+			return ERR_TYPE_AUTH
+		case "404", "410":
+			return ERR_TYPE_NOT_FOUND
+		}
+		return ERR_TYPE_NETWORK
+	}
+	return ERR_TYPE_OTHER
+}
+
+// Returns the ID or URL mentioned in the error message (if any) to be shown as an example in the summary
+func getErrorExample(err error) string {
+	matched := errExampleRegex.FindStringSubmatch(err.Error())
+	if matched == nil {
+		return ""
+	}
+	if matched[1] != "" {
+		return matched[1]
+	}
+	return matched[2]
+}
+
+// Records the error with the number of times it has occurred for the summary printed at the end of a run
+func recordErrorForSummary(err error, count int) {
+	key := errSummaryKey{
+		site:    GetErrorSite(err),
+		errType: ClassifyError(err),
+	}
+
+	errSummaryMu.Lock()
+	defer errSummaryMu.Unlock()
+	group, ok := errSummary[key]
+	if !ok {
+		group = &errSummaryGroup{}
+		errSummary[key] = group
+	}
+	group.count += count
+
+	example := getErrorExample(err)
+	if example != "" && len(group.examples) < MAX_SUMMARY_EXAMPLES && !SliceContains(group.examples, example) {
+		group.examples = append(group.examples, example)
+	}
+}
+
+// Prints a summary of the errors that occurred during the run grouped by site and error type.
+//
+// The full details of the errors can be found in the log file.
+func PrintErrorSummary() {
+	errSummaryMu.Lock()
+	defer errSummaryMu.Unlock()
+	if len(errSummary) == 0 {
+		return
+	}
+
+	keys := make([]errSummaryKey, 0, len(errSummary))
+	for key := range errSummary {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].site != keys[j].site {
+			return keys[i].site < keys[j].site
+		}
+		return keys[i].errType < keys[j].errType
+	})
+
+	PrintError("\nError summary:")
+	for _, key := range keys {
+		group := errSummary[key]
+		summaryLine := fmt.Sprintf("  [%s] %s errors: %d", key.site, key.errType, group.count)
+		if len(group.examples) > 0 {
+			summaryLine += fmt.Sprintf(" (e.g. %s)", strings.Join(group.examples, ", "))
+		}
+		PrintError(summaryLine)
+	}
+	PrintError("Please refer to the logs at %s for more details.", logFolder)
+}
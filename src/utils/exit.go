@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Exit codes returned by the program so that
+// scripts can distinguish the cause of a failure
+const (
+	EXIT_OK            = 0
+	EXIT_GENERAL_ERROR = 1
+	EXIT_AUTH_ERROR    = 2
+	EXIT_NETWORK_ERROR = 3
+	EXIT_PARTIAL_ERROR = 4
+	EXIT_INPUT_ERROR   = 5
+	EXIT_DISK_FULL     = 6
+	EXIT_INTERRUPTED   = 130 // same as the convention used by shells for SIGINT
+)
+
+// Help text for the exit codes to be shown in the root command's help output
+var EXIT_CODES_HELP = CombineStringsWithNewline(
+	"Exit Codes:",
+	"  0   Success",
+	"  1   General error",
+	"  2   Authentication error (e.g. invalid cookie, refresh token, or reCAPTCHA)",
+	"  3   Network error",
+	"  4   Finished but some items failed to be processed or downloaded",
+	"  5   Input error (e.g. invalid flags or arguments)",
+	"  6   Stopped as there is no space left on the disk to download to",
+	"  130 Interrupted by the user (Ctrl + C)",
+)
+
+var (
+	errCodeRegex   = regexp.MustCompile(`error (\d{4})`)
+	hasFailedItems atomic.Bool
+	failFast       atomic.Bool
+
+	exitHooksMu   sync.Mutex
+	exitHooks     []func()
+	exitHooksOnce sync.Once
+	exitingCode   atomic.Int32
+)
+
+// Maps the program's error codes like INPUT_ERROR to its exit code
+func GetExitCode(errCode int) int {
+	switch errCode {
+	case CAPTCHA_ERROR:
+		return EXIT_AUTH_ERROR
+	case CONNECTION_ERROR, RESPONSE_ERROR:
+		return EXIT_NETWORK_ERROR
+	case INPUT_ERROR:
+		return EXIT_INPUT_ERROR
+	default:
+		return EXIT_GENERAL_ERROR
+	}
+}
+
+// Returns the exit code based on the error code in the error message.
+//
+// E.g. "pixiv error 1005: ..." will return EXIT_NETWORK_ERROR
+func GetExitCodeFromErr(err error) int {
+	if err == nil {
+		return EXIT_GENERAL_ERROR
+	}
+
+	matched := errCodeRegex.FindStringSubmatch(err.Error())
+	if matched == nil {
+		return EXIT_GENERAL_ERROR
+	}
+	errCode, convErr := strconv.Atoi(matched[1])
+	if convErr != nil {
+		return EXIT_GENERAL_ERROR
+	}
+	return GetExitCode(errCode)
+}
+
+// Registers a function to be called before the program exits via Exit(),
+// e.g. to save the failed downloads before the run is aborted.
+func OnExit(fn func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, fn)
+}
+
+// Runs the functions registered via OnExit() (only once) and exits the program with the given exit code
+func Exit(exitCode int) {
+	exitHooksOnce.Do(func() {
+		exitingCode.Store(int32(exitCode))
+		exitHooksMu.Lock()
+		hooks := exitHooks
+		exitHooksMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+	})
+	os.Exit(exitCode)
+}
+
+// Returns the exit code passed to Exit() so that the
+// functions registered via OnExit() can tell how the run ended.
+func GetExitingCode() int {
+	return int(exitingCode.Load())
+}
+
+// Exits the program with the exit code mapped from the given error code
+func ExitWithErrCode(errCode int) {
+	Exit(GetExitCode(errCode))
+}
+
+// Configures whether the whole run should be aborted on the
+// first error when retrieving the post details or downloading the files.
+func SetFailFast(enabled bool) {
+	failFast.Store(enabled)
+}
+
+// Returns true if the user has enabled "--fail_fast"
+func IsFailFast() bool {
+	return failFast.Load()
+}
+
+// Aborts the run by logging the error and exiting if the user has enabled "--fail_fast"
+//
+// Errors caused by the user cancelling the program (context.Canceled) are ignored.
+func FailFastOnErr(err error) {
+	if err == nil || err == context.Canceled || !IsFailFast() {
+		return
+	}
+	RecordFailedItems()
+	LogError(err, "aborted the run due to --fail_fast", true, ERROR)
+}
+
+// Records the failed item in the run stats and aborts the run if the user has enabled "--fail_fast"
+func RecordFailure(err error) {
+	RUN_STATS.AddFailedItem(err)
+	FailFastOnErr(err)
+}
+
+// Records that some items failed to be processed or downloaded
+// so that the program will exit with EXIT_PARTIAL_ERROR at the end of the run.
+func RecordFailedItems() {
+	hasFailedItems.Store(true)
+}
+
+// Returns the exit code to use at the end of a run
+func GetRunExitCode() int {
+	if IsInterrupted() {
+		return EXIT_INTERRUPTED
+	}
+	if hasFailedItems.Load() {
+		return EXIT_PARTIAL_ERROR
+	}
+	return EXIT_OK
+}
@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"archive/zip"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/fatih/color"
 	"github.com/mholt/archiver/v4"
 )
 
@@ -55,9 +57,8 @@ func extractFileLogic(ctx context.Context, src, dest string, extractor *archiveE
 	if err != nil {
 		if err == context.Canceled {
 			// delete all the files that were extracted
-			err := os.RemoveAll(dest)
-			if err != nil {
-				LogError(err, "", false, ERROR)
+			if removeErr := os.RemoveAll(dest); removeErr != nil {
+				LogError(removeErr, "", false, ERROR)
 			}
 			return err
 		}
@@ -154,3 +155,201 @@ func ExtractFiles(ctx context.Context, src, dest string, ignoreIfMissing bool) e
 		extractor,
 	)
 }
+
+// Archive file extensions that will be checked for
+// password-protected extraction when a password was detected in the post
+//
+// Note: .zip is not included as the archiver library cannot extract encrypted zip files.
+// Such zip files will be detected by isEncryptedZip() so that the user can be warned instead.
+var PASSWORD_ARCHIVE_EXT = []string{".rar", ".7z"}
+
+// Returns true if any of the files in the given zip file is encrypted
+func isEncryptedZip(src string) bool {
+	zipReader, err := zip.OpenReader(src)
+	if err != nil {
+		return false
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		// bit 0 of the general purpose flags is set for encrypted files
+		if file.Flags&0x1 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a copy of the extractor with the given password set.
+//
+// The second return value will be false if the archive format does not support passwords.
+func setExtractorPassword(ex archiver.Extractor, password string) (archiver.Extractor, bool) {
+	switch format := ex.(type) {
+	case archiver.Rar:
+		format.Password = password
+		return format, true
+	case archiver.SevenZip:
+		format.Password = password
+		return format, true
+	default:
+		return nil, false
+	}
+}
+
+// Same as ExtractFiles but uses the given password to extract the archive
+//
+// Note: Only .rar and .7z archives are supported for password-protected extraction.
+func ExtractFilesWithPassword(ctx context.Context, src, dest, password string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: unable to open archive file %s",
+			OS_ERROR,
+			src,
+		)
+	}
+	defer f.Close()
+
+	extractor, err := getExtractor(f, src)
+	if err != nil {
+		return err
+	}
+	if extractor.readCloser != nil {
+		defer extractor.readCloser.Close()
+	}
+
+	ex, ok := setExtractorPassword(extractor.ex, password)
+	if !ok {
+		return fmt.Errorf(
+			"error %d: password-protected extraction is not supported for %s",
+			INPUT_ERROR,
+			src,
+		)
+	}
+	extractor.ex = ex
+	return extractFileLogic(
+		ctx,
+		src,
+		dest,
+		extractor,
+	)
+}
+
+// Returns the path to the detected passwords file of the post
+// that the archive belongs to or an empty string if there is none.
+//
+// Archives are usually saved in a sub-folder of the post folder
+// like "attachments" so the parent folder will be checked as well.
+func getDetectedPasswordFilePath(archivePath string) string {
+	dirPath := filepath.Dir(archivePath)
+	for i := 0; i < 2; i++ {
+		passwordFilePath := filepath.Join(dirPath, PASSWORD_FILENAME)
+		if PathExists(passwordFilePath) {
+			return passwordFilePath
+		}
+		dirPath = filepath.Dir(dirPath)
+	}
+	return ""
+}
+
+// Tries to extract the given archive with each of the candidate passwords.
+//
+// Each attempt is extracted into a temporary folder next to the archive which will only be
+// renamed to the archive's name without its extension after the extraction has succeeded
+// so that a failed attempt will not delete any existing files.
+//
+// Returns the password that worked or an error if none of them worked.
+// The original archive will not be deleted.
+func TryExtractWithPasswords(ctx context.Context, src string, passwords []string) (string, error) {
+	dest := RemoveExtFromFilename(src)
+	if PathExists(dest) {
+		return "", fmt.Errorf(
+			"error %d: unable to extract %s as %s already exists",
+			OS_ERROR,
+			src,
+			dest,
+		)
+	}
+
+	for _, password := range passwords {
+		tempDest, err := os.MkdirTemp(filepath.Dir(src), ".extracting-")
+		if err != nil {
+			return "", fmt.Errorf(
+				"error %d: unable to create a temporary folder to extract %s, more info => %v",
+				OS_ERROR,
+				src,
+				err,
+			)
+		}
+
+		err = ExtractFilesWithPassword(ctx, src, tempDest, password)
+		if err == nil {
+			if err := os.Rename(tempDest, dest); err != nil {
+				os.RemoveAll(tempDest)
+				return "", fmt.Errorf(
+					"error %d: unable to move the extracted files of %s to %s, more info => %v",
+					OS_ERROR,
+					src,
+					dest,
+					err,
+				)
+			}
+			return password, nil
+		}
+
+		os.RemoveAll(tempDest)
+		if err == context.Canceled {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf(
+		"error %d: none of the %d password(s) in the password list could extract %s",
+		INPUT_ERROR,
+		len(passwords),
+		src,
+	)
+}
+
+// Attempts to extract the downloaded archives of posts where a
+// password was detected using the candidate passwords from the user's password list.
+//
+// The password that worked will be logged to the post's detected passwords file.
+func ExtractPasswordProtectedArchives(ctx context.Context, filePaths, passwords []string) {
+	if len(passwords) == 0 {
+		return
+	}
+
+	for _, filePath := range filePaths {
+		if NormalizeExt(filepath.Ext(filePath)) == "zip" && isEncryptedZip(filePath) {
+			msg := fmt.Sprintf(
+				"Unable to extract %s as password-protected zip files are not supported, please extract it manually",
+				filePath,
+			)
+			color.Yellow(msg)
+			LogError(nil, msg, false, INFO)
+			continue
+		}
+		if !ExtInSlice(PASSWORD_ARCHIVE_EXT, filepath.Ext(filePath)) {
+			continue
+		}
+
+		passwordFilePath := getDetectedPasswordFilePath(filePath)
+		if passwordFilePath == "" {
+			continue
+		}
+
+		password, err := TryExtractWithPasswords(ctx, filePath, passwords)
+		if err != nil {
+			if err == context.Canceled {
+				return
+			}
+			LogError(err, "", false, ERROR)
+			continue
+		}
+		LogMessageToPath(
+			fmt.Sprintf("Extracted %s using the password: %s", filepath.Base(filePath), password),
+			passwordFilePath,
+			INFO,
+		)
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Creates a zip archive with a single file in the given folder
+func createTestZip(t *testing.T, dirPath string) string {
+	archivePath := filepath.Join(dirPath, "archive.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zipWriter := zip.NewWriter(f)
+	w, err := zipWriter.Create("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestTryExtractWithPasswordsKeepsExistingFiles(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := createTestZip(t, dirPath)
+
+	// the folder with the archive's name may already contain the user's files
+	existingFilePath := filepath.Join(dirPath, "archive", "existing.txt")
+	if err := os.MkdirAll(filepath.Dir(existingFilePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existingFilePath, []byte("keep me"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := TryExtractWithPasswords(context.Background(), archivePath, []string{"a", "b"}); err == nil {
+		t.Fatal("TryExtractWithPasswords() returned no error")
+	}
+	if !PathExists(existingFilePath) {
+		t.Errorf("%s was deleted after the failed extraction", existingFilePath)
+	}
+}
+
+func TestTryExtractWithPasswordsCleansUpFailedAttempts(t *testing.T) {
+	dirPath := t.TempDir()
+	// zip archives cannot be extracted with a password so every attempt will fail
+	archivePath := createTestZip(t, dirPath)
+
+	if _, err := TryExtractWithPasswords(context.Background(), archivePath, []string{"a", "b"}); err == nil {
+		t.Fatal("TryExtractWithPasswords() returned no error")
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "archive.zip" {
+		names := make([]string, len(entries))
+		for idx, entry := range entries {
+			names[idx] = entry.Name()
+		}
+		t.Errorf("the folder contains %v after the failed extraction, want only archive.zip", names)
+	}
+}
+
+// Copies the given archive from the testdata folder to the given folder
+func copyTestArchive(t *testing.T, filename, dirPath string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(dirPath, filename)
+	if err := os.WriteFile(archivePath, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestTryExtractWithPasswords(t *testing.T) {
+	dirPath := t.TempDir()
+	// testdata/password_protected.7z is from the test data of github.com/bodgit/sevenzip
+	// and contains "foo" and "bar" which are encrypted with the password "password"
+	archivePath := copyTestArchive(t, "password_protected.7z", dirPath)
+
+	password, err := TryExtractWithPasswords(context.Background(), archivePath, []string{"wrong", "password"})
+	if err != nil {
+		t.Fatalf("TryExtractWithPasswords() returned %v", err)
+	}
+	if password != "password" {
+		t.Errorf("TryExtractWithPasswords() = %q, want %q", password, "password")
+	}
+	for _, filename := range []string{"foo", "bar"} {
+		content, err := os.ReadFile(filepath.Join(dirPath, "password_protected", filename))
+		if err != nil {
+			t.Fatalf("unable to read the extracted %s: %v", filename, err)
+		}
+		if want := filename + "\n"; string(content) != want {
+			t.Errorf("the extracted %s contains %q, want %q", filename, content, want)
+		}
+	}
+}
+
+func TestTryExtractWithPasswordsWrongPassword(t *testing.T) {
+	dirPath := t.TempDir()
+	archivePath := copyTestArchive(t, "password_protected.7z", dirPath)
+
+	if _, err := TryExtractWithPasswords(context.Background(), archivePath, []string{"wrong"}); err == nil {
+		t.Fatal("TryExtractWithPasswords() returned no error for the wrong password")
+	}
+	if extractedPath := filepath.Join(dirPath, "password_protected"); PathExists(extractedPath) {
+		t.Errorf("%s was created after the failed extraction", extractedPath)
+	}
+}
+
+func TestIsEncryptedZip(t *testing.T) {
+	dirPath := t.TempDir()
+	tests := []struct {
+		name        string
+		archivePath string
+		want        bool
+	}{
+		// testdata/encrypted.zip was created with "zip -P secret"
+		{name: "encrypted", archivePath: copyTestArchive(t, "encrypted.zip", dirPath), want: true},
+		{name: "not encrypted", archivePath: createTestZip(t, dirPath), want: false},
+		{name: "not a zip file", archivePath: copyTestArchive(t, "password_protected.7z", dirPath), want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isEncryptedZip(test.archivePath); got != test.want {
+				t.Errorf("isEncryptedZip(%q) = %v, want %v", test.archivePath, got, test.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,51 @@ package utils
 
 import (
 	"bufio"
-	"encoding/json"
+	"crypto/md5"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
 )
 
+// Returned when a file could not be written as there is no space left on the disk
+var ErrDiskFull = errors.New("no space left on the disk")
+
+// Checks if the given error was caused by the disk being full (ENOSPC)
+func IsDiskFullErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrDiskFull) || errors.Is(err, syscall.ENOSPC) {
+		return true
+	}
+
+	// ERROR_HANDLE_DISK_FULL (39) and ERROR_DISK_FULL (112)
+	var errno syscall.Errno
+	if runtime.GOOS == "windows" && errors.As(err, &errno) {
+		return errno == 39 || errno == 112
+	}
+	return false
+}
+
+// Returns the number of bytes available to the user on the disk of the given path
+func GetFreeDiskSpace(path string) (int64, error) {
+	freeSpace, err := getFreeDiskSpace(path)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"error %d: failed to get the free disk space of %q, more info => %v",
+			OS_ERROR,
+			path,
+			err,
+		)
+	}
+	return freeSpace, nil
+}
+
 // checks if a file or directory exists
 func PathExists(filepath string) bool {
 	_, err := os.Stat(filepath)
@@ -35,6 +73,43 @@ func GetFileSize(filePath string) (int64, error) {
 	return fileInfo.Size(), nil
 }
 
+// Returns the hex-encoded md5 checksum of the data read from the given reader.
+//
+// The data is streamed into the hash so large files will not be loaded into memory.
+func Md5Checksum(reader io.Reader) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Writes the data to a temporary file in the same directory before renaming it to the given file path
+// so that the file at the given file path will never be left partially written.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp_*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // Uses bufio.Reader to read a line from a file and returns it as a byte slice
 //
 // Mostly thanks to https://devmarkpro.com/working-big-files-golang
@@ -52,6 +127,88 @@ func ReadLine(reader *bufio.Reader) ([]byte, error) {
 	return totalLine, err
 }
 
+// Reads the candidate passwords from the given text file
+// where each password is separated by a new line.
+//
+// Empty lines and duplicate passwords will be ignored.
+func ReadPasswordList(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to open password list at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	var passwords []string
+	reader := bufio.NewReader(f)
+	for {
+		lineBytes, err := ReadLine(reader)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf(
+				"error %d: failed to read password list at %s, more info => %v",
+				OS_ERROR,
+				filePath,
+				err,
+			)
+		}
+
+		// only remove the carriage return as
+		// whitespaces could be part of the password
+		password := strings.TrimSuffix(string(lineBytes), "\r")
+		if strings.TrimSpace(password) != "" {
+			passwords = append(passwords, password)
+		}
+	}
+	return RemoveSliceDuplicates(passwords), nil
+}
+
+// Reads the IDs like illustrator IDs from the given text file
+// where each ID is separated by a new line.
+//
+// Empty lines, lines starting with "#", and duplicate IDs will be ignored.
+func ReadIdsFromFile(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to open ID list at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	var ids []string
+	reader := bufio.NewReader(f)
+	for {
+		lineBytes, err := ReadLine(reader)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf(
+				"error %d: failed to read ID list at %s, more info => %v",
+				OS_ERROR,
+				filePath,
+				err,
+			)
+		}
+
+		id := strings.TrimSpace(string(lineBytes))
+		if id != "" && !strings.HasPrefix(id, "#") {
+			ids = append(ids, id)
+		}
+	}
+	return RemoveSliceDuplicates(ids), nil
+}
+
 // Used in CleanPathName to remove illegal characters in a path name
 func removeIllegalRuneInPath(r rune) rune {
 	if strings.ContainsRune("<>:\"/\\|?*\n\r\t", r) {
@@ -72,135 +229,92 @@ func CleanPathName(pathName string) string {
 	return strings.Map(removeIllegalRuneInPath, pathName)
 }
 
+// Returns the directory path of the creator that contains the creator's post folders
+//
+// The casing of the creator folder name will be normalised if the user has set a creator case mode.
+func GetCreatorFolder(downloadPath, creatorName string) string {
+	return filepath.Join(
+		downloadPath,
+		normalizeCreatorFolderName(downloadPath, CleanPathName(creatorName)),
+	)
+}
+
 // Returns a directory path for a post, artwork, etc.
 // based on the user's saved download path and the provided arguments
+//
+// The casing of the creator folder name will be normalised if the user has set a creator case mode.
 func GetPostFolder(downloadPath, creatorName, postId, postTitle string) string {
-	creatorName = CleanPathName(creatorName)
 	postTitle = CleanPathName(postTitle)
 
 	postFolderPath := filepath.Join(
-		downloadPath,
-		creatorName,
+		GetCreatorFolder(downloadPath, creatorName),
 		fmt.Sprintf("[%s] %s", postId, postTitle),
 	)
 	return postFolderPath
 }
 
-type ConfigFile struct {
-	DownloadDir string `json:"download_directory"`
-	Language    string `json:"language"`
-}
-
-// Returns the download path from the config file
-func GetDefaultDownloadPath() string {
-	configFilePath := filepath.Join(APP_PATH, "config.json")
-	if !PathExists(configFilePath) {
-		return ""
-	}
-
-	configFile, err := os.ReadFile(configFilePath)
-	if err != nil {
-		os.Remove(configFilePath)
-		return ""
-	}
-
-	var config ConfigFile
-	err = json.Unmarshal(configFile, &config)
-	if err != nil {
-		os.Remove(configFilePath)
-		return ""
-	}
-
-	if !PathExists(config.DownloadDir) {
-		return ""
-	}
-	return config.DownloadDir
-}
-
-// saves the new download path to the config file if it does not exist
-func saveConfig(newDownloadPath, configFilePath string) error {
-	config := ConfigFile{
-		DownloadDir: newDownloadPath,
-		Language:    "en",
-	}
-	configFile, err := json.MarshalIndent(config, "", "    ")
+// Normalises the given download path by converting it to an absolute path,
+// resolving any symlinks, and removing any trailing path separators.
+//
+// It also verifies that the path is a writable directory by creating and removing a probe file in it.
+func NormalizeDownloadPath(downloadPath string) (string, error) {
+	absPath, err := filepath.Abs(strings.TrimSpace(downloadPath))
 	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to marshal config file, more info => %v",
-			JSON_ERROR,
+		return "", fmt.Errorf(
+			"error %d: failed to get the absolute path of %q, more info => %v",
+			INPUT_ERROR,
+			downloadPath,
 			err,
 		)
 	}
 
-	err = os.WriteFile(configFilePath, configFile, 0666)
-	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to write config file, more info => %v",
-			OS_ERROR,
-			err,
+	if !PathExists(absPath) {
+		return "", fmt.Errorf(
+			"error %d: download path, %q, does not exist, please create the directory and try again",
+			INPUT_ERROR,
+			absPath,
 		)
 	}
-	return nil
-}
 
-// saves the new download path to the config file and overwrites the old one
-func overwriteConfig(newDownloadPath, configFilePath string) error {
-	// read the file
-	configFile, err := os.ReadFile(configFilePath)
+	resolvedPath, err := filepath.EvalSymlinks(absPath)
 	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to read config file, more info => %v",
+		return "", fmt.Errorf(
+			"error %d: failed to resolve the download path, %q, more info => %v",
 			OS_ERROR,
+			absPath,
 			err,
 		)
 	}
+	resolvedPath = filepath.Clean(resolvedPath)
 
-	var config ConfigFile
-	err = json.Unmarshal(configFile, &config)
+	pathInfo, err := os.Stat(resolvedPath)
 	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to unmarshal config file, more info => %v",
-			JSON_ERROR,
+		return "", fmt.Errorf(
+			"error %d: failed to get info of the download path, %q, more info => %v",
+			OS_ERROR,
+			resolvedPath,
 			err,
 		)
 	}
-
-	// update the file if the download directory is different
-	if config.DownloadDir == newDownloadPath {
-		return nil
-	}
-
-	config.DownloadDir = newDownloadPath
-	configFile, err = json.MarshalIndent(config, "", "    ")
-	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to marshal config file, more info => %v",
-			JSON_ERROR,
-			err,
+	if !pathInfo.IsDir() {
+		return "", fmt.Errorf(
+			"error %d: download path, %q, is not a directory",
+			INPUT_ERROR,
+			resolvedPath,
 		)
 	}
 
-	err = os.WriteFile(configFilePath, configFile, 0666)
+	// check if the directory is writable
+	probeFile, err := os.CreateTemp(resolvedPath, ".cultured_downloader_probe_*")
 	if err != nil {
-		return fmt.Errorf(
-			"error %d: failed to write config file, more info => %v",
+		return "", fmt.Errorf(
+			"error %d: download path, %q, is not writable, more info => %v",
 			OS_ERROR,
+			resolvedPath,
 			err,
 		)
 	}
-	return nil
-}
-
-// Configure and saves the config file with updated download path
-func SetDefaultDownloadPath(newDownloadPath string) error {
-	if !PathExists(newDownloadPath) {
-		return fmt.Errorf("error %d: download path does not exist, please create the directory and try again", INPUT_ERROR)
-	}
-
-	os.MkdirAll(APP_PATH, 0755)
-	configFilePath := filepath.Join(APP_PATH, "config.json")
-	if !PathExists(configFilePath) {
-		return saveConfig(newDownloadPath, configFilePath)
-	}
-	return overwriteConfig(newDownloadPath, configFilePath)
+	probeFile.Close()
+	os.Remove(probeFile.Name())
+	return resolvedPath, nil
 }
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDownloadPath(t *testing.T) {
+	// the temp dir may itself be behind a symlink like on macOS
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dlDir := filepath.Join(dir, "downloads")
+	if err := os.Mkdir(dlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	symlinkPath := filepath.Join(dir, "link")
+	if err := os.Symlink(dlDir, symlinkPath); err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolvedCwd, err := filepath.EvalSymlinks(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		want      string
+		wantErrIn string
+	}{
+		{name: "absolute path", path: dlDir, want: dlDir},
+		{name: "trailing separator", path: dlDir + string(filepath.Separator), want: dlDir},
+		{name: "surrounding spaces", path: "  " + dlDir + "  ", want: dlDir},
+		{name: "unclean path", path: filepath.Join(dlDir, "..", "downloads", "."), want: dlDir},
+		{name: "relative path", path: ".", want: resolvedCwd},
+		{name: "symlink", path: symlinkPath, want: dlDir},
+		{name: "missing directory", path: filepath.Join(dir, "missing"), wantErrIn: "does not exist"},
+		{name: "file", path: filePath, wantErrIn: "is not a directory"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NormalizeDownloadPath(test.path)
+			if test.wantErrIn != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErrIn) {
+					t.Fatalf("NormalizeDownloadPath(%q) returned error %v, want an error containing %q", test.path, err, test.wantErrIn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeDownloadPath(%q) returned error %v", test.path, err)
+			}
+			if got != test.want {
+				t.Errorf("NormalizeDownloadPath(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+
+	t.Run("no probe file left behind", func(t *testing.T) {
+		entries, err := os.ReadDir(dlDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("download path has %d leftover file(s) after the writable check", len(entries))
+		}
+	})
+}
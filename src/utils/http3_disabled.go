@@ -0,0 +1,7 @@
+//go:build nohttp3
+
+package utils
+
+// False if the program was built with the "nohttp3" build tag
+// in which case the HTTP/3 requests will be sent over HTTP/2 instead.
+const HTTP3_COMPILED = false
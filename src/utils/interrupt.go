@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The maximum time to wait for the in-progress downloads to clean up
+// their incomplete files after a SIGINT/SIGTERM signal before exiting.
+const INTERRUPT_GRACE_PERIOD = 3 * time.Second
+
+var (
+	interruptCtx, cancelInterruptCtx = context.WithCancel(context.Background())
+
+	interruptHooksMu sync.Mutex
+	interruptHooks   []func()
+
+	incompleteFilesMu sync.Mutex
+	incompleteFiles   = make(map[string]struct{})
+)
+
+// Returns the context that will be cancelled when a SIGINT/SIGTERM signal is received
+// after HandleInterrupts() has been called.
+func GetInterruptCtx() context.Context {
+	return interruptCtx
+}
+
+// Cancels the context from GetInterruptCtx() to stop the in-flight requests and downloads
+// for the callers that handle the SIGINT/SIGTERM signals themselves like the watch mode.
+func CancelInterruptCtx() {
+	cancelInterruptCtx()
+}
+
+// Returns true if the run has been interrupted by a SIGINT/SIGTERM signal
+func IsInterrupted() bool {
+	return interruptCtx.Err() != nil
+}
+
+// Registers a function to be called when the run has been interrupted
+// before the program exits, e.g. to stop the running spinners.
+func OnInterrupt(fn func()) {
+	interruptHooksMu.Lock()
+	defer interruptHooksMu.Unlock()
+	interruptHooks = append(interruptHooks, fn)
+}
+
+// Records a file that is being written to so that it will be removed
+// if the program exits before the download has been completed.
+func AddIncompleteFile(filePath string) {
+	incompleteFilesMu.Lock()
+	defer incompleteFilesMu.Unlock()
+	incompleteFiles[filePath] = struct{}{}
+}
+
+// Removes the file from the incomplete files after it has been completed or cleaned up
+func RemoveIncompleteFile(filePath string) {
+	incompleteFilesMu.Lock()
+	defer incompleteFilesMu.Unlock()
+	delete(incompleteFiles, filePath)
+}
+
+// Deletes the files that were still being written to
+func removeIncompleteFiles() {
+	incompleteFilesMu.Lock()
+	defer incompleteFilesMu.Unlock()
+	for filePath := range incompleteFiles {
+		os.Remove(filePath)
+		delete(incompleteFiles, filePath)
+	}
+}
+
+// Waits for the in-progress downloads to clean up their incomplete files up to INTERRUPT_GRACE_PERIOD
+func waitForIncompleteFiles() {
+	deadline := time.Now().Add(INTERRUPT_GRACE_PERIOD)
+	for time.Now().Before(deadline) {
+		incompleteFilesMu.Lock()
+		remaining := len(incompleteFiles)
+		incompleteFilesMu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Catches SIGINT/SIGTERM signals for the rest of the run.
+//
+// On the first signal, the context from GetInterruptCtx() will be cancelled to stop the in-flight requests and downloads,
+// the functions registered via OnInterrupt() will be called, and the incomplete files will be deleted
+// before the program exits with EXIT_INTERRUPTED. A second signal will exit the program right away.
+func HandleInterrupts() {
+	OnExit(removeIncompleteFiles)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancelInterruptCtx()
+		go func() {
+			<-sigs
+			os.Exit(EXIT_INTERRUPTED)
+		}()
+
+		waitForIncompleteFiles()
+		interruptHooksMu.Lock()
+		hooks := interruptHooks
+		interruptHooksMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+		PrintError("Stopped the run as it was interrupted by the user.")
+		Exit(EXIT_INTERRUPTED)
+	}()
+}
@@ -4,19 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 func logJsonResponse(body []byte) {
 	var prettyJson bytes.Buffer
 	err := json.Indent(&prettyJson, body, "", "    ")
 	if err != nil {
-		color.Red(
+		PrintError(
 			fmt.Sprintf(
 				"error %d: failed to indent JSON response body due to %v",
 				JSON_ERROR,
@@ -31,7 +31,7 @@ func logJsonResponse(body []byte) {
 	os.MkdirAll(filepath.Dir(filePath), 0755)
 	err = os.WriteFile(filePath, prettyJson.Bytes(), 0666)
 	if err != nil {
-		color.Red(
+		PrintError(
 			fmt.Sprintf(
 				"error %d: failed to write JSON response body to file due to %v",
 				UNEXPECTED_ERROR,
@@ -41,11 +41,50 @@ func logJsonResponse(body []byte) {
 	}
 }
 
+// The maximum number of bytes of the body to include in the JSON error messages
+const JSON_ERR_SNIPPET_LEN = 512
+
+// Returns the body for the error messages which will only be the start and the end
+// of the body if it is longer than JSON_ERR_SNIPPET_LEN bytes.
+func getBodySnippet(body []byte) string {
+	if len(body) <= JSON_ERR_SNIPPET_LEN {
+		return strings.ToValidUTF8(string(body), "?")
+	}
+
+	half := JSON_ERR_SNIPPET_LEN / 2
+	return fmt.Sprintf(
+		"%s\n...(%d bytes omitted)...\n%s",
+		strings.ToValidUTF8(string(body[:half]), "?"),
+		len(body)-JSON_ERR_SNIPPET_LEN,
+		strings.ToValidUTF8(string(body[len(body)-half:]), "?"),
+	)
+}
+
+// Returns the number of bytes read from the response body for the error messages
+// like "1024 of the expected 4096 bytes" if the response has a Content-Length header.
+func getBytesReadMsg(res *http.Response, bytesRead int) string {
+	if res.ContentLength >= 0 && int64(bytesRead) != res.ContentLength {
+		return fmt.Sprintf("%d of the expected %d bytes (the response might be truncated)", bytesRead, res.ContentLength)
+	}
+	return fmt.Sprintf("%d bytes", bytesRead)
+}
+
 // Read the response body and unmarshal it into a interface and returns it
+//
+// The errors will include the number of bytes read and a snippet of the body
+// to help diagnose truncated responses from dropped connections.
 func LoadJsonFromResponse(res *http.Response, format any) error {
-	body, err := ReadResBody(res)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return err
+		return fmt.Errorf(
+			"error %d: failed to read json response from %s after %s due to %v\nBody: %s",
+			RESPONSE_ERROR,
+			res.Request.URL.String(),
+			getBytesReadMsg(res, len(body)),
+			err,
+			getBodySnippet(body),
+		)
 	}
 
 	// write to file if debug mode is on
@@ -53,13 +92,22 @@ func LoadJsonFromResponse(res *http.Response, format any) error {
 		logJsonResponse(body)
 	}
 
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf(
+			"error %d: received an empty body instead of a json response from %s (%s response)",
+			RESPONSE_ERROR,
+			res.Request.URL.String(),
+			res.Status,
+		)
+	}
 	if err = json.Unmarshal(body, &format); err != nil {
 		return fmt.Errorf(
-			"error %d: failed to unmarshal json response from %s due to %v\nBody: %s",
+			"error %d: failed to unmarshal json response from %s due to %v\nRead %s, body: %s",
 			RESPONSE_ERROR,
 			res.Request.URL.String(),
 			err,
-			string(body),
+			getBytesReadMsg(res, len(body)),
+			getBodySnippet(body),
 		)
 	}
 	return nil
@@ -71,8 +119,22 @@ func LoadJsonFromBytes(body []byte, format any) error {
 			"error %d: failed to unmarshal json due to %v\nBody: %s",
 			JSON_ERROR,
 			err,
-			string(body),
+			getBodySnippet(body),
 		)
 	}
 	return nil
 }
+
+// Returns the given value as an indented JSON byte slice
+// which is used for writing human-readable JSON files like the config file.
+func PrettifyJson(v any) ([]byte, error) {
+	prettyJson, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to marshal JSON, more info => %v",
+			JSON_ERROR,
+			err,
+		)
+	}
+	return prettyJson, nil
+}
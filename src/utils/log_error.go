@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -13,23 +15,60 @@ import (
 	"github.com/fatih/color"
 )
 
-const LogSuffix = "\n\n"
+const (
+	LogSuffix = "\n\n"
+
+	// The prefix of the log files' names so that only the program's
+	// log files will be deleted or compressed when cleaning up the log folder
+	LOG_FILENAME_PREFIX = "cultured_downloader-cli_"
+)
+
 var (
-	mainLogger *logger
-	logFolder = filepath.Join(APP_PATH, "logs")
-	logFilePath = filepath.Join(
+	mainLogger    *logger
+	mainLoggerMu  sync.Mutex
+	mainLoggerOut *os.File
+	logFolder     = getLogFolder()
+	logFilePath   = getLogFilePath()
+
+	// set via SetLogPath() to keep the log path given by the user when the app path is changed
+	hasLogPathOverride bool
+)
+
+func getLogFolder() string {
+	return filepath.Join(APP_PATH, "logs")
+}
+
+// Returns the path of the log file of the current run in the log folder.
+//
+// The process ID is included in the file name so that multiple instances
+// running at the same time will not interleave their logs in the same file.
+func getLogFilePath() string {
+	return filepath.Join(
 		logFolder,
 		fmt.Sprintf(
-			"cultured_downloader-cli_v%s_%s.log", 
+			"%sv%s_%s_%d.log",
+			LOG_FILENAME_PREFIX,
 			VERSION, 
 			time.Now().Format("2006-01-02"),
+			os.Getpid(),
 		),
 	)
-)
+}
+
+// Returns the main logger and opens the log file on the first call.
+//
+// The log file is opened lazily instead of in an init function so that
+// any override of APP_PATH via SetAppPath() is applied before the log file is created.
+func getMainLogger() *logger {
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	if mainLogger != nil {
+		return mainLogger
+	}
 
-func init() {
 	// will be opened througout the program's runtime
 	// hence, there is no need to call f.Close() at the end of this function
+	os.MkdirAll(logFolder, 0755)
 	f, fileErr := os.OpenFile(
 		logFilePath, 
 		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 
@@ -42,20 +81,149 @@ func init() {
 			logFilePath,
 		)
 		log.Println(color.RedString(fileErr.Error()))
-		os.Exit(1)
+
+		// discard the logs of the exit hooks as they would otherwise try to open the log file again
+		mainLogger = NewLogger(io.Discard)
+		mainLoggerMu.Unlock()
+		Exit(EXIT_GENERAL_ERROR)
 	}
+	mainLoggerOut = f
 	mainLogger = NewLogger(f)
+
+	// so that the log files attached to bug reports are self-describing
+	mainLogger.Infof(
+		"Build info: %s",
+		strings.ReplaceAll(GetBuildInfo().String(), "\n", ", "),
+	)
+	return mainLogger
+}
+
+// Returns the path of the log file if anything has been logged in the current run, otherwise an empty string
+func GetLogFilePathIfWritten() string {
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	if mainLoggerOut == nil {
+		return ""
+	}
+	return logFilePath
+}
+
+// Returns the path of the log file that will be used for the current run
+func GetLogFilePath() string {
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	return logFilePath
+}
+
+// Overrides the location of the log file given via the "--log-path" flag.
+//
+// If the given path ends with ".log", it will be used as the log file.
+// Otherwise, it will be used as the log folder with the default log file name.
+//
+// Should be called after SetAppPath() and before anything is logged
+// as the default log file path is computed when the package is initialised.
+func SetLogPath(logPath string) error {
+	absPath, err := filepath.Abs(logPath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to get the absolute path of %q, more info => %v",
+			INPUT_ERROR,
+			logPath,
+			err,
+		)
+	}
+
+	isFilePath := strings.EqualFold(filepath.Ext(absPath), ".log")
+	folder := absPath
+	if isFilePath {
+		folder = filepath.Dir(absPath)
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create the log folder at %q, more info => %v",
+			OS_ERROR,
+			folder,
+			err,
+		)
+	}
+
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	hasLogPathOverride = true
+	logFolder = folder
+	if isFilePath {
+		logFilePath = absPath
+	} else {
+		logFilePath = getLogFilePath()
+	}
+
+	// close the previously opened log file (if any)
+	// so that the next log will be written to the new location
+	if mainLoggerOut != nil {
+		mainLoggerOut.Close()
+		mainLoggerOut = nil
+	}
+	mainLogger = nil
+	return nil
+}
+
+// Overrides the application's config directory which is
+// used for the config file, logs, etc.
+//
+// Should be called before anything else uses APP_PATH like logging.
+func SetAppPath(appPath string) error {
+	absPath, err := filepath.Abs(appPath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to get the absolute path of %q, more info => %v",
+			INPUT_ERROR,
+			appPath,
+			err,
+		)
+	}
+	if err := os.MkdirAll(absPath, 0755); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create the app data directory at %q, more info => %v",
+			OS_ERROR,
+			absPath,
+			err,
+		)
+	}
+
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	APP_PATH = absPath
+	DOWNLOAD_PATH = GetDefaultDownloadPath()
+	if !hasLogPathOverride {
+		logFolder = getLogFolder()
+		logFilePath = getLogFilePath()
+	}
+
+	// close the previously opened log file (if any)
+	// so that the next log will be written to the new location
+	if mainLoggerOut != nil {
+		mainLoggerOut.Close()
+		mainLoggerOut = nil
+	}
+	mainLogger = nil
+	return nil
 }
 
 // Delete all empty log files and log files
-// older than 30 days except for the current day's log file.
+// older than 30 days except for the current run's log file.
+//
+// Only the program's log files are deleted in case the log folder given via "--log-path" contains other files.
 func DeleteEmptyAndOldLogs() error {
 	err := filepath.Walk(logFolder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() || path == logFilePath {
+		if info.IsDir() && path != logFolder {
+			// the log files are not saved in subfolders
+			return filepath.SkipDir
+		}
+		if info.IsDir() || path == logFilePath || !isProgramLogFile(path) {
 			return nil
 		}
 
@@ -73,12 +241,104 @@ func DeleteEmptyAndOldLogs() error {
 	return nil
 }
 
+// Compresses the log files of the previous days with gzip to save space.
+//
+// The current day's log files are kept uncompressed so that they can still be tailed
+// as they may be written to by other instances of the program that are still running.
+func CompressOldLogs() error {
+	today := time.Now().Format("2006-01-02")
+	return filepath.Walk(logFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && path != logFolder {
+			// the log files are not saved in subfolders
+			return filepath.SkipDir
+		}
+		if info.IsDir() || path == logFilePath || filepath.Ext(path) != ".log" || !isProgramLogFile(path) {
+			return nil
+		}
+		if info.ModTime().Format("2006-01-02") == today {
+			return nil
+		}
+		return compressLogFile(path)
+	})
+}
+
+// Checks if the file at the given path is one of the program's log files, including the compressed ones
+func isProgramLogFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), LOG_FILENAME_PREFIX)
+}
+
+// Compresses the given log file to a ".gz" file and removes the original log file
+func compressLogFile(path string) error {
+	logFile, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to open log file at %s for compression, more info => %v",
+			OS_ERROR,
+			path,
+			err,
+		)
+	}
+	defer logFile.Close()
+
+	gzPath := path + ".gz"
+	gzFile, err := os.Create(gzPath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create compressed log file at %s, more info => %v",
+			OS_ERROR,
+			gzPath,
+			err,
+		)
+	}
+
+	gzWriter := gzip.NewWriter(gzFile)
+	gzWriter.Name = filepath.Base(path)
+	_, err = io.Copy(gzWriter, logFile)
+	if err == nil {
+		err = gzWriter.Close()
+	}
+	if closeErr := gzFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(gzPath)
+		return fmt.Errorf(
+			"error %d: failed to compress log file at %s, more info => %v",
+			OS_ERROR,
+			path,
+			err,
+		)
+	}
+
+	// keep the original modification time so that
+	// DeleteEmptyAndOldLogs() can still remove it after 30 days
+	if logInfo, err := logFile.Stat(); err == nil {
+		os.Chtimes(gzPath, logInfo.ModTime(), logInfo.ModTime())
+	}
+	logFile.Close()
+	return os.Remove(path)
+}
+
 // Thread-safe logging function that logs to "cultured_downloader.log" in the logs directory
 func LogError(err error, errorMsg string, exit bool, level int) {
+	logError(err, errorMsg, exit, level, 1)
+}
+
+// Same as LogError but records the given number of occurrences of the error for the error summary
+func logError(err error, errorMsg string, exit bool, level int, count int) {
 	if err == nil && errorMsg == "" {
 		return
 	}
 
+	if err != nil && level == ERROR {
+		recordErrorForSummary(err, count)
+	}
+
+	mainLogger := getMainLogger()
 	if err != nil && errorMsg != "" {
 		mainLogger.LogBasedOnLvl(level, err.Error() + LogSuffix)
 		if errorMsg != "" {
@@ -92,11 +352,11 @@ func LogError(err error, errorMsg string, exit bool, level int) {
 
 	if exit {
 		if err != nil {
-			color.Red(err.Error())
+			PrintError(err.Error())
 		} else {
-			color.Red(errorMsg)
+			PrintError(errorMsg)
 		}
-		os.Exit(1)
+		Exit(GetExitCodeFromErr(err))
 	}
 }
 
@@ -113,20 +373,26 @@ func LogErrors(exit bool, errChan chan error, level int, errs ...error) bool {
 		)
 	}
 
-	hasCanceled := false
+	if len(errs) > 0 || (errChan != nil && len(errChan) > 0) {
+		RecordFailedItems()
+	}
+
+	// abort the run on the first logged error if the user has enabled "--fail_fast"
+	if IsFailFast() && level == ERROR {
+		exit = true
+	}
+
 	if errChan != nil {
 		for err := range errChan {
-			if err == context.Canceled {
-				if !hasCanceled {
-					hasCanceled = true
-				}
-				continue
-			}
-			LogError(err, "", exit, level)
+			errs = append(errs, err)
 		}
-		return hasCanceled
 	}
 
+	// group identical error messages so that
+	// the same error will not be logged hundreds of times
+	hasCanceled := false
+	var uniqueErrs []error
+	errCounts := make(map[string]int)
 	for _, err := range errs {
 		if err == context.Canceled {
 			if !hasCanceled {
@@ -134,7 +400,21 @@ func LogErrors(exit bool, errChan chan error, level int, errs ...error) bool {
 			}
 			continue
 		}
-		LogError(err, "", exit, level)
+
+		errMsg := err.Error()
+		if _, ok := errCounts[errMsg]; !ok {
+			uniqueErrs = append(uniqueErrs, err)
+		}
+		errCounts[errMsg]++
+	}
+
+	for _, err := range uniqueErrs {
+		count := errCounts[err.Error()]
+		if count > 1 {
+			logError(err, fmt.Sprintf("the error above occurred %d times", count), exit, level, count)
+		} else {
+			logError(err, "", exit, level, count)
+		}
 	}
 	return hasCanceled
 }
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+)
+
+// Returned by the download layer when a file was not downloaded as "--max-files" has been reached
+var ErrMaxFilesReached = errors.New("the maximum number of files to download has been reached")
+
+// fileLimit caps the number of files downloaded across all the sites in a run.
+//
+// A slot is reserved for each file before it is downloaded so that the number of downloaded files
+// will not exceed the limit even when the files are downloaded concurrently.
+type fileLimit struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	maxFiles   int // 0 means there is no limit
+	completed  int
+	inProgress int
+	cancelled  int
+}
+
+var maxFilesLimit = newFileLimit()
+
+func newFileLimit() *fileLimit {
+	limit := &fileLimit{}
+	limit.cond = sync.NewCond(&limit.mu)
+	return limit
+}
+
+// Configures the maximum number of files to download in the run or 0 for no limit
+func SetMaxFiles(maxFiles int) {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	maxFilesLimit.maxFiles = maxFiles
+}
+
+// Returns true if "--max-files" has been reached and no more files should be queued for download
+func IsMaxFilesReached() bool {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	return maxFilesLimit.maxFiles > 0 && maxFilesLimit.completed >= maxFilesLimit.maxFiles
+}
+
+// Reserves a slot for a file that is about to be downloaded.
+//
+// If the slots are all taken by the downloads in progress, this will wait for one of them to finish
+// as the slot will be freed if the download fails. Returns false if "--max-files" has been reached
+// in which case the file will be recorded as cancelled and ReleaseFileSlot() must not be called.
+func AcquireFileSlot() bool {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	if maxFilesLimit.maxFiles <= 0 {
+		return true
+	}
+
+	for maxFilesLimit.completed < maxFilesLimit.maxFiles &&
+		maxFilesLimit.completed+maxFilesLimit.inProgress >= maxFilesLimit.maxFiles {
+		maxFilesLimit.cond.Wait()
+	}
+	if maxFilesLimit.completed >= maxFilesLimit.maxFiles {
+		maxFilesLimit.cancelled++
+		return false
+	}
+	maxFilesLimit.inProgress++
+	return true
+}
+
+// Releases the slot reserved by AcquireFileSlot() after the download has finished
+// and counts the file towards "--max-files" if it was downloaded.
+func ReleaseFileSlot(downloaded bool) {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	if maxFilesLimit.maxFiles <= 0 {
+		return
+	}
+
+	maxFilesLimit.inProgress--
+	if downloaded {
+		maxFilesLimit.completed++
+	}
+	maxFilesLimit.cond.Broadcast()
+}
+
+// Records the given number of queued files that were not downloaded as "--max-files" has been reached
+func AddCancelledFiles(count int) {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	maxFilesLimit.cancelled += count
+}
+
+// Returns the number of downloaded files if the run was stopped early
+// due to "--max-files" and whether the run was stopped early.
+func GetMaxFilesStoppedAt() (int, bool) {
+	maxFilesLimit.mu.Lock()
+	defer maxFilesLimit.mu.Unlock()
+	return maxFilesLimit.completed, maxFilesLimit.maxFiles > 0 && maxFilesLimit.cancelled > 0
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	MEGA_FOLDER         = "mega"
+	MEGA_LINKS_FILENAME = "mega_links.txt"
+)
+
+// Matches both the new (mega.nz/file/..., mega.nz/folder/...) and the legacy (mega.nz/#!..., mega.co.nz/#F!...) MEGA links
+var MEGA_URL_REGEX = regexp.MustCompile(
+	`https?://(?:www\.)?mega(?:\.co)?\.nz/(?:file/|folder/|#F?!)[\w!#/-]+`,
+)
+
+// A detected MEGA link to be downloaded into DirPath
+type MegaLink struct {
+	Url     string
+	DirPath string
+}
+
+var (
+	dlMega          atomic.Bool
+	megaLinksMu     sync.Mutex
+	queuedMegaLinks []*MegaLink
+)
+
+// Configures whether the detected MEGA links should be queued for download as given by "--dl_mega"
+func SetDlMega(enabled bool) {
+	dlMega.Store(enabled)
+}
+
+// Returns true if the user has enabled "--dl_mega"
+func IsDlMegaEnabled() bool {
+	return dlMega.Load()
+}
+
+// Detects the MEGA links in the text and appends them to the mega_links.txt file in the post folder
+// so that they can be downloaded in batches with an external tool.
+//
+// If "--dl_mega" is enabled, the links will also be queued to be downloaded by the program
+// unless the post's mega folder already has files from a previous run.
+// Returns the detected MEGA links.
+func DetectMegaLinks(text, postFolderPath string) []string {
+	megaLinks := MEGA_URL_REGEX.FindAllString(text, -1)
+	if len(megaLinks) == 0 {
+		return nil
+	}
+
+	megaLinksMu.Lock()
+	defer megaLinksMu.Unlock()
+
+	if IsDlMegaEnabled() {
+		queueMegaLinks(megaLinks, filepath.Join(postFolderPath, MEGA_FOLDER))
+	}
+
+	filePath := filepath.Join(postFolderPath, MEGA_LINKS_FILENAME)
+	var loggedLinks []string
+	if fileContents, err := os.ReadFile(filePath); err == nil {
+		loggedLinks = strings.Fields(string(fileContents))
+	}
+
+	var newLinks []string
+	for _, megaLink := range megaLinks {
+		if SliceContains(loggedLinks, megaLink) || SliceContains(newLinks, megaLink) {
+			continue
+		}
+		newLinks = append(newLinks, megaLink)
+	}
+	if len(newLinks) == 0 {
+		return megaLinks
+	}
+
+	os.MkdirAll(postFolderPath, 0755)
+	megaLinksFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		LogError(err, "failed to write the detected MEGA links to "+filePath, false, ERROR)
+		return megaLinks
+	}
+	defer megaLinksFile.Close()
+
+	megaLinksFile.WriteString(strings.Join(newLinks, "\n") + "\n")
+	RUN_STATS.AddExternalLinksFile(filePath)
+	return megaLinks
+}
+
+// Queues the MEGA links to be downloaded into the given mega folder
+// unless the folder already has files from a previous run.
+//
+// The caller must hold megaLinksMu.
+func queueMegaLinks(megaLinks []string, megaDirPath string) {
+	if entries, err := os.ReadDir(megaDirPath); err == nil && len(entries) > 0 {
+		return
+	}
+
+	for _, megaLink := range megaLinks {
+		isQueued := false
+		for _, queuedLink := range queuedMegaLinks {
+			if queuedLink.Url == megaLink && queuedLink.DirPath == megaDirPath {
+				isQueued = true
+				break
+			}
+		}
+		if !isQueued {
+			queuedMegaLinks = append(queuedMegaLinks, &MegaLink{
+				Url:     megaLink,
+				DirPath: megaDirPath,
+			})
+		}
+	}
+}
+
+// Returns the MEGA links queued for download and clears the queue
+func PopQueuedMegaLinks() []*MegaLink {
+	megaLinksMu.Lock()
+	defer megaLinksMu.Unlock()
+	megaLinks := queuedMegaLinks
+	queuedMegaLinks = nil
+	return megaLinks
+}
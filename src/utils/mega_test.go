@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectMegaLinksQueue(t *testing.T) {
+	const megaLink = "https://mega.nz/file/abc#key"
+	tests := []struct {
+		name          string
+		loggedLinks   bool // the link has already been saved to mega_links.txt by a previous run
+		megaDirFile   bool // the mega folder already has a downloaded file
+		wantQueuedLen int
+	}{
+		{
+			name:          "new link",
+			wantQueuedLen: 1,
+		},
+		{
+			name:          "link saved by a previous run",
+			loggedLinks:   true,
+			wantQueuedLen: 1,
+		},
+		{
+			name:        "already downloaded",
+			loggedLinks: true,
+			megaDirFile: true,
+		},
+	}
+	SetDlMega(true)
+	defer SetDlMega(false)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postFolderPath := t.TempDir()
+			if test.loggedLinks {
+				if err := os.WriteFile(filepath.Join(postFolderPath, MEGA_LINKS_FILENAME), []byte(megaLink+"\n"), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.megaDirFile {
+				megaDirPath := filepath.Join(postFolderPath, MEGA_FOLDER)
+				if err := os.MkdirAll(megaDirPath, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(megaDirPath, "file.zip"), []byte("data"), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the same link may appear more than once in a post
+			DetectMegaLinks("Download: "+megaLink+" (mirror: "+megaLink+")", postFolderPath)
+			if queued := PopQueuedMegaLinks(); len(queued) != test.wantQueuedLen {
+				t.Errorf("queued %d MEGA link(s), want %d", len(queued), test.wantQueuedLen)
+			}
+
+			fileContents, err := os.ReadFile(filepath.Join(postFolderPath, MEGA_LINKS_FILENAME))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(fileContents) != megaLink+"\n" {
+				t.Errorf("%s contains %q, want the link once", MEGA_LINKS_FILENAME, fileContents)
+			}
+		})
+	}
+}
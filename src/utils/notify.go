@@ -11,14 +11,16 @@ import (
 	"github.com/gen2brain/beeep"
 )
 
-var (
-	//go:embed icon.png
-	iconImg []byte
-	iconPath = filepath.Join(APP_PATH, "icon.png")
-)
+//go:embed icon.png
+var iconImg []byte
 
 const Title = "Cultured Downloader CLI"
 
+// Returns the path to the notification icon in the application's directory
+func getIconPath() string {
+	return filepath.Join(APP_PATH, "icon.png")
+}
+
 func writeIcon() error {
 	defer func() {
 		if iconImg != nil {
@@ -26,6 +28,7 @@ func writeIcon() error {
 		}
 	}()
 
+	iconPath := getIconPath()
 	if PathExists(iconPath) {
 		return nil
 	}
@@ -51,7 +54,7 @@ func Alert(title, message string) error {
 		)
 	}
 
-	if err := beeep.Alert(title, message, iconPath); err != nil {
+	if err := beeep.Alert(title, message, getIconPath()); err != nil {
 		return fmt.Errorf(
 			"error %d: unable to show notification => %v", 
 			UNEXPECTED_ERROR,
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The filename of the metadata JSON file written into the folder of each post with "--write_metadata"
+const POST_METADATA_FILENAME = "metadata.json"
+
+// PostMetadata is the post's details that are saved alongside the downloaded files
+// taken from the JSON of the post that has already been fetched.
+type PostMetadata struct {
+	Id       string   `json:"id"`
+	Title    string   `json:"title"`
+	Creator  string   `json:"creator"`
+	Tags     []string `json:"tags"`
+	PostDate string   `json:"postDate"`
+	Url      string   `json:"url"`
+}
+
+// Writes the given metadata as a JSON file into the post folder for archival purposes.
+//
+// The existing metadata file will be left as it is unless overwrite is true.
+func WritePostMetadata(postFolderPath string, metadata any, overwrite bool) error {
+	filePath := filepath.Join(postFolderPath, POST_METADATA_FILENAME)
+	if !overwrite && PathExists(filePath) {
+		return nil
+	}
+
+	metadataJson, err := PrettifyJson(metadata)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(postFolderPath, 0755); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create the post folder, %q, for its metadata, more info => %v",
+			OS_ERROR,
+			postFolderPath,
+			err,
+		)
+	}
+	if err := WriteFileAtomic(filePath, metadataJson, 0644); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the post's metadata to %q, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The URL schemes of the proxies that can be given to "--proxy"
+var ACCEPTED_PROXY_SCHEMES = []string{
+	"http",
+	"https",
+	"socks5",
+}
+
+// The proxy from "--proxy" or nil if the requests should not be routed through a proxy
+var proxyUrl *url.URL
+
+// Configures the proxy to route the requests of the run through, e.g. "socks5://127.0.0.1:1080".
+//
+// The proxy is also set on the default HTTP transport so that the requests sent by
+// the other libraries like the Google Drive API client will be routed through it too.
+//
+// An empty URL removes the proxy and restores the default transport's proxy from the environment variables.
+func SetProxy(rawUrl string) error {
+	if rawUrl == "" {
+		proxyUrl = nil
+		if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport.Proxy = http.ProxyFromEnvironment
+		}
+		return nil
+	}
+
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || parsedUrl.Host == "" {
+		return fmt.Errorf(
+			"error %d: invalid proxy URL, %q, it should be in the format of \"scheme://[username:password@]host:port\"",
+			INPUT_ERROR,
+			rawUrl,
+		)
+	}
+
+	parsedUrl.Scheme = strings.ToLower(parsedUrl.Scheme)
+	if !SliceContains(ACCEPTED_PROXY_SCHEMES, parsedUrl.Scheme) {
+		return fmt.Errorf(
+			"error %d: proxy scheme %q is not supported, must be one of %s",
+			INPUT_ERROR,
+			parsedUrl.Scheme,
+			strings.Join(ACCEPTED_PROXY_SCHEMES, ", "),
+		)
+	}
+
+	proxyUrl = parsedUrl
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.Proxy = http.ProxyURL(parsedUrl)
+	}
+	return nil
+}
+
+// Returns the proxy URL from "--proxy" or an empty string if no proxy was given
+func GetProxy() string {
+	if proxyUrl == nil {
+		return ""
+	}
+	return proxyUrl.String()
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSetProxyRestoresDefaultTransport(t *testing.T) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		t.Skip("the default transport is not a *http.Transport")
+	}
+	req, err := http.NewRequest("GET", "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetProxy("socks5://127.0.0.1:1080"); err != nil {
+		t.Fatalf("SetProxy() returned %v", err)
+	}
+	if proxy, err := transport.Proxy(req); err != nil || proxy.String() != "socks5://127.0.0.1:1080" {
+		t.Errorf("the default transport uses the proxy %v (%v), want the given proxy", proxy, err)
+	}
+
+	if err := SetProxy(""); err != nil {
+		t.Fatalf("SetProxy(\"\") returned %v", err)
+	}
+	if GetProxy() != "" {
+		t.Errorf("GetProxy() = %q after removing the proxy", GetProxy())
+	}
+	// the proxy from the environment variables (if any) may be used instead
+	if proxy, _ := transport.Proxy(req); proxy != nil && proxy.String() == "socks5://127.0.0.1:1080" {
+		t.Error("the default transport still uses the removed proxy")
+	}
+}
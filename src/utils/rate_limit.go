@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The sites that can be configured in the rate limit config file
+var RATE_LIMIT_SITES = []string{FANTIA, PIXIV_FANBOX, PIXIV, PIXIV_MOBILE, KEMONO, GDRIVE}
+
+// SiteRateLimit is the pacing of the requests to a site from the rate limit config file.
+//
+// Zero values will use the program's defaults.
+type SiteRateLimit struct {
+	// The maximum number of requests to send to the site per minute
+	RequestsPerMinute float64 `yaml:"requests_per_minute"`
+
+	// The maximum number of concurrent downloads from the site
+	Concurrency int `yaml:"concurrency"`
+
+	// The number of attempts for a failed request before giving up
+	Retries int `yaml:"retries"`
+
+	// The range of the random delay in seconds before retrying a failed request
+	BackoffMin float64 `yaml:"backoff_min"`
+	BackoffMax float64 `yaml:"backoff_max"`
+
+	mu          sync.Mutex
+	nextRequest time.Time
+}
+
+var (
+	rateLimitsMu sync.RWMutex
+	rateLimits   = make(map[string]*SiteRateLimit)
+)
+
+// Validates the values of the site's rate limit
+func (rl *SiteRateLimit) validate() error {
+	switch {
+	case rl.RequestsPerMinute < 0:
+		return fmt.Errorf("requests_per_minute must be 0 (unlimited) or more, got %v", rl.RequestsPerMinute)
+	case rl.Concurrency < 0:
+		return fmt.Errorf("concurrency must be 0 (default) or more, got %d", rl.Concurrency)
+	case rl.Retries < 0:
+		return fmt.Errorf("retries must be 0 (default) or more, got %d", rl.Retries)
+	case rl.BackoffMin < 0 || rl.BackoffMax < 0:
+		return fmt.Errorf("backoff_min and backoff_max must be 0 or more, got %v and %v", rl.BackoffMin, rl.BackoffMax)
+	case rl.BackoffMax > 0 && rl.BackoffMin > rl.BackoffMax:
+		return fmt.Errorf("backoff_min, %v, cannot be more than backoff_max, %v", rl.BackoffMin, rl.BackoffMax)
+	}
+	return nil
+}
+
+// Loads the per-site rate limits from the given YAML or JSON file, e.g.
+//
+//	pixiv:
+//	  requests_per_minute: 30
+//	  concurrency: 2
+//	  retries: 5
+//	  backoff_min: 2
+//	  backoff_max: 5
+//
+// Unknown sites and fields will be reported as an error.
+func LoadRateLimitConfig(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to open rate limit config file at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	var siteRateLimits map[string]*SiteRateLimit
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&siteRateLimits); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(
+			"error %d: failed to parse rate limit config file at %s, more info => %v",
+			INPUT_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	var errs []string
+	for site, rl := range siteRateLimits {
+		if !SliceContains(RATE_LIMIT_SITES, site) {
+			errs = append(errs, fmt.Sprintf("unknown site %q, must be one of %s", site, strings.Join(RATE_LIMIT_SITES, ", ")))
+			continue
+		}
+		if rl == nil {
+			continue
+		}
+		if err := rl.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", site, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf(
+			"error %d: invalid rate limit config file at %s:\n- %s",
+			INPUT_ERROR,
+			filePath,
+			strings.Join(errs, "\n- "),
+		)
+	}
+
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+	for site, rl := range siteRateLimits {
+		if rl != nil {
+			rateLimits[site] = rl
+		}
+	}
+	return nil
+}
+
+// Returns the configured rate limit of the site or nil if it was not configured
+func getSiteRateLimit(site string) *SiteRateLimit {
+	rateLimitsMu.RLock()
+	defer rateLimitsMu.RUnlock()
+	return rateLimits[site]
+}
+
+// Returns the site like PIXIV of the given URL based on its host or an empty string if it is not a known site
+func GetSiteFromUrl(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsedUrl.Hostname())
+	hasDomain := func(domain string) bool {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	switch {
+	case hasDomain("fantia.jp"):
+		return FANTIA
+	case hasDomain("fanbox.cc"), host == "pixiv.pximg.net":
+		// Pixiv Fanbox's images are hosted on pixiv.pximg.net
+		return PIXIV_FANBOX
+	case host == "app-api.pixiv.net", host == "oauth.secure.pixiv.net":
+		return PIXIV_MOBILE
+	case hasDomain("pixiv.net"), hasDomain("pximg.net"):
+		return PIXIV
+	case hasDomain("kemono.party"), hasDomain("kemono.su"):
+		return KEMONO
+	case hasDomain("googleapis.com"), hasDomain("drive.google.com"):
+		return GDRIVE
+	}
+	return ""
+}
+
+// Blocks until the next request to the site can be sent
+// according to its configured requests per minute (if any).
+func WaitForRateLimit(site string) {
+	rl := getSiteRateLimit(site)
+	if rl == nil || rl.RequestsPerMinute <= 0 {
+		return
+	}
+
+	interval := time.Duration(float64(time.Minute) / rl.RequestsPerMinute)
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.nextRequest.Before(now) {
+		rl.nextRequest = now
+	}
+	wait := rl.nextRequest.Sub(now)
+	rl.nextRequest = rl.nextRequest.Add(interval)
+	rl.mu.Unlock()
+
+	if wait > 0 {
+		Sleep(wait)
+	}
+}
+
+// Returns the configured number of attempts for a failed request to the site or RETRY_COUNTER by default
+func GetRetryCount(site string) int {
+	if rl := getSiteRateLimit(site); rl != nil && rl.Retries > 0 {
+		return rl.Retries
+	}
+	return RETRY_COUNTER
+}
+
+// Returns the configured random delay before retrying a failed request to the site or GetRandomDelay() by default
+func GetRetryDelay(site string) time.Duration {
+	if rl := getSiteRateLimit(site); rl != nil && (rl.BackoffMin > 0 || rl.BackoffMax > 0) {
+		backoffMax := rl.BackoffMax
+		if backoffMax < rl.BackoffMin {
+			backoffMax = rl.BackoffMin
+		}
+		return GetRandomTime(rl.BackoffMin, backoffMax)
+	}
+	return GetRandomDelay()
+}
+
+// The maximum time to wait for as requested by the Retry-After header before retrying a request
+const MAX_RETRY_AFTER = 120 * time.Second
+
+// Parses the Retry-After header which is either the number of seconds to wait or an HTTP-date.
+//
+// Returns the time to wait from now capped at MAX_RETRY_AFTER or 0 if the header is missing or invalid.
+func ParseRetryAfter(retryAfter string, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return 0
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if retryAt, err := http.ParseTime(retryAfter); err == nil {
+		wait = retryAt.Sub(now)
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return min(wait, MAX_RETRY_AFTER)
+}
+
+// Returns the delay before retrying the request to the site that got the given non-200 response.
+//
+// If the server has responded with the Retry-After header like for 429 Too Many Requests,
+// the delay will be at least as long as requested (up to MAX_RETRY_AFTER) and the wait will be logged.
+func GetRetryDelayFromRes(site string, res *http.Response) time.Duration {
+	retryDelay := GetRetryDelay(site)
+	retryAfter := ParseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+	if retryAfter <= retryDelay {
+		return retryDelay
+	}
+
+	host := "the server"
+	if res.Request != nil {
+		host = res.Request.URL.Host
+	}
+	LogError(
+		nil,
+		fmt.Sprintf(
+			"Received %s response from %s, waiting %s before retrying as requested by the Retry-After header...",
+			res.Status,
+			host,
+			retryAfter.Round(time.Second),
+		),
+		false,
+		INFO,
+	)
+	return retryAfter
+}
+
+const (
+	// The delay before the first retry of a request that got a 429 Too Many Requests response
+	// from a site with the rate limit backoff enabled which will be doubled on each consecutive 429 response
+	RATE_LIMIT_BACKOFF_BASE = 30 * time.Second
+
+	// The maximum number of times a request will wait for the rate limit backoff
+	// before the 429 Too Many Requests response is treated like any other failed request
+	MAX_RATE_LIMIT_BACKOFFS = 6
+)
+
+var (
+	rateLimitBackoffsMu sync.RWMutex
+	rateLimitBackoffs   = make(map[string]time.Duration) // site => max delay
+)
+
+// Enables the exponential backoff for the 429 Too Many Requests responses from the site
+// with the given maximum delay between the retries or disables it if maxDelay is 0.
+func SetRateLimitBackoff(site string, maxDelay time.Duration) {
+	rateLimitBackoffsMu.Lock()
+	defer rateLimitBackoffsMu.Unlock()
+	if maxDelay <= 0 {
+		delete(rateLimitBackoffs, site)
+		return
+	}
+	rateLimitBackoffs[site] = maxDelay
+}
+
+// Returns the delay before retrying a request to the site after the given number of consecutive
+// 429 Too Many Requests responses, e.g. 30s, 60s, 120s, ... with up to 10% jitter, capped at the configured maximum.
+//
+// The second return value will be false if the backoff is not enabled for the site
+// or the request has already waited for MAX_RATE_LIMIT_BACKOFFS times.
+func GetRateLimitBackoff(site string, attempt int) (time.Duration, bool) {
+	rateLimitBackoffsMu.RLock()
+	maxDelay, ok := rateLimitBackoffs[site]
+	rateLimitBackoffsMu.RUnlock()
+	if !ok || attempt < 1 || attempt > MAX_RATE_LIMIT_BACKOFFS {
+		return 0, false
+	}
+
+	delay := RATE_LIMIT_BACKOFF_BASE << (attempt - 1)
+	delay += GetRandomTime(0, delay.Seconds()*0.1)
+	return min(delay, maxDelay), true
+}
+
+// Returns the configured maximum number of concurrent downloads from the site or the given default value
+func GetSiteConcurrency(site string, defaultValue int) int {
+	if rl := getSiteRateLimit(site); rl != nil && rl.Concurrency > 0 {
+		return rl.Concurrency
+	}
+	return defaultValue
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Statuses of a finished run for the notifications
+const (
+	RUN_STATUS_SUCCESS     = "success"
+	RUN_STATUS_PARTIAL     = "partial"
+	RUN_STATUS_FAILED      = "failed"
+	RUN_STATUS_INTERRUPTED = "interrupted"
+)
+
+// Colours of the Discord embed for each run status
+var runStatusEmbedColours = map[string]int{
+	RUN_STATUS_SUCCESS:     0x2ECC71, // green
+	RUN_STATUS_PARTIAL:     0xF1C40F, // yellow
+	RUN_STATUS_FAILED:      0xE74C3C, // red
+	RUN_STATUS_INTERRUPTED: 0x95A5A6, // grey
+}
+
+const WEBHOOK_TIMEOUT = 15 * time.Second
+
+// Returns the run status like RUN_STATUS_SUCCESS for the given exit code
+func GetRunStatus(exitCode int) string {
+	switch exitCode {
+	case EXIT_OK:
+		return RUN_STATUS_SUCCESS
+	case EXIT_PARTIAL_ERROR:
+		return RUN_STATUS_PARTIAL
+	case EXIT_INTERRUPTED:
+		return RUN_STATUS_INTERRUPTED
+	default:
+		return RUN_STATUS_FAILED
+	}
+}
+
+// Returns the title of the notification for the given run status
+func getRunStatusTitle(status string) string {
+	switch status {
+	case RUN_STATUS_SUCCESS:
+		return "Download finished"
+	case RUN_STATUS_PARTIAL:
+		return "Download finished with failures"
+	case RUN_STATUS_INTERRUPTED:
+		return "Download interrupted"
+	default:
+		return "Download failed"
+	}
+}
+
+// Returns a short human-readable summary of the totals of the run
+func getRunSummaryMsg(totals *SiteStats) string {
+	return fmt.Sprintf(
+		"%d downloaded, %d skipped, %d failed (%s) in %s",
+		totals.FilesDownloaded,
+		totals.FilesSkipped,
+		totals.FilesFailed,
+		FormatBytes(totals.BytesTransferred),
+		formatElapsedSeconds(totals.ElapsedSeconds),
+	)
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Color       int                  `json:"color"`
+	Fields      []*discordEmbedField `json:"fields"`
+	Timestamp   string               `json:"timestamp"`
+}
+
+// RunWebhookPayload is the JSON body posted to the webhook URL at the end of the run.
+//
+// The "username", "content", and "embeds" fields follow the shape of Discord's
+// webhooks while the remaining fields are for other services and scripts.
+type RunWebhookPayload struct {
+	Username string          `json:"username"`
+	Content  string          `json:"content"`
+	Embeds   []*discordEmbed `json:"embeds"`
+
+	Status           string  `json:"status"`
+	ExitCode         int     `json:"exit_code"`
+	Version          string  `json:"version"`
+	PostsProcessed   int     `json:"posts_processed"`
+	FilesDownloaded  int     `json:"files_downloaded"`
+	FilesSkipped     int     `json:"files_skipped"`
+	FilesFailed      int     `json:"files_failed"`
+	BytesTransferred int64   `json:"bytes_transferred"`
+	DurationSeconds  float64 `json:"duration_seconds"`
+}
+
+// Returns the webhook payload for the given run report and exit code
+func newRunWebhookPayload(report *RunReport, exitCode int) *RunWebhookPayload {
+	status := GetRunStatus(exitCode)
+	totals := report.GetTotals()
+	title := getRunStatusTitle(status)
+	summaryMsg := getRunSummaryMsg(totals)
+	return &RunWebhookPayload{
+		Username: Title,
+		Content:  fmt.Sprintf("%s: %s", title, summaryMsg),
+		Embeds: []*discordEmbed{
+			{
+				Title:       title,
+				Description: summaryMsg,
+				Color:       runStatusEmbedColours[status],
+				Fields: []*discordEmbedField{
+					{Name: "Status", Value: status, Inline: true},
+					{Name: "Exit Code", Value: fmt.Sprintf("%d", exitCode), Inline: true},
+					{Name: "Posts Processed", Value: fmt.Sprintf("%d", totals.PostsProcessed), Inline: true},
+				},
+				Timestamp: report.EndTime.UTC().Format(time.RFC3339),
+			},
+		},
+		Status:           status,
+		ExitCode:         exitCode,
+		Version:          report.Version,
+		PostsProcessed:   totals.PostsProcessed,
+		FilesDownloaded:  totals.FilesDownloaded,
+		FilesSkipped:     totals.FilesSkipped,
+		FilesFailed:      totals.FilesFailed,
+		BytesTransferred: totals.BytesTransferred,
+		DurationSeconds:  totals.ElapsedSeconds,
+	}
+}
+
+// Posts the payload as JSON to the given webhook URL
+func postRunWebhook(webhookUrl string, payload *RunWebhookPayload) error {
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the webhook payload, more info => %v",
+			JSON_ERROR,
+			err,
+		)
+	}
+
+	client := &http.Client{Timeout: WEBHOOK_TIMEOUT}
+	res, err := client.Post(webhookUrl, "application/json", bytes.NewReader(payloadJson))
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to send the webhook to %s, more info => %v",
+			CONNECTION_ERROR,
+			webhookUrl,
+			err,
+		)
+	}
+	res.Body.Close()
+
+	// Discord responds with 204 No Content on success
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf(
+			"error %d: failed to send the webhook to %s due to %s response",
+			RESPONSE_ERROR,
+			webhookUrl,
+			res.Status,
+		)
+	}
+	return nil
+}
+
+// Notifies the user that the run has ended via a desktop notification
+// and/or a webhook based on the exit code passed to Exit().
+//
+// Errors are only logged so that a failed notification will not affect the run's exit code.
+func (rs *RunStats) NotifyRunEnd(desktopNotification bool, webhookUrl string) {
+	rs.mu.Lock()
+	started := rs.started
+	rs.mu.Unlock()
+	if !started || (!desktopNotification && webhookUrl == "") {
+		return
+	}
+
+	exitCode := GetExitingCode()
+	report := rs.GetReport()
+	if desktopNotification {
+		status := GetRunStatus(exitCode)
+		AlertWithoutErr(
+			fmt.Sprintf("%s - %s", Title, getRunStatusTitle(status)),
+			getRunSummaryMsg(report.GetTotals()),
+		)
+	}
+	if webhookUrl != "" {
+		if err := postRunWebhook(webhookUrl, newRunWebhookPayload(report, exitCode)); err != nil {
+			LogError(err, "", false, ERROR)
+		}
+	}
+}
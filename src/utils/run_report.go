@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const RUN_REPORT_FILENAME = "run_report.json"
+
+// Reasons for skipping a file, post, etc.
+const (
+	SKIP_REASON_EXISTS     = "exists"     // the file has already been downloaded
+	SKIP_REASON_FILTERED   = "filtered"   // filtered out by the user's options like "--sample"
+	SKIP_REASON_RESTRICTED = "restricted" // the post is restricted to supporters of a higher plan
+)
+
+// SiteStats contains the counts of a site for the run report
+type SiteStats struct {
+	PostsProcessed       int            `json:"posts_processed"`
+	FilesDownloaded      int            `json:"files_downloaded"`
+	FilesRedownloaded    int            `json:"files_redownloaded"`
+	FilesSkipped         int            `json:"files_skipped"`
+	FilesSkippedByReason map[string]int `json:"files_skipped_by_reason"`
+	FilesFailed          int            `json:"files_failed"`
+	BytesTransferred     int64          `json:"bytes_transferred"`
+	ElapsedSeconds       float64        `json:"elapsed_seconds"`
+}
+
+// FailedItem is a post, artwork, file, etc. that failed to be processed or downloaded
+type FailedItem struct {
+	Site   string `json:"site"`
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+// ExternalLink is a link to an external file hosting provider
+// that was detected in a post but was not downloaded by the program
+type ExternalLink struct {
+	Site           string `json:"site"`
+	Link           string `json:"link"`
+	PostFolderPath string `json:"post_folder_path"`
+}
+
+// RunReport is the format of the run report JSON file written at the end of every run
+type RunReport struct {
+	Version        string                `json:"version"`
+	StartTime      time.Time             `json:"start_time"`
+	EndTime        time.Time             `json:"end_time"`
+	ElapsedSeconds float64               `json:"elapsed_seconds"`
+	Sites          map[string]*SiteStats `json:"sites"`
+	FailedItems    []*FailedItem         `json:"failed_items"`
+	ExternalLinks  []*ExternalLink       `json:"external_links"`
+
+	// Paths of the files that the detected external links were written to
+	ExternalLinksFiles []string `json:"external_links_files"`
+
+	// The number of downloaded files if the run was stopped early due to "--max-files"
+	StoppedEarlyAtFiles int `json:"stopped_early_at_files,omitempty"`
+}
+
+// RunStats is shared by the download layer and the site modules
+// to collect the stats of the current run for the run report.
+//
+// All methods are thread-safe.
+type RunStats struct {
+	mu                 sync.Mutex
+	started            bool
+	site               string
+	siteStartTime      time.Time
+	startTime          time.Time
+	sites              map[string]*SiteStats
+	failedItems        []*FailedItem
+	externalLinks      []*ExternalLink
+	externalLinksFiles []string
+}
+
+var RUN_STATS = &RunStats{
+	sites: make(map[string]*SiteStats),
+}
+
+// Starts the timer of the run.
+//
+// The run report will only be written if the run has been started.
+func (rs *RunStats) Start() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.started = true
+	rs.startTime = time.Now()
+}
+
+// Sets the site that the stats recorded afterwards belong to like FANTIA
+//
+// The time spent since the previous call will be added to the elapsed time of the previous site.
+func (rs *RunStats) SetSite(site string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.site != "" {
+		rs.getSiteStats().ElapsedSeconds += time.Since(rs.siteStartTime).Seconds()
+	}
+	rs.site = site
+	rs.siteStartTime = time.Now()
+
+	// so that the site will be in the summary even if nothing was processed
+	rs.getSiteStats()
+}
+
+// Returns the stats of the current site.
+//
+// Note: The caller must hold the lock.
+func (rs *RunStats) getSiteStats() *SiteStats {
+	site := rs.getSite()
+	stats, ok := rs.sites[site]
+	if !ok {
+		stats = &SiteStats{
+			FilesSkippedByReason: make(map[string]int),
+		}
+		rs.sites[site] = stats
+	}
+	return stats
+}
+
+// Returns the current site or "general" if the site has not been set
+//
+// Note: The caller must hold the lock.
+func (rs *RunStats) getSite() string {
+	if rs.site == "" {
+		return "general"
+	}
+	return rs.site
+}
+
+// Adds the given number of processed posts, artworks, etc. to the current site
+func (rs *RunStats) AddPostsProcessed(count int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.getSiteStats().PostsProcessed += count
+}
+
+// Records a downloaded file with the number of bytes written to the disk
+func (rs *RunStats) AddDownloadedFile(bytesTransferred int64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	stats := rs.getSiteStats()
+	stats.FilesDownloaded++
+	stats.BytesTransferred += bytesTransferred
+}
+
+// Records an existing file that was downloaded again as it was smaller than the file on the server.
+//
+// Note: The file should also be recorded via AddDownloadedFile().
+func (rs *RunStats) AddRedownloadedFile() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.getSiteStats().FilesRedownloaded++
+}
+
+// Records a file that was skipped with the reason like SKIP_REASON_EXISTS
+func (rs *RunStats) AddSkippedFile(reason string) {
+	rs.AddSkippedFiles(reason, 1)
+}
+
+// Records the given number of files, posts, etc. that were skipped with the reason like SKIP_REASON_FILTERED
+func (rs *RunStats) AddSkippedFiles(reason string, count int) {
+	if count <= 0 {
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	stats := rs.getSiteStats()
+	stats.FilesSkipped += count
+	stats.FilesSkippedByReason[reason] += count
+}
+
+// Records a file that failed to be downloaded with the reason of the failure
+func (rs *RunStats) AddFailedFile(fileUrl string, reason error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.getSiteStats().FilesFailed++
+	rs.failedItems = append(rs.failedItems, &FailedItem{
+		Site:   rs.getSite(),
+		Item:   fileUrl,
+		Reason: reason.Error(),
+	})
+}
+
+// Records a post, artwork, etc. that failed to be processed.
+//
+// The item will be the ID or URL mentioned in the error message (if any).
+func (rs *RunStats) AddFailedItem(reason error) {
+	if reason == nil {
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.failedItems = append(rs.failedItems, &FailedItem{
+		Site:   rs.getSite(),
+		Item:   getErrorExample(reason),
+		Reason: reason.Error(),
+	})
+}
+
+// Records a detected link that will not be downloaded by the program
+func (rs *RunStats) AddExternalLink(link, postFolderPath string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.externalLinks = append(rs.externalLinks, &ExternalLink{
+		Site:           rs.getSite(),
+		Link:           link,
+		PostFolderPath: postFolderPath,
+	})
+}
+
+// Records the path of a file that detected external links were written to
+func (rs *RunStats) AddExternalLinksFile(filePath string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !SliceContains(rs.externalLinksFiles, filePath) {
+		rs.externalLinksFiles = append(rs.externalLinksFiles, filePath)
+	}
+}
+
+// Returns the run report of the stats collected so far
+func (rs *RunStats) GetReport() *RunReport {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	endTime := time.Now()
+	report := &RunReport{
+		Version:        VERSION,
+		StartTime:      rs.startTime,
+		EndTime:        endTime,
+		ElapsedSeconds: endTime.Sub(rs.startTime).Seconds(),
+		Sites:          make(map[string]*SiteStats, len(rs.sites)),
+		FailedItems:    append([]*FailedItem{}, rs.failedItems...),
+		ExternalLinks:  append([]*ExternalLink{}, rs.externalLinks...),
+
+		ExternalLinksFiles: append([]string{}, rs.externalLinksFiles...),
+	}
+	if downloadedFiles, stoppedEarly := GetMaxFilesStoppedAt(); stoppedEarly {
+		report.StoppedEarlyAtFiles = downloadedFiles
+	}
+	for site, stats := range rs.sites {
+		statsCopy := *stats
+		statsCopy.FilesSkippedByReason = make(map[string]int, len(stats.FilesSkippedByReason))
+		for reason, count := range stats.FilesSkippedByReason {
+			statsCopy.FilesSkippedByReason[reason] = count
+		}
+		if site == rs.getSite() && !rs.siteStartTime.IsZero() {
+			// include the time spent on the current site so far
+			statsCopy.ElapsedSeconds += endTime.Sub(rs.siteStartTime).Seconds()
+		}
+		report.Sites[site] = &statsCopy
+	}
+	return report
+}
+
+// Returns the sum of the stats of all the sites in the report
+func (report *RunReport) GetTotals() *SiteStats {
+	total := &SiteStats{
+		FilesSkippedByReason: make(map[string]int),
+	}
+	for _, stats := range report.Sites {
+		total.PostsProcessed += stats.PostsProcessed
+		total.FilesDownloaded += stats.FilesDownloaded
+		total.FilesRedownloaded += stats.FilesRedownloaded
+		total.FilesSkipped += stats.FilesSkipped
+		total.FilesFailed += stats.FilesFailed
+		total.BytesTransferred += stats.BytesTransferred
+		for reason, count := range stats.FilesSkippedByReason {
+			total.FilesSkippedByReason[reason] += count
+		}
+	}
+	total.ElapsedSeconds = report.ElapsedSeconds
+	return total
+}
+
+// Returns the default file path of the run report in the application's directory
+func GetDefaultRunReportPath() string {
+	return filepath.Join(APP_PATH, RUN_REPORT_FILENAME)
+}
+
+// Writes the run report to the given file path.
+//
+// If the run has not been started (e.g. only the help message was shown), nothing will be written.
+func (rs *RunStats) WriteReport(filePath string) error {
+	rs.mu.Lock()
+	started := rs.started
+	rs.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	if filePath == "" {
+		filePath = GetDefaultRunReportPath()
+	}
+	reportJson, err := PrettifyJson(rs.GetReport())
+	if err != nil {
+		return err
+	}
+
+	os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err := os.WriteFile(filePath, reportJson, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write run report to %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return nil
+}
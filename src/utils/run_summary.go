@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Formats the given number of bytes in a human-readable format like "1.5 MiB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// Formats the given number of seconds rounded to the nearest second like "1m30s"
+func formatElapsedSeconds(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+// Prints the summary of the run as a single paragraph to stdout for "--quiet"
+// as it is the only output that is not suppressed.
+func printQuietSummary(report *RunReport) {
+	siteNames := make([]string, 0, len(report.Sites))
+	for site := range report.Sites {
+		siteNames = append(siteNames, GetReadableSiteStr(site))
+	}
+	sort.Strings(siteNames)
+
+	total := report.GetTotals()
+	summary := fmt.Sprintf(
+		"Finished in %s: processed %d post(s) and downloaded %d file(s) (%s) from %s, skipped %d file(s), and %d file(s) failed.",
+		formatElapsedSeconds(report.ElapsedSeconds),
+		total.PostsProcessed,
+		total.FilesDownloaded,
+		FormatBytes(total.BytesTransferred),
+		strings.Join(siteNames, ", "),
+		total.FilesSkipped,
+		total.FilesFailed,
+	)
+	if report.StoppedEarlyAtFiles > 0 {
+		summary += fmt.Sprintf(" Stopped early at %d files due to --max-files.", report.StoppedEarlyAtFiles)
+	}
+	if logPath := GetLogFilePathIfWritten(); logPath != "" {
+		summary += fmt.Sprintf(" Log file: %s", logPath)
+	}
+	fmt.Fprintln(os.Stdout, summary)
+}
+
+// Prints a compact summary table of the run at the end of the run.
+//
+// Nothing will be printed if the run has not been started or if no site was processed.
+func (rs *RunStats) PrintSummary() {
+	rs.mu.Lock()
+	started := rs.started
+	rs.mu.Unlock()
+	if !started {
+		return
+	}
+
+	report := rs.GetReport()
+	if len(report.Sites) == 0 {
+		return
+	}
+	if IsQuiet() {
+		printQuietSummary(report)
+		return
+	}
+
+	sites := make([]string, 0, len(report.Sites))
+	for site := range report.Sites {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+
+	color.Cyan("\nRun summary:")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "  Site\tPosts\tDownloaded (re-downloaded)\tSkipped (exists/filtered/restricted)\tFailed\tSize\tElapsed")
+	for _, site := range sites {
+		stats := report.Sites[site]
+		fmt.Fprintf(
+			writer,
+			"  %s\t%d\t%d (%d)\t%d (%d/%d/%d)\t%d\t%s\t%s\n",
+			GetReadableSiteStr(site),
+			stats.PostsProcessed,
+			stats.FilesDownloaded,
+			stats.FilesRedownloaded,
+			stats.FilesSkipped,
+			stats.FilesSkippedByReason[SKIP_REASON_EXISTS],
+			stats.FilesSkippedByReason[SKIP_REASON_FILTERED],
+			stats.FilesSkippedByReason[SKIP_REASON_RESTRICTED],
+			stats.FilesFailed,
+			FormatBytes(stats.BytesTransferred),
+			formatElapsedSeconds(stats.ElapsedSeconds),
+		)
+	}
+	if len(sites) > 1 {
+		total := report.GetTotals()
+		fmt.Fprintf(
+			writer,
+			"  Total\t%d\t%d (%d)\t%d\t%d\t%s\t%s\n",
+			total.PostsProcessed,
+			total.FilesDownloaded,
+			total.FilesRedownloaded,
+			total.FilesSkipped,
+			total.FilesFailed,
+			FormatBytes(total.BytesTransferred),
+			formatElapsedSeconds(report.ElapsedSeconds),
+		)
+	}
+	writer.Flush()
+
+	if report.StoppedEarlyAtFiles > 0 {
+		color.Yellow(
+			"Stopped early at %d files as the maximum number of files to download (--max-files) was reached.",
+			report.StoppedEarlyAtFiles,
+		)
+	}
+	fmt.Printf("Total elapsed time: %s\n", formatElapsedSeconds(report.ElapsedSeconds))
+	if logPath := GetLogFilePathIfWritten(); logPath != "" {
+		fmt.Printf("Log file: %s\n", logPath)
+	}
+	if len(report.ExternalLinksFiles) > 0 {
+		fmt.Printf(
+			"Detected external links were written to:\n- %s\n",
+			strings.Join(report.ExternalLinksFiles, "\n- "),
+		)
+	}
+}
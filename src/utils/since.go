@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// The date format accepted by "--since" in addition to RFC 3339
+const SINCE_DATE_FORMAT = "2006-01-02"
+
+// The layouts of the post dates returned by the APIs of the supported sites
+var postDateLayouts = []string{
+	time.RFC3339,          // Pixiv, Pixiv Fanbox
+	time.RFC1123Z,         // Fantia
+	time.RFC1123,          // Kemono Party (legacy)
+	"2006-01-02T15:04:05", // Kemono Party
+}
+
+// The posts published before this time in Unix nanoseconds will be skipped or 0 to download all posts
+var sinceTime atomic.Int64
+
+// Parses the value of "--since" which can either be a date in the YYYY-MM-DD format
+// in the local timezone or a date and time in the RFC 3339 format.
+func ParseSince(value string) (time.Time, error) {
+	if since, err := time.ParseInLocation(SINCE_DATE_FORMAT, value, time.Local); err == nil {
+		return since, nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"error %d: --since, %q, must be in the YYYY-MM-DD or RFC 3339 format like \"2024-01-31T15:04:05+09:00\"",
+			INPUT_ERROR,
+			value,
+		)
+	}
+	return since, nil
+}
+
+// Configures the time before which the posts will be skipped or the zero time to download all posts.
+//
+// Set from "--since" and moved to the start of the last successful cycle in the watch mode.
+func SetSince(since time.Time) {
+	if since.IsZero() {
+		sinceTime.Store(0)
+		return
+	}
+	sinceTime.Store(since.UnixNano())
+}
+
+// Returns the time before which the posts will be skipped or the zero time if all posts will be downloaded
+func GetSince() time.Time {
+	since := sinceTime.Load()
+	if since == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, since)
+}
+
+// Returns true if the post should be skipped as it was published before "--since".
+//
+// Posts with a date that could not be parsed will not be skipped.
+func IsBeforeSince(postDate string) bool {
+	since := GetSince()
+	if since.IsZero() {
+		return false
+	}
+
+	for _, layout := range postDateLayouts {
+		if publishedAt, err := time.Parse(layout, postDate); err == nil {
+			return publishedAt.Before(since)
+		}
+	}
+	return false
+}
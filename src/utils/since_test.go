@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsBeforeSince(t *testing.T) {
+	since := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		since    time.Time
+		postDate string
+		want     bool
+	}{
+		{name: "no since", postDate: "2020-01-01T00:00:00+00:00", want: false},
+		{name: "rfc3339 before", since: since, postDate: "2024-01-31T11:59:59+00:00", want: true},
+		{name: "rfc3339 same time", since: since, postDate: "2024-01-31T12:00:00+00:00", want: false},
+		{name: "rfc3339 other timezone", since: since, postDate: "2024-01-31T20:00:00+09:00", want: true},
+		{name: "rfc1123z after", since: since, postDate: "Wed, 31 Jan 2024 22:00:00 +0900", want: false},
+		{name: "kemono without timezone", since: since, postDate: "2024-01-30T23:00:00", want: true},
+		{name: "invalid date", since: since, postDate: "yesterday", want: false},
+		{name: "empty date", since: since, postDate: "", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetSince(test.since)
+			t.Cleanup(func() { SetSince(time.Time{}) })
+			if got := IsBeforeSince(test.postDate); got != test.want {
+				t.Errorf("IsBeforeSince(%q) = %v, want %v", test.postDate, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{value: "2024-01-31T15:04:05+09:00", want: time.Date(2024, 1, 31, 6, 4, 5, 0, time.UTC)},
+		{value: "31/01/2024", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseSince(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseSince(%q) returned error %v, want error: %v", test.value, err, test.wantErr)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("ParseSince(%q) = %s, want %s", test.value, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	sleeperMu sync.RWMutex
+	sleeper   func(time.Duration) // nil means time.Sleep
+)
+
+// Replaces the function used for all the rate limit and retry delays in the program
+// so that the delays can be recorded instead of actually sleeping (e.g. in tests).
+//
+// Returns a function to restore the previous sleep function.
+func SetSleeper(sleepFn func(time.Duration)) func() {
+	sleeperMu.Lock()
+	defer sleeperMu.Unlock()
+	prevSleeper := sleeper
+	sleeper = sleepFn
+	return func() {
+		sleeperMu.Lock()
+		defer sleeperMu.Unlock()
+		sleeper = prevSleeper
+	}
+}
+
+// Pauses the current goroutine for the given duration using the configured sleep function
+//
+// Should be used instead of time.Sleep for any rate limit or retry delays.
+func Sleep(d time.Duration) {
+	if sleepFn := getSleeper(); sleepFn != nil {
+		sleepFn(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+// Returns the sleep function set by SetSleeper() or nil if time.Sleep is used
+func getSleeper() func(time.Duration) {
+	sleeperMu.RLock()
+	defer sleeperMu.RUnlock()
+	return sleeper
+}
+
+// Same as Sleep but returns the context's error early if the context is cancelled
+func SleepWithContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if sleepFn := getSleeper(); sleepFn != nil {
+		// the replaced sleep function is expected to return right away
+		sleepFn(d)
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
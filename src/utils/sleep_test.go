@@ -0,0 +1,67 @@
+package utils_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/internal/testutils"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestSleepUsesReplacedSleeper(t *testing.T) {
+	recorder := &testutils.SleepRecorder{}
+	restore := utils.SetSleeper(recorder.Sleep)
+	defer restore()
+
+	utils.Sleep(2 * time.Second)
+	if err := utils.SleepWithContext(context.Background(), 3*time.Second); err != nil {
+		t.Fatalf("SleepWithContext() returned %v", err)
+	}
+
+	want := []time.Duration{2 * time.Second, 3 * time.Second}
+	sleeps := recorder.Sleeps()
+	if len(sleeps) != len(want) {
+		t.Fatalf("recorded %v, want %v", sleeps, want)
+	}
+	for idx, d := range want {
+		if sleeps[idx] != d {
+			t.Errorf("sleep %d = %s, want %s", idx, sleeps[idx], d)
+		}
+	}
+}
+
+func TestSleepWithContextCancelled(t *testing.T) {
+	recorder := &testutils.SleepRecorder{}
+	restore := utils.SetSleeper(recorder.Sleep)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := utils.SleepWithContext(ctx, time.Second); err != context.Canceled {
+		t.Errorf("SleepWithContext() with a cancelled context returned %v, want context.Canceled", err)
+	}
+	if len(recorder.Sleeps()) != 0 {
+		t.Errorf("slept %v with a cancelled context", recorder.Sleeps())
+	}
+	restore()
+
+	// the real timer must be stopped without leaving a goroutine behind
+	goroutines := runtime.NumGoroutine()
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	if err := utils.SleepWithContext(ctx, time.Minute); err != context.Canceled {
+		t.Errorf("SleepWithContext() returned %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SleepWithContext() took %s after the context was cancelled", elapsed)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > goroutines {
+		t.Errorf("%d goroutine(s) left behind after SleepWithContext() returned", after-goroutines)
+	}
+}
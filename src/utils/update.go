@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Environment variable that can be set to any non-empty value to disable the passive update check.
+//
+// Mainly for packagers that distribute the program through their own update channels.
+const DISABLE_UPDATE_CHECK_ENV_VAR = "CULTURED_DOWNLOADER_DISABLE_UPDATE_CHECK"
+
+// How often the passive update check will be done at the start of the program
+const UPDATE_CHECK_INTERVAL = 24 * time.Hour
+
+// Returns true if the passive update check has been disabled via the environment variable
+func IsUpdateCheckDisabledByEnv() bool {
+	return strings.TrimSpace(os.Getenv(DISABLE_UPDATE_CHECK_ENV_VAR)) != ""
+}
+
+func getLastUpdateCheckPath() string {
+	return filepath.Join(APP_PATH, "last_update_check")
+}
+
+// Checks if the last passive update check was done more than UPDATE_CHECK_INTERVAL ago
+func IsUpdateCheckDue() bool {
+	data, err := os.ReadFile(getLastUpdateCheckPath())
+	if err != nil {
+		return true
+	}
+
+	lastCheck, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return true
+	}
+	return time.Since(lastCheck) >= UPDATE_CHECK_INTERVAL
+}
+
+// Saves the current time as the time of the last passive update check
+func SaveUpdateCheckTime() error {
+	os.MkdirAll(APP_PATH, 0755)
+	err := os.WriteFile(
+		getLastUpdateCheckPath(),
+		[]byte(time.Now().UTC().Format(time.RFC3339)),
+		0666,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to save the time of the last update check, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+	return nil
+}
+
+// Returns the path to the binary that is currently running with any symlinks resolved
+func GetExecutablePath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf(
+			"error %d: failed to get the path of the program, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+
+	resolvedPath, err := filepath.EvalSymlinks(exePath)
+	if err != nil {
+		return "", fmt.Errorf(
+			"error %d: failed to resolve the path of the program, %q, more info => %v",
+			OS_ERROR,
+			exePath,
+			err,
+		)
+	}
+	return resolvedPath, nil
+}
+
+// Returns the path that the old binary is moved to on Windows during an update
+func getOldExecutablePath(exePath string) string {
+	return exePath + ".old"
+}
+
+// Replaces the binary at exePath with the new binary at newExePath.
+//
+// The new binary should be in the same directory as exePath so that the rename is atomic.
+// On Windows, the running binary cannot be overwritten but can be renamed,
+// so it will be moved aside and removed on the next run by RemoveOldExecutable.
+func ReplaceExecutable(exePath, newExePath string) error {
+	if runtime.GOOS != "windows" {
+		if err := os.Rename(newExePath, exePath); err != nil {
+			return fmt.Errorf(
+				"error %d: failed to replace the program at %q, more info => %v",
+				OS_ERROR,
+				exePath,
+				err,
+			)
+		}
+		return nil
+	}
+
+	oldExePath := getOldExecutablePath(exePath)
+	os.Remove(oldExePath)
+	if err := os.Rename(exePath, oldExePath); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to move the current program at %q aside, more info => %v",
+			OS_ERROR,
+			exePath,
+			err,
+		)
+	}
+	if err := os.Rename(newExePath, exePath); err != nil {
+		// restore the current binary so that the program can still be used
+		os.Rename(oldExePath, exePath)
+		return fmt.Errorf(
+			"error %d: failed to replace the program at %q, more info => %v",
+			OS_ERROR,
+			exePath,
+			err,
+		)
+	}
+	return nil
+}
+
+// Removes the old binary left behind by an update on Windows (if any)
+func RemoveOldExecutable() {
+	if runtime.GOOS != "windows" {
+		return
+	}
+
+	exePath, err := GetExecutablePath()
+	if err != nil {
+		return
+	}
+	oldExePath := getOldExecutablePath(exePath)
+	if PathExists(oldExePath) {
+		os.Remove(oldExePath)
+	}
+}
@@ -15,6 +15,9 @@ import (
 
 // Prints out a warning message to the user to not stop the program while it is downloading
 func PrintWarningMsg() {
+	if IsQuiet() {
+		return
+	}
 	color.Yellow("CAUTION:")
 	color.Yellow("Please do NOT terminate the program while it is downloading unless you really have to!")
 	color.Yellow("Doing so MAY result in incomplete downloads and corrupted files.")
@@ -34,6 +37,8 @@ func GetReadableSiteStr(site string) string {
 		return PIXIV_TITLE
 	case KEMONO, KEMONO_BACKUP:
 		return KEMONO_TITLE
+	case GDRIVE:
+		return GDRIVE_TITLE
 	default:
 		// panic since this is a dev error
 		panic(
@@ -71,27 +76,27 @@ func ConvertPageNumToOffset(minPageNum, maxPageNum, perPage int) (int, int) {
 // check page nums if they are in the correct format.
 //
 // E.g. "1-10" is valid, but "0-9" is not valid because "0" is not accepted
-// If the page nums are not in the correct format, os.Exit(1) is called
+// If the page nums are not in the correct format, os.Exit(EXIT_INPUT_ERROR) is called
 func ValidatePageNumInput(baseSliceLen int, pageNums []string, errMsgs []string) {
 	pageNumsLen := len(pageNums)
 	if baseSliceLen != pageNumsLen {
 		if len(errMsgs) > 0 {
 			for _, errMsg := range errMsgs {
-				color.Red(errMsg)
+				PrintError(errMsg)
 			}
 		} else {
-			color.Red("Error: %d URLs provided, but %d page numbers provided.", baseSliceLen, pageNumsLen)
-			color.Red("Please provide the same number of page numbers as the number of URLs.")
+			PrintError("Error: %d URLs provided, but %d page numbers provided.", baseSliceLen, pageNumsLen)
+			PrintError("Please provide the same number of page numbers as the number of URLs.")
 		}
-		os.Exit(1)
+		os.Exit(EXIT_INPUT_ERROR)
 	}
 
 	valid, outlier := SliceMatchesRegex(PAGE_NUM_REGEX, pageNums)
 	if !valid {
-		color.Red("Invalid page number format: %s", outlier)
-		color.Red("Please follow the format, \"1-10\", as an example.")
-		color.Red("Note that \"0\" are not accepted! E.g. \"0-9\" is invalid.")
-		os.Exit(1)
+		PrintError("Invalid page number format: %s", outlier)
+		PrintError("Please follow the format, \"1-10\", as an example.")
+		PrintError("Note that \"0\" are not accepted! E.g. \"0-9\" is invalid.")
+		os.Exit(EXIT_INPUT_ERROR)
 	}
 }
 
@@ -159,10 +164,31 @@ func GetRandomDelay() time.Duration {
 	return GetRandomTime(MIN_RETRY_DELAY, MAX_RETRY_DELAY)
 }
 
-// Checks if the given str is in the given arr and returns a boolean
-func SliceContains(arr []string, str string) bool {
+// Checks if the given target is in the given arr and returns a boolean
+func SliceContains[T comparable](arr []T, target T) bool {
 	for _, el := range arr {
-		if el == str {
+		if el == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Lowercases the given file extension and removes its leading dot (if any)
+//
+// E.g. ".JPG" and "jpg" will both return "jpg"
+func NormalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+}
+
+// Checks if the given file extension is in the given slice of extensions.
+//
+// The comparison is case-insensitive and ignores the leading dot of the extensions
+// so that ".PNG" will match "png" in the given slice and vice versa.
+func ExtInSlice(exts []string, ext string) bool {
+	ext = NormalizeExt(ext)
+	for _, el := range exts {
+		if NormalizeExt(el) == ext {
 			return true
 		}
 	}
@@ -186,25 +212,80 @@ func RemoveSliceDuplicates[T SliceTypes](s []T) []T {
 	return result
 }
 
+// Prints a warning for each removed duplicate with its positions (1-based) in the user's input
+//
+// The first position of each duplicate in the positions map is the one that was kept.
+func warnRemovedDuplicates[K comparable](itemType string, duplicates []K, positions map[K][]int) {
+	for _, duplicate := range duplicates {
+		dupPositions := positions[duplicate]
+		posStrs := make([]string, 0, len(dupPositions)-1)
+		for _, pos := range dupPositions[1:] {
+			posStrs = append(posStrs, strconv.Itoa(pos))
+		}
+		color.Yellow(
+			"Removed duplicate %s %v at position(s) %s (kept the one at position %d)",
+			itemType,
+			duplicate,
+			strings.Join(posStrs, ", "),
+			dupPositions[0],
+		)
+	}
+}
+
+// Removes the items with the same key returned by getKey from the given slice while preserving the order
+// and warns the user about the removed duplicates with their keys and positions.
+//
+// Only the first occurrence of each key will be kept.
+func RemoveDuplicatesFunc[T any, K comparable](itemType string, slice []T, getKey func(T) K) []T {
+	result := make([]T, 0, len(slice))
+	var duplicates []K
+	positions := make(map[K][]int)
+	for idx, item := range slice {
+		key := getKey(item)
+		if _, ok := positions[key]; ok {
+			if len(positions[key]) == 1 {
+				duplicates = append(duplicates, key)
+			}
+			positions[key] = append(positions[key], idx+1)
+			continue
+		}
+		positions[key] = []int{idx + 1}
+		result = append(result, item)
+	}
+	warnRemovedDuplicates(itemType, duplicates, positions)
+	return result
+}
+
+// Removes duplicate IDs from the given slice while preserving the order
+// and warns the user about the removed duplicates with their positions.
+func RemoveDuplicateIds[T SliceTypes](idType string, idSlice []T) []T {
+	return RemoveDuplicatesFunc(idType, idSlice, func(id T) T {
+		return id
+	})
+}
+
 // Used for removing duplicate IDs with its corresponding page number from the given slices.
 //
+// Only the first occurrence of the ID will be kept and the user will be warned about the removed duplicates.
+//
 // Returns the the new idSlice and pageSlice with the duplicates removed.
-func RemoveDuplicateIdAndPageNum[T SliceTypes](idSlice, pageSlice []T) ([]T, []T) {
+func RemoveDuplicateIdAndPageNum[T SliceTypes](idType string, idSlice, pageSlice []T) ([]T, []T) {
+	indices := make([]int, len(idSlice))
+	for idx := range indices {
+		indices[idx] = idx
+	}
+
 	var idResult, pageResult []T
-	seen := make(map[T]struct{})
-	for idx, v := range idSlice {
-		if _, ok := seen[v]; !ok {
-			seen[v] = struct{}{}
-			idResult = append(idResult, v)
-			pageResult = append(pageResult, pageSlice[idx])
-		}
+	for _, idx := range RemoveDuplicatesFunc(idType, indices, func(idx int) T { return idSlice[idx] }) {
+		idResult = append(idResult, idSlice[idx])
+		pageResult = append(pageResult, pageSlice[idx])
 	}
 	return idResult, pageResult
 }
 
 // Checks if the slice of string contains the target str
 //
-// Otherwise, os.Exit(1) is called after printing error messages for the user to read
+// Otherwise, os.Exit(EXIT_INPUT_ERROR) is called after printing error messages for the user to read
 func ValidateStrArgs(str string, slice, errMsgs []string) string {
 	if SliceContains(slice, str) {
 		return str
@@ -212,33 +293,40 @@ func ValidateStrArgs(str string, slice, errMsgs []string) string {
 
 	if len(errMsgs) > 0 {
 		for _, msg := range errMsgs {
-			color.Red(msg)
+			PrintError(msg)
 		}
 	} else {
-		color.Red(
+		PrintError(
 			fmt.Sprintf("Input error, got: %s", str),
 		)
 	}
-	color.Red(
+	PrintError(
 		fmt.Sprintf(
 			"Expecting one of the following: %s",
 			strings.TrimSpace(strings.Join(slice, ", ")),
 		),
 	)
-	os.Exit(1)
+	os.Exit(EXIT_INPUT_ERROR)
 	return ""
 }
 
 // Validates if the slice of strings contains only numbers
-// Otherwise, os.Exit(1) is called after printing error messages for the user to read
+// Otherwise, os.Exit(EXIT_INPUT_ERROR) is called after printing error messages for the user to read
+//
+// All the invalid IDs will be printed with their positions (1-based) in the user's input.
 func ValidateIds(args []string) {
-	for _, id := range args {
+	hasInvalid := false
+	for idx, id := range args {
 		if !NUMBER_REGEX.MatchString(id) {
-			color.Red("Invalid ID: %s", id)
-			color.Red("IDs must be numbers!")
-			os.Exit(1)
+			hasInvalid = true
+			PrintError("Invalid ID at position %d: %q", idx+1, id)
 		}
 	}
+
+	if hasInvalid {
+		PrintError("IDs must be numbers!")
+		os.Exit(EXIT_INPUT_ERROR)
+	}
 }
 
 // Same as strings.Join([]string, "\n")
@@ -288,6 +376,7 @@ func DetectGDriveLinks(text, postFolderPath string, isUrl, logUrls bool) bool {
 			text,
 		)
 		LogMessageToPath(gdriveText, gdriveFilepath, INFO)
+		RUN_STATS.AddExternalLinksFile(gdriveFilepath)
 	}
 	return true
 }
@@ -302,6 +391,14 @@ func DetectOtherExtDLLink(text, postFolderPath string) bool {
 				text,
 			)
 			LogMessageToPath(otherExtText, otherExtFilepath, INFO)
+			RUN_STATS.AddExternalLinksFile(otherExtFilepath)
+
+			// only record the links instead of the whole description in the run report
+			for _, link := range URL_REGEX.FindAllString(text, -1) {
+				if strings.Contains(strings.ToLower(link), extDownloadProvider) {
+					RUN_STATS.AddExternalLink(link, postFolderPath)
+				}
+			}
 			return true
 		}
 	}
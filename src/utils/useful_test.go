@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectOtherExtDLLinkRecordsOnlyTheLinks(t *testing.T) {
+	text := "Thanks for the support!\nDownload: https://www.mediafire.com/file/abc/file.zip\nPassword: 1234\nMirror: https://example.com/file.zip"
+	before := len(RUN_STATS.GetReport().ExternalLinks)
+	if !DetectOtherExtDLLink(text, t.TempDir()) {
+		t.Fatal("DetectOtherExtDLLink() did not detect the link")
+	}
+
+	var gotLinks []string
+	for _, externalLink := range RUN_STATS.GetReport().ExternalLinks[before:] {
+		gotLinks = append(gotLinks, externalLink.Link)
+	}
+	wantLinks := []string{"https://www.mediafire.com/file/abc/file.zip"}
+	if !reflect.DeepEqual(gotLinks, wantLinks) {
+		t.Errorf("recorded %q, want %q", gotLinks, wantLinks)
+	}
+}
+
+func TestNormalizeExt(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{ext: "jpg", want: "jpg"},
+		{ext: ".jpg", want: "jpg"},
+		{ext: ".JPG", want: "jpg"},
+		{ext: "Png", want: "png"},
+		{ext: " .webp ", want: "webp"},
+		{ext: "", want: ""},
+	}
+	for _, test := range tests {
+		if got := NormalizeExt(test.ext); got != test.want {
+			t.Errorf("NormalizeExt(%q) = %q, want %q", test.ext, got, test.want)
+		}
+	}
+}
+
+func TestExtInSlice(t *testing.T) {
+	exts := []string{"jpg", ".PNG", "Gif"}
+	tests := []struct {
+		ext  string
+		want bool
+	}{
+		{ext: "jpg", want: true},
+		{ext: ".JPG", want: true},
+		{ext: "png", want: true},
+		{ext: ".png", want: true},
+		{ext: "GIF", want: true},
+		{ext: "webp", want: false},
+		{ext: "jpeg", want: false},
+		{ext: "", want: false},
+	}
+	for _, test := range tests {
+		if got := ExtInSlice(exts, test.ext); got != test.want {
+			t.Errorf("ExtInSlice(%q, %q) = %v, want %v", exts, test.ext, got, test.want)
+		}
+	}
+
+	if ExtInSlice(nil, "jpg") {
+		t.Error("ExtInSlice(nil, \"jpg\") = true, want false")
+	}
+}
+
+const validateIdsHelperEnvVar = "CULTURED_DOWNLOADER_TEST_VALIDATE_IDS"
+
+// Run in a separate process by TestValidateIdsReportsAllInvalidIds as ValidateIds exits on invalid IDs
+func TestValidateIdsHelperProcess(t *testing.T) {
+	ids := os.Getenv(validateIdsHelperEnvVar)
+	if ids == "" {
+		t.Skip("only run as a helper process")
+	}
+	ValidateIds(strings.Split(ids, ","))
+}
+
+func TestValidateIdsReportsAllInvalidIds(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         string
+		wantExit    int
+		wantReports []string
+	}{
+		{name: "valid IDs", ids: "1,23,456", wantExit: 0},
+		{
+			name:        "one invalid ID",
+			ids:         "1,abc,3",
+			wantExit:    EXIT_INPUT_ERROR,
+			wantReports: []string{`Invalid ID at position 2: "abc"`},
+		},
+		{
+			name:     "multiple invalid IDs",
+			ids:      "x,2,3y,4",
+			wantExit: EXIT_INPUT_ERROR,
+			wantReports: []string{
+				`Invalid ID at position 1: "x"`,
+				`Invalid ID at position 3: "3y"`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestValidateIdsHelperProcess$")
+			cmd.Env = append(os.Environ(), validateIdsHelperEnvVar+"="+test.ids)
+			output, err := cmd.CombinedOutput()
+
+			exitCode := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if exitCode != test.wantExit {
+				t.Fatalf("exited with %d, want %d\noutput: %s", exitCode, test.wantExit, output)
+			}
+			for _, report := range test.wantReports {
+				if !strings.Contains(string(output), report) {
+					t.Errorf("output does not contain %q\noutput: %s", report, output)
+				}
+			}
+		})
+	}
+}
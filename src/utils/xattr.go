@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Names of the extended attributes written to the downloaded files
+// which follow the freedesktop.org recommendations for the common extended attributes.
+//
+// On Linux, the names will be prefixed with "user." as required for unprivileged users.
+const (
+	XATTR_ORIGIN_URL   = "xdg.origin.url"   // the URL the file was downloaded from
+	XATTR_REFERRER_URL = "xdg.referrer.url" // the URL of the post of the file
+	XATTR_CREATOR      = "xdg.creator"
+	XATTR_TAGS         = "xdg.tags" // comma-separated
+)
+
+// FileMetadata is the source metadata of a downloaded file
+// taken from the JSON of its post that has already been fetched.
+type FileMetadata struct {
+	PostUrl string   `json:"post_url,omitempty"`
+	Creator string   `json:"creator,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+var (
+	errXattrsNotSupported = errors.New("extended attributes are not supported")
+	xattrsWarningOnce     sync.Once
+)
+
+// Writes the URL the file was downloaded from and its source metadata (if any)
+// into the extended attributes of the file so that the info travels with the file.
+//
+// If extended attributes are not supported by the OS or the filesystem,
+// nothing will be written and a warning will be printed once per run.
+func WriteXattrs(filePath, fileUrl string, metadata *FileMetadata) error {
+	attrs := [][2]string{{XATTR_ORIGIN_URL, fileUrl}}
+	if metadata != nil {
+		attrs = append(
+			attrs,
+			[2]string{XATTR_REFERRER_URL, metadata.PostUrl},
+			[2]string{XATTR_CREATOR, metadata.Creator},
+			[2]string{XATTR_TAGS, strings.Join(metadata.Tags, ",")},
+		)
+	}
+
+	for _, attr := range attrs {
+		name, value := attr[0], attr[1]
+		if value == "" {
+			continue
+		}
+
+		err := setXattr(filePath, name, value)
+		if errors.Is(err, errXattrsNotSupported) {
+			xattrsWarningOnce.Do(func() {
+				msg := "Extended attributes are not supported by the OS or the filesystem of the download path, the source metadata will not be written to the downloaded files."
+				color.Yellow(msg)
+				LogError(nil, msg, false, INFO)
+			})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to write the extended attribute %s of %s, more info => %v",
+				OS_ERROR,
+				name,
+				filePath,
+				err,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package utils
+
+func setXattr(filePath, name, value string) error {
+	return errXattrsNotSupported
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package utils
+
+import (
+	"errors"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+func setXattr(filePath, name, value string) error {
+	if runtime.GOOS == "linux" {
+		name = "user." + name
+	}
+
+	err := unix.Setxattr(filePath, name, []byte(value), 0)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return errXattrsNotSupported
+	}
+	return err
+}